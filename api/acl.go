@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+// FilterFields returns the rows of the model T without the fields of the `fieldACL` that none of the
+// roles of the user is allowed to see. The models preloaded by the rows are filtered the same way with
+// their own `fieldACL`, the rows are returned as is when no model has restricted fields.
+func FilterFields[T any, R any](c *gin.Context, rows []R) ([]any, error) {
+	acls := loader.AppConfig().FieldACL
+
+	filtered := make([]any, 0, len(rows))
+	if len(acls) == 0 {
+		for _, row := range rows {
+			filtered = append(filtered, row)
+		}
+
+		return filtered, nil
+	}
+
+	b, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// The numbers are kept as is so that the large ids do not lose their precision to a float64.
+	objs := []map[string]any{}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&objs); err != nil {
+		return nil, err
+	}
+
+	roles := middleware.UserRoles(c)
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	for _, obj := range objs {
+		filterObject(acls, typ, obj, roles)
+		filtered = append(filtered, obj)
+	}
+
+	return filtered, nil
+}
+
+// filterObject removes the restricted fields of the obj, the JSON encoding of a model of the type typ,
+// and filters the models nested in it.
+func filterObject(acls map[string]map[string][]string, typ reflect.Type, obj map[string]any, roles []string) {
+	acl := acls[typ.Name()]
+
+	for key, val := range obj {
+		if !fieldAllowed(acl, key, roles) {
+			delete(obj, key)
+			continue
+		}
+
+		if field, ok := jsonField(typ, key); ok {
+			filterValue(acls, field.Type, val, roles)
+		}
+	}
+}
+
+// filterValue filters the val when it is the JSON encoding of a model (or of a slice of models).
+func filterValue(acls map[string]map[string][]string, typ reflect.Type, val any, roles []string) {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+
+	switch val := val.(type) {
+	case map[string]any:
+		filterObject(acls, typ, val, roles)
+	case []any:
+		for _, elem := range val {
+			filterValue(acls, typ, elem, roles)
+		}
+	}
+}
+
+// fieldAllowed reports whether one of the roles may see the field of the key.
+func fieldAllowed(acl map[string][]string, key string, roles []string) bool {
+	for field, allowed := range acl {
+		if strings.EqualFold(key, field) && !slices.ContainsFunc(roles, func(role string) bool {
+			return slices.Contains(allowed, role)
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jsonField returns the field of the struct typ encoded under the key, the fields of the embedded
+// structs are looked up too since they are encoded inline.
+func jsonField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && len(name) == 0 {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				if found, ok := jsonField(embedded, key); ok {
+					return found, true
+				}
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		if name == key {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
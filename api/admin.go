@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/core/auth/session"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	CLEANUP_SESSIONS_PATH  = "/admin/sessions/cleanup"
+	CONFIG_PATH            = "/admin/config"
+	CONFIG_PROVENANCE_PATH = "/admin/config/provenance"
+
+	ROLE_ADMIN = "admin"
+)
+
+// CleanupSessionsHandler sweeps the expired sessions of the session store on demand, the same sweep runs
+// on the scheduler as the `sweep_sessions` task.
+func CleanupSessionsHandler(c *gin.Context) {
+	n, err := session.Default().Sweep(c.Request.Context())
+	if err != nil {
+		WriteError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+// ConfigHandler answers with the dump of the effective config of the instance, its secrets are masked.
+func ConfigHandler(c *gin.Context) {
+	dump, err := loader.AppConfig().Dump()
+	if err != nil {
+		WriteError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dump)
+}
+
+// ConfigProvenanceHandler answers with where the value of every field of the config came from (e.g.
+// `{"MysqlUser": "env"}`), only the sources are exposed so the secrets stay masked.
+func ConfigProvenanceHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, loader.AppConfig().Provenance())
+}
+
+// RegisterAdminRoutes registers the routes only the admins are allowed to access, the config is only
+// exposed when the `exposeConfigEndpoint` of the app config is set.
+func RegisterAdminRoutes(router gin.IRoutes) {
+	router.POST(CLEANUP_SESSIONS_PATH, middleware.RequireRoles(ROLE_ADMIN), CleanupSessionsHandler)
+
+	if loader.AppConfig().ExposeConfigEndpoint {
+		router.GET(CONFIG_PATH, middleware.RequireRoles(ROLE_ADMIN), ConfigHandler)
+		router.GET(CONFIG_PROVENANCE_PATH, middleware.RequireRoles(ROLE_ADMIN), ConfigProvenanceHandler)
+	}
+}
@@ -0,0 +1,134 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/core/auth/session"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminRouter registers the admin routes and returns a func serving the method and path as a user with the
+// roles, a request without any role is anonymous.
+func adminRouter(method, path string) func(roles ...string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if roles, ok := c.Request.Header["X-User-Roles"]; ok {
+			middleware.SetUserId(c, 1)
+			middleware.SetUserRoles(c, roles...)
+		}
+	})
+	api.RegisterAdminRoutes(router)
+
+	return func(roles ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		for _, role := range roles {
+			r.Header.Add("X-User-Roles", role)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+}
+
+func Test_adminsShouldCleanupTheExpiredSessions(t *testing.T) {
+	ctx := context.Background()
+	store := session.Default()
+
+	assert.Nil(t, store.Put(ctx, &session.Session{Id: "expired", UserId: 1, ExpiresAt: time.Now().Add(-time.Minute)}))
+	assert.Nil(t, store.Put(ctx, &session.Session{Id: "valid", UserId: 2, ExpiresAt: time.Now().Add(time.Hour)}))
+	defer store.Delete(ctx, "valid")
+
+	serve := adminRouter(http.MethodPost, api.CLEANUP_SESSIONS_PATH)
+
+	assert.Equal(t, http.StatusUnauthorized, serve().Code)
+	assert.Equal(t, http.StatusForbidden, serve("accountant").Code)
+
+	w := serve(api.ROLE_ADMIN)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"deleted": 1}`, w.Body.String())
+
+	_, err := store.Get(ctx, "valid")
+	assert.Nil(t, err, "A valid session must survive the cleanup.")
+}
+
+func Test_adminsShouldGetTheRedactedConfig(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.MysqlPassword = "hunter2"
+		conf.ExposeConfigEndpoint = false
+	})
+	assert.Equal(t, http.StatusNotFound, adminRouter(http.MethodGet, api.CONFIG_PATH)(api.ROLE_ADMIN).Code)
+
+	conf := mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ExposeConfigEndpoint = true
+	})
+	serve := adminRouter(http.MethodGet, api.CONFIG_PATH)
+
+	assert.Equal(t, http.StatusUnauthorized, serve().Code)
+	assert.Equal(t, http.StatusForbidden, serve("accountant").Code)
+
+	w := serve(api.ROLE_ADMIN)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "hunter2", "A secret must never be exposed.")
+
+	dump := map[string]any{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &dump))
+	assert.Equal(t, loader.SECRET_MASK, dump["MysqlPassword"])
+	assert.Equal(t, loader.SECRET_MASK, dump["FbClientSecret"])
+	assert.Equal(t, conf.Version, dump["Version"])
+}
+
+func Test_adminsShouldGetTheProvenanceOfTheConfig(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ExposeConfigEndpoint = true
+	})
+	serve := adminRouter(http.MethodGet, api.CONFIG_PROVENANCE_PATH)
+
+	assert.Equal(t, http.StatusForbidden, serve("accountant").Code)
+
+	w := serve(api.ROLE_ADMIN)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	provenance := map[string]string{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &provenance))
+	assert.Equal(t, loader.SOURCE_FILE, provenance["Message"])
+	assert.Contains(t, provenance, "MysqlPassword")
+}
+
+func Test_theRouterShouldAuthenticateTheAdminRoutesWithTheSession(t *testing.T) {
+	ctx := context.Background()
+	store := session.Default()
+
+	assert.Nil(t, store.Put(ctx, &session.Session{Id: "admin", UserId: 1, Roles: []string{api.ROLE_ADMIN}, ExpiresAt: time.Now().Add(time.Hour)}))
+	assert.Nil(t, store.Put(ctx, &session.Session{Id: "accountant", UserId: 2, Roles: []string{"accountant"}, ExpiresAt: time.Now().Add(time.Hour)}))
+	defer store.Delete(ctx, "admin")
+	defer store.Delete(ctx, "accountant")
+
+	router := api.Router()
+
+	serve := func(authorization string) int {
+		r := httptest.NewRequest(http.MethodPost, api.CLEANUP_SESSIONS_PATH, nil)
+		if len(authorization) > 0 {
+			r.Header.Set("Authorization", authorization)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(""))
+	assert.Equal(t, http.StatusUnauthorized, serve(middleware.AUTH_SCHEME+"unknown"))
+	assert.Equal(t, http.StatusForbidden, serve(middleware.AUTH_SCHEME+"accountant"))
+	assert.Equal(t, http.StatusOK, serve(middleware.AUTH_SCHEME+"admin"))
+}
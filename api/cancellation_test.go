@@ -0,0 +1,93 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/auth/facebook"
+	"github.com/rommms07/idream-erp/core/repository"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_aClientDisconnectShouldCancelTheDbAndGraphCalls(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.FbEnabled = true
+	})
+
+	graphCancelled := make(chan struct{})
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(graphCancelled)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(graph.Close)
+
+	bakGraph := facebook.FACEBOOK_GRAPH
+	facebook.FACEBOOK_GRAPH = graph.URL
+	t.Cleanup(func() { facebook.FACEBOOK_GRAPH = bakGraph })
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `products`").WillDelayFor(5 * time.Second)
+
+	var dbErr, graphErr error
+	done := make(chan struct{})
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.GET("/slow", func(c *gin.Context) {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			_, dbErr = repository.New[Product](db).List(c)
+		}()
+
+		go func() {
+			defer wg.Done()
+			graphErr = facebook.CheckCredentials(c, facebook.LoginType_CONSUMER)
+		}()
+
+		wg.Wait()
+	})
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/slow", nil)
+	assert.Nil(t, err)
+
+	time.AfterFunc(100*time.Millisecond, disconnect)
+	start := time.Now()
+	http.DefaultClient.Do(req)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("The handler kept running after the client disconnected.")
+	}
+
+	assert.Less(t, time.Since(start), 3*time.Second)
+	assert.NotNil(t, dbErr, "The query should have been cancelled.")
+	assert.NotNil(t, graphErr, "The Graph call should have been cancelled.")
+
+	select {
+	case <-graphCancelled:
+	case <-time.After(time.Second):
+		t.Error("The Graph API never saw the cancellation.")
+	}
+}
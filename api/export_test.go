@@ -0,0 +1,27 @@
+package api
+
+import "time"
+
+// SetNow overrides the clock of the error rate, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
+
+// SetDefaultErrorRate overrides the tracker fed by the ErrorRateHandler, the returned func restores it.
+func SetDefaultErrorRate(tracker *ErrorRateTracker) func() {
+	DefaultErrorRate()
+
+	bak := defaultErrorRate
+	defaultErrorRate = tracker
+	return func() { defaultErrorRate = bak }
+}
+
+// ResetVersionedRoutes drops the routes registered by the OnVersionedRoutes, the returned func
+// restores them.
+func ResetVersionedRoutes() func() {
+	bak := versionedRoutes
+	versionedRoutes = nil
+	return func() { versionedRoutes = bak }
+}
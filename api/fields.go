@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/repository"
+)
+
+// SPARSE_FIELDS_PARAM is the query param listing the fields of a sparse response (e.g. `?fields=id,name`).
+const SPARSE_FIELDS_PARAM = "fields"
+
+// ListSparse answers the request with the rows of the model T, only the fields of the `fields` query
+// param are read from the database and rendered when it is set. A field that is not one of the
+// `sparseFields` of the model is answered with a 400. The fields restricted by the `fieldACL` are
+// omitted for the roles that may not see them.
+func ListSparse[T any](c *gin.Context, repo *repository.Repository[T]) {
+	fields, err := repository.ParseFields[T](c.Query(SPARSE_FIELDS_PARAM))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	models, err := repo.List(c.Request.Context(), repository.WithFields(fields))
+	if err != nil {
+		WriteError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	var rows []any
+
+	if fields == nil {
+		rows, err = FilterFields[T](c, models)
+	} else {
+		var picked []map[string]any
+		if picked, err = repo.Pick(models, fields); err == nil {
+			rows, err = FilterFields[T](c, picked)
+		}
+	}
+
+	if err != nil {
+		WriteError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteList(c, completeList(rows))
+}
@@ -0,0 +1,141 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/core/repository"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Product struct {
+	Id    uint64 `gorm:"primaryKey"`
+	Name  string
+	Price int64
+	Notes string
+}
+
+func sparseRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SparseFields = map[string][]string{"Product": {"id", "name", "price"}}
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.GET("/products", func(c *gin.Context) { api.ListSparse(c, repository.New[Product](db)) })
+
+	return router, mock
+}
+
+func Test_shouldOnlyReturnTheRequestedFields(t *testing.T) {
+	router, mock := sparseRouter(t)
+
+	mock.ExpectQuery("SELECT `id`,`name` FROM `products`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Chair").AddRow(2, "Table"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?fields=id,name", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"Id":1,"Name":"Chair"},{"Id":2,"Name":"Table"}]`, w.Body.String())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldReturnEveryFieldWhenNoneIsRequested(t *testing.T) {
+	router, mock := sparseRouter(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `products`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "notes"}).AddRow(1, "Chair", 4999, "oak"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?fields=,", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"Id":1,"Name":"Chair","Price":4999,"Notes":"oak"}]`, w.Body.String())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldRejectAnUnknownField(t *testing.T) {
+	router, mock := sparseRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?fields=id,notes", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown field: [notes]")
+	assert.Nil(t, mock.ExpectationsWereMet(), "The products must not have been queried.")
+}
+
+func Test_shouldOmitTheRestrictedFieldsForTheOtherRoles(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.FieldACL = map[string]map[string][]string{"Product": {"price": {"admin", "manager"}}}
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.GET("/products", func(c *gin.Context) {
+		middleware.SetUserRoles(c, c.Query("role"))
+		api.ListSparse(c, repository.New[Product](db))
+	})
+
+	for role, expected := range map[string]string{
+		"clerk":   `[{"Id":1,"Name":"Chair","Notes":""}]`,
+		"manager": `[{"Id":1,"Name":"Chair","Price":4999,"Notes":""}]`,
+	} {
+		mock.ExpectQuery("SELECT \\* FROM `products`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(1, "Chair", 4999))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?role="+role, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, expected, w.Body.String(), role)
+	}
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+type Supplier struct {
+	Id    uint64
+	Name  string
+	Phone string
+}
+
+type SuppliedProduct struct {
+	Id        uint64
+	Name      string
+	Supplier  *Supplier
+	Suppliers []Supplier
+}
+
+func Test_shouldOmitTheRestrictedFieldsOfThePreloadedModels(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.FieldACL = map[string]map[string][]string{"Supplier": {"phone": {"purchaser"}}}
+	})
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	middleware.SetUserRoles(c, "clerk")
+
+	supplier := Supplier{Id: 7, Name: "Acme", Phone: "+63 2 8123 4567"}
+	rows, err := api.FilterFields[SuppliedProduct](c, []*SuppliedProduct{{Id: 1, Name: "Chair", Supplier: &supplier, Suppliers: []Supplier{supplier}}})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []any{map[string]any{
+		"Id":        json.Number("1"),
+		"Name":      "Chair",
+		"Supplier":  map[string]any{"Id": json.Number("7"), "Name": "Acme"},
+		"Suppliers": []any{map[string]any{"Id": json.Number("7"), "Name": "Acme"}},
+	}}, rows)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/auth/facebook"
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	HEALTH_PATH = "/healthz"
+
+	HEALTH_DATABASE = "database"
+	HEALTH_FACEBOOK = "facebook"
+	HEALTH_CONFIG   = "config"
+
+	// DEFAULT_HEALTH_TIMEOUT is the deadline of the components missing from the `healthTimeouts`.
+	DEFAULT_HEALTH_TIMEOUT = 2 * time.Second
+)
+
+// ErrHealthTimeout is the error of a component whose check did not answer before its deadline.
+var ErrHealthTimeout = errors.New("error: the health check timed out")
+
+// HealthComponent is a component of the health check, its Check runs with the Timeout as its deadline.
+type HealthComponent struct {
+	Name    string
+	Timeout time.Duration
+	Check   func(ctx context.Context) error
+}
+
+type ComponentHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// check runs the check of the component, the ErrHealthTimeout is returned as soon as the deadline
+// expires even if the check ignores the ctx and keeps running.
+func (hc *HealthComponent) check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, hc.Timeout)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() { errc <- hc.Check(ctx) }()
+
+	select {
+	case err := <-errc:
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrHealthTimeout
+		}
+
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrHealthTimeout
+		}
+
+		return ctx.Err()
+	}
+}
+
+// CheckHealth checks all of the components at once, the bool is false when any of them failed.
+func CheckHealth(ctx context.Context, components ...*HealthComponent) (map[string]*ComponentHealth, bool) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	report := make(map[string]*ComponentHealth, len(components))
+	healthy := true
+
+	for _, component := range components {
+		wg.Add(1)
+
+		go func(component *HealthComponent) {
+			defer wg.Done()
+
+			health := &ComponentHealth{Status: "ok"}
+			if err := component.check(ctx); err != nil {
+				health = &ComponentHealth{Status: "failed", Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report[component.Name] = health
+			healthy = healthy && health.Status == "ok"
+		}(component)
+	}
+
+	wg.Wait()
+	return report, healthy
+}
+
+// HealthHandler answers with the health of every component, the status is a 503 when any of them
+// failed.
+func HealthHandler(components ...*HealthComponent) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, healthy := CheckHealth(c.Request.Context(), components...)
+
+		status := http.StatusOK
+		if !healthy {
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, gin.H{"status_code": status, "components": report})
+	}
+}
+
+// healthTimeout returns the deadline of the component from the `healthTimeouts` of the app config.
+func healthTimeout(name string) time.Duration {
+	if ms, ok := loader.AppConfig().HealthTimeouts[name]; ok && ms != 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+
+	return DEFAULT_HEALTH_TIMEOUT
+}
+
+// DefaultHealthComponents returns the components checked by the health check of the app, the facebook
+// component is only checked when the Facebook login is enabled.
+func DefaultHealthComponents() []*HealthComponent {
+	components := []*HealthComponent{
+		{Name: HEALTH_DATABASE, Timeout: healthTimeout(HEALTH_DATABASE), Check: func(ctx context.Context) error {
+			db, err := mysql.Default()
+			if err != nil {
+				return err
+			}
+
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+
+			return sqlDB.PingContext(ctx)
+		}},
+		{Name: HEALTH_CONFIG, Timeout: healthTimeout(HEALTH_CONFIG), Check: func(ctx context.Context) error {
+			_, err := loader.AppConfig().Validate()
+			return err
+		}},
+	}
+
+	if loader.AppConfig().FbEnabled {
+		components = append(components, &HealthComponent{
+			Name:    HEALTH_FACEBOOK,
+			Timeout: healthTimeout(HEALTH_FACEBOOK),
+			Check: func(ctx context.Context) error {
+				return facebook.CheckCredentials(ctx, facebook.LoginType_CONSUMER)
+			},
+		})
+	}
+
+	return components
+}
@@ -0,0 +1,79 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowComponent never answers before a second passes, and it ignores the cancellation of its ctx.
+func slowComponent(name string, timeout time.Duration) *api.HealthComponent {
+	return &api.HealthComponent{Name: name, Timeout: timeout, Check: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+}
+
+func fastComponent(name string) *api.HealthComponent {
+	return &api.HealthComponent{Name: name, Timeout: time.Second, Check: func(ctx context.Context) error {
+		return nil
+	}}
+}
+
+func Test_slowComponentShouldFailAtItsTimeout(t *testing.T) {
+	start := time.Now()
+
+	report, healthy := api.CheckHealth(context.Background(),
+		slowComponent(api.HEALTH_FACEBOOK, 50*time.Millisecond),
+		fastComponent(api.HEALTH_DATABASE),
+		fastComponent(api.HEALTH_CONFIG))
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "The check should not wait for the slow component.")
+	assert.False(t, healthy)
+	assert.Equal(t, &api.ComponentHealth{Status: "failed", Error: api.ErrHealthTimeout.Error()}, report[api.HEALTH_FACEBOOK])
+	assert.Equal(t, "ok", report[api.HEALTH_DATABASE].Status)
+	assert.Equal(t, "ok", report[api.HEALTH_CONFIG].Status)
+}
+
+func Test_healthHandlerShouldAnswerWithTheReportOfTheComponents(t *testing.T) {
+	router := gin.New()
+	router.GET(api.HEALTH_PATH, api.HealthHandler(
+		slowComponent(api.HEALTH_FACEBOOK, 50*time.Millisecond),
+		fastComponent(api.HEALTH_DATABASE)))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, api.HEALTH_PATH, nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var body struct {
+		Components map[string]*api.ComponentHealth
+	}
+
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "failed", body.Components[api.HEALTH_FACEBOOK].Status)
+	assert.Equal(t, "ok", body.Components[api.HEALTH_DATABASE].Status)
+}
+
+func Test_healthComponentsShouldUseTheConfiguredTimeouts(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.HealthTimeouts = map[string]uint64{api.HEALTH_DATABASE: 250}
+	})
+
+	timeouts := map[string]time.Duration{}
+	for _, component := range api.DefaultHealthComponents() {
+		timeouts[component.Name] = component.Timeout
+	}
+
+	assert.Equal(t, 250*time.Millisecond, timeouts[api.HEALTH_DATABASE])
+	assert.Equal(t, api.DEFAULT_HEALTH_TIMEOUT, timeouts[api.HEALTH_CONFIG], "The missing components should get the default timeout.")
+}
@@ -1,27 +1,201 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
 	"github.com/rommms07/idream-erp/core/auth/facebook"
 	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/metrics"
 )
 
-func Start() (err error) {
+const (
+	// SHUTDOWN_TIMEOUT is how long the servers wait for the pending requests when shutting down.
+	SHUTDOWN_TIMEOUT = 10 * time.Second
+)
+
+// Router creates the gin.Engine serving the routes of the api.
+func Router() *gin.Engine {
 	router := gin.New()
 	config := loader.AppConfig()
 
-	router.GET(config.FbRedirectUri, facebook.FbRedirectHandler)
+	// The handlers may pass the gin.Context to the downstream calls instead of its c.Request.Context().
+	router.ContextWithFallback = config.PropagateCancellation
+
+	router.Use(
+		middleware.RequestIdMiddleware(),
+		middleware.NPlusOneMiddleware(),
+		middleware.ConsistentReadMiddleware(),
+		middleware.AccessLogMiddleware(),
+		middleware.RecoveryMiddleware(),
+		middleware.HTTPSRedirectMiddleware(),
+		middleware.WarmupMiddleware(),
+		middleware.PayloadSizeMiddleware(),
+		middleware.SecurityHeadersMiddleware(),
+		middleware.LocaleMiddleware(),
+		middleware.AuthMiddleware(),
+		middleware.RateLimitMiddleware(),
+		middleware.PolicyMiddleware(),
+		middleware.RequestBodyLogMiddleware(),
+		middleware.JSONSchemaMiddleware(),
+	)
+
+	router.Use(middleware.PoolSaturationMiddleware(), middleware.DegradedModeMiddleware(), middleware.ETagMiddleware())
+
+	router.GET(HEALTH_PATH, HealthHandler(DefaultHealthComponents()...))
+	router.GET(READINESS_PATH, ReadinessHandler)
+
+	if config.RecordVersionHistory {
+		router.GET(VERSION_HISTORY_PATH, VersionHistoryHandler)
+	}
+
+	if config.FbEnabled {
+		router.GET(config.FbRedirectUri, facebook.FbRedirectHandler)
+	}
+
+	RegisterAdminRoutes(router)
 
-	switch config.ServerProto {
-	case "http":
-		err = router.Run(config.ServerAddr)
-	case "https":
-		err = router.RunTLS(config.ServerAddr, config.ServerCertFile, config.ServerKeyFile)
-	default:
-		err = errors.New("error: invalid server protocol")
+	versions := NewVersionedRouter(router)
+	for _, register := range versionedRoutes {
+		register(versions)
 	}
 
-	return
+	return router
+}
+
+// Handler wraps the Router with the handlers that must run outside of gin.
+func Handler() http.Handler {
+	var h http.Handler = Router()
+	config := loader.AppConfig()
+
+	if config.RequestTimeoutMs != 0 {
+		h = middleware.TimeoutHandler(h, time.Duration(config.RequestTimeoutMs)*time.Millisecond)
+	}
+
+	return ErrorRateHandler(h)
+}
+
+// MetricsHandler creates the http.Handler of the internal metrics server.
+func MetricsHandler() http.Handler {
+	config := loader.AppConfig()
+	metrics.SetBuildInfo(config.VersionInfo.String(), config.BuildCommit, config.Environment)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	if config.EnablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return mux
+}
+
+// Serve serves the api on the lis and the metrics on the metricsLis (when it is not nil) until the
+// ctx is cancelled, both of the servers are shutdown together. Serve returns early with an error
+// when any of the servers fails.
+func Serve(ctx context.Context, lis, metricsLis net.Listener) error {
+	config := loader.AppConfig()
+
+	if config.ServerProto != "http" && config.ServerProto != "https" {
+		return errors.New("error: invalid server protocol")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	server := &http.Server{Handler: Handler()}
+	servers := []*http.Server{server}
+	errc := make(chan error, 2)
+
+	go func() {
+		if config.ServerProto == "https" {
+			errc <- server.ServeTLS(lis, config.ServerCertFile, config.ServerKeyFile)
+		} else {
+			errc <- server.Serve(lis)
+		}
+	}()
+
+	if metricsLis != nil {
+		metricsServer := &http.Server{Handler: MetricsHandler()}
+		servers = append(servers, metricsServer)
+
+		go func() {
+			errc <- metricsServer.Serve(metricsLis)
+		}()
+	}
+
+	var err error
+
+	select {
+	case <-ctx.Done():
+	case err = <-errc:
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+	defer cancelShutdown()
+
+	for _, server := range servers {
+		if serr := server.Shutdown(shutdownCtx); serr != nil && err == nil {
+			err = serr
+		}
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+
+	return err
+}
+
+// listen listens on the addr configured by the env, a port that is already in use is reported with an
+// error naming the port instead of the cryptic error of the bind.
+func listen(env, addr string) (net.Listener, error) {
+	lis, err := net.Listen("tcp", addr)
+
+	if errors.Is(err, syscall.EADDRINUSE) {
+		_, port, _ := net.SplitHostPort(addr)
+		return nil, fmt.Errorf("error: the port %s of the %s (%s) is already in use, it is occupied by another process: %w",
+			port, env, addr, err)
+	}
+
+	return lis, err
+}
+
+// RunServer listens on the `SERVER_ADDR` (and the `METRICS_ADDR` when it is set) and serves the api
+// until the ctx is cancelled.
+func RunServer(ctx context.Context) error {
+	config := loader.AppConfig()
+
+	lis, err := listen("SERVER_ADDR", config.ServerAddr)
+	if err != nil {
+		return err
+	}
+
+	var metricsLis net.Listener
+
+	if len(config.MetricsAddr) != 0 {
+		metricsLis, err = listen("METRICS_ADDR", config.MetricsAddr)
+		if err != nil {
+			lis.Close()
+			return err
+		}
+	}
+
+	return Serve(ctx, lis, metricsLis)
+}
+
+func Start() (err error) {
+	return RunServer(context.Background())
 }
@@ -1 +1,96 @@
 package api_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func listen(t *testing.T) net.Listener {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return lis
+}
+
+func get(addr net.Addr, path string) (int, error) {
+	res, err := http.Get(fmt.Sprintf("http://%s%s", addr.String(), path))
+	if err != nil {
+		return 0, err
+	}
+
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
+func Test_shouldServeTheMetricsOnTheirOwnAddressAndShutdownTogether(t *testing.T) {
+	lis, metricsLis := listen(t), listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() { done <- api.Serve(ctx, lis, metricsLis) }()
+
+	code, err := get(metricsLis.Addr(), "/metrics")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code, "The metrics server should expose /metrics.")
+
+	code, err = get(lis.Addr(), "/metrics")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, code, "The metrics must not be exposed on the main port.")
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err, "The servers should shutdown cleanly.")
+	case <-time.After(api.SHUTDOWN_TIMEOUT):
+		assert.Fail(t, "The servers did not shutdown.")
+	}
+
+	_, err = get(lis.Addr(), "/")
+	assert.NotNil(t, err, "The main server should no longer accept connections.")
+
+	_, err = get(metricsLis.Addr(), "/metrics")
+	assert.NotNil(t, err, "The metrics server should no longer accept connections.")
+}
+
+func Test_runServerShouldReportAPortThatIsInUse(t *testing.T) {
+	occupied := listen(t)
+	defer occupied.Close()
+
+	conf := mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ServerAddr = occupied.Addr().String()
+	})
+	_, port, _ := net.SplitHostPort(conf.ServerAddr)
+
+	err := api.RunServer(context.Background())
+
+	if assert.NotNil(t, err, "The server must not start on an occupied port.") {
+		assert.Contains(t, err.Error(), "the port "+port+" of the SERVER_ADDR")
+		assert.Contains(t, err.Error(), "already in use")
+	}
+}
+
+func Test_metricsShouldExposeTheBuildInfo(t *testing.T) {
+	conf := mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.BuildCommit = "0a1b2c3"
+		conf.Environment = "staging"
+	})
+
+	w := httptest.NewRecorder()
+	api.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, w.Body.String(),
+		fmt.Sprintf(`erp_build_info{commit="0a1b2c3",environment="staging",version="%s"} 1`, conf.VersionInfo.String()))
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/repository"
+)
+
+// INCLUDE_PARAM is the query param listing the associations to preload (e.g. `?include=Items.Product`).
+const INCLUDE_PARAM = "include"
+
+// ListIncluded answers the request with the rows of the model T along with the associations of the
+// `include` query param. An include nested deeper than the `maxPreloadDepth` (or that is not an
+// association of the model) is answered with a 400.
+func ListIncluded[T any](c *gin.Context, repo *repository.Repository[T]) {
+	includes, err := repo.ParseIncludes(c.Query(INCLUDE_PARAM))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	models, err := repo.List(c.Request.Context(), repository.WithIncludes(includes))
+	if err != nil {
+		WriteError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	rows, err := FilterFields[T](c, models)
+	if err != nil {
+		WriteError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	WriteList(c, completeList(rows))
+}
@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/core/repository"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Category struct {
+	Id       uint64 `gorm:"primaryKey"`
+	ParentId *uint64
+	Parent   *Category
+}
+
+func includeRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.MaxPreloadDepth = 2
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.GET("/categories", func(c *gin.Context) { api.ListIncluded(c, repository.New[Category](db)) })
+
+	return router, mock
+}
+
+func Test_shouldPreloadAnIncludeWithinTheMaximumDepth(t *testing.T) {
+	router, mock := includeRouter(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `categories`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(3, 2))
+	mock.ExpectQuery("SELECT \\* FROM `categories` WHERE `categories`.`id` = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(2, 1))
+	mock.ExpectQuery("SELECT \\* FROM `categories` WHERE `categories`.`id` = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(1, nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/categories?include=Parent.Parent", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldRejectAnIncludeOverTheMaximumDepth(t *testing.T) {
+	router, mock := includeRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/categories?include=Parent.Parent.Parent", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "nested too deeply")
+	assert.Nil(t, mock.ExpectationsWereMet(), "The categories must not have been queried.")
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+// ListResponse is a page of the rows of a list endpoint, Total is the number of the rows of the whole list.
+type ListResponse[T any] struct {
+	Data     []T   `json:"data"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+// WriteList answers the request with the page of the list, the page is wrapped in its ListResponse when
+// the `listEnvelope` of the app config is set, otherwise only the bare array of its Data is written. The
+// timestamps of the rows are converted with the WriteJSON.
+func WriteList[T any](c *gin.Context, list ListResponse[T]) {
+	if list.Data == nil {
+		list.Data = []T{}
+	}
+
+	if !loader.AppConfig().ListEnvelope {
+		WriteJSON(c, http.StatusOK, list.Data)
+		return
+	}
+
+	WriteJSON(c, http.StatusOK, list)
+}
+
+// completeList is the ListResponse of a list that is not paginated, its single page holds every row.
+func completeList[T any](rows []T) ListResponse[T] {
+	return ListResponse[T]{Data: rows, Page: 1, PageSize: len(rows), Total: int64(len(rows))}
+}
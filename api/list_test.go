@@ -0,0 +1,51 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveList(t *testing.T, envelope bool, list api.ListResponse[Product]) string {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ListEnvelope = envelope
+	})
+
+	router := gin.New()
+	router.GET("/products", func(c *gin.Context) { api.WriteList(c, list) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+func Test_shouldWrapTheListInTheEnvelopeWhenConfigured(t *testing.T) {
+	list := api.ListResponse[Product]{
+		Data:     []Product{{Id: 3, Name: "Lamp", Price: 1500}},
+		Page:     2,
+		PageSize: 2,
+		Total:    3,
+	}
+
+	assert.JSONEq(t, `{
+		"data": [{"Id": 3, "Name": "Lamp", "Price": 1500, "Notes": ""}],
+		"page": 2,
+		"page_size": 2,
+		"total": 3
+	}`, serveList(t, true, list))
+
+	assert.JSONEq(t, `[{"Id": 3, "Name": "Lamp", "Price": 1500, "Notes": ""}]`, serveList(t, false, list))
+}
+
+func Test_anEmptyListShouldStillBeAnArray(t *testing.T) {
+	assert.JSONEq(t, `[]`, serveList(t, false, api.ListResponse[Product]{}))
+	assert.JSONEq(t, `{"data": [], "page": 0, "page_size": 0, "total": 0}`, serveList(t, true, api.ListResponse[Product]{}))
+}
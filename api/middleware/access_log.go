@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/rommms07/idream-erp/internal/requestid"
+)
+
+// accessLogFields maps the names of the `accessLogFields` to the attr of the access log record, the
+// names must be kept in sync with the loader.AccessLogFieldNames.
+var accessLogFields = map[string]func(c *gin.Context, elapsed time.Duration) slog.Attr{
+	"method":   func(c *gin.Context, _ time.Duration) slog.Attr { return slog.String("method", c.Request.Method) },
+	"path":     func(c *gin.Context, _ time.Duration) slog.Attr { return slog.String("path", c.Request.URL.Path) },
+	"route":    func(c *gin.Context, _ time.Duration) slog.Attr { return slog.String("route", c.FullPath()) },
+	"query":    func(c *gin.Context, _ time.Duration) slog.Attr { return slog.String("query", c.Request.URL.RawQuery) },
+	"status":   func(c *gin.Context, _ time.Duration) slog.Attr { return slog.Int("status", c.Writer.Status()) },
+	"duration": func(_ *gin.Context, d time.Duration) slog.Attr { return slog.Float64("duration", d.Seconds()*1000) },
+	"bytes":    func(c *gin.Context, _ time.Duration) slog.Attr { return slog.Int("bytes", max(c.Writer.Size(), 0)) },
+	"ip":       func(c *gin.Context, _ time.Duration) slog.Attr { return slog.String("ip", c.ClientIP()) },
+	"user_agent": func(c *gin.Context, _ time.Duration) slog.Attr {
+		return slog.String("user_agent", c.Request.UserAgent())
+	},
+	"user_id": func(c *gin.Context, _ time.Duration) slog.Attr {
+		id, _ := UserId(c)
+		return slog.Uint64("user_id", id)
+	},
+	"request_id": func(c *gin.Context, _ time.Duration) slog.Attr {
+		id, _ := requestid.From(c.Request.Context())
+		return slog.String("request_id", id)
+	},
+}
+
+// AccessLogHandler writes a record of every request to the logger, the record only holds the
+// `accessLogFields` of the app config (the duration is in milliseconds).
+func AccessLogHandler(logger *slog.Logger) gin.HandlerFunc {
+	fields := loader.AppConfig().AccessLogFields
+
+	return func(c *gin.Context) {
+		start := now()
+		c.Next()
+		elapsed := now().Sub(start)
+
+		attrs := make([]slog.Attr, 0, len(fields))
+		for _, name := range fields {
+			if field, exists := accessLogFields[name]; exists {
+				attrs = append(attrs, field(c, elapsed))
+			}
+		}
+
+		logger.LogAttrs(c.Request.Context(), slog.LevelInfo, "access", attrs...)
+	}
+}
+
+// AccessLogMiddleware logs the requests to the app logger, the access log is disabled when the
+// `accessLogFields` of the app config is empty.
+func AccessLogMiddleware() gin.HandlerFunc {
+	if len(loader.AppConfig().AccessLogFields) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return AccessLogHandler(logging.Logger())
+}
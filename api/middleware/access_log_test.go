@@ -0,0 +1,61 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_accessLogShouldOnlyHaveTheConfiguredFields(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.AccessLogFields = []string{"method", "status", "user_id", "request_id"}
+	})
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}))
+
+	router := gin.New()
+	router.Use(middleware.RequestIdMiddleware(), middleware.AccessLogHandler(logger))
+	router.POST("/orders", func(c *gin.Context) {
+		middleware.SetUserId(c, 42)
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?draft=1", nil)
+	req.Header.Set(middleware.REQUEST_ID_HEADER, "4f2a9c")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := map[string]any{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.Equal(t, map[string]any{
+		"msg":        "access",
+		"method":     "POST",
+		"status":     float64(http.StatusCreated),
+		"user_id":    float64(42),
+		"request_id": "4f2a9c",
+	}, record)
+}
+
+func Test_everyAccessLogFieldShouldBeLogged(t *testing.T) {
+	for _, name := range loader.AccessLogFieldNames {
+		assert.Contains(t, middleware.AccessLogFields, name, "The field is accepted by the config but never logged.")
+	}
+}
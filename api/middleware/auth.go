@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/auth/session"
+)
+
+const (
+	// AUTH_SCHEME prefixes the id of the session in the `Authorization` header.
+	AUTH_SCHEME = "Bearer "
+)
+
+// AuthMiddleware authenticates the requests carrying the id of a session in their `Authorization`
+// header (e.g. `Bearer <id>`), the user, the roles and the tenant of the session are stored in the
+// gin.Context. A request without a valid session stays anonymous, it is up to the PolicyMiddleware
+// to reject it.
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := strings.CutPrefix(c.GetHeader("Authorization"), AUTH_SCHEME)
+		if !ok || len(id) == 0 {
+			c.Next()
+			return
+		}
+
+		s, err := session.Default().Get(c.Request.Context(), id)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		SetUserId(c, s.UserId)
+		SetUserRoles(c, s.Roles...)
+
+		if s.TenantId != 0 {
+			SetTenantId(c, s.TenantId)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferedWriter holds the response of the handlers in memory instead of sending it, it is used by
+// the middlewares that need to decide what to send only after the handlers are done. The buffer is
+// either copied to the wrapped writer with `flush` or thrown away with `discard`. A handler flushing
+// its response (e.g. the StreamJSON of the core/source/export package) commits it, the buffer is then
+// sent and the later writes go straight to the wrapped writer.
+type bufferedWriter struct {
+	gin.ResponseWriter
+
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	discarded   bool
+	streaming   bool
+}
+
+func newBufferedWriter(w gin.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, header: w.Header().Clone(), status: w.Status()}
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.discarded {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.wroteHeader = true
+	return w.buf.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.discarded || w.wroteHeader || w.streaming {
+		return
+	}
+
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.wroteHeader = true
+}
+
+func (w *bufferedWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+
+	if !w.wroteHeader {
+		return -1
+	}
+
+	return w.buf.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.wroteHeader
+}
+
+// Flush commits the response, the buffered one is sent and the writer streams from then on.
+func (w *bufferedWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.discarded {
+		return
+	}
+
+	if !w.streaming {
+		w.send()
+		w.buf.Reset()
+		w.streaming = true
+	}
+
+	w.ResponseWriter.Flush()
+}
+
+// streamed reports whether the response was committed by a Flush, it was then already sent.
+func (w *bufferedWriter) streamed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.streaming
+}
+
+// discard throws away the buffered response, any later write of the handlers is ignored.
+func (w *bufferedWriter) discard() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.discarded = true
+	w.buf.Reset()
+}
+
+// flush copies the buffered response to the wrapped writer, unless it was already streamed.
+func (w *bufferedWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.streaming {
+		w.send()
+	}
+}
+
+func (w *bufferedWriter) send() {
+	for key, vals := range w.header {
+		w.ResponseWriter.Header()[key] = vals
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.buf.Bytes())
+}
+
+// bytes returns a copy of the buffered body.
+func (w *bufferedWriter) bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]byte{}, w.buf.Bytes()...)
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/readsplit"
+)
+
+// DEFAULT_CONSISTENT_READ_HEADER is the header pinning a request to the primary when the
+// `consistentReadHeader` is not set.
+const DEFAULT_CONSISTENT_READ_HEADER = "X-Consistent-Read"
+
+// ConsistentReadMiddleware pins the statements of the writes and of the requests carrying the
+// `consistentReadHeader` (with any value but a false one) to the primary, overriding the read split.
+// The handlers must run their statements within the ctx of the request for it to take effect.
+func ConsistentReadMiddleware() gin.HandlerFunc {
+	header := loader.AppConfig().ConsistentReadHeader
+	if len(header) == 0 {
+		header = DEFAULT_CONSISTENT_READ_HEADER
+	}
+
+	return func(c *gin.Context) {
+		pinned := false
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if val := c.GetHeader(header); len(val) != 0 {
+				consistent, err := strconv.ParseBool(val)
+				pinned = err != nil || consistent
+			}
+		default:
+			pinned = true
+		}
+
+		if pinned {
+			c.Request = c.Request.WithContext(readsplit.WithPrimary(c.Request.Context()))
+		}
+
+		c.Next()
+	}
+}
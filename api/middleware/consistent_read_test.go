@@ -0,0 +1,40 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/internal/db/readsplit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldPinTheWritesAndTheConsistentReadsToThePrimary(t *testing.T) {
+	pinned := false
+
+	router := gin.New()
+	router.Use(middleware.ConsistentReadMiddleware())
+	router.Any("/orders", func(c *gin.Context) { pinned = readsplit.IsPinned(c.Request.Context()) })
+
+	for _, tt := range []struct {
+		method, header string
+		pinned         bool
+	}{
+		{http.MethodGet, "", false},
+		{http.MethodGet, "1", true},
+		{http.MethodGet, "yes", true},
+		{http.MethodGet, "false", false},
+		{http.MethodPost, "", true},
+		{http.MethodDelete, "", true},
+	} {
+		req := httptest.NewRequest(tt.method, "/orders", nil)
+		if len(tt.header) != 0 {
+			req.Header.Set(middleware.DEFAULT_CONSISTENT_READ_HEADER, tt.header)
+		}
+
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, tt.pinned, pinned, "%s with the header %q", tt.method, tt.header)
+	}
+}
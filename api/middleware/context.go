@@ -0,0 +1,63 @@
+// This package contains the gin middlewares used by the api server.
+
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+const (
+	// UserIdKey is the key used by the auth middleware to store the id of the authenticated user
+	// in the gin.Context.
+	UserIdKey = "idream.user_id"
+
+	// UserRolesKey is the key used by the auth middleware to store the roles of the authenticated user
+	// in the gin.Context.
+	UserRolesKey = "idream.user_roles"
+
+	// TenantIdKey is the key used by the auth middleware to store the tenant of the authenticated user
+	// in the gin.Context.
+	TenantIdKey = "idream.tenant_id"
+)
+
+// SetUserId marks the request as authenticated by the user with the given id.
+func SetUserId(c *gin.Context, id uint64) {
+	c.Set(UserIdKey, id)
+}
+
+// UserId returns the id of the authenticated user, the second return value is false for the
+// anonymous requests.
+func UserId(c *gin.Context) (uint64, bool) {
+	val, exists := c.Get(UserIdKey)
+	if !exists {
+		return 0, false
+	}
+
+	id, ok := val.(uint64)
+	return id, ok
+}
+
+// SetUserRoles stores the roles of the authenticated user, they are checked by the PolicyMiddleware.
+func SetUserRoles(c *gin.Context, roles ...string) {
+	c.Set(UserRolesKey, roles)
+}
+
+// UserRoles returns the roles of the authenticated user.
+func UserRoles(c *gin.Context) []string {
+	return c.GetStringSlice(UserRolesKey)
+}
+
+// SetTenantId stores the tenant of the authenticated user.
+func SetTenantId(c *gin.Context, id uint64) {
+	c.Set(TenantIdKey, id)
+}
+
+// TenantId returns the tenant of the authenticated user, the second return value is false when the
+// request is anonymous or the user has no tenant.
+func TenantId(c *gin.Context) (uint64, bool) {
+	val, exists := c.Get(TenantIdKey)
+	if !exists {
+		return 0, false
+	}
+
+	id, ok := val.(uint64)
+	return id, ok
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	// STALE_WARNING is the `Warning` header of a response served from the cache of the degraded mode.
+	STALE_WARNING = `110 - "Response is Stale"`
+
+	DEFAULT_DEGRADED_MAX_ENTRIES = 1000
+)
+
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// responseCache keeps the last successful response of every cacheable GET request, once it is full
+// an arbitrary entry is evicted for the new one.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	max     int
+}
+
+func (rc *responseCache) get(key string) (*cachedResponse, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	res, exists := rc.entries[key]
+	return res, exists
+}
+
+func (rc *responseCache) put(key string, res *cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if _, exists := rc.entries[key]; !exists && len(rc.entries) >= rc.max {
+		for evicted := range rc.entries {
+			delete(rc.entries, evicted)
+			break
+		}
+	}
+
+	rc.entries[key] = res
+}
+
+// hasConnectionError reports whether any of the errors attached by the handlers to the gin.Context
+// was caused by the database being unreachable.
+func hasConnectionError(c *gin.Context) bool {
+	for _, err := range c.Errors {
+		if mysql.IsConnectionError(err.Err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// anonymous reports whether the request carries no credentials, only the responses of such requests
+// are the same for every client and may be served to someone else.
+func anonymous(c *gin.Context) bool {
+	if _, exists := UserId(c); exists {
+		return false
+	}
+
+	return len(c.GetHeader("Authorization")) == 0 && len(c.GetHeader("Cookie")) == 0
+}
+
+// shareable reports whether the response may be stored in the shared cache, the ones setting a
+// cookie or marked private by the handlers are never kept.
+func shareable(header http.Header) bool {
+	if len(header.Values("Set-Cookie")) > 0 || len(header.Values("Vary")) > 0 {
+		return false
+	}
+
+	cacheControl := strings.ToLower(header.Get("Cache-Control"))
+	return !strings.Contains(cacheControl, "private") && !strings.Contains(cacheControl, "no-store")
+}
+
+// DegradedModeMiddleware remembers the last successful response of the GET requests matching the
+// `cacheablePaths` of the degraded mode config. When a handler fails because the database is down
+// (the handler must attach the error with c.Error), the remembered response is served with a
+// `Warning` header instead. Any other request is left untouched, so the writes still fail. Since the
+// cache is shared by every client, only the public responses of the anonymous requests are kept.
+func DegradedModeMiddleware() gin.HandlerFunc {
+	conf := loader.AppConfig().DegradedMode
+
+	if !conf.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	max := conf.MaxEntries
+	if max <= 0 {
+		max = DEFAULT_DEGRADED_MAX_ENTRIES
+	}
+
+	cache := &responseCache{entries: make(map[string]*cachedResponse), max: max}
+
+	cacheable := func(path string) bool {
+		for _, prefix := range conf.CacheablePaths {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || !cacheable(c.Request.URL.Path) || !anonymous(c) {
+			c.Next()
+			return
+		}
+
+		key := c.Request.URL.RequestURI()
+		original := c.Writer
+		bw := newBufferedWriter(original)
+
+		c.Writer = bw
+		c.Next()
+		c.Writer = original
+
+		// A streamed response was sent as it was written, it is too large to be cached anyway.
+		if bw.streamed() {
+			return
+		}
+
+		status := bw.Status()
+
+		if status >= 200 && status < 300 {
+			if shareable(bw.Header()) {
+				header := bw.Header().Clone()
+				header.Del("Set-Cookie")
+				cache.put(key, &cachedResponse{status, header, bw.bytes()})
+			}
+		} else if res, exists := cache.get(key); exists && hasConnectionError(c) {
+			for name, vals := range res.header {
+				original.Header()[name] = vals
+			}
+
+			original.Header().Set("Warning", STALE_WARNING)
+			original.WriteHeader(res.status)
+			original.Write(res.body)
+			return
+		}
+
+		bw.flush()
+	}
+}
@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldServeCachedReadsWhileTheDatabaseIsDown(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DegradedMode.Enabled = true
+		conf.DegradedMode.CacheablePaths = []string{"/products"}
+	})
+
+	dbDown := false
+	handler := func(c *gin.Context) {
+		if dbDown {
+			c.Error(driver.ErrBadConn)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database unavailable"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"products": []string{"chair"}})
+	}
+
+	router := gin.New()
+	router.Use(middleware.DegradedModeMiddleware())
+	router.GET("/products", handler)
+	router.POST("/products", handler)
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/products", nil))
+		return w
+	}
+
+	w := serve(http.MethodGet)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Warning"))
+
+	dbDown = true
+
+	w = serve(http.MethodGet)
+	assert.Equal(t, http.StatusOK, w.Code, "The cached response should be served while the database is down.")
+	assert.Equal(t, middleware.STALE_WARNING, w.Header().Get("Warning"))
+	assert.JSONEq(t, `{"products":["chair"]}`, w.Body.String())
+
+	w = serve(http.MethodPost)
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "The writes must still fail.")
+}
+
+func Test_shouldNotShareTheCachedReadsOfAuthenticatedRequests(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DegradedMode.Enabled = true
+		conf.DegradedMode.CacheablePaths = []string{"/orders", "/cart"}
+	})
+
+	dbDown := false
+	handler := func(c *gin.Context) {
+		if dbDown {
+			c.Error(driver.ErrBadConn)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database unavailable"})
+			return
+		}
+
+		if c.Request.URL.Path == "/cart" {
+			c.SetCookie("cart", "1", 3600, "/", "", false, true)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"owner": c.GetHeader("Authorization")})
+	}
+
+	router := gin.New()
+	router.Use(middleware.DegradedModeMiddleware())
+	router.GET("/orders", handler)
+	router.GET("/cart", handler)
+
+	serve := func(path, authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if len(authorization) > 0 {
+			r.Header.Set("Authorization", authorization)
+		}
+
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	serve("/orders", "Bearer alice")
+	serve("/cart", "")
+
+	dbDown = true
+
+	w := serve("/orders", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "The response of an authenticated request must not be served to others.")
+
+	w = serve("/cart", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "The responses setting a cookie must not be cached.")
+	assert.Empty(t, w.Header().Values("Set-Cookie"))
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+// WeakETag returns a weak ETag of the given content, the content is either the response body or
+// anything that changes with it (e.g. the max of a version column).
+func WeakETag(b []byte) string {
+	sum := sha1.Sum(b)
+	return `W/"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatches reports whether the etag is listed in the `If-None-Match` header, the comparison is
+// weak as required for the conditional GET requests.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if len(ifNoneMatch) == 0 {
+		return false
+	}
+
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NotModified sets the ETag of the response and aborts the request with a 304 when it matches the
+// `If-None-Match` of the request. The handlers that can tell the version of their data without
+// rendering it can call this first and return early when it reports true.
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	if !etagMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// ETagMiddleware computes the weak ETag of the successful GET responses of the `ETagPaths` of the
+// app config, the response is replaced with a 304 when the client already has it. A response whose
+// ETag was already set by the handler (see NotModified) is left as is.
+func ETagMiddleware() gin.HandlerFunc {
+	paths := loader.AppConfig().ETagPaths
+
+	if len(paths) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	matches := func(path string) bool {
+		for _, prefix := range paths {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || !matches(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		bw := newBufferedWriter(original)
+
+		c.Writer = bw
+		c.Next()
+		c.Writer = original
+
+		// A streamed response was sent as it was written, it has no ETag.
+		if bw.streamed() {
+			return
+		}
+
+		if bw.Status() != http.StatusOK || len(bw.Header().Get("ETag")) != 0 {
+			bw.flush()
+			return
+		}
+
+		etag := WeakETag(bw.bytes())
+		bw.Header().Set("ETag", etag)
+
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			original.Header().Set("ETag", etag)
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+
+		bw.flush()
+	}
+}
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newETagRouter(t *testing.T) *gin.Engine {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ETagPaths = []string{"/customers"}
+	})
+
+	router := gin.New()
+	router.Use(middleware.ETagMiddleware())
+
+	router.GET("/customers", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"customers": []string{"Juan", "Maria"}})
+	})
+
+	router.GET("/customers/version", func(c *gin.Context) {
+		if middleware.NotModified(c, middleware.WeakETag([]byte("2023-01-02 03:04:05"))) {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"customers": []string{"Juan", "Maria"}})
+	})
+
+	return router
+}
+
+func conditionalGet(router http.Handler, path, etag string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+
+	if len(etag) != 0 {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test_shouldAnswerAnUnchangedResponseWithNotModified(t *testing.T) {
+	router := newETagRouter(t)
+
+	w := conditionalGet(router, "/customers", "")
+	etag := w.Header().Get("ETag")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Maria")
+	assert.Regexp(t, `^W/".+"$`, etag, "The first response should carry a weak ETag.")
+
+	w = conditionalGet(router, "/customers", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String(), "A 304 must not have a body.")
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = conditionalGet(router, "/customers", `W/"stale"`)
+	assert.Equal(t, http.StatusOK, w.Code, "A stale ETag should get the full response.")
+}
+
+func Test_handlerShouldBeAbleToUseItsOwnVersion(t *testing.T) {
+	router := newETagRouter(t)
+
+	w := conditionalGet(router, "/customers/version", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = conditionalGet(router, "/customers/version", w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+}
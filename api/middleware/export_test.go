@@ -0,0 +1,12 @@
+package middleware
+
+import "time"
+
+// SetNow overrides the clock used by the middlewares, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
+
+var AccessLogFields = accessLogFields
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+// HTTPSRedirectHandler redirects the plain-http requests to their https equivalent with a 301, the
+// scheme of a request is the one of the `X-Forwarded-Proto` header set by the TLS-terminating proxy
+// (or of the connection when it is missing). The requests to the exempt paths are never redirected.
+func HTTPSRedirectHandler(exempt []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+
+		if proto := c.GetHeader("X-Forwarded-Proto"); len(proto) != 0 {
+			// A chain of proxies appends its schemes, the first one is the scheme of the client.
+			scheme, _, _ = strings.Cut(proto, ",")
+		}
+
+		if !strings.EqualFold(strings.TrimSpace(scheme), "http") || slices.Contains(exempt, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		target := *c.Request.URL
+		target.Scheme, target.Host = "https", c.Request.Host
+
+		c.Redirect(http.StatusMovedPermanently, target.String())
+		c.Abort()
+	}
+}
+
+// HTTPSRedirectMiddleware redirects the plain-http requests when the `forceHTTPS` of the app config is
+// set, the `forceHTTPSExemptPaths` (e.g. the health check of the load balancer) are never redirected.
+func HTTPSRedirectMiddleware() gin.HandlerFunc {
+	conf := loader.AppConfig()
+	if !conf.ForceHTTPS {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return HTTPSRedirectHandler(conf.ForceHTTPSExemptPaths)
+}
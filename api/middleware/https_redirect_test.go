@@ -0,0 +1,38 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldRedirectThePlainHttpRequests(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.HTTPSRedirectHandler([]string{"/health"}))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/orders", ok)
+	router.GET("/health", ok)
+
+	serve := func(target, proto string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Host = "erp.example.com"
+		r.Header.Set("X-Forwarded-Proto", proto)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("/orders?page=2", "http")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "https://erp.example.com/orders?page=2", w.Header().Get("Location"))
+
+	assert.Equal(t, http.StatusOK, serve("/orders", "https").Code)
+	assert.Equal(t, http.StatusOK, serve("/orders", "https, http").Code, "The scheme of the client must win.")
+	assert.Equal(t, http.StatusOK, serve("/health", "http").Code, "An exempt path must not be redirected.")
+}
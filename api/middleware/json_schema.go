@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/jsonschema"
+)
+
+// LoadJSONSchemas loads the schema file of every route from the dir.
+func LoadJSONSchemas(dir string, routes map[string]string) (map[string]*jsonschema.Schema, error) {
+	schemas := make(map[string]*jsonschema.Schema, len(routes))
+
+	for route, file := range routes {
+		schema, err := jsonschema.Load(filepath.Join(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("error: cannot load the schema of the route %s: %w", route, err)
+		}
+
+		schemas[route] = schema
+	}
+
+	return schemas, nil
+}
+
+// JSONSchemaHandler validates the body of the requests against the schema of their route, the
+// schemas are keyed by the method and the pattern of the route (e.g. `POST /v1/users`). A body that
+// does not match the schema is rejected with a 400 listing the offending fields.
+func JSONSchemaHandler(schemas map[string]*jsonschema.Schema) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		schema, ok := schemas[c.Request.Method+" "+c.FullPath()]
+		if !ok || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status_code": http.StatusBadRequest,
+				"error":       "error: cannot read the request body",
+			})
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		errs, err := schema.ValidateJSON(body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status_code": http.StatusBadRequest,
+				"error":       "error: the request body is not a valid JSON",
+			})
+			return
+		}
+
+		if len(errs) != 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status_code": http.StatusBadRequest,
+				"error":       "error: the request body does not match its schema",
+				"fields":      errs,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// JSONSchemaMiddleware validates the request bodies against the `requestSchemas` of the app config,
+// it panics when a schema cannot be loaded so that a broken schema never goes unnoticed.
+func JSONSchemaMiddleware() gin.HandlerFunc {
+	config := loader.AppConfig()
+	if len(config.RequestSchemas) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	schemas, err := LoadJSONSchemas(config.RequestSchemaDir, config.RequestSchemas)
+	if err != nil {
+		panic(err)
+	}
+
+	return JSONSchemaHandler(schemas)
+}
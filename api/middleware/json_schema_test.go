@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+const CUSTOMER_SCHEMA = `{
+	"type": "object",
+	"required": ["name"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"}
+	}
+}`
+
+func schemaRouter(t *testing.T) *gin.Engine {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "customer.json"), []byte(CUSTOMER_SCHEMA), 0o644))
+
+	schemas, err := middleware.LoadJSONSchemas(dir, map[string]string{"POST /customers/:id": "customer.json"})
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.Use(middleware.JSONSchemaHandler(schemas))
+	router.POST("/customers/:id", func(c *gin.Context) {
+		var body map[string]any
+		assert.Nil(t, c.BindJSON(&body))
+		c.JSON(http.StatusOK, body)
+	})
+
+	return router
+}
+
+func serveSchema(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/customers/1", strings.NewReader(body)))
+	return w
+}
+
+func Test_shouldRejectABodyMissingARequiredField(t *testing.T) {
+	w := serveSchema(schemaRouter(t), `{"email": "nobody"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var res struct {
+		Fields []map[string]string `json:"fields"`
+	}
+
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []map[string]string{
+		{"field": "name", "message": "is required"},
+		{"field": "email", "message": "must match ^[^@]+@[^@]+$"},
+	}, res.Fields)
+}
+
+func Test_shouldPassAValidBodyToTheHandler(t *testing.T) {
+	w := serveSchema(schemaRouter(t), `{"name": "Acme", "email": "billing@acme.test"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name": "Acme", "email": "billing@acme.test"}`, w.Body.String())
+}
+
+func Test_shouldRejectABodyThatIsNotAJson(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, serveSchema(schemaRouter(t), `{"name":`).Code)
+}
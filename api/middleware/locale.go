@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/validation"
+)
+
+// LocaleMiddleware carries the preferred locale of the `Accept-Language` header in the context of the
+// request, the validation messages are translated to it (see the core/validation package).
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		preferred, _, _ := strings.Cut(c.GetHeader("Accept-Language"), ",")
+		locale, _, _ := strings.Cut(preferred, ";")
+
+		if locale = strings.TrimSpace(locale); len(locale) != 0 && locale != "*" {
+			c.Request = c.Request.WithContext(validation.WithLocale(c.Request.Context(), locale))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/internal/db/nplusone"
+)
+
+// NPlusOneMiddleware counts the queries made within every request so that the likely N+1 queries are
+// logged, it does nothing outside of the development environment.
+func NPlusOneMiddleware() gin.HandlerFunc {
+	enabled := nplusone.Enabled()
+
+	return func(c *gin.Context) {
+		if enabled {
+			c.Request = c.Request.WithContext(nplusone.WithTracking(c.Request.Context()))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/metrics"
+)
+
+// PayloadRecorder records the number of body bytes read and written by a request of the route.
+type PayloadRecorder func(route string, requestBytes, responseBytes int64)
+
+// countingReader counts the bytes of the request body that were read by the handlers.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.n += int64(n)
+	return n, err
+}
+
+// countingWriter counts the bytes of the response body written by the handlers.
+type countingWriter struct {
+	gin.ResponseWriter
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *countingWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.n += int64(n)
+	return n, err
+}
+
+// PayloadSizeHandler measures the body of every request and of its response and passes them to the
+// record, the requests that did not match any route are recorded under `unmatched`.
+func PayloadSizeHandler(record PayloadRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reader := &countingReader{}
+		if c.Request.Body != nil {
+			reader.ReadCloser = c.Request.Body
+			c.Request.Body = reader
+		}
+
+		writer := &countingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+
+		route := c.FullPath()
+		if len(route) == 0 {
+			route = "unmatched"
+		}
+
+		record(route, reader.n, writer.n)
+	}
+}
+
+// PayloadSizeMiddleware records the payload sizes to the prometheus histograms when the
+// `payloadSizeMetrics` of the app config is set.
+func PayloadSizeMiddleware() gin.HandlerFunc {
+	if !loader.AppConfig().PayloadSizeMetrics {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return PayloadSizeHandler(metrics.ObservePayloadSizes)
+}
@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+type payloadRecord struct {
+	route                       string
+	requestBytes, responseBytes int64
+}
+
+func Test_shouldRecordThePayloadSizesOfTheRoute(t *testing.T) {
+	records := []payloadRecord{}
+
+	router := gin.New()
+	router.Use(middleware.PayloadSizeHandler(func(route string, req, res int64) {
+		records = append(records, payloadRecord{route, req, res})
+	}))
+
+	router.POST("/orders/:id", func(c *gin.Context) {
+		io.Copy(io.Discard, c.Request.Body)
+		c.String(http.StatusOK, "accepted")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/1", strings.NewReader(`{"qty":12}`)))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, payloadRecord{"/orders/:id", 10, 8}, records[0], "The sizes should be labeled by the route, not the path.")
+		assert.Equal(t, "unmatched", records[1].route)
+		assert.Zero(t, records[1].requestBytes)
+	}
+}
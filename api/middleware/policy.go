@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+// matchRoute reports whether the route matches the pattern, a pattern ending with "/*" matches the
+// route at its prefix and every route below it, the other patterns are matched with path.Match (so a
+// "*" matches a single segment of the route).
+func matchRoute(pattern, route string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		if route == prefix || strings.HasPrefix(route, prefix+"/") {
+			return true
+		}
+	}
+
+	matched, _ := path.Match(pattern, route)
+	return matched
+}
+
+// policyFor returns the roles allowed to access the route, the most specific (i.e. the longest) of the
+// patterns matching the route wins. The second return value is false when no pattern matches it.
+func policyFor(policies map[string][]string, route string) ([]string, bool) {
+	var (
+		roles []string
+		best  = -1
+	)
+
+	for pattern, allowed := range policies {
+		if len(pattern) > best && matchRoute(pattern, route) {
+			roles, best = allowed, len(pattern)
+		}
+	}
+
+	return roles, best != -1
+}
+
+// PolicyHandler only lets the users having one of the roles allowed by the policies access a route,
+// the policies map the patterns of the routes to their allowed roles. The routes without a policy are
+// left open, an anonymous request to a guarded route is answered with a 401 and a user without any of
+// the allowed roles with a 403.
+func PolicyHandler(policies map[string][]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if len(route) == 0 {
+			route = c.Request.URL.Path
+		}
+
+		allowed, guarded := policyFor(policies, route)
+		if !guarded {
+			c.Next()
+			return
+		}
+
+		if _, authenticated := UserId(c); !authenticated {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"status_code": http.StatusUnauthorized,
+				"error":       "error: the request is not authenticated",
+			})
+			return
+		}
+
+		for _, role := range UserRoles(c) {
+			if slices.Contains(allowed, role) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"status_code": http.StatusForbidden,
+			"error":       "error: the user is not allowed to access the route",
+		})
+	}
+}
+
+// RequireRoles only lets the users having one of the roles access the routes it is registered on.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return PolicyHandler(map[string][]string{"/*": roles})
+}
+
+// PolicyMiddleware enforces the `policies` of the app config, it must be registered after the
+// AuthMiddleware.
+func PolicyMiddleware() gin.HandlerFunc {
+	return PolicyHandler(loader.AppConfig().Policies)
+}
@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// policyRouter authenticates the requests having an X-User-Roles header with the roles of the header.
+func policyRouter(policies map[string][]string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if roles, ok := c.Request.Header["X-User-Roles"]; ok {
+			middleware.SetUserId(c, 1)
+			middleware.SetUserRoles(c, roles...)
+		}
+	}, middleware.PolicyHandler(policies))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/invoices/:id", ok)
+	router.GET("/admin/users", ok)
+	router.GET("/health", ok)
+
+	return router
+}
+
+func servePolicy(router *gin.Engine, target string, roles ...string) int {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, role := range roles {
+		r.Header.Add("X-User-Roles", role)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+func Test_shouldEnforceThePoliciesOfTheRoutes(t *testing.T) {
+	router := policyRouter(map[string][]string{
+		"/invoices/*": {"accountant", "admin"},
+		"/admin/*":    {"admin"},
+	})
+
+	assert.Equal(t, http.StatusOK, servePolicy(router, "/invoices/1", "clerk", "accountant"))
+	assert.Equal(t, http.StatusOK, servePolicy(router, "/admin/users", "admin"))
+	assert.Equal(t, http.StatusForbidden, servePolicy(router, "/admin/users", "accountant"))
+	assert.Equal(t, http.StatusUnauthorized, servePolicy(router, "/admin/users"))
+
+	// The routes without a policy are left open.
+	assert.Equal(t, http.StatusOK, servePolicy(router, "/health"))
+}
+
+func Test_shouldPreferTheMostSpecificPolicy(t *testing.T) {
+	router := policyRouter(map[string][]string{
+		"/*":            {"admin"},
+		"/invoices/:id": {"accountant"},
+	})
+
+	assert.Equal(t, http.StatusOK, servePolicy(router, "/invoices/1", "accountant"))
+	assert.Equal(t, http.StatusForbidden, servePolicy(router, "/admin/users", "accountant"))
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	DEFAULT_SATURATION_WINDOW = time.Second
+	DEFAULT_MAX_RETRY_AFTER   = 30 * time.Second
+)
+
+// PoolStats returns the stats of the connection pool, the second return value is false when there is
+// no pool yet.
+type PoolStats func() (sql.DBStats, bool)
+
+// poolSaturation tells whether the connection pool is saturated, it samples the stats of the pool
+// every window and the pool is saturated once the requests that waited for a connection during the
+// last window reached the threshold.
+type poolSaturation struct {
+	mu sync.Mutex
+
+	stats     PoolStats
+	threshold int64
+	window    time.Duration
+
+	sampledAt  time.Time
+	prev       sql.DBStats
+	saturated  bool
+	retryAfter time.Duration
+}
+
+func (ps *poolSaturation) sample() (bool, time.Duration) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	t := now()
+	if t.Sub(ps.sampledAt) < ps.window {
+		return ps.saturated, ps.retryAfter
+	}
+
+	stats, ok := ps.stats()
+	if !ok {
+		return false, 0
+	}
+
+	waits := stats.WaitCount - ps.prev.WaitCount
+	ps.saturated = !ps.sampledAt.IsZero() && waits >= ps.threshold
+	ps.retryAfter = 0
+
+	// The Retry-After is the average wait for a connection during the window.
+	if ps.saturated && waits > 0 {
+		ps.retryAfter = (stats.WaitDuration - ps.prev.WaitDuration) / time.Duration(waits)
+	}
+
+	ps.sampledAt, ps.prev = t, stats
+	return ps.saturated, ps.retryAfter
+}
+
+// retryAfterSeconds rounds the wait up to the whole seconds of the `Retry-After` header.
+func retryAfterSeconds(wait, max time.Duration) string {
+	if wait > max {
+		wait = max
+	}
+
+	return strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds()))))
+}
+
+// PoolSaturationHandler answers the write requests with a 503 while the connection pool of the stats
+// is saturated, the reads are only shed when the `shedReads` of the config is set.
+func PoolSaturationHandler(stats PoolStats) gin.HandlerFunc {
+	conf := loader.AppConfig().PoolSaturation
+
+	window := DEFAULT_SATURATION_WINDOW
+	if conf.WindowMs != 0 {
+		window = time.Duration(conf.WindowMs) * time.Millisecond
+	}
+
+	max := DEFAULT_MAX_RETRY_AFTER
+	if conf.MaxRetryAfterSeconds != 0 {
+		max = time.Duration(conf.MaxRetryAfterSeconds) * time.Second
+	}
+
+	ps := &poolSaturation{stats: stats, threshold: conf.WaitCountThreshold, window: window}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if !conf.ShedReads {
+				c.Next()
+				return
+			}
+		}
+
+		if saturated, wait := ps.sample(); saturated {
+			c.Header("Retry-After", retryAfterSeconds(wait, max))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"status_code": http.StatusServiceUnavailable,
+				"error":       "error: the server is busy, try again later",
+			})
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// PoolSaturationMiddleware sheds the writes while the pool of the database is saturated, it is
+// enabled by the `poolSaturation` of the app config.
+func PoolSaturationMiddleware() gin.HandlerFunc {
+	if !loader.AppConfig().PoolSaturation.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return PoolSaturationHandler(mysql.Stats)
+}
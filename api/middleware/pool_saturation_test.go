@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldShedTheWritesWhileThePoolIsSaturated(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.PoolSaturation.WaitCountThreshold = 50
+		conf.PoolSaturation.WindowMs = 1000
+		conf.PoolSaturation.MaxRetryAfterSeconds = 30
+	})
+
+	for _, shedReads := range []bool{false, true} {
+		mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+			conf.PoolSaturation.ShedReads = shedReads
+		})
+
+		clock := time.Now()
+		restore := middleware.SetNow(func() time.Time { return clock })
+
+		stats := sql.DBStats{MaxOpenConnections: 10, InUse: 10}
+
+		router := gin.New()
+		router.Use(middleware.PoolSaturationHandler(func() (sql.DBStats, bool) { return stats, true }))
+		router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+		router.POST("/orders", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+		serve := func(method string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(method, "/orders", nil))
+			return w
+		}
+
+		assert.Equal(t, http.StatusCreated, serve(http.MethodPost).Code, "The first sample only sets the baseline.")
+
+		// 60 requests waited 2.5s on average for a connection during the last window.
+		clock = clock.Add(time.Second)
+		stats.WaitCount += 60
+		stats.WaitDuration += 60 * 2500 * time.Millisecond
+
+		w := serve(http.MethodPost)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "3", w.Header().Get("Retry-After"))
+
+		if shedReads {
+			assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodGet).Code)
+		} else {
+			assert.Equal(t, http.StatusOK, serve(http.MethodGet).Code, "The reads must be unaffected.")
+		}
+
+		// The pool recovered during the next window.
+		clock = clock.Add(time.Second)
+		stats.WaitCount += 2
+
+		assert.Equal(t, http.StatusCreated, serve(http.MethodPost).Code)
+
+		restore()
+	}
+}
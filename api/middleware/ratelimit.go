@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+var (
+	// now is used by the rate limiter to tell the current time, the tests override it so that
+	// the refill of the buckets can be simulated.
+	now = time.Now
+)
+
+// bucket is a single token bucket, the tokens are lazily refilled every time the bucket is taken.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Quota is the state of a bucket after it was taken, Reset is how long until the bucket is full again.
+type Quota struct {
+	Allowed   bool
+	Limit     uint64
+	Remaining uint64
+	Reset     time.Duration
+}
+
+func (b *bucket) quota(limit *loader.RateLimit, allowed bool) Quota {
+	q := Quota{Allowed: allowed, Limit: limit.Burst, Remaining: uint64(math.Max(0, math.Floor(b.tokens)))}
+
+	if missing := float64(limit.Burst) - b.tokens; missing > 0 && limit.Rate > 0 {
+		q.Reset = time.Duration(missing / limit.Rate * float64(time.Second))
+	}
+
+	return q
+}
+
+func (b *bucket) take(limit *loader.RateLimit, t time.Time) bool {
+	elapsed := t.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.last = t
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+const (
+	// DEFAULT_TENANT_RATE_LIMIT is the key of the PerTenant limit applied to the tenants that are not
+	// listed.
+	DEFAULT_TENANT_RATE_LIMIT = "default"
+)
+
+// RateLimiter keeps a token bucket for every user and ip address that made a request, authenticated
+// requests are limited per user while the anonymous ones fallback to the ip address. The requests of
+// a tenant with a PerTenant limit (or of any tenant when the DEFAULT_TENANT_RATE_LIMIT is set) share
+// the bucket of their tenant instead.
+type RateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	PerUser *loader.RateLimit
+	PerIp   *loader.RateLimit
+
+	// PerTenant maps the id of a tenant to its limit.
+	PerTenant map[string]*loader.RateLimit
+
+	// Headers exposes the Quota of the client with the `X-RateLimit-*` headers.
+	Headers bool
+}
+
+func NewRateLimiter(perUser, perIp *loader.RateLimit) *RateLimiter {
+	return &RateLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: now(),
+		PerUser:   perUser,
+		PerIp:     perIp,
+	}
+}
+
+// Allow takes a token from the bucket of the given key, a nil limit always allows the request.
+func (rl *RateLimiter) Allow(key string, limit *loader.RateLimit) bool {
+	if limit == nil {
+		return true
+	}
+
+	return rl.Take(key, limit).Allowed
+}
+
+// Take takes a token from the bucket of the given key and returns the quota left in it, the limit must
+// not be nil.
+func (rl *RateLimiter) Take(key string, limit *loader.RateLimit) Quota {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	t := now()
+	if t.Sub(rl.lastSweep) > time.Hour {
+		rl.sweep(t)
+	}
+
+	b, exists := rl.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(limit.Burst), last: t}
+		rl.buckets[key] = b
+	}
+
+	return b.quota(limit, b.take(limit, t))
+}
+
+// sweep removes the buckets that were idle for an hour, by then they are refilled and are the same as
+// a new bucket so it is safe to forget them. This keeps the memory bounded by the active clients.
+func (rl *RateLimiter) sweep(t time.Time) {
+	for key, b := range rl.buckets {
+		if t.Sub(b.last) > time.Hour {
+			delete(rl.buckets, key)
+		}
+	}
+
+	rl.lastSweep = t
+}
+
+// tenantLimit returns the bucket and the limit of the tenant of the request, the bool is false when the
+// request has no tenant or the tenant has no limit.
+func (rl *RateLimiter) tenantLimit(c *gin.Context) (string, *loader.RateLimit, bool) {
+	if _, ok := UserId(c); !ok {
+		return "", nil, false
+	}
+
+	id, ok := TenantId(c)
+	if !ok {
+		return "", nil, false
+	}
+
+	limit, exists := rl.PerTenant[strconv.FormatUint(id, 10)]
+	if !exists {
+		limit, exists = rl.PerTenant[DEFAULT_TENANT_RATE_LIMIT]
+	}
+
+	return fmt.Sprintf("tenant:%d", id), limit, exists
+}
+
+func (rl *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, limit := "ip:"+c.ClientIP(), rl.PerIp
+
+		if id, ok := UserId(c); ok {
+			key, limit = fmt.Sprintf("user:%d", id), rl.PerUser
+		}
+
+		if tenantKey, tenantLimit, ok := rl.tenantLimit(c); ok {
+			key, limit = tenantKey, tenantLimit
+		}
+
+		if limit == nil {
+			c.Next()
+			return
+		}
+
+		quota := rl.Take(key, limit)
+
+		if rl.Headers {
+			c.Header("X-RateLimit-Limit", strconv.FormatUint(quota.Limit, 10))
+			c.Header("X-RateLimit-Remaining", strconv.FormatUint(quota.Remaining, 10))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(quota.Reset.Seconds())), 10))
+		}
+
+		if !quota.Allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"status_code": http.StatusTooManyRequests,
+				"error":       "error: rate limit exceeded",
+			})
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimitMiddleware returns the rate limiting middleware configured by the `PerUserRateLimit`, the
+// `PerIpRateLimit`, the `TenantRateLimits` and the `RateLimitHeaders` of the app config. It must be
+// registered after the auth middleware, otherwise all of the requests are treated as anonymous.
+func RateLimitMiddleware() gin.HandlerFunc {
+	config := loader.AppConfig()
+
+	rl := NewRateLimiter(config.PerUserRateLimit, config.PerIpRateLimit)
+	rl.PerTenant = config.TenantRateLimits
+	rl.Headers = config.RateLimitHeaders
+	return rl.Handler()
+}
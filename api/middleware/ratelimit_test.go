@@ -0,0 +1,171 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newRateLimitedRouter creates a router where the user id is taken from the `X-User` header, this
+// stands in for the auth middleware.
+func newRateLimitedRouter(rl *middleware.RateLimiter) *gin.Engine {
+	router := gin.New()
+
+	router.Use(func(c *gin.Context) {
+		switch c.GetHeader("X-User") {
+		case "1":
+			middleware.SetUserId(c, 1)
+		case "2":
+			middleware.SetUserId(c, 2)
+		}
+	}, rl.Handler())
+
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func doRequest(router http.Handler, user string) int {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if len(user) != 0 {
+		req.Header.Set("X-User", user)
+	}
+
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func Test_usersShouldHaveIndependentBuckets(t *testing.T) {
+	t0 := time.Now()
+	defer middleware.SetNow(func() time.Time { return t0 })()
+
+	router := newRateLimitedRouter(middleware.NewRateLimiter(
+		&loader.RateLimit{Rate: 1, Burst: 2},
+		&loader.RateLimit{Rate: 1, Burst: 1},
+	))
+
+	assert.Equal(t, http.StatusOK, doRequest(router, "1"))
+	assert.Equal(t, http.StatusOK, doRequest(router, "1"))
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(router, "1"), "User 1 should have exhausted its bucket.")
+
+	assert.Equal(t, http.StatusOK, doRequest(router, "2"), "User 2 must not share the bucket of user 1.")
+	assert.Equal(t, http.StatusOK, doRequest(router, "2"))
+}
+
+func Test_anonymousRequestsShouldFallbackToTheIpBucket(t *testing.T) {
+	t0 := time.Now()
+	restore := middleware.SetNow(func() time.Time { return t0 })
+	defer restore()
+
+	router := newRateLimitedRouter(middleware.NewRateLimiter(
+		&loader.RateLimit{Rate: 1, Burst: 5},
+		&loader.RateLimit{Rate: 1, Burst: 1},
+	))
+
+	assert.Equal(t, http.StatusOK, doRequest(router, ""))
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(router, ""), "The ip bucket should be exhausted.")
+	assert.Equal(t, http.StatusOK, doRequest(router, "1"), "Authenticated requests must not use the ip bucket.")
+
+	middleware.SetNow(func() time.Time { return t0.Add(time.Second) })
+	assert.Equal(t, http.StatusOK, doRequest(router, ""), "The ip bucket should be refilled after a second.")
+}
+
+func Test_shouldExposeTheQuotaOfTheClient(t *testing.T) {
+	t0 := time.Now()
+	clock := t0
+	defer middleware.SetNow(func() time.Time { return clock })()
+
+	rl := middleware.NewRateLimiter(nil, &loader.RateLimit{Rate: 1, Burst: 3})
+	rl.Headers = true
+	router := newRateLimitedRouter(rl)
+
+	quota := func() (int, string, string, string) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return w.Code, w.Header().Get("X-RateLimit-Limit"), w.Header().Get("X-RateLimit-Remaining"), w.Header().Get("X-RateLimit-Reset")
+	}
+
+	code, limit, remaining, reset := quota()
+	assert.Equal(t, []any{http.StatusOK, "3", "2", "1"}, []any{code, limit, remaining, reset})
+
+	code, _, remaining, reset = quota()
+	assert.Equal(t, []any{http.StatusOK, "1", "2"}, []any{code, remaining, reset})
+
+	code, _, remaining, reset = quota()
+	assert.Equal(t, []any{http.StatusOK, "0", "3"}, []any{code, remaining, reset})
+
+	code, _, remaining, _ = quota()
+	assert.Equal(t, []any{http.StatusTooManyRequests, "0"}, []any{code, remaining})
+
+	// The bucket is full again once the reset passed.
+	clock = t0.Add(3 * time.Second)
+
+	code, _, remaining, _ = quota()
+	assert.Equal(t, []any{http.StatusOK, "2"}, []any{code, remaining})
+}
+
+func Test_shouldHideTheQuotaUnlessEnabled(t *testing.T) {
+	router := newRateLimitedRouter(middleware.NewRateLimiter(nil, &loader.RateLimit{Rate: 1, Burst: 3}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Empty(t, w.Header().Get("X-RateLimit-Remaining"))
+}
+
+func Test_tenantsShouldBeLimitedPerTheirOwnLimit(t *testing.T) {
+	t0 := time.Now()
+	defer middleware.SetNow(func() time.Time { return t0 })()
+
+	rl := middleware.NewRateLimiter(&loader.RateLimit{Rate: 1, Burst: 100}, nil)
+	rl.PerTenant = map[string]*loader.RateLimit{
+		"10":                                 {Rate: 1, Burst: 1},
+		"20":                                 {Rate: 1, Burst: 3},
+		middleware.DEFAULT_TENANT_RATE_LIMIT: {Rate: 1, Burst: 2},
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		middleware.SetUserId(c, 1)
+
+		if tenant, err := strconv.ParseUint(c.GetHeader("X-Tenant"), 10, 64); err == nil {
+			middleware.SetTenantId(c, tenant)
+		}
+	}, rl.Handler())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(tenant string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant", tenant)
+
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	allowed := func(tenant string) int {
+		n := 0
+		for serve(tenant) == http.StatusOK {
+			n++
+		}
+
+		return n
+	}
+
+	assert.Equal(t, 1, allowed("10"))
+	assert.Equal(t, 3, allowed("20"), "The tenant 20 must not share the bucket of the tenant 10.")
+	assert.Equal(t, 2, allowed("30"), "An unconfigured tenant should get the default limit.")
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+)
+
+// RecoveryHandler recovers the panics of the rest of the handlers, the panic is logged along with its
+// stack trace once both are passed through the scrub (when not nil) and the request is answered with
+// a 500.
+func RecoveryHandler(logger *slog.Logger, scrub func(string) string) gin.HandlerFunc {
+	if scrub == nil {
+		scrub = func(s string) string { return s }
+	}
+
+	return func(c *gin.Context) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			logger.ErrorContext(c.Request.Context(), "recovered a panic",
+				"panic", scrub(fmt.Sprint(p)),
+				"stack", scrub(string(debug.Stack())),
+				"path", c.Request.URL.Path)
+
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"status_code": http.StatusInternalServerError,
+				"error":       "error: internal server error",
+			})
+		}()
+
+		c.Next()
+	}
+}
+
+// RecoveryMiddleware logs the recovered panics to the app logger, the secrets are scrubbed from them
+// when the `scrubPanicSecrets` of the app config is set.
+func RecoveryMiddleware() gin.HandlerFunc {
+	config := loader.AppConfig()
+	if !config.ScrubPanicSecrets {
+		return RecoveryHandler(logging.Logger(), nil)
+	}
+
+	secrets := config.Secrets()
+	return RecoveryHandler(logging.Logger(), func(s string) string {
+		return logging.ScrubSecrets(s, secrets)
+	})
+}
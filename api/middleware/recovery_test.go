@@ -0,0 +1,35 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldMaskThePasswordOfTheDSNInTheLoggedPanic(t *testing.T) {
+	buf := &bytes.Buffer{}
+	scrub := func(s string) string { return logging.ScrubSecrets(s, []string{"fb-app-s3cret"}) }
+
+	router := gin.New()
+	router.Use(middleware.RecoveryHandler(slog.New(slog.NewJSONHandler(buf, nil)), scrub))
+	router.GET("/boom", func(c *gin.Context) {
+		panic("error: cannot open erp:Pa55w0rd@tcp(127.0.0.1:3306)/idream with fb-app-s3cret")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, buf.String(), `"msg":"recovered a panic"`)
+	assert.Contains(t, buf.String(), "erp:********@tcp(127.0.0.1:3306)/idream")
+	assert.Contains(t, buf.String(), `"stack":"goroutine`)
+	assert.NotContains(t, buf.String(), "Pa55w0rd")
+	assert.NotContains(t, buf.String(), "fb-app-s3cret")
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/reports"
+)
+
+// ReportLimitHandler runs the rest of the handlers of the request within a slot of the limiter, a
+// rejected (or given up) report is answered with a 429.
+func ReportLimitHandler(limiter *reports.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := limiter.Run(c.Request.Context(), func(ctx context.Context) error {
+			c.Next()
+			return nil
+		})
+
+		if errors.Is(err, reports.ErrTooManyReports) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"status_code": http.StatusTooManyRequests,
+				"error":       reports.ErrTooManyReports.Error(),
+			})
+		}
+	}
+}
+
+// ReportLimitMiddleware limits the concurrent reports with the limiter of the app, it must only be
+// registered on the routes generating the reports.
+func ReportLimitMiddleware() gin.HandlerFunc {
+	return ReportLimitHandler(reports.Default())
+}
@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/core/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldAnswerTheExcessReportsWithA429(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+
+	router := gin.New()
+	router.GET("/reports/sales", middleware.ReportLimitHandler(reports.NewLimiter(1, false)), func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+
+	go func() {
+		router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/reports/sales", nil))
+		close(done)
+	}()
+
+	<-started
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports/sales", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	close(release)
+	<-done
+	assert.Equal(t, http.StatusOK, first.Code)
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+)
+
+const (
+	// DEFAULT_MAX_LOGGED_BODY_BYTES is the length the logged bodies are truncated to when the
+	// `maxLoggedBodyBytes` is not set.
+	DEFAULT_MAX_LOGGED_BODY_BYTES = 4096
+
+	BODY_MASK = "********"
+)
+
+// RedactBody masks the values of the fields in the JSON body, see the `redactedBodyFields`. A body that
+// is not a JSON is returned as is.
+func RedactBody(body []byte, fields []string) []byte {
+	var val any
+	if len(fields) == 0 || json.Unmarshal(body, &val) != nil {
+		return body
+	}
+
+	redacted, err := json.Marshal(redactValue("", val, fields))
+	if err != nil {
+		return body
+	}
+
+	return redacted
+}
+
+// RedactForm masks the values of the fields in the form-urlencoded body, see the `redactedBodyFields`.
+// A body that is not a valid form is returned as is.
+func RedactForm(body []byte, fields []string) []byte {
+	values, err := url.ParseQuery(string(body))
+	if len(fields) == 0 || err != nil {
+		return body
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		for _, val := range values[key] {
+			if redactedField(key, key, fields) {
+				val = BODY_MASK
+			} else {
+				val = url.QueryEscape(val)
+			}
+
+			pairs = append(pairs, url.QueryEscape(key)+"="+val)
+		}
+	}
+
+	return []byte(strings.Join(pairs, "&"))
+}
+
+// loggedBody returns the body as it is logged, the redacted fields of the JSON and the form bodies are
+// masked and the other bodies are not logged at all. The body is truncated to the limit, a body longer
+// than that cannot be parsed to be redacted so it is only logged when there is nothing to redact.
+func loggedBody(contentType string, body []byte, limit int, fields []string) string {
+	truncated := len(body) > limit
+	if truncated && len(fields) != 0 {
+		return fmt.Sprintf("(more than %d bytes, not logged)", limit)
+	}
+
+	switch {
+	case contentType == gin.MIMEJSON || strings.HasSuffix(contentType, "+json"):
+		body = RedactBody(body, fields)
+	case contentType == gin.MIMEPOSTForm:
+		body = RedactForm(body, fields)
+	default:
+		return fmt.Sprintf("(%q body, not logged)", contentType)
+	}
+
+	if len(body) > limit {
+		return fmt.Sprintf("%s...(truncated)", body[:limit])
+	}
+
+	return string(body)
+}
+
+func redactValue(path string, val any, fields []string) any {
+	switch val := val.(type) {
+	case map[string]any:
+		for key, child := range val {
+			childPath := key
+			if len(path) != 0 {
+				childPath = path + "." + key
+			}
+
+			if redactedField(childPath, key, fields) {
+				val[key] = BODY_MASK
+				continue
+			}
+
+			val[key] = redactValue(childPath, child, fields)
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = redactValue(path, child, fields)
+		}
+	}
+
+	return val
+}
+
+func redactedField(path, key string, fields []string) bool {
+	for _, field := range fields {
+		if strings.EqualFold(field, path) || (!strings.Contains(field, ".") && strings.EqualFold(field, key)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequestBodyLogHandler logs the redacted body of every request at the debug level, the body is only
+// read when the logger is enabled at that level and it is handed back to the handlers untouched. Only
+// the JSON and the form bodies are logged and no more than the limit is read from them.
+func RequestBodyLogHandler(logger *slog.Logger) gin.HandlerFunc {
+	conf := loader.AppConfig().Logging
+
+	limit := conf.MaxLoggedBodyBytes
+	if limit <= 0 {
+		limit = DEFAULT_MAX_LOGGED_BODY_BYTES
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if c.Request.Body == nil || !logger.Enabled(ctx, slog.LevelDebug) {
+			c.Next()
+			return
+		}
+
+		original := c.Request.Body
+		head, err := io.ReadAll(io.LimitReader(original, int64(limit)+1))
+
+		// The rest of the body is left to the handlers.
+		var rest io.Reader = original
+		if err != nil {
+			rest = errReader{err}
+		}
+
+		c.Request.Body = &replayedBody{Reader: io.MultiReader(bytes.NewReader(head), rest), Closer: original}
+
+		logged := loggedBody(c.ContentType(), head, limit, conf.RedactedBodyFields)
+		logger.DebugContext(ctx, "request body", "method", c.Request.Method, "path", c.Request.URL.Path, "body", logged)
+		c.Next()
+	}
+}
+
+// replayedBody is the body of the request handed back to the handlers, the part that was read for the
+// log is read again before the rest of the original body.
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader hands the error of the read of the body over to the handlers, once they read the part of
+// the body that was read before it.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	return 0, io.EOF
+}
+
+// RequestBodyLogMiddleware logs the bodies of the requests to the app logger when the `logRequestBody`
+// of the app config is set.
+func RequestBodyLogMiddleware() gin.HandlerFunc {
+	if !loader.AppConfig().Logging.LogRequestBody {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return RequestBodyLogHandler(logging.Logger())
+}
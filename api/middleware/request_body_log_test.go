@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func logBody(t *testing.T, contentType, body string) (logged string, received string) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	router := gin.New()
+	router.Use(middleware.RequestBodyLogHandler(logger))
+	router.POST("/login", func(c *gin.Context) {
+		b, err := io.ReadAll(c.Request.Body)
+		assert.Nil(t, err)
+		received = string(b)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	record := map[string]any{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+
+	logged, _ = record["body"].(string)
+	return
+}
+
+func Test_shouldLogTheBodyWithTheSecretsMasked(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Logging.RedactedBodyFields = []string{"password", "card.number"}
+	})
+
+	body := `{"email":"juan@example.com","password":"hunter22","card":{"number":"4111111111111111"},"items":[{"password":"x"}]}`
+	logged, received := logBody(t, "application/json; charset=utf-8", body)
+
+	assert.JSONEq(t, `{"email":"juan@example.com","password":"********","card":{"number":"********"},"items":[{"password":"********"}]}`, logged)
+	assert.Equal(t, body, received, "The handler should still receive the original body.")
+}
+
+func Test_shouldTruncateALargeBody(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Logging.MaxLoggedBodyBytes = 16
+		conf.Logging.RedactedBodyFields = nil
+	})
+
+	body := `{"note":"` + strings.Repeat("a", 100) + `"}`
+	logged, received := logBody(t, "application/json", body)
+
+	assert.Equal(t, body[:16]+"...(truncated)", logged)
+	assert.Equal(t, body, received, "The handler should receive the part of the body that was not read too.")
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Logging.RedactedBodyFields = []string{"password"}
+	})
+
+	body = `{"note":"` + strings.Repeat("a", 100) + `","password":"hunter22"}`
+	logged, received = logBody(t, "application/json", body)
+
+	assert.NotContains(t, logged, "hunter22", "A body that cannot be redacted must not be logged.")
+	assert.Equal(t, body, received)
+}
+
+func Test_shouldRedactTheFormsAndSkipTheOtherBodies(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Logging.RedactedBodyFields = []string{"password"}
+	})
+
+	logged, received := logBody(t, "application/x-www-form-urlencoded", "email=juan%40example.com&password=hunter22")
+	assert.Equal(t, "email=juan%40example.com&password=********", logged)
+	assert.Equal(t, "email=juan%40example.com&password=hunter22", received)
+
+	logged, _ = logBody(t, "text/plain", "password=hunter22")
+	assert.NotContains(t, logged, "hunter22")
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/internal/requestid"
+)
+
+const (
+	REQUEST_ID_HEADER = "X-Request-Id"
+)
+
+// RequestIdMiddleware carries the id of the request in its context and echoes it in the response, the
+// `X-Request-Id` of the client is kept when it is a valid id, otherwise a new id is generated.
+func RequestIdMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(REQUEST_ID_HEADER)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Header(REQUEST_ID_HEADER, id)
+		c.Request = c.Request.WithContext(requestid.With(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/internal/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveWithRequestId(header string) (*httptest.ResponseRecorder, string) {
+	seen := ""
+
+	router := gin.New()
+	router.Use(middleware.RequestIdMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		seen, _ = requestid.From(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.REQUEST_ID_HEADER, header)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, seen
+}
+
+func Test_shouldCarryTheRequestIdOfTheClient(t *testing.T) {
+	w, seen := serveWithRequestId("4f2a9c")
+
+	assert.Equal(t, "4f2a9c", seen)
+	assert.Equal(t, "4f2a9c", w.Header().Get(middleware.REQUEST_ID_HEADER))
+}
+
+func Test_shouldReplaceAnInvalidRequestId(t *testing.T) {
+	w, seen := serveWithRequestId("x */ DROP TABLE users; /*")
+
+	assert.True(t, requestid.Valid(seen), "An invalid id must be replaced by a generated one.")
+	assert.Equal(t, seen, w.Header().Get(middleware.REQUEST_ID_HEADER))
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	DEFAULT_CONTENT_SECURITY_POLICY = "default-src 'self'"
+	DEFAULT_CONTENT_TYPE_OPTIONS    = "nosniff"
+	DEFAULT_FRAME_OPTIONS           = "DENY"
+	DEFAULT_REFERRER_POLICY         = "strict-origin-when-cross-origin"
+)
+
+func valueOr(val, def string) string {
+	if len(val) == 0 {
+		return def
+	}
+
+	return val
+}
+
+// SecurityHeadersMiddleware sets the standard security headers configured in the `securityHeaders`
+// section of the app config on every response.
+func SecurityHeadersMiddleware() gin.HandlerFunc {
+	conf := loader.AppConfig().SecurityHeaders
+
+	headers := map[string]string{
+		"Content-Security-Policy": valueOr(conf.ContentSecurityPolicy, DEFAULT_CONTENT_SECURITY_POLICY),
+		"X-Content-Type-Options":  valueOr(conf.ContentTypeOptions, DEFAULT_CONTENT_TYPE_OPTIONS),
+		"X-Frame-Options":         valueOr(conf.FrameOptions, DEFAULT_FRAME_OPTIONS),
+		"Referrer-Policy":         valueOr(conf.ReferrerPolicy, DEFAULT_REFERRER_POLICY),
+	}
+
+	if len(conf.StrictTransportSecurity) != 0 {
+		headers["Strict-Transport-Security"] = conf.StrictTransportSecurity
+	}
+
+	for _, name := range conf.Disabled {
+		delete(headers, http.CanonicalHeaderKey(name))
+	}
+
+	return func(c *gin.Context) {
+		for name, val := range headers {
+			c.Header(name, val)
+		}
+
+		c.Next()
+	}
+}
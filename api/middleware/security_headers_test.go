@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveWithSecurityHeaders() http.Header {
+	router := gin.New()
+	router.Use(middleware.SecurityHeadersMiddleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Header()
+}
+
+func Test_shouldSetTheConfiguredSecurityHeaders(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SecurityHeaders.ContentSecurityPolicy = "default-src 'none'"
+		conf.SecurityHeaders.StrictTransportSecurity = "max-age=63072000"
+		conf.SecurityHeaders.Disabled = nil
+	})
+
+	header := serveWithSecurityHeaders()
+
+	assert.Equal(t, "default-src 'none'", header.Get("Content-Security-Policy"))
+	assert.Equal(t, "max-age=63072000", header.Get("Strict-Transport-Security"))
+	assert.Equal(t, middleware.DEFAULT_CONTENT_TYPE_OPTIONS, header.Get("X-Content-Type-Options"))
+	assert.Equal(t, middleware.DEFAULT_FRAME_OPTIONS, header.Get("X-Frame-Options"))
+	assert.Equal(t, middleware.DEFAULT_REFERRER_POLICY, header.Get("Referrer-Policy"))
+}
+
+func Test_disabledSecurityHeaderShouldBeAbsent(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SecurityHeaders.StrictTransportSecurity = ""
+		conf.SecurityHeaders.Disabled = []string{"x-frame-options"}
+	})
+
+	header := serveWithSecurityHeaders()
+
+	assert.Empty(t, header.Get("X-Frame-Options"), "A disabled header must not be set.")
+	assert.Empty(t, header.Get("Strict-Transport-Security"), "HSTS must only be set when configured.")
+	assert.NotEmpty(t, header.Get("Content-Security-Policy"))
+}
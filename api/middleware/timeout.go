@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	DEFAULT_TIMEOUT_MESSAGE = "error: request timeout"
+)
+
+// timeoutWriter buffers the response of the handler running behind the TimeoutHandler, the buffer
+// is only copied to the real writer when the handler finished before the deadline. A handler flushing
+// its response commits it, the buffer is then sent and the later writes go straight to the real
+// writer. The writes of the handler after the deadline are dropped.
+type timeoutWriter struct {
+	w http.ResponseWriter
+
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+	streaming   bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if tw.streaming {
+		return tw.w.Write(b)
+	}
+
+	tw.wroteHeader = true
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	tw.wroteHeader = true
+	tw.status = code
+}
+
+// Flush commits the response, the buffered one is sent and the writer streams from then on.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return
+	}
+
+	if !tw.streaming {
+		tw.send()
+		tw.buf.Reset()
+		tw.streaming = true
+	}
+
+	if flusher, ok := tw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// send copies the buffered response to the real writer.
+func (tw *timeoutWriter) send() {
+	for key, vals := range tw.header {
+		tw.w.Header()[key] = vals
+	}
+
+	tw.w.WriteHeader(tw.status)
+	tw.w.Write(tw.buf.Bytes())
+}
+
+// TimeoutHandler aborts the request with a 503 once the duration d elapsed, the context of the
+// request is cancelled at the same time so that the queries and outbound calls of the handler
+// abort as well. Like the http.TimeoutHandler, the response of the handler is buffered and it is
+// discarded when it took too long. The 503 is sent right away, the handler keeps running in the
+// background until it notices the cancelled context.
+//
+// It wraps the whole gin.Engine instead of being a gin middleware, the gin.Context of the request
+// is still used by the handlers after the deadline and it must not be given back to gin before.
+func TimeoutHandler(h http.Handler, d time.Duration) http.Handler {
+	message := loader.AppConfig().RequestTimeoutMessage
+	if len(message) == 0 {
+		message = DEFAULT_TIMEOUT_MESSAGE
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		tw := &timeoutWriter{w: w, header: w.Header().Clone(), status: http.StatusOK}
+
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+
+				close(done)
+			}()
+
+			h.ServeHTTP(tw, r.WithContext(ctx))
+		}()
+
+		select {
+		case <-done:
+			select {
+			case p := <-panicked:
+				panic(p)
+			default:
+			}
+
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			if !tw.streaming {
+				tw.send()
+			}
+
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			streaming := tw.streaming
+			tw.mu.Unlock()
+
+			// The streamed response was already committed, it is cut short instead.
+			if streaming {
+				return
+			}
+
+			b, _ := json.Marshal(gin.H{
+				"status_code": http.StatusServiceUnavailable,
+				"error":       message,
+			})
+
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write(b)
+		}
+	})
+}
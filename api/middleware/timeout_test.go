@@ -0,0 +1,129 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_handlerThatCompletesInTimeShouldRespondNormally(t *testing.T) {
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		c.Header("X-Handled", "yes")
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	w := httptest.NewRecorder()
+	middleware.TimeoutHandler(router, time.Second).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "yes", w.Header().Get("X-Handled"))
+	assert.JSONEq(t, `{"ok":true}`, w.Body.String())
+}
+
+func Test_slowHandlerShouldTimeoutWithACancelledContext(t *testing.T) {
+	cancelled := make(chan error, 1)
+
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			cancelled <- c.Request.Context().Err()
+		case <-time.After(time.Second):
+			cancelled <- nil
+		}
+
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	w := httptest.NewRecorder()
+	middleware.TimeoutHandler(router, 20*time.Millisecond).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), `"ok":true`, "The late response of the handler must be discarded.")
+	assert.ErrorIs(t, <-cancelled, context.DeadlineExceeded, "The handler should have received a cancelled context.")
+}
+
+func Test_timeoutShouldKeepTheNotFoundStatusOfUnknownRoutes(t *testing.T) {
+	router := gin.New()
+
+	w := httptest.NewRecorder()
+	middleware.TimeoutHandler(router, time.Second).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_timeoutShouldRespondWithoutWaitingForTheHandler(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan struct{})
+
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		defer close(finished)
+
+		// The handler ignores the cancelled context.
+		<-release
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	w := httptest.NewRecorder()
+	middleware.TimeoutHandler(router, 20*time.Millisecond).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	close(release)
+	<-finished
+
+	assert.NotContains(t, w.Body.String(), `"ok":true`, "The late writes of the handler must be dropped.")
+}
+
+func Test_aFlushedResponseShouldStreamThroughTheBufferingMiddlewares(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ETagPaths = []string{"/export"}
+	})
+
+	release := make(chan struct{})
+
+	router := gin.New()
+	router.Use(middleware.ETagMiddleware())
+	router.GET("/export", func(c *gin.Context) {
+		c.Writer.WriteString("[1,")
+		c.Writer.Flush()
+
+		<-release
+		c.Writer.WriteString("2]")
+	})
+
+	srv := httptest.NewServer(middleware.TimeoutHandler(router, time.Second))
+	t.Cleanup(srv.Close)
+
+	res, err := http.Get(srv.URL + "/export")
+	if !assert.Nil(t, err) {
+		close(release)
+		return
+	}
+
+	defer res.Body.Close()
+
+	// The first chunk is received while the handler is still running.
+	first := make([]byte, 3)
+	_, err = io.ReadFull(res.Body, first)
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,", string(first))
+
+	close(release)
+
+	rest, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "2]", string(rest))
+	assert.Empty(t, res.Header.Get("ETag"), "A streamed response has no ETag.")
+}
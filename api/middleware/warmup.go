@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	MAX_READINESS_WEIGHT = 100
+)
+
+// ReadinessWeight returns the weight of the instance at the time t for a server started at the
+// given time, the weight linearly ramps from 0 to MAX_READINESS_WEIGHT over the warmup period.
+func ReadinessWeight(start, t time.Time, warmup time.Duration) int {
+	elapsed := t.Sub(start)
+
+	if warmup <= 0 || elapsed >= warmup {
+		return MAX_READINESS_WEIGHT
+	}
+
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return int(elapsed * MAX_READINESS_WEIGHT / warmup)
+}
+
+// WarmupMiddleware sets the `Readiness-Weight` header on every response, the warmup period starts
+// when the middleware is created so it must be created right before the server is started.
+func WarmupMiddleware() gin.HandlerFunc {
+	start := now()
+	warmup := time.Duration(loader.AppConfig().WarmupPeriodSeconds) * time.Second
+
+	return func(c *gin.Context) {
+		c.Header("Readiness-Weight", strconv.Itoa(ReadinessWeight(start, now(), warmup)))
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readinessWeightShouldRampOverTheWarmupPeriod(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.WarmupPeriodSeconds = 10
+	})
+
+	t0 := time.Now()
+	clock := t0
+	defer middleware.SetNow(func() time.Time { return clock })()
+
+	router := gin.New()
+	router.Use(middleware.WarmupMiddleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	weightAt := func(d time.Duration) int {
+		clock = t0.Add(d)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		weight, err := strconv.Atoi(w.Header().Get("Readiness-Weight"))
+		assert.Nil(t, err)
+		return weight
+	}
+
+	assert.Equal(t, 0, weightAt(0))
+	assert.Equal(t, 50, weightAt(5*time.Second))
+	assert.Equal(t, 90, weightAt(9*time.Second))
+	assert.Equal(t, middleware.MAX_READINESS_WEIGHT, weightAt(10*time.Second))
+	assert.Equal(t, middleware.MAX_READINESS_WEIGHT, weightAt(time.Hour))
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	DEFAULT_WEBHOOK_DEDUP_WINDOW = 24 * time.Hour
+)
+
+// ReceivedWebhook is the dedup store of the webhook deliveries, it remembers the id of every delivery
+// received within the window.
+type ReceivedWebhook struct {
+	mu        sync.Mutex
+	received  map[string]time.Time
+	lastSweep time.Time
+
+	Window time.Duration
+}
+
+func NewReceivedWebhook(window time.Duration) *ReceivedWebhook {
+	return &ReceivedWebhook{
+		received:  make(map[string]time.Time),
+		lastSweep: now(),
+		Window:    window,
+	}
+}
+
+// Receive records the delivery id and reports whether it is the first time it was received within
+// the window, a duplicate delivery returns false.
+func (rw *ReceivedWebhook) Receive(id string) bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	t := now()
+	if t.Sub(rw.lastSweep) > rw.Window {
+		rw.sweep(t)
+	}
+
+	if at, exists := rw.received[id]; exists && t.Sub(at) < rw.Window {
+		return false
+	}
+
+	rw.received[id] = t
+	return true
+}
+
+// Forget removes the delivery id from the store, this lets the sender retry a delivery that we failed
+// to process.
+func (rw *ReceivedWebhook) Forget(id string) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	delete(rw.received, id)
+}
+
+// sweep removes the ids that were received outside of the window.
+func (rw *ReceivedWebhook) sweep(t time.Time) {
+	for id, at := range rw.received {
+		if t.Sub(at) >= rw.Window {
+			delete(rw.received, id)
+		}
+	}
+
+	rw.lastSweep = t
+}
+
+// deliveryId takes the id of the delivery from the header, or from the field of the JSON payload when
+// the header is missing. The body of the request is restored so that the handler can still read it.
+func deliveryId(c *gin.Context, header, field string) string {
+	if len(header) != 0 {
+		if id := c.GetHeader(header); len(id) != 0 {
+			return id
+		}
+	}
+
+	if len(field) == 0 || c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err != nil {
+		return ""
+	}
+
+	var v any
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil {
+		return ""
+	}
+
+	for _, key := range strings.Split(field, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+
+		v = obj[key]
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+
+	return ""
+}
+
+// Handler short-circuits the duplicate deliveries with a 200 so that the sender stops redelivering
+// them, the deliveries without an id are always passed to the handler. A delivery that fails with
+// a 5xx is forgotten so that its redelivery is processed.
+func (rw *ReceivedWebhook) Handler(header, field string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := deliveryId(c, header, field)
+
+		if len(id) == 0 {
+			c.Next()
+			return
+		}
+
+		if !rw.Receive(id) {
+			c.AbortWithStatusJSON(http.StatusOK, gin.H{
+				"status_code": http.StatusOK,
+				"duplicate":   true,
+			})
+
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			rw.Forget(id)
+		}
+	}
+}
+
+// WebhookDedupMiddleware returns the deduplication middleware configured by the `WebhookDedup` of the
+// app config, it is meant to be registered on the webhook routes only.
+func WebhookDedupMiddleware() gin.HandlerFunc {
+	conf := loader.AppConfig().WebhookDedup
+	window := DEFAULT_WEBHOOK_DEDUP_WINDOW
+
+	if conf.WindowSeconds != 0 {
+		window = time.Duration(conf.WindowSeconds) * time.Second
+	}
+
+	return NewReceivedWebhook(window).Handler(conf.Header, conf.Field)
+}
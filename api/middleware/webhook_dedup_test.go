@@ -0,0 +1,86 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebhookRouter(rw *middleware.ReceivedWebhook, runs *int, status int) *gin.Engine {
+	router := gin.New()
+
+	router.POST("/webhook", rw.Handler("X-Delivery-Id", "entry.id"), func(c *gin.Context) {
+		*runs++
+		c.Status(status)
+	})
+
+	return router
+}
+
+func deliver(router http.Handler, id, body string) int {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+
+	if len(id) != 0 {
+		req.Header.Set("X-Delivery-Id", id)
+	}
+
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func Test_duplicateDeliveriesShouldOnlyBeHandledOnce(t *testing.T) {
+	runs := 0
+	router := newWebhookRouter(middleware.NewReceivedWebhook(time.Hour), &runs, http.StatusOK)
+
+	assert.Equal(t, http.StatusOK, deliver(router, "d-1", "{}"))
+	assert.Equal(t, http.StatusOK, deliver(router, "d-1", "{}"), "A duplicate delivery must be answered with a 200.")
+	assert.Equal(t, 1, runs, "The handler should have run once for the same delivery id.")
+
+	assert.Equal(t, http.StatusOK, deliver(router, "d-2", "{}"))
+	assert.Equal(t, 2, runs)
+}
+
+func Test_shouldTakeTheDeliveryIdFromThePayload(t *testing.T) {
+	runs := 0
+	router := newWebhookRouter(middleware.NewReceivedWebhook(time.Hour), &runs, http.StatusOK)
+
+	deliver(router, "", `{"entry":{"id":1001}}`)
+	deliver(router, "", `{"entry":{"id":1001}}`)
+	assert.Equal(t, 1, runs, "The id in the payload should have deduplicated the delivery.")
+
+	deliver(router, "", `{"entry":{}}`)
+	deliver(router, "", `{"entry":{}}`)
+	assert.Equal(t, 3, runs, "The deliveries without an id must always be handled.")
+}
+
+func Test_deliveryShouldBeHandledAgainAfterTheWindow(t *testing.T) {
+	t0 := time.Now()
+	defer middleware.SetNow(func() time.Time { return t0 })()
+
+	runs := 0
+	router := newWebhookRouter(middleware.NewReceivedWebhook(time.Minute), &runs, http.StatusOK)
+
+	deliver(router, "d-1", "{}")
+
+	middleware.SetNow(func() time.Time { return t0.Add(time.Minute) })
+	deliver(router, "d-1", "{}")
+
+	assert.Equal(t, 2, runs, "The delivery id should have expired with the window.")
+}
+
+func Test_failedDeliveryShouldBeHandledWhenRedelivered(t *testing.T) {
+	runs := 0
+	router := newWebhookRouter(middleware.NewReceivedWebhook(time.Hour), &runs, http.StatusInternalServerError)
+
+	deliver(router, "d-1", "{}")
+	deliver(router, "d-1", "{}")
+
+	assert.Equal(t, 2, runs, "A delivery that failed must not be treated as a duplicate.")
+}
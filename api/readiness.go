@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	READINESS_PATH = "/readyz"
+)
+
+var (
+	// now is used to bucket the errors by the second, the tests override it.
+	now = time.Now
+
+	defaultErrorRate     *ErrorRateTracker
+	defaultErrorRateOnce sync.Once
+)
+
+// ErrorRateTracker counts the errors of the sliding window, the errors are bucketed by the second so
+// that the memory it takes does not grow with the errors.
+type ErrorRateTracker struct {
+	mu      sync.Mutex
+	seconds []int64
+	counts  []uint64
+}
+
+// NewErrorRateTracker creates the tracker of the window, it is rounded up to the second.
+func NewErrorRateTracker(window time.Duration) *ErrorRateTracker {
+	n := int((window + time.Second - 1) / time.Second)
+	if n < 1 {
+		n = 1
+	}
+
+	return &ErrorRateTracker{seconds: make([]int64, n), counts: make([]uint64, n)}
+}
+
+// Record counts an error at the current time.
+func (t *ErrorRateTracker) Record() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sec := now().Unix()
+	i := int(sec % int64(len(t.seconds)))
+
+	if t.seconds[i] != sec {
+		t.seconds[i], t.counts[i] = sec, 0
+	}
+
+	t.counts[i]++
+}
+
+// Rate returns the errors per second over the window.
+func (t *ErrorRateTracker) Rate() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sec := now().Unix()
+	window := int64(len(t.seconds))
+
+	var total uint64
+	for i, s := range t.seconds {
+		if sec-s < window {
+			total += t.counts[i]
+		}
+	}
+
+	return float64(total) / float64(window)
+}
+
+// DefaultErrorRate returns the tracker of the `errorRateWindowSeconds` of the app config, it is fed with
+// the server errors answered through the ErrorRateHandler.
+func DefaultErrorRate() *ErrorRateTracker {
+	defaultErrorRateOnce.Do(func() {
+		window := time.Duration(loader.AppConfig().ErrorRateWindowSeconds) * time.Second
+		defaultErrorRate = NewErrorRateTracker(window)
+	})
+
+	return defaultErrorRate
+}
+
+// WriteError answers the request with the status and the error.
+func WriteError(c *gin.Context, status int, err error) {
+	c.JSON(status, gin.H{"status_code": status, "error": err.Error()})
+}
+
+// statusWriter remembers the status of the response written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ErrorRateHandler counts the server errors answered by the h in the DefaultErrorRate, it goes by the
+// status that was actually sent so that the panics, the timeouts and the shed requests count as well.
+// The 503 of the READINESS_PATH is not an error of the app, it is not counted.
+func ErrorRateHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r)
+
+		if sw.status >= http.StatusInternalServerError && r.URL.Path != READINESS_PATH {
+			DefaultErrorRate().Record()
+		}
+	})
+}
+
+// ReadinessHandler answers with a 503 while the rate of the server errors is over the
+// `errorRateThreshold`, the instance is then taken out of the rotation until the rate drops.
+func ReadinessHandler(c *gin.Context) {
+	rate := DefaultErrorRate().Rate()
+
+	if threshold := loader.AppConfig().ErrorRateThreshold; threshold > 0 && rate > threshold {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status_code": http.StatusServiceUnavailable,
+			"ready":       false,
+			"error_rate":  rate,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status_code": http.StatusOK, "ready": true, "error_rate": rate})
+}
@@ -0,0 +1,81 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readinessShouldFlipWithTheErrorRate(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ErrorRateThreshold = 0.5
+	})
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer api.SetNow(func() time.Time { return clock })()
+	defer api.SetDefaultErrorRate(api.NewErrorRateTracker(10 * time.Second))()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(api.READINESS_PATH, api.ReadinessHandler)
+	router.GET("/fail", func(c *gin.Context) {
+		api.WriteError(c, http.StatusInternalServerError, errors.New("error: synthetic"))
+	})
+	router.GET("/bad", func(c *gin.Context) {
+		api.WriteError(c, http.StatusBadRequest, errors.New("error: synthetic"))
+	})
+
+	h := api.ErrorRateHandler(router)
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, http.StatusBadRequest, get("/bad"))
+	}
+
+	assert.Equal(t, http.StatusOK, get(api.READINESS_PATH), "The client errors should not count.")
+
+	for i := 0; i < 6; i++ {
+		assert.Equal(t, http.StatusInternalServerError, get("/fail"))
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, get(api.READINESS_PATH), "6 errors over 10s is over the threshold.")
+
+	clock = clock.Add(10 * time.Second)
+	assert.Equal(t, http.StatusOK, get(api.READINESS_PATH), "The readiness should recover once the errors left the window.")
+}
+
+func Test_shouldCountTheServerErrorsNotAnsweredByTheWriteError(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer api.SetNow(func() time.Time { return clock })()
+
+	tracker := api.NewErrorRateTracker(10 * time.Second)
+	defer api.SetDefaultErrorRate(tracker)()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, _ any) { c.AbortWithStatus(http.StatusInternalServerError) }))
+	router.GET("/panic", func(c *gin.Context) { panic("synthetic") })
+	router.GET("/unavailable", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+	router.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	h := api.ErrorRateHandler(router)
+
+	for _, path := range []string{"/panic", "/unavailable", "/ok"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 0.2, tracker.Rate(), "The recovered panic and the 503 should both count.")
+}
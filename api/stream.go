@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/source/export"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"gorm.io/gorm"
+)
+
+// StreamList answers the request with all of the rows of the model T (optionally scoped by the db) as
+// a JSON array streamed with the export.StreamJSON, the memory used does not grow with the number of
+// the rows. A model with fields restricted by the `fieldACL` is not streamed, its rows are read at once
+// so that the fields can be filtered for the roles of the user.
+func StreamList[T any](c *gin.Context, db *gorm.DB) {
+	if len(loader.AppConfig().FieldACL[reflect.TypeOf((*T)(nil)).Elem().Name()]) != 0 {
+		var models []*T
+		if err := db.WithContext(c.Request.Context()).Find(&models).Error; err != nil {
+			WriteError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		rows, err := FilterFields[T](c, models)
+		if err != nil {
+			WriteError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		WriteList(c, completeList(rows))
+		return
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+
+	err := export.StreamJSON(c.Request.Context(), db, c.Writer, new(T))
+	if err == nil {
+		return
+	}
+
+	// Once the first rows are sent the status can not change anymore, the array is left unterminated.
+	if !c.Writer.Written() {
+		WriteError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	logging.Logger().Warn("the streamed list was cut short", "path", c.Request.URL.Path, "error", err)
+	c.Abort()
+}
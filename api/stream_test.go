@@ -0,0 +1,61 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func streamRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.GET("/products", func(c *gin.Context) {
+		middleware.SetUserRoles(c, "clerk")
+		api.StreamList[Product](c, db)
+	})
+
+	return router, mock
+}
+
+func Test_shouldStreamTheList(t *testing.T) {
+	router, mock := streamRouter(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `products`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(1, "Chair", 4999).AddRow(2, "Table", 9999))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, w.Flushed)
+	assert.JSONEq(t, `[{"Id":1,"Name":"Chair","Price":4999,"Notes":""},{"Id":2,"Name":"Table","Price":9999,"Notes":""}]`, w.Body.String())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldNotStreamAListWithRestrictedFields(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.FieldACL = map[string]map[string][]string{"Product": {"price": {"manager"}}}
+	})
+
+	router, mock := streamRouter(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `products`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(1, "Chair", 4999))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"Id":1,"Name":"Chair","Notes":""}]`, w.Body.String())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	// TIMEZONE_HEADER is the IANA name of the zone the client wants the timestamps of the response in.
+	TIMEZONE_HEADER = "X-Timezone"
+)
+
+// locations caches the loaded locations by their name, loading one reads the tz database.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, bool) {
+	// The empty name and `Local` are valid for the time package but not an IANA zone of the client.
+	if len(name) == 0 || name == "Local" {
+		return nil, false
+	}
+
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), true
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, false
+	}
+
+	locations.Store(name, loc)
+	return loc, true
+}
+
+// ResponseLocation returns the zone of the `X-Timezone` header of the request, it falls back to the
+// `responseTimezone` of the app config when the header is missing or is not an IANA zone.
+func ResponseLocation(c *gin.Context) *time.Location {
+	if loc, ok := loadLocation(c.GetHeader(TIMEZONE_HEADER)); ok {
+		return loc
+	}
+
+	if loc, ok := loadLocation(loader.AppConfig().ResponseTimezone); ok {
+		return loc
+	}
+
+	return time.UTC
+}
+
+// ConvertTimes converts the timestamps of the decoded JSON to the loc, the timestamps are the strings
+// in the RFC 3339 format the time.Time values are encoded with.
+func ConvertTimes(val any, loc *time.Location) any {
+	switch val := val.(type) {
+	case map[string]any:
+		for key, nested := range val {
+			val[key] = ConvertTimes(nested, loc)
+		}
+	case []any:
+		for i, nested := range val {
+			val[i] = ConvertTimes(nested, loc)
+		}
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+			return t.In(loc).Format(time.RFC3339Nano)
+		}
+	}
+
+	return val
+}
+
+// WriteJSON answers the request with the JSON of the obj, its timestamps are converted to the
+// ResponseLocation of the request and the name of the zone is sent back in the `X-Timezone` header.
+func WriteJSON(c *gin.Context, status int, obj any) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"status_code": http.StatusInternalServerError,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	var val any
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&val); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"status_code": http.StatusInternalServerError,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	loc := ResponseLocation(c)
+
+	c.Header(TIMEZONE_HEADER, loc.String())
+	c.JSON(status, ConvertTimes(val, loc))
+}
@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Shipment struct {
+	Id          uint64     `json:"id"`
+	Tracking    string     `json:"tracking"`
+	ShippedAt   time.Time  `json:"shipped_at"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+func serveShipments(t *testing.T, timezone string) *httptest.ResponseRecorder {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ResponseTimezone = "UTC"
+	})
+
+	shipped := time.Date(2024, 5, 31, 20, 30, 0, 0, time.UTC)
+	shipments := []Shipment{{Id: 1, Tracking: "2024-05-31", ShippedAt: shipped}}
+
+	router := gin.New()
+	router.GET("/shipments", func(c *gin.Context) { api.WriteJSON(c, http.StatusOK, shipments) })
+
+	r := httptest.NewRequest(http.MethodGet, "/shipments", nil)
+	if len(timezone) != 0 {
+		r.Header.Set(api.TIMEZONE_HEADER, timezone)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	return w
+}
+
+func Test_shouldConvertTheTimestampsToTheRequestedTimezone(t *testing.T) {
+	w := serveShipments(t, "Asia/Manila")
+
+	assert.Equal(t, "Asia/Manila", w.Header().Get(api.TIMEZONE_HEADER))
+	assert.JSONEq(t, `[{
+		"id": 1,
+		"tracking": "2024-05-31",
+		"shipped_at": "2024-06-01T04:30:00+08:00",
+		"delivered_at": null
+	}]`, w.Body.String())
+}
+
+func Test_shouldFallBackToTheConfiguredTimezoneOnAnInvalidOne(t *testing.T) {
+	for _, timezone := range []string{"Mars/Olympus_Mons", "Local", ""} {
+		w := serveShipments(t, timezone)
+
+		assert.Equal(t, "UTC", w.Header().Get(api.TIMEZONE_HEADER))
+		assert.Contains(t, w.Body.String(), `"shipped_at":"2024-05-31T20:30:00Z"`)
+	}
+}
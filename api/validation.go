@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/validation"
+)
+
+// WriteValidationError answers the request with a 422 holding the messages of the err keyed by the JSON
+// path of their field (e.g. `{"errors": {"email": "..."}}`), the errors that are not about a single
+// field are keyed by the `_`. See the FieldErrors of the core/validation package.
+func WriteValidationError(c *gin.Context, err error) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"status_code": http.StatusUnprocessableEntity,
+		"errors":      validation.FieldErrors(err),
+	})
+}
@@ -0,0 +1,49 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/core/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type signup struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8"`
+	Address  struct {
+		City string `json:"city" validate:"required"`
+	} `json:"address"`
+}
+
+func Test_shouldRenderTheValidationErrorsKeyedByField(t *testing.T) {
+	router := gin.New()
+	router.POST("/signup", func(c *gin.Context) {
+		err := validation.ValidateModel(context.Background(), &signup{Email: "juan", Password: "secret"})
+
+		api.WriteValidationError(c, errors.Join(
+			err,
+			&validation.FieldError{Field: "email", Message: "email is already taken"},
+			errors.New("signups are closed on weekends"),
+		))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/signup", nil))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{
+		"status_code": 422,
+		"errors": {
+			"email": "Email must be a valid email address; email is already taken",
+			"password": "Password must be at least 8 characters in length",
+			"address.city": "City is a required field",
+			"_": "signups are closed on weekends"
+		}
+	}`, w.Body.String())
+}
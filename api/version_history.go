@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/core/source/mysql"
+)
+
+const (
+	VERSION_HISTORY_PATH = "/version/history"
+)
+
+// VersionHistoryHandler answers with the versions of the app that connected to the database, from the
+// oldest to the newest, see the VersionHistory of the core/source/mysql package.
+func VersionHistoryHandler(c *gin.Context) {
+	db, err := mysql.Default()
+	if err == nil {
+		var history []*mysql.AppVersionSeen
+		if history, err = mysql.VersionHistory(db); err == nil {
+			WriteJSON(c, http.StatusOK, gin.H{"versions": history})
+			return
+		}
+	}
+
+	c.Error(err)
+	WriteError(c, http.StatusInternalServerError, err)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+// VersionedRouter mounts the handlers of every version of the api under the prefix of the version
+// (e.g. `/v1/orders`), the versions are the `apiVersions` of the app config. The retired versions
+// (the `retiredApiVersions`) are answered with a 410 and any other version with a 404.
+type VersionedRouter struct {
+	groups map[string]*gin.RouterGroup
+}
+
+// versionedRoutes register the routes of the versions once the Router is created, see the
+// OnVersionedRoutes.
+var versionedRoutes []func(vr *VersionedRouter)
+
+// OnVersionedRoutes registers the fn to be called with the VersionedRouter of every Router, the
+// packages of the handlers use it to mount their routes under the versions they support.
+func OnVersionedRoutes(fn func(vr *VersionedRouter)) {
+	versionedRoutes = append(versionedRoutes, fn)
+}
+
+func NewVersionedRouter(router *gin.Engine) *VersionedRouter {
+	config := loader.AppConfig()
+	vr := &VersionedRouter{groups: make(map[string]*gin.RouterGroup)}
+
+	for _, version := range config.ApiVersions {
+		vr.groups[version] = router.Group("/" + version)
+	}
+
+	for _, version := range config.RetiredApiVersions {
+		retired := version
+
+		router.Any(fmt.Sprintf("/%s/*path", retired), func(c *gin.Context) {
+			c.JSON(http.StatusGone, gin.H{
+				"status_code": http.StatusGone,
+				"error":       fmt.Sprintf("error: the version %s of the api was retired", retired),
+			})
+		})
+	}
+
+	return vr
+}
+
+// Version returns the group of the version, it panics when the version is not one of the configured
+// `apiVersions` since the handlers would never be reachable.
+func (vr *VersionedRouter) Version(version string) *gin.RouterGroup {
+	group, exists := vr.groups[version]
+	if !exists {
+		panic(fmt.Sprintf("error: the version %s of the api is not configured", version))
+	}
+
+	return group
+}
+
+// Handle registers the handlers of the path to every one of the versions.
+func (vr *VersionedRouter) Handle(versions []string, method, path string, handlers ...gin.HandlerFunc) {
+	for _, version := range versions {
+		vr.Version(version).Handle(method, path, handlers...)
+	}
+}
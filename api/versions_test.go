@@ -0,0 +1,66 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldServeEveryVersionUnderItsPrefix(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ApiVersions = []string{"v1", "v2"}
+		conf.RetiredApiVersions = []string{"v0"}
+	})
+
+	router := gin.New()
+	versions := api.NewVersionedRouter(router)
+
+	versions.Version("v1").GET("/orders", func(c *gin.Context) { c.String(http.StatusOK, "orders v1") })
+	versions.Version("v2").GET("/orders", func(c *gin.Context) { c.String(http.StatusOK, "orders v2") })
+	versions.Handle([]string{"v1", "v2"}, http.MethodGet, "/health", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, "orders v1", serve("/v1/orders").Body.String())
+	assert.Equal(t, "orders v2", serve("/v2/orders").Body.String())
+	assert.Equal(t, http.StatusNoContent, serve("/v1/health").Code)
+	assert.Equal(t, http.StatusNoContent, serve("/v2/health").Code)
+
+	assert.Equal(t, http.StatusGone, serve("/v0/orders").Code, "A retired version must be gone.")
+	assert.Equal(t, http.StatusNotFound, serve("/v3/orders").Code, "An unknown version must not be found.")
+
+	assert.Panics(t, func() { versions.Version("v3") }, "An unconfigured version can not have handlers.")
+}
+
+func Test_theRouterShouldServeTheRegisteredVersionedRoutes(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ApiVersions = []string{"v1"}
+		conf.RetiredApiVersions = []string{"v0"}
+	})
+
+	defer api.ResetVersionedRoutes()()
+
+	api.OnVersionedRoutes(func(vr *api.VersionedRouter) {
+		vr.Version("v1").GET("/orders", func(c *gin.Context) { c.String(http.StatusOK, "orders v1") })
+	})
+
+	router := api.Router()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+	assert.Equal(t, "orders v1", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v0/orders", nil))
+	assert.Equal(t, http.StatusGone, w.Code, "A retired version must be gone.")
+}
@@ -11,3 +11,7 @@ func AppConfig() *loader.AppConfigType {
 func Dsn() string {
 	return loader.Dsn()
 }
+
+func ReplicaDsn(addr string) string {
+	return loader.ReplicaDsn(addr)
+}
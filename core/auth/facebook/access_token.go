@@ -2,6 +2,7 @@ package facebook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,6 +12,16 @@ import (
 )
 
 func (token *FacebookAccessToken) GetLongLivedToken(typ LoginType) (res_token *FacebookAccessToken, err error) {
+	return token.GetLongLivedTokenContext(context.Background(), typ)
+}
+
+// GetLongLivedTokenContext is the same as GetLongLivedToken but the calls to the Graph API are
+// cancelled with the ctx, the retries are stopped once the ctx is done.
+func (token *FacebookAccessToken) GetLongLivedTokenContext(ctx context.Context, typ LoginType) (res_token *FacebookAccessToken, err error) {
+	if err := checkEnabled(); err != nil {
+		return nil, err
+	}
+
 	res_token = &FacebookAccessToken{}
 	fbGraphUrl, _ := url.Parse(fmt.Sprintf("%s/oauth/access_token", FACEBOOK_GRAPH))
 	config := loader.AppConfig()
@@ -24,7 +35,13 @@ func (token *FacebookAccessToken) GetLongLivedToken(typ LoginType) (res_token *F
 	fbGraphUrl.RawQuery = q.Encode()
 
 	for Nt := 5; Nt > 0; Nt-- {
-		res, err := http.Get(fbGraphUrl.String())
+		var res *http.Response
+
+		res, err = graph_get(ctx, fbGraphUrl.String())
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
 		if err != nil {
 			continue
 		}
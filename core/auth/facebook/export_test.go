@@ -3,3 +3,4 @@ package facebook
 var MakeFbLoginUrl = make_fblogin_url
 var WriteRp = write_rp
 var ExchangeCodeToToken = exchange_code_to_token
+var GraphGet = graph_get
@@ -0,0 +1,139 @@
+package facebook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/outbound"
+)
+
+const (
+	DEFAULT_FB_TIMEOUT = 10 * time.Second
+
+	// GRAPH_INVALID_TOKEN is the code of the Graph API error returned for an expired or revoked token.
+	GRAPH_INVALID_TOKEN = 190
+)
+
+// ErrFacebookDisabled is returned by the helpers of the package when the `fbEnabled` of the app config
+// is unset.
+var ErrFacebookDisabled = errors.New("error: the facebook login is disabled")
+
+// checkEnabled returns the ErrFacebookDisabled when the Facebook login is disabled.
+func checkEnabled() error {
+	if !loader.AppConfig().FbEnabled {
+		return ErrFacebookDisabled
+	}
+
+	return nil
+}
+
+// GraphClient returns the http client used for calling the Graph API, its timeout is taken from the
+// `fbTimeoutMs` of the app config so that a hanging Graph API can not hang the caller. It shares the
+// pooled transport of the other outbound calls.
+func GraphClient() *http.Client {
+	timeout := DEFAULT_FB_TIMEOUT
+
+	if ms := loader.AppConfig().FbTimeoutMs; ms != 0 {
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	return &http.Client{Transport: outbound.Transport(), Timeout: timeout}
+}
+
+// graph_get sends a GET request to the Graph API, whichever of the configured timeout and the
+// deadline of the ctx comes first cancels the call.
+func graph_get(ctx context.Context, url string) (*http.Response, error) {
+	return graph_do(ctx, http.MethodGet, url)
+}
+
+func graph_do(ctx context.Context, method, url string) (*http.Response, error) {
+	if err := checkEnabled(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return GraphClient().Do(req)
+}
+
+// CheckCredentials asks the Graph API for an app access token using the client id and secret of the
+// login type, an error is returned when the Graph API rejects the credentials.
+func CheckCredentials(ctx context.Context, typ LoginType) error {
+	config := loader.AppConfig()
+	graphUrl, err := url.Parse(fmt.Sprintf("%s/oauth/access_token", FACEBOOK_GRAPH))
+	if err != nil {
+		return err
+	}
+
+	q := graphUrl.Query()
+	q.Add("client_id", config.GetFbClientId(uint(typ)))
+	q.Add("client_secret", config.GetFbClientSecret(uint(typ)))
+	q.Add("grant_type", "client_credentials")
+	graphUrl.RawQuery = q.Encode()
+
+	res, err := graph_get(ctx, graphUrl.String())
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("error: the Graph API rejected the credentials (%d): %s", res.StatusCode, b)
+	}
+
+	return nil
+}
+
+// graphError is the error object of the responses of the Graph API.
+type graphError struct {
+	Error struct {
+		Message string
+		Type    string
+		Code    int
+	}
+}
+
+// RevokeFacebookToken revokes all of the permissions granted to the app by the user of the token, a
+// token that was already revoked (or has expired) is treated as revoked.
+func RevokeFacebookToken(ctx context.Context, token string) error {
+	graphUrl, err := url.Parse(fmt.Sprintf("%s/me/permissions", FACEBOOK_GRAPH))
+	if err != nil {
+		return err
+	}
+
+	q := graphUrl.Query()
+	q.Add("access_token", token)
+	graphUrl.RawQuery = q.Encode()
+
+	res, err := graph_do(ctx, http.MethodDelete, graphUrl.String())
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	graphErr := &graphError{}
+
+	if json.Unmarshal(b, graphErr) == nil && graphErr.Error.Code == GRAPH_INVALID_TOKEN {
+		return nil
+	}
+
+	return fmt.Errorf("error: the Graph API failed to revoke the token (%d): %s", res.StatusCode, b)
+}
@@ -0,0 +1,152 @@
+package facebook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/auth/facebook"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSlowGraph starts a Graph API stand-in that only responds after a second.
+func newSlowGraph(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			w.Write([]byte(`{"access_token":"late"}`))
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func setFbTimeout(t *testing.T, ms uint64) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.FbTimeoutMs = ms
+	})
+}
+
+func Test_graphCallShouldTimeoutAtTheConfiguredDuration(t *testing.T) {
+	srv := newSlowGraph(t)
+	setFbTimeout(t, 50)
+
+	start := time.Now()
+	_, err := facebook.GraphGet(context.Background(), srv.URL)
+	elapsed := time.Since(start)
+
+	assert.True(t, os.IsTimeout(err), "The call should have failed with a timeout, got: %v", err)
+	assert.GreaterOrEqual(t, elapsed, 50*time.Millisecond)
+	assert.Less(t, elapsed, 500*time.Millisecond, "The call must not wait for the slow server.")
+}
+
+func Test_shorterContextShouldWinOverTheConfiguredTimeout(t *testing.T) {
+	srv := newSlowGraph(t)
+	setFbTimeout(t, 800)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := facebook.GraphGet(ctx, srv.URL)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "The call should have failed with the ctx, got: %v", err)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func Test_longLivedTokenShouldStopRetryingOnceTheContextIsDone(t *testing.T) {
+	srv := newSlowGraph(t)
+	setFbTimeout(t, 800)
+
+	bak := facebook.FACEBOOK_GRAPH
+	facebook.FACEBOOK_GRAPH = srv.URL
+	defer func() { facebook.FACEBOOK_GRAPH = bak }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	token, err := (&facebook.FacebookAccessToken{}).GetLongLivedTokenContext(ctx, facebook.LoginType_CONSUMER)
+
+	assert.Nil(t, token)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 400*time.Millisecond, "The call must not retry after the ctx is done.")
+}
+
+// newRevokingGraph starts a Graph API stand-in answering the revocation of the permissions with the
+// status and body, the received requests are sent to the returned channel.
+func newRevokingGraph(t *testing.T, status int, body string) <-chan *http.Request {
+	received := make(chan *http.Request, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+
+	bak := facebook.FACEBOOK_GRAPH
+	facebook.FACEBOOK_GRAPH = srv.URL
+
+	t.Cleanup(func() {
+		facebook.FACEBOOK_GRAPH = bak
+		srv.Close()
+	})
+
+	return received
+}
+
+func Test_shouldRevokeTheFacebookToken(t *testing.T) {
+	received := newRevokingGraph(t, http.StatusOK, `{"success":true}`)
+
+	assert.Nil(t, facebook.RevokeFacebookToken(context.Background(), "EAAB-token"))
+
+	r := <-received
+	assert.Equal(t, http.MethodDelete, r.Method)
+	assert.Equal(t, "/me/permissions", r.URL.Path)
+	assert.Equal(t, "EAAB-token", r.URL.Query().Get("access_token"))
+}
+
+func Test_anAlreadyRevokedTokenShouldBeRevoked(t *testing.T) {
+	newRevokingGraph(t, http.StatusBadRequest,
+		`{"error":{"message":"Error validating access token: The user has not authorized application 1234.","type":"OAuthException","code":190}}`)
+
+	assert.Nil(t, facebook.RevokeFacebookToken(context.Background(), "EAAB-token"))
+}
+
+func Test_shouldReportAFailedRevocation(t *testing.T) {
+	newRevokingGraph(t, http.StatusInternalServerError,
+		`{"error":{"message":"An unknown error has occurred.","type":"OAuthException","code":1}}`)
+
+	err := facebook.RevokeFacebookToken(context.Background(), "EAAB-token")
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "An unknown error has occurred.")
+	}
+}
+
+func Test_theGraphHelpersShouldFailWhenFbIsDisabled(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.FbEnabled = false
+	})
+
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	t.Cleanup(srv.Close)
+
+	_, err := facebook.GraphGet(context.Background(), srv.URL)
+	assert.ErrorIs(t, err, facebook.ErrFacebookDisabled)
+	assert.False(t, called, "The Graph API must not be called while FB is disabled.")
+
+	assert.ErrorIs(t, facebook.CheckCredentials(context.Background(), facebook.LoginType_CONSUMER), facebook.ErrFacebookDisabled)
+
+	_, err = facebook.Login(&facebook.FacebookLoginOptions{})
+	assert.ErrorIs(t, err, facebook.ErrFacebookDisabled)
+}
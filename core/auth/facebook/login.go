@@ -5,6 +5,7 @@ package facebook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -141,7 +142,11 @@ func write_rp(w io.Writer, data any) error {
 
 // exchange_code_to_token is responsible for exchanging the authorization code that comes from Facebook
 // to an access token.
-func exchange_code_to_token(opts *FacebookLoginOptions) (token *FacebookAccessToken, err error) {
+func exchange_code_to_token(ctx context.Context, opts *FacebookLoginOptions) (token *FacebookAccessToken, err error) {
+	if err := checkEnabled(); err != nil {
+		return nil, err
+	}
+
 	token = &FacebookAccessToken{}
 	config := loader.AppConfig()
 	exchanger, _ := url.Parse(fmt.Sprintf("%s/oauth/access_token", FACEBOOK_GRAPH))
@@ -156,7 +161,11 @@ func exchange_code_to_token(opts *FacebookLoginOptions) (token *FacebookAccessTo
 	exchanger.RawQuery = q.Encode()
 
 	for Nt := 0; Nt < 5; Nt++ {
-		res, err := http.Get(exchanger.String())
+		res, err := graph_get(ctx, exchanger.String())
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
 		if err != nil {
 			continue
 		}
@@ -185,6 +194,15 @@ func exchange_code_to_token(opts *FacebookLoginOptions) (token *FacebookAccessTo
 // kind of handler must be guarded by a rate limiting middleware to avoid someone abuse the
 // this handler or possible take down the server by overflowing the `pendingLoginRp`
 func FbRedirectHandler(c *gin.Context) {
+	if err := checkEnabled(); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status_code": http.StatusNotFound,
+			"error":       err.Error(),
+		})
+
+		return
+	}
+
 	stateQuery := c.Request.URL.Query().Get("state")
 
 	if len(stateQuery) == 0 {
@@ -242,6 +260,16 @@ func FbRedirectHandler(c *gin.Context) {
 // function to which we call when we want to start the Facebook login flow and
 // to get a short-lived user access token from Facebook.
 func Login(opts *FacebookLoginOptions) (*FacebookAccessToken, error) {
+	return LoginContext(context.Background(), opts)
+}
+
+// LoginContext is the same as Login but the wait for the redirect and the exchange of the code are
+// cancelled with the ctx (e.g. once the client of the request that started the login disconnects).
+func LoginContext(ctx context.Context, opts *FacebookLoginOptions) (*FacebookAccessToken, error) {
+	if err := checkEnabled(); err != nil {
+		return nil, err
+	}
+
 	opts.Pending = make(chan struct{})
 
 	if opts.State == nil {
@@ -273,10 +301,13 @@ func Login(opts *FacebookLoginOptions) (*FacebookAccessToken, error) {
 		}
 	case <-time.After(time.Minute * 15):
 		return nil, errors.New("error: facebook login timeout")
+	case <-ctx.Done():
+		delete(pendingLoginReq, opts.State["uuid"])
+		return nil, ctx.Err()
 	}
 
 	// Exchange the received authorzation code for a new access token.
-	token, err := exchange_code_to_token(opts)
+	token, err := exchange_code_to_token(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,10 @@
+package session
+
+import "time"
+
+// SetNow overrides the clock used to tell the expired sessions, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
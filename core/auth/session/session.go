@@ -0,0 +1,224 @@
+// This package keeps the sessions of the users signed in with Facebook, a session holds the access
+// token of the user until it expires. The sessions are kept in memory (for the development) or in the
+// `sessions` table (so that they survive a restart), the store is selected by the `sessionStore` of
+// the app config. The expired sessions are swept on the scheduler as the `sweep_sessions` task (its interval
+// is the one of the `schedules` of the app config) and by the admin endpoint of the api.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/scheduler"
+	"gorm.io/gorm"
+)
+
+const (
+	TASK_NAME = "sweep_sessions"
+
+	STORE_MEMORY   = "memory"
+	STORE_DATABASE = "database"
+
+	DEFAULT_CLEANUP_BATCH_SIZE = 1000
+)
+
+var (
+	ErrSessionNotFound = errors.New("error: the session does not exist or has expired")
+
+	// now is used to tell the expired sessions, the tests override it.
+	now = time.Now
+
+	_default Store
+	once     sync.Once
+)
+
+func init() {
+	source.GormMigrator.Add(&Session{})
+
+	scheduler.Default().Register(TASK_NAME, func(ctx context.Context) error {
+		_, err := Default().Sweep(ctx)
+		return err
+	})
+}
+
+// Session is a signed in user, the Roles and the TenantId are the ones of the user when it signed in,
+// they are set on the requests by the AuthMiddleware of the api/middleware package.
+type Session struct {
+	Id        string   `gorm:"primaryKey;size:64"`
+	UserId    uint64   `gorm:"index"`
+	Roles     []string `gorm:"serializer:json"`
+	TenantId  uint64
+	Token     string    `gorm:"type:text"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (s *Session) Expired() bool {
+	return !now().Before(s.ExpiresAt)
+}
+
+// Store keeps the sessions, Get returns an ErrSessionNotFound for an expired session even before it
+// was swept.
+type Store interface {
+	Put(ctx context.Context, s *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	Delete(ctx context.Context, id string) error
+
+	// Sweep deletes the expired sessions and returns how many were deleted.
+	Sweep(ctx context.Context) (int64, error)
+}
+
+// NewStore creates the store named by the `sessionStore` of the app config, the database store
+// keeps the sessions in the db.
+func NewStore(db *gorm.DB) Store {
+	if loader.AppConfig().SessionStore == STORE_DATABASE {
+		return NewGormStore(db)
+	}
+
+	return NewMemoryStore()
+}
+
+// Default returns the store of the app.
+func Default() Store {
+	once.Do(func() {
+		var db *gorm.DB
+
+		if loader.AppConfig().SessionStore == STORE_DATABASE {
+			db = source.Source[gorm.DB]()
+		}
+
+		_default = NewStore(db)
+	})
+
+	return _default
+}
+
+// MemoryStore keeps the sessions in a map, the sessions are lost once the app stops.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]Session)}
+}
+
+func (ms *MemoryStore) Put(ctx context.Context, s *Session) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.sessions[s.Id] = *s
+	return nil
+}
+
+func (ms *MemoryStore) Get(ctx context.Context, id string) (*Session, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	s, exists := ms.sessions[id]
+	if !exists || s.Expired() {
+		return nil, ErrSessionNotFound
+	}
+
+	return &s, nil
+}
+
+func (ms *MemoryStore) Delete(ctx context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.sessions, id)
+	return nil
+}
+
+func (ms *MemoryStore) Sweep(ctx context.Context) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var n int64
+
+	for id, s := range ms.sessions {
+		if s.Expired() {
+			delete(ms.sessions, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// GormStore keeps the sessions in the `sessions` table.
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (gs *GormStore) Put(ctx context.Context, s *Session) error {
+	return gs.db.WithContext(ctx).Save(s).Error
+}
+
+func (gs *GormStore) Get(ctx context.Context, id string) (*Session, error) {
+	s := &Session{}
+
+	err := gs.db.WithContext(ctx).Where("id = ? AND expires_at > ?", id, now()).Take(s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (gs *GormStore) Delete(ctx context.Context, id string) error {
+	return gs.db.WithContext(ctx).Delete(&Session{Id: id}).Error
+}
+
+func (gs *GormStore) Sweep(ctx context.Context) (int64, error) {
+	return CleanupExpiredSessions(gs.db.WithContext(ctx))
+}
+
+func cleanupBatchSize() int {
+	if size := loader.AppConfig().SessionCleanupBatchSize; size > 0 {
+		return size
+	}
+
+	return DEFAULT_CLEANUP_BATCH_SIZE
+}
+
+// CleanupExpiredSessions deletes the expired sessions of the `sessions` table a batch of the configured
+// `sessionCleanupBatchSize` at a time, so that the table is never locked for long. It returns how many
+// sessions were deleted.
+func CleanupExpiredSessions(db *gorm.DB) (int64, error) {
+	size := cleanupBatchSize()
+	cutoff := now()
+
+	var total int64
+
+	for {
+		if ctx := db.Statement.Context; ctx != nil && ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+
+		res := db.Where("expires_at <= ?", cutoff).Limit(size).Delete(&Session{})
+		if res.Error != nil {
+			return total, res.Error
+		}
+
+		total += res.RowsAffected
+
+		// A short batch means there is nothing left to delete.
+		if res.RowsAffected < int64(size) {
+			return total, nil
+		}
+	}
+}
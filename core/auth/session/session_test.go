@@ -0,0 +1,127 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/auth/session"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_memoryStoreShouldPutGetAndExpireTheSessions(t *testing.T) {
+	t0 := time.Now()
+	clock := t0
+	defer session.SetNow(func() time.Time { return clock })()
+
+	ctx := context.Background()
+	store := session.NewMemoryStore()
+
+	assert.Nil(t, store.Put(ctx, &session.Session{Id: "a", UserId: 1, Token: "EAAB-a", ExpiresAt: t0.Add(time.Hour)}))
+	assert.Nil(t, store.Put(ctx, &session.Session{Id: "b", UserId: 2, Token: "EAAB-b", ExpiresAt: t0.Add(time.Minute)}))
+
+	s, err := store.Get(ctx, "b")
+	if assert.Nil(t, err) {
+		assert.Equal(t, uint64(2), s.UserId)
+		assert.Equal(t, "EAAB-b", s.Token)
+	}
+
+	clock = t0.Add(2 * time.Minute)
+
+	_, err = store.Get(ctx, "b")
+	assert.ErrorIs(t, err, session.ErrSessionNotFound, "An expired session must not be returned.")
+
+	n, err := store.Sweep(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = store.Get(ctx, "a")
+	assert.Nil(t, err, "A live session must survive the sweep.")
+
+	assert.Nil(t, store.Delete(ctx, "a"))
+	_, err = store.Get(ctx, "a")
+	assert.ErrorIs(t, err, session.ErrSessionNotFound)
+}
+
+func Test_gormStoreShouldPutGetAndExpireTheSessions(t *testing.T) {
+	t0 := time.Now()
+	defer session.SetNow(func() time.Time { return t0 })()
+
+	ctx := context.Background()
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("UPDATE `sessions` SET `user_id`=\\?,`roles`=\\?,`tenant_id`=\\?,`token`=\\?,`expires_at`=\\? WHERE `id` = \\?").
+		WithArgs(1, sqlmock.AnyArg(), 0, "EAAB-a", t0.Add(time.Hour), "a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT \\* FROM `sessions` WHERE id = \\? AND expires_at > \\? LIMIT 1").
+		WithArgs("a", t0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token", "expires_at"}).AddRow("a", 1, "EAAB-a", t0.Add(time.Hour)))
+	mock.ExpectQuery("SELECT \\* FROM `sessions` WHERE id = \\? AND expires_at > \\? LIMIT 1").
+		WithArgs("b", t0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token", "expires_at"}))
+	mock.ExpectExec("DELETE FROM `sessions` WHERE expires_at <= \\? LIMIT 1000").
+		WithArgs(t0).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	store := session.NewGormStore(db)
+
+	assert.Nil(t, store.Put(ctx, &session.Session{Id: "a", UserId: 1, Token: "EAAB-a", ExpiresAt: t0.Add(time.Hour)}))
+
+	s, err := store.Get(ctx, "a")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "EAAB-a", s.Token)
+	}
+
+	_, err = store.Get(ctx, "b")
+	assert.ErrorIs(t, err, session.ErrSessionNotFound)
+
+	n, err := store.Sweep(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_theConfigShouldSelectTheStore(t *testing.T) {
+	db, _, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SessionStore = session.STORE_MEMORY
+	})
+	assert.IsType(t, &session.MemoryStore{}, session.NewStore(db))
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SessionStore = session.STORE_DATABASE
+	})
+	assert.IsType(t, &session.GormStore{}, session.NewStore(db))
+}
+
+func Test_shouldCleanupTheExpiredSessionsInBatches(t *testing.T) {
+	t0 := time.Now()
+	defer session.SetNow(func() time.Time { return t0 })()
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SessionCleanupBatchSize = 2
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	// 3 of the sessions expired, the valid ones are never matched by the delete.
+	mock.ExpectExec("DELETE FROM `sessions` WHERE expires_at <= \\? LIMIT 2").
+		WithArgs(t0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM `sessions` WHERE expires_at <= \\? LIMIT 2").
+		WithArgs(t0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := session.CleanupExpiredSessions(db)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
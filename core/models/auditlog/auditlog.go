@@ -0,0 +1,174 @@
+// This package implements the `audit_logs` table recording the writes made by the actors, the log is
+// compacted on the scheduler as the `compact_audit_log` task: the entries older than the `afterDays` of
+// the `auditCompaction` config are archived as JSON lines to its `archiveDir` and removed from the table,
+// a row of the `audit_log_summaries` is kept for every compaction.
+
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/scheduler"
+	"gorm.io/gorm"
+)
+
+const (
+	TASK_NAME = "compact_audit_log"
+
+	DEFAULT_BATCH_SIZE = 1000
+)
+
+var (
+	ErrMissingArchiveDir = errors.New("error: the audit log cannot be compacted without an archive dir")
+
+	// now is used to tell the age of the entries, the tests override it.
+	now = time.Now
+)
+
+func init() {
+	source.GormMigrator.Add(&Entry{})
+	source.GormMigrator.Add(&Summary{})
+
+	scheduler.Default().Register(TASK_NAME, func(ctx context.Context) error {
+		_, err := Compact(ctx, source.Source[gorm.DB]())
+		return err
+	})
+}
+
+// Entry is a write made by an actor.
+type Entry struct {
+	Id       uint64 `gorm:"primaryKey"`
+	ActorId  uint64 `gorm:"index"`
+	Action   string `gorm:"size:16"`
+	Model    string `gorm:"size:128"`
+	RecordId string `gorm:"size:64"`
+	Changes  string `gorm:"type:text"`
+
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (*Entry) TableName() string {
+	return "audit_logs"
+}
+
+// Summary is what is left of the entries removed by a compaction, their number and time span and the
+// archive they were moved to.
+type Summary struct {
+	Id      uint64 `gorm:"primaryKey"`
+	From    time.Time
+	To      time.Time
+	Entries int64
+	Archive string `gorm:"size:255"`
+
+	CreatedAt time.Time
+}
+
+func (*Summary) TableName() string {
+	return "audit_log_summaries"
+}
+
+func batchSize() int {
+	if size := loader.AppConfig().AuditCompaction.BatchSize; size > 0 {
+		return size
+	}
+
+	return DEFAULT_BATCH_SIZE
+}
+
+// Compact moves the entries older than the configured `afterDays` to the archive of the day in the
+// `archiveDir` (e.g. `<dir>/audit_logs-2006-01-02.jsonl`) a batch at a time, a batch is only removed
+// from the table once it was written to the archive. It returns the summary of the compaction, a nil
+// summary when there was nothing to compact or the compaction is disabled.
+func Compact(ctx context.Context, db *gorm.DB) (*Summary, error) {
+	conf := loader.AppConfig().AuditCompaction
+	if conf.AfterDays <= 0 {
+		return nil, nil
+	}
+
+	if len(conf.ArchiveDir) == 0 {
+		return nil, ErrMissingArchiveDir
+	}
+
+	if err := os.MkdirAll(conf.ArchiveDir, 0o750); err != nil {
+		return nil, err
+	}
+
+	name := filepath.Join(conf.ArchiveDir, fmt.Sprintf("audit_logs-%s.jsonl", now().Format(time.DateOnly)))
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	cutoff := now().AddDate(0, 0, -conf.AfterDays)
+	size := batchSize()
+	enc := json.NewEncoder(f)
+	summary := &Summary{Archive: name}
+
+	db = db.WithContext(ctx)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entries := []*Entry{}
+		if err := db.Where("created_at < ?", cutoff).Order("id").Limit(size).Find(&entries).Error; err != nil {
+			return nil, err
+		}
+
+		if len(entries) == 0 {
+			break
+		}
+
+		ids := make([]uint64, len(entries))
+
+		for i, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return nil, err
+			}
+
+			ids[i] = entry.Id
+
+			if summary.From.IsZero() || entry.CreatedAt.Before(summary.From) {
+				summary.From = entry.CreatedAt
+			}
+
+			if entry.CreatedAt.After(summary.To) {
+				summary.To = entry.CreatedAt
+			}
+		}
+
+		// The batch must be on the disk before it is removed from the table.
+		if err := f.Sync(); err != nil {
+			return nil, err
+		}
+
+		res := db.Where("id IN ?", ids).Delete(&Entry{})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		summary.Entries += res.RowsAffected
+
+		if len(entries) < size {
+			break
+		}
+	}
+
+	if summary.Entries == 0 {
+		return nil, nil
+	}
+
+	return summary, db.Create(summary).Error
+}
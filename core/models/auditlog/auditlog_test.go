@@ -0,0 +1,95 @@
+package auditlog_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/auditlog"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+var entryColumns = []string{"id", "actor_id", "action", "model", "record_id", "changes", "created_at"}
+
+func setCompaction(t *testing.T, afterDays int, dir string, batchSize int) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.AuditCompaction.AfterDays = afterDays
+		conf.AuditCompaction.ArchiveDir = dir
+		conf.AuditCompaction.BatchSize = batchSize
+	})
+}
+
+func Test_shouldArchiveAndRemoveTheOldEntries(t *testing.T) {
+	t0 := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	defer auditlog.SetNow(func() time.Time { return t0 })()
+
+	dir := t.TempDir()
+	setCompaction(t, 90, dir, 2)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	cutoff := t0.AddDate(0, 0, -90)
+	old := func(days int) time.Time { return cutoff.AddDate(0, 0, -days) }
+
+	// The 3 entries older than the cutoff are read 2 at a time, the recent ones are never matched.
+	mock.ExpectQuery("SELECT \\* FROM `audit_logs` WHERE created_at < \\? ORDER BY id LIMIT 2").
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows(entryColumns).
+			AddRow(1, 7, "create", "Invoice", "10", "{}", old(30)).
+			AddRow(2, 7, "update", "Invoice", "10", "{}", old(20)))
+	mock.ExpectExec("DELETE FROM `audit_logs` WHERE id IN \\(\\?,\\?\\)").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT \\* FROM `audit_logs` WHERE created_at < \\? ORDER BY id LIMIT 2").
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows(entryColumns).
+			AddRow(3, 8, "delete", "Invoice", "11", "{}", old(10)))
+	mock.ExpectExec("DELETE FROM `audit_logs` WHERE id IN \\(\\?\\)").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `audit_log_summaries`").
+		WithArgs(old(30), old(10), 3, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	summary, err := auditlog.Compact(context.Background(), db)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	if !assert.NotNil(t, summary) {
+		return
+	}
+
+	assert.Equal(t, int64(3), summary.Entries)
+
+	f, err := os.Open(summary.Archive)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	archived := []uint64{}
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		entry := &auditlog.Entry{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), entry))
+		archived = append(archived, entry.Id)
+	}
+
+	assert.Equal(t, []uint64{1, 2, 3}, archived)
+}
+
+func Test_shouldNotCompactWithoutTheAfterDays(t *testing.T) {
+	setCompaction(t, 0, "", 0)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	summary, err := auditlog.Compact(context.Background(), db)
+	assert.Nil(t, err)
+	assert.Nil(t, summary)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
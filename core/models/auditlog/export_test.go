@@ -0,0 +1,10 @@
+package auditlog
+
+import "time"
+
+// SetNow overrides the clock used to tell the age of the entries, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
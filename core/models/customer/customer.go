@@ -0,0 +1,86 @@
+package customer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/email"
+	migrator "github.com/rommms07/idream-erp/internal/db/migrator/gorm"
+	"gorm.io/gorm"
+)
+
+// TENANT_EMAIL_INDEX is the composite unique index keeping an email unique within a tenant.
+const TENANT_EMAIL_INDEX = "idx_customers_tenant_email"
+
+var ErrDuplicateEmail = errors.New("error: the email is already used by another customer of the tenant")
+
+func init() {
+	source.GormMigrator.Add(&Customer{})
+	source.GormMigrator.AddMigration(&migrator.Migration{
+		Version: "0001",
+		Name:    "add_customers_tenant_email_index",
+		Up:      addTenantEmailIndex,
+	})
+}
+
+type Customer struct {
+	Id       uint64 `gorm:"primaryKey"`
+	TenantId uint64 `gorm:"uniqueIndex:idx_customers_tenant_email"`
+	Name     string `gorm:"size:255"`
+	Email    string `gorm:"size:255;uniqueIndex:idx_customers_tenant_email"`
+	Phone    string `gorm:"size:32"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// BeforeCreate rejects an email that is already used within the tenant with the ErrDuplicateEmail, the
+// unique index is the last line of defense but its error is not fit to be shown to the users. The email
+// is stored normalized when the `caseInsensitiveEmails` is set, so that it is compared (and kept unique
+// by the index) as is.
+func (c *Customer) BeforeCreate(tx *gorm.DB) error {
+	c.Email = strings.TrimSpace(c.Email)
+
+	if loader.AppConfig().CaseInsensitiveEmails {
+		c.Email = email.Normalize(c.Email)
+	}
+
+	var count int64
+
+	err := tx.Session(&gorm.Session{NewDB: true}).
+		Model(&Customer{}).
+		Where("tenant_id = ? AND email = ?", c.TenantId, c.Email).
+		Count(&count).Error
+
+	if err != nil {
+		return err
+	}
+
+	if count != 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateEmail, c.Email)
+	}
+
+	return nil
+}
+
+// addTenantEmailIndex adds the tenant of the customers and the index keeping their emails unique
+// within it, for the databases migrated in the versioned mode.
+func addTenantEmailIndex(tx *gorm.DB) error {
+	m := tx.Migrator()
+
+	if !m.HasColumn(&Customer{}, "TenantId") {
+		if err := m.AddColumn(&Customer{}, "TenantId"); err != nil {
+			return err
+		}
+	}
+
+	if m.HasIndex(&Customer{}, TENANT_EMAIL_INDEX) {
+		return nil
+	}
+
+	return m.CreateIndex(&Customer{}, TENANT_EMAIL_INDEX)
+}
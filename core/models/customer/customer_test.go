@@ -0,0 +1,68 @@
+package customer_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/customer"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setCaseInsensitiveEmails(t *testing.T, enabled bool) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.CaseInsensitiveEmails = enabled
+	})
+}
+
+func Test_shouldRejectADuplicateEmailWithinTheTenant(t *testing.T) {
+	setCaseInsensitiveEmails(t, true)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `customers` WHERE tenant_id = \\? AND email = \\?").
+		WithArgs(1, "john@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	err = db.Create(&customer.Customer{TenantId: 1, Name: "John Smith", Email: "John@Example.com"}).Error
+	assert.ErrorIs(t, err, customer.ErrDuplicateEmail)
+	assert.Nil(t, mock.ExpectationsWereMet(), "The duplicate must not have been inserted.")
+}
+
+func Test_shouldAllowTheSameEmailInAnotherTenant(t *testing.T) {
+	setCaseInsensitiveEmails(t, true)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `customers` WHERE tenant_id = \\? AND email = \\?").
+		WithArgs(2, "john@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO `customers`").
+		WithArgs(2, "John Smith", "john@example.com", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+
+	c := &customer.Customer{TenantId: 2, Name: "John Smith", Email: " JOHN@example.com"}
+	assert.Nil(t, db.Create(c).Error)
+	assert.Equal(t, "john@example.com", c.Email, "The email should have been normalized.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldCompareTheEmailsAsIsWhenCaseSensitive(t *testing.T) {
+	setCaseInsensitiveEmails(t, false)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `customers` WHERE tenant_id = \\? AND email = \\?").
+		WithArgs(1, "John@Example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO `customers`").
+		WithArgs(1, "John Smith", "John@Example.com", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+
+	assert.Nil(t, db.Create(&customer.Customer{TenantId: 1, Name: "John Smith", Email: "John@Example.com"}).Error)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
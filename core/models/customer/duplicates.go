@@ -0,0 +1,157 @@
+package customer
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+const (
+	DEFAULT_DUPLICATE_THRESHOLD = 0.9
+
+	// duplicatesBatchSize is the number of customers scored at a time.
+	duplicatesBatchSize = 500
+)
+
+// normalizeEmail lowercases the email and drops the `+tag` of its local part.
+func normalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	local, domain, found := strings.Cut(email, "@")
+	if !found {
+		return email
+	}
+
+	local, _, _ = strings.Cut(local, "+")
+	return local + "@" + domain
+}
+
+// normalizeName lowercases the name, drops its punctuation and sorts its words so that the order of
+// the names does not matter (e.g. `Smith, John` is the same as `John Smith`).
+func normalizeName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	sort.Strings(fields)
+	return strings.Join(fields, " ")
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of a and b, from 0 (nothing in common) to 1 (equal).
+func jaroWinkler(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := max(len(s1), len(s2))/2 - 1
+	window = max(window, 0)
+
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+
+	for i := range s1 {
+		for j := max(0, i-window); j < min(len(s2), i+window+1); j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+
+		for !matched2[j] {
+			j++
+		}
+
+		if s1[i] != s2[j] {
+			transpositions++
+		}
+
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions/2))/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Score is how likely b is a duplicate of a, from 0 to 1. It is the similarity of their names, a
+// matching email makes up for half of the score.
+func Score(a, b *Customer) float64 {
+	score := jaroWinkler(normalizeName(a.Name), normalizeName(b.Name))
+
+	if email := normalizeEmail(a.Email); len(email) != 0 && email == normalizeEmail(b.Email) {
+		score = (1 + score) / 2
+	}
+
+	return score
+}
+
+// MatchDuplicates returns the existing customers whose Score against the candidate reaches the
+// threshold, the most likely duplicates first. A zero threshold falls back to the
+// `duplicateMatchThreshold` of the app config.
+func MatchDuplicates(db *gorm.DB, candidate Customer, threshold float64) ([]Customer, error) {
+	if threshold <= 0 {
+		threshold = loader.AppConfig().DuplicateMatchThreshold
+	}
+
+	if threshold <= 0 {
+		threshold = DEFAULT_DUPLICATE_THRESHOLD
+	}
+
+	type scored struct {
+		customer Customer
+		score    float64
+	}
+
+	matches := []scored{}
+	batch := []*Customer{}
+
+	err := db.FindInBatches(&batch, duplicatesBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, existing := range batch {
+			if candidate.Id != 0 && existing.Id == candidate.Id {
+				continue
+			}
+
+			if score := Score(&candidate, existing); score >= threshold {
+				matches = append(matches, scored{*existing, score})
+			}
+		}
+
+		return nil
+	}).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	duplicates := make([]Customer, len(matches))
+	for i, match := range matches {
+		duplicates[i] = match.customer
+	}
+
+	return duplicates, nil
+}
@@ -0,0 +1,44 @@
+package customer_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/customer"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldMatchTheNearDuplicates(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `customers` ORDER BY `customers`.`id` LIMIT 500").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).
+			AddRow(1, "Jane Doe", "jane.doe@example.com").
+			AddRow(2, "Jonathan  Smith", "j.smith@example.com").
+			AddRow(3, "SMITH, John", "John.Smith+billing@Example.com").
+			AddRow(4, "Maria Santos", "maria@example.com").
+			AddRow(5, "John Smyth", "jsmyth@example.org"))
+
+	candidate := customer.Customer{Name: "John Smith", Email: " john.smith@example.com"}
+
+	duplicates, err := customer.MatchDuplicates(db, candidate, 0.8)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	ids := []uint64{}
+	for _, duplicate := range duplicates {
+		ids = append(ids, duplicate.Id)
+	}
+
+	assert.Equal(t, []uint64{3, 5, 2}, ids, "The near duplicates must be matched, the most likely first.")
+}
+
+func Test_shouldScoreTheClearlyDifferentCustomersLow(t *testing.T) {
+	john := &customer.Customer{Name: "John Smith", Email: "john.smith@example.com"}
+
+	assert.Less(t, customer.Score(john, &customer.Customer{Name: "Maria Santos", Email: "maria@example.com"}), 0.6)
+	assert.Less(t, customer.Score(john, &customer.Customer{Name: "Jane Doe"}), 0.6)
+	assert.Equal(t, 1.0, customer.Score(john, &customer.Customer{Name: "john  smith.", Email: "JOHN.SMITH@example.com"}))
+}
@@ -2,6 +2,21 @@ package models
 
 // AutoMigrating a gorm model is done by blank importing the package
 // to which the model is explicitly AutoMigrated via init() function.
+//
+// The retention package registers the purge of the soft-deleted rows
+// to the scheduler, the storage package registers the cleanup of the
+// orphaned files. The user and tenant packages seed the admins and the
+// default tenant once the source is migrated.
 import (
+	_ "github.com/rommms07/idream-erp/core/auth/session"
+	_ "github.com/rommms07/idream-erp/core/models/auditlog"
+	_ "github.com/rommms07/idream-erp/core/models/customer"
+	_ "github.com/rommms07/idream-erp/core/models/flag"
+	_ "github.com/rommms07/idream-erp/core/models/job"
+	_ "github.com/rommms07/idream-erp/core/models/retention"
+	_ "github.com/rommms07/idream-erp/core/models/sequence"
+	_ "github.com/rommms07/idream-erp/core/models/setting"
+	_ "github.com/rommms07/idream-erp/core/models/tenant"
 	_ "github.com/rommms07/idream-erp/core/models/user"
+	_ "github.com/rommms07/idream-erp/internal/storage"
 )
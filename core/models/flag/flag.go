@@ -0,0 +1,166 @@
+// This package implements the optional `flags` table, the dynamic flags toggled live by the admins.
+// The flags of the table are kept in memory (refreshed every `dynamicFlags.refreshSeconds`) and
+// override the `features` of the app config.
+
+package flag
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func init() {
+	source.GormMigrator.Add(&Flag{})
+
+	source.OnMigrated(func(db *gorm.DB) error {
+		Watch(context.Background(), db)
+		return nil
+	})
+}
+
+// Flag is a dynamic flag, Value is the JSON encoding of its value.
+type Flag struct {
+	Name      string `gorm:"primaryKey;size:128"`
+	Value     string `gorm:"type:text"`
+	UpdatedAt time.Time
+}
+
+// DynamicFlags holds the flags read from the `flags` table.
+type DynamicFlags struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+var defaultFlags = NewDynamicFlags()
+
+func NewDynamicFlags() *DynamicFlags {
+	return &DynamicFlags{values: make(map[string]any)}
+}
+
+// Default returns the flags shared by the app.
+func Default() *DynamicFlags {
+	return defaultFlags
+}
+
+// Refresh replaces the flags with the rows of the `flags` table, a row whose value cannot be decoded is
+// skipped.
+func (f *DynamicFlags) Refresh(db *gorm.DB) error {
+	rows := []*Flag{}
+	if err := db.Find(&rows).Error; err != nil {
+		return err
+	}
+
+	values := make(map[string]any, len(rows))
+	for _, row := range rows {
+		var value any
+		if err := json.Unmarshal([]byte(row.Value), &value); err != nil {
+			logging.Logger().Warn("error decoding a dynamic flag", "name", row.Name, "error", err)
+			continue
+		}
+
+		values[row.Name] = value
+	}
+
+	f.mu.Lock()
+	f.values = values
+	f.mu.Unlock()
+
+	return nil
+}
+
+// Value returns the value of the flag, the bool is false when the flag is not in the table.
+func (f *DynamicFlags) Value(name string) (any, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	value, exists := f.values[name]
+	return value, exists
+}
+
+// Enabled reports whether the flag is set to true, the `features` of the app config are used when the
+// flag is not in the table.
+func (f *DynamicFlags) Enabled(name string) bool {
+	if value, exists := f.Value(name); exists {
+		enabled, _ := value.(bool)
+		return enabled
+	}
+
+	return loader.AppConfig().Features[name]
+}
+
+// Set stores the value of the flag in the table and applies it right away, the other instances of the
+// app pick it up on their next refresh.
+func (f *DynamicFlags) Set(db *gorm.DB, name string, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	err = db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&Flag{Name: name, Value: string(b)}).Error
+	if err != nil {
+		return err
+	}
+
+	// The value is stored as it would be read back by the Refresh (e.g. the numbers as float64).
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.values[name] = decoded
+	f.mu.Unlock()
+
+	return nil
+}
+
+// Enabled reports whether the flag of the default DynamicFlags is enabled.
+func Enabled(name string) bool {
+	return defaultFlags.Enabled(name)
+}
+
+// SetFlag sets the flag of the default DynamicFlags.
+func SetFlag(db *gorm.DB, name string, value any) error {
+	return defaultFlags.Set(db, name, value)
+}
+
+// Watch reads the `flags` table into the default DynamicFlags and keeps it refreshed every interval
+// until the ctx is cancelled. It does nothing when the dynamic flags are disabled in the config.
+func Watch(ctx context.Context, db *gorm.DB) {
+	conf := loader.AppConfig().DynamicFlags
+	if !conf.Enabled {
+		return
+	}
+
+	if err := defaultFlags.Refresh(db); err != nil {
+		logging.Logger().Error("error refreshing the dynamic flags", "error", err)
+	}
+
+	if conf.RefreshSeconds == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(conf.RefreshSeconds) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := defaultFlags.Refresh(db); err != nil {
+					logging.Logger().Error("error refreshing the dynamic flags", "error", err)
+				}
+			}
+		}
+	}()
+}
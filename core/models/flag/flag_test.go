@@ -0,0 +1,64 @@
+package flag_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/flag"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func withFeatures(t *testing.T, features map[string]bool) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Features = features
+	})
+}
+
+func Test_theFlagsOfTheTableShouldOverrideTheFeatures(t *testing.T) {
+	withFeatures(t, map[string]bool{"invoicing": true, "payroll": false})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	flags := flag.NewDynamicFlags()
+	assert.True(t, flags.Enabled("invoicing"))
+
+	mock.ExpectQuery("SELECT \\* FROM `flags`").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).
+			AddRow("invoicing", "false").
+			AddRow("payroll", "true").
+			AddRow("broken", "{"))
+
+	assert.Nil(t, flags.Refresh(db))
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	assert.False(t, flags.Enabled("invoicing"), "The table must override an enabled feature.")
+	assert.True(t, flags.Enabled("payroll"), "The table must override a disabled feature.")
+	assert.False(t, flags.Enabled("broken"))
+}
+
+func Test_shouldApplyTheSetFlagRightAway(t *testing.T) {
+	withFeatures(t, map[string]bool{})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("INSERT INTO `flags` \\(`name`,`value`,`updated_at`\\) VALUES \\(\\?,\\?,\\?\\) ON DUPLICATE KEY UPDATE").
+		WithArgs("payroll", "true", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `flags`").
+		WithArgs("batch_size", "250", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, flag.SetFlag(db, "payroll", true))
+	assert.Nil(t, flag.SetFlag(db, "batch_size", 250))
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	assert.True(t, flag.Enabled("payroll"))
+
+	value, exists := flag.Default().Value("batch_size")
+	assert.True(t, exists)
+	assert.Equal(t, float64(250), value)
+}
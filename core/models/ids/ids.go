@@ -0,0 +1,140 @@
+// This package generates the ids of the models embedding the Model (or the UUIDModel) on create, the
+// `idStrategy` of the app config picks between the auto-increment of the database, the UUIDs and the
+// snowflakes of the `snowflakeNodeId` (which stay unique across the instances of the app).
+
+package ids
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+const (
+	STRATEGY_AUTOINCREMENT = "autoincrement"
+	STRATEGY_UUID          = "uuid"
+	STRATEGY_SNOWFLAKE     = "snowflake"
+
+	// SNOWFLAKE_EPOCH is the time (in unix milliseconds) the timestamps of the snowflakes start from,
+	// the 41 bits of the timestamp last about 69 years from it.
+	SNOWFLAKE_EPOCH = 1704067200000 // 2024-01-01T00:00:00Z
+
+	SNOWFLAKE_NODE_BITS     = 10
+	SNOWFLAKE_SEQUENCE_BITS = 12
+
+	MAX_SNOWFLAKE_NODE     = 1<<SNOWFLAKE_NODE_BITS - 1
+	MAX_SNOWFLAKE_SEQUENCE = 1<<SNOWFLAKE_SEQUENCE_BITS - 1
+)
+
+// ErrUUIDModel is returned when a Model is created with the `uuid` strategy, its uint64 id cannot hold
+// a UUID.
+var ErrUUIDModel = errors.New("error: the uuid strategy needs the models to embed the ids.UUIDModel")
+
+var (
+	// now is used to stamp the snowflakes, the tests override it.
+	now = time.Now
+
+	defaultSnowflake     *Snowflake
+	defaultSnowflakeOnce sync.Once
+	defaultSnowflakeErr  error
+)
+
+// Snowflake generates the 63 bits snowflake ids of a node, made of the milliseconds since the
+// SNOWFLAKE_EPOCH, the id of the node and a sequence within the millisecond.
+type Snowflake struct {
+	mu       sync.Mutex
+	node     uint64
+	lastMs   int64
+	sequence uint64
+}
+
+func NewSnowflake(node uint64) (*Snowflake, error) {
+	if node > MAX_SNOWFLAKE_NODE {
+		return nil, fmt.Errorf("error: the snowflake node %d is over %d", node, MAX_SNOWFLAKE_NODE)
+	}
+
+	return &Snowflake{node: node}, nil
+}
+
+// Next returns the next id of the node, it waits for the next millisecond once the sequence of the
+// current one is exhausted. A clock moving backwards keeps using the last millisecond so the ids never
+// repeat.
+func (s *Snowflake) Next() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := now().UnixMilli() - SNOWFLAKE_EPOCH
+	if ms < s.lastMs {
+		ms = s.lastMs
+	}
+
+	if ms == s.lastMs {
+		s.sequence = (s.sequence + 1) & MAX_SNOWFLAKE_SEQUENCE
+
+		if s.sequence == 0 {
+			for ms <= s.lastMs {
+				time.Sleep(100 * time.Microsecond)
+				ms = now().UnixMilli() - SNOWFLAKE_EPOCH
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+
+	s.lastMs = ms
+	return uint64(ms)<<(SNOWFLAKE_NODE_BITS+SNOWFLAKE_SEQUENCE_BITS) | s.node<<SNOWFLAKE_SEQUENCE_BITS | s.sequence
+}
+
+// DefaultSnowflake returns the snowflake of the `snowflakeNodeId` of the app config.
+func DefaultSnowflake() (*Snowflake, error) {
+	defaultSnowflakeOnce.Do(func() {
+		defaultSnowflake, defaultSnowflakeErr = NewSnowflake(loader.AppConfig().SnowflakeNodeId)
+	})
+
+	return defaultSnowflake, defaultSnowflakeErr
+}
+
+// Model is the base of the models whose numeric id is generated per the `idStrategy`, the id is left
+// to the auto-increment of the database unless the strategy is `snowflake`. A model embedding it must
+// call the BeforeCreate of the Model from its own BeforeCreate, if it has one.
+type Model struct {
+	Id uint64 `gorm:"primaryKey"`
+}
+
+func (m *Model) BeforeCreate(tx *gorm.DB) error {
+	if m.Id != 0 {
+		return nil
+	}
+
+	switch loader.AppConfig().IDStrategy {
+	case STRATEGY_UUID:
+		return ErrUUIDModel
+	case STRATEGY_SNOWFLAKE:
+		snowflake, err := DefaultSnowflake()
+		if err != nil {
+			return err
+		}
+
+		m.Id = snowflake.Next()
+	}
+
+	return nil
+}
+
+// UUIDModel is the base of the models identified by a UUID, it is used by the `uuid` strategy.
+type UUIDModel struct {
+	Id string `gorm:"primaryKey;size:36"`
+}
+
+func (m *UUIDModel) BeforeCreate(tx *gorm.DB) error {
+	if len(m.Id) == 0 {
+		m.Id = uuid.NewString()
+	}
+
+	return nil
+}
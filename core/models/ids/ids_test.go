@@ -0,0 +1,140 @@
+package ids_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rommms07/idream-erp/core/models/ids"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Invoice struct {
+	ids.Model
+	Number string
+}
+
+type Attachment struct {
+	ids.UUIDModel
+	Name string
+}
+
+func setStrategy(t *testing.T, strategy string) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.IDStrategy = strategy
+	})
+}
+
+func Test_autoIncrementShouldLeaveTheIdToTheDatabase(t *testing.T) {
+	setStrategy(t, ids.STRATEGY_AUTOINCREMENT)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("INSERT INTO `invoices` \\(`number`\\)").
+		WithArgs("INV-0001").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	invoice := &Invoice{Number: "INV-0001"}
+	assert.Nil(t, db.Create(invoice).Error)
+	assert.Equal(t, uint64(7), invoice.Id)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldGenerateTheUUIDOnCreate(t *testing.T) {
+	setStrategy(t, ids.STRATEGY_UUID)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("INSERT INTO `attachments` \\(`id`,`name`\\)").
+		WithArgs(sqlmock.AnyArg(), "receipt.pdf").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	attachment := &Attachment{Name: "receipt.pdf"}
+	assert.Nil(t, db.Create(attachment).Error)
+
+	parsed, err := uuid.Parse(attachment.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, uuid.Version(4), parsed.Version())
+
+	assert.ErrorIs(t, db.Create(&Invoice{Number: "INV-0001"}).Error, ids.ErrUUIDModel,
+		"A numeric id cannot hold a UUID.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldGenerateTheSnowflakeOnCreate(t *testing.T) {
+	setStrategy(t, ids.STRATEGY_SNOWFLAKE)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("INSERT INTO `invoices` \\(`number`,`id`\\)").
+		WithArgs("INV-0001", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	invoice := &Invoice{Number: "INV-0001"}
+	assert.Nil(t, db.Create(invoice).Error)
+
+	node := invoice.Id >> ids.SNOWFLAKE_SEQUENCE_BITS & ids.MAX_SNOWFLAKE_NODE
+	assert.Equal(t, loader.AppConfig().SnowflakeNodeId, node, "The snowflake should hold the configured node.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_snowflakesShouldBeUniqueUnderConcurrency(t *testing.T) {
+	snowflake, err := ids.NewSnowflake(42)
+	assert.Nil(t, err)
+
+	const workers, perWorker = 8, 2000
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[uint64]bool, workers*perWorker)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			generated := make([]uint64, 0, perWorker)
+			for i := 0; i < perWorker; i++ {
+				generated = append(generated, snowflake.Next())
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for i, id := range generated {
+				assert.False(t, seen[id], "The snowflake %d was generated twice.", id)
+				seen[id] = true
+
+				if i != 0 {
+					assert.Greater(t, id, generated[i-1], "The snowflakes of a node should increase.")
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Len(t, seen, workers*perWorker)
+}
+
+func Test_snowflakesShouldNotRepeatWhenTheClockMovesBackwards(t *testing.T) {
+	t0 := time.Now()
+	restore := ids.SetNow(func() time.Time { return t0 })
+	defer restore()
+
+	snowflake, _ := ids.NewSnowflake(1)
+	first := snowflake.Next()
+
+	ids.SetNow(func() time.Time { return t0.Add(-time.Second) })
+	assert.Greater(t, snowflake.Next(), first)
+
+	_, err := ids.NewSnowflake(ids.MAX_SNOWFLAKE_NODE + 1)
+	assert.NotNil(t, err)
+}
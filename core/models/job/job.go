@@ -0,0 +1,256 @@
+// This package implements the `jobs` table used as the job queue of the app, the jobs are picked up
+// by a Worker in the order of their priority. When the queue backs up past the `queueHighWaterMark`
+// the worker sheds the low-priority jobs until the queue drains below the `queueLowWaterMark`.
+//
+// The id of the request (and the actor) enqueuing a job is kept in its Metadata, the worker restores them
+// in the ctx of the handler so that the logs and the writes of the job tie back to the request.
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/rommms07/idream-erp/internal/db/audit"
+	"github.com/rommms07/idream-erp/internal/requestid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	STATUS_PENDING = "pending"
+	STATUS_RUNNING = "running"
+	STATUS_DONE    = "done"
+	STATUS_FAILED  = "failed"
+
+	PRIORITY_LOW    = 0
+	PRIORITY_NORMAL = 5
+	PRIORITY_HIGH   = 10
+
+	DEFAULT_POLL_INTERVAL = time.Second
+)
+
+func init() {
+	source.GormMigrator.Add(&Job{})
+}
+
+type Job struct {
+	Id       uint64 `gorm:"primaryKey"`
+	Kind     string `gorm:"size:128"`
+	Payload  string `gorm:"type:text"`
+	Status   string `gorm:"size:16;index:idx_jobs_pending,priority:1"`
+	Priority int    `gorm:"index:idx_jobs_pending,priority:2"`
+	Error    string `gorm:"type:text"`
+	Metadata string `gorm:"type:text"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Metadata is the context of the request that enqueued a job.
+type Metadata struct {
+	RequestId string `json:"request_id,omitempty"`
+	ActorId   uint64 `json:"actor_id,omitempty"`
+}
+
+// metadataOf captures the id of the request and the actor of the db session, an empty string is returned
+// when there is nothing to capture (or the `jobCorrelation` of the app config is unset).
+func metadataOf(ctx context.Context, db *gorm.DB) (string, error) {
+	if !loader.AppConfig().JobCorrelation {
+		return "", nil
+	}
+
+	md := Metadata{}
+	md.RequestId, _ = requestid.From(ctx)
+	md.ActorId, _ = audit.Actor(db)
+
+	if md == (Metadata{}) {
+		return "", nil
+	}
+
+	b, err := json.Marshal(md)
+	return string(b), err
+}
+
+// Context returns a copy of the ctx carrying the id of the request and the actor that enqueued the job.
+func (j *Job) Context(ctx context.Context) context.Context {
+	if len(j.Metadata) == 0 {
+		return ctx
+	}
+
+	md := Metadata{}
+	if err := json.Unmarshal([]byte(j.Metadata), &md); err != nil {
+		logging.Logger().Warn("error decoding the metadata of a job", "job_id", j.Id, "error", err)
+		return ctx
+	}
+
+	if len(md.RequestId) != 0 {
+		ctx = requestid.With(ctx, md.RequestId)
+	}
+
+	if md.ActorId != 0 {
+		ctx = audit.ContextWithActor(ctx, md.ActorId)
+	}
+
+	return ctx
+}
+
+// Handler runs a job of a kind, the job is marked as failed when it returns an error.
+type Handler func(ctx context.Context, job *Job) error
+
+// Enqueue adds a pending job of the kind, the payload is stored as its JSON encoding. The id of the
+// request carried by the ctx and the actor of the db session are kept in the Metadata of the job.
+func Enqueue(ctx context.Context, db *gorm.DB, kind string, payload any, priority int) (*Job, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	db = db.WithContext(ctx)
+
+	md, err := metadataOf(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{Kind: kind, Payload: string(b), Status: STATUS_PENDING, Priority: priority, Metadata: md}
+	if err := db.Create(job).Error; err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Backpressure tells whether the low-priority jobs must be shed, it starts shedding once the pending
+// jobs exceed the High water mark and keeps shedding until they drained down to the Low water mark.
+// A zero High water mark disables the backpressure.
+type Backpressure struct {
+	High, Low int64
+
+	shedding bool
+}
+
+// Shedding updates the state of the backpressure with the number of pending jobs.
+func (b *Backpressure) Shedding(pending int64) bool {
+	switch {
+	case b.High <= 0:
+		b.shedding = false
+	case pending > b.High:
+		b.shedding = true
+	case pending <= b.Low:
+		b.shedding = false
+	}
+
+	return b.shedding
+}
+
+// Worker picks up the pending jobs and runs the handler of their kind.
+type Worker struct {
+	db           *gorm.DB
+	handlers     map[string]Handler
+	backpressure *Backpressure
+}
+
+// NewWorker creates a worker with the water marks of the app config.
+func NewWorker(db *gorm.DB) *Worker {
+	conf := loader.AppConfig()
+
+	return &Worker{
+		db:           db,
+		handlers:     make(map[string]Handler),
+		backpressure: &Backpressure{High: conf.QueueHighWaterMark, Low: conf.QueueLowWaterMark},
+	}
+}
+
+// Handle registers the handler of the kind.
+func (w *Worker) Handle(kind string, handler Handler) *Worker {
+	w.handlers[kind] = handler
+	return w
+}
+
+// claim marks the next pending job as running, the jobs are claimed in the order they were enqueued
+// but only the high-priority ones are claimed while the queue is shedding. The low-priority jobs are
+// delayed until the pending jobs drained down to the low water mark. A nil job is returned when there
+// is nothing to run.
+func (w *Worker) claim(ctx context.Context) (*Job, error) {
+	db := w.db.WithContext(ctx)
+
+	var pending int64
+	if err := db.Model(&Job{}).Where("status = ?", STATUS_PENDING).Count(&pending).Error; err != nil {
+		return nil, err
+	}
+
+	shedding := w.backpressure.Shedding(pending)
+	job := &Job{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", STATUS_PENDING)
+
+		if shedding {
+			query = query.Where("priority >= ?", PRIORITY_HIGH)
+		}
+
+		if err := query.Order("id").Take(job).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(job).Update("status", STATUS_RUNNING).Error
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return job, err
+}
+
+// RunOnce runs the next job, the returned bool is false when there was no job to run.
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	job, err := w.claim(ctx)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	updates := map[string]any{"status": STATUS_DONE, "error": ""}
+
+	if handler, exists := w.handlers[job.Kind]; !exists {
+		updates["status"], updates["error"] = STATUS_FAILED, fmt.Sprintf("error: no handler for the %s jobs", job.Kind)
+	} else if err := handler(job.Context(ctx), job); err != nil {
+		updates["status"], updates["error"] = STATUS_FAILED, err.Error()
+
+		id, _ := requestid.From(job.Context(ctx))
+		logging.Logger().Warn("error running a job", "job_id", job.Id, "kind", job.Kind, "request_id", id, "error", err)
+	}
+
+	return true, w.db.WithContext(ctx).Model(job).Updates(updates).Error
+}
+
+// Work runs the jobs until the ctx is cancelled, the queue is polled every interval once it is empty.
+func (w *Worker) Work(ctx context.Context, interval time.Duration) {
+	if interval == 0 {
+		interval = DEFAULT_POLL_INTERVAL
+	}
+
+	for ctx.Err() == nil {
+		ran, err := w.RunOnce(ctx)
+		if err != nil {
+			logging.Logger().Error("error running a job", "error", err)
+		}
+
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+	}
+}
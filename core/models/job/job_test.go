@@ -0,0 +1,186 @@
+package job_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/job"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/audit"
+	"github.com/rommms07/idream-erp/internal/requestid"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+var jobColumns = []string{"id", "kind", "payload", "status", "priority"}
+
+func expectCount(mock sqlmock.Sqlmock, pending int) {
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `jobs` WHERE status = \\?").
+		WithArgs(job.STATUS_PENDING).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(pending))
+}
+
+func expectClaim(mock sqlmock.Sqlmock, id uint64) {
+	mock.ExpectExec("UPDATE `jobs` SET `status`=\\?").
+		WithArgs(job.STATUS_RUNNING, sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE `jobs` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func jobRow(id uint64, priority int) *sqlmock.Rows {
+	return sqlmock.NewRows(jobColumns).AddRow(id, "send_invoice", "{}", job.STATUS_PENDING, priority)
+}
+
+func setWaterMarks(t *testing.T, high, low int64) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.QueueHighWaterMark, conf.QueueLowWaterMark = high, low
+	})
+}
+
+func Test_shouldShedUntilDrainedBelowTheLowWaterMark(t *testing.T) {
+	b := &job.Backpressure{High: 4, Low: 2}
+
+	shedding := []bool{}
+	for _, pending := range []int64{3, 5, 4, 3, 2, 3, 4} {
+		shedding = append(shedding, b.Shedding(pending))
+	}
+
+	assert.Equal(t, []bool{false, true, true, true, false, false, false}, shedding)
+	assert.False(t, (&job.Backpressure{}).Shedding(100), "A zero high water mark must never shed.")
+}
+
+func Test_shouldOnlyRunTheHighPriorityJobsPastTheHighWaterMark(t *testing.T) {
+	setWaterMarks(t, 4, 2)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	// 2 high-priority jobs (2 and 5) are queued among 3 low-priority ones, past the high water mark.
+	expectCount(mock, 5)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE status = \\? AND priority >= \\? ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED").
+		WithArgs(job.STATUS_PENDING, job.PRIORITY_HIGH).
+		WillReturnRows(jobRow(2, job.PRIORITY_HIGH))
+	expectClaim(mock, 2)
+
+	expectCount(mock, 4)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE status = \\? AND priority >= \\?").
+		WithArgs(job.STATUS_PENDING, job.PRIORITY_HIGH).
+		WillReturnRows(jobRow(5, job.PRIORITY_HIGH))
+	expectClaim(mock, 5)
+
+	// Drained down to the low water mark, the oldest job is run regardless of its priority.
+	expectCount(mock, 2)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE status = \\? ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED").
+		WithArgs(job.STATUS_PENDING).
+		WillReturnRows(jobRow(1, job.PRIORITY_LOW))
+	expectClaim(mock, 1)
+
+	ran := []uint64{}
+	worker := job.NewWorker(db).Handle("send_invoice", func(ctx context.Context, j *job.Job) error {
+		ran = append(ran, j.Id)
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		ok, err := worker.RunOnce(context.Background())
+		assert.True(t, ok)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, []uint64{2, 5, 1}, ran)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldDelayTheLowPriorityJobsWhileShedding(t *testing.T) {
+	setWaterMarks(t, 4, 2)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	ran := false
+	worker := job.NewWorker(db).Handle("send_invoice", func(ctx context.Context, j *job.Job) error {
+		ran = true
+		return nil
+	})
+
+	// Only the low-priority jobs are pending past the high water mark.
+	expectCount(mock, 6)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE status = \\? AND priority >= \\? ORDER BY id").
+		WithArgs(job.STATUS_PENDING, job.PRIORITY_HIGH).
+		WillReturnRows(sqlmock.NewRows(jobColumns))
+	mock.ExpectRollback()
+
+	ok, err := worker.RunOnce(context.Background())
+	assert.False(t, ok, "The low-priority jobs must not run while shedding.")
+	assert.Nil(t, err)
+
+	// Still shedding above the low water mark.
+	expectCount(mock, 3)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE status = \\? AND priority >= \\? ORDER BY id").
+		WithArgs(job.STATUS_PENDING, job.PRIORITY_HIGH).
+		WillReturnRows(sqlmock.NewRows(jobColumns))
+	mock.ExpectRollback()
+
+	ok, err = worker.RunOnce(context.Background())
+	assert.False(t, ok)
+	assert.Nil(t, err)
+
+	assert.False(t, ran)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+// metadataArg captures the metadata of the enqueued job.
+type metadataArg struct {
+	val string
+}
+
+func (m *metadataArg) Match(v driver.Value) bool {
+	m.val, _ = v.(string)
+	return true
+}
+
+func Test_theWorkerShouldRestoreTheRequestOfTheJob(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	md := &metadataArg{}
+	mock.ExpectExec("INSERT INTO `jobs`").
+		WithArgs("send_invoice", `{"invoice_id":1}`, job.STATUS_PENDING, job.PRIORITY_NORMAL, "", md, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	ctx := requestid.With(context.Background(), "req-42")
+	_, err = job.Enqueue(ctx, audit.WithActor(db, 7), "send_invoice", map[string]int{"invoice_id": 1}, job.PRIORITY_NORMAL)
+	assert.Nil(t, err)
+
+	expectCount(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE status = \\? ORDER BY id").
+		WillReturnRows(sqlmock.NewRows(append(jobColumns, "metadata")).
+			AddRow(1, "send_invoice", `{"invoice_id":1}`, job.STATUS_PENDING, job.PRIORITY_NORMAL, md.val))
+	expectClaim(mock, 1)
+
+	var (
+		reqId string
+		actor uint64
+	)
+
+	ok, err := job.NewWorker(db).Handle("send_invoice", func(ctx context.Context, j *job.Job) error {
+		reqId, _ = requestid.From(ctx)
+		actor, _ = audit.ActorFromContext(ctx)
+		return nil
+	}).RunOnce(context.Background())
+
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, "req-42", reqId, "The worker must carry the id of the request that enqueued the job.")
+	assert.Equal(t, uint64(7), actor)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,61 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	DEFAULT_BASE_CURRENCY = "USD"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("error: the lines of the invoice are not in the base currency")
+)
+
+// BaseCurrency returns the configured `baseCurrency`, the currency the invoices are totaled in.
+func BaseCurrency() string {
+	if code := loader.AppConfig().BaseCurrency; len(code) != 0 {
+		return strings.ToUpper(code)
+	}
+
+	return DEFAULT_BASE_CURRENCY
+}
+
+// Line is a line of an invoice, a nil Quantity is a single unit. A UnitPrice without a currency is in
+// the base currency.
+type Line struct {
+	UnitPrice Money
+	Quantity  *big.Rat
+}
+
+// Total returns the displayed total of the line, the fraction of the minor unit is rounded with the
+// configured rounding mode.
+func (l Line) Total() Money {
+	if l.Quantity == nil {
+		return l.UnitPrice
+	}
+
+	return l.UnitPrice.Mul(l.Quantity)
+}
+
+// InvoiceTotal sums the rounded totals of the lines in the minor unit of the base currency, so the total
+// always equals the sum of the displayed totals of the lines (rounding the exact sum instead may be off
+// by a minor unit).
+func InvoiceTotal(lines []Line) (Money, error) {
+	total := New(0, BaseCurrency())
+
+	for _, line := range lines {
+		amount := line.Total()
+		if len(amount.Currency) != 0 && !strings.EqualFold(amount.Currency, total.Currency) {
+			return Money{}, ErrCurrencyMismatch
+		}
+
+		total.Amount += amount.Amount
+	}
+
+	return total, nil
+}
@@ -0,0 +1,85 @@
+// This package implements the Money type used by the accounting models, an amount of money is always
+// kept in the minor unit of its currency (e.g. cents) so that no precision is lost along the way.
+
+package money
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	// DEFAULT_DECIMAL_PLACES is used by the currencies that are neither built-in nor configured.
+	DEFAULT_DECIMAL_PLACES = 2
+)
+
+var (
+	// DefaultCurrencies are the built-in currencies, the `currencies` of the app config take
+	// precedence over them.
+	DefaultCurrencies = map[string]*loader.Currency{
+		"USD": {Symbol: "$", DecimalPlaces: 2},
+		"EUR": {Symbol: "€", DecimalPlaces: 2},
+		"GBP": {Symbol: "£", DecimalPlaces: 2},
+		"JPY": {Symbol: "¥", DecimalPlaces: 0},
+		"CNY": {Symbol: "¥", DecimalPlaces: 2},
+		"KRW": {Symbol: "₩", DecimalPlaces: 0},
+		"PHP": {Symbol: "₱", DecimalPlaces: 2},
+		"SGD": {Symbol: "S$", DecimalPlaces: 2},
+		"AUD": {Symbol: "A$", DecimalPlaces: 2},
+		"KWD": {Symbol: "KD", DecimalPlaces: 3},
+	}
+)
+
+type Money struct {
+	// Amount is in the minor unit of the currency, 1050 USD is $10.50
+	Amount   int64
+	Currency string
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// LookupCurrency returns the formatting info of the currency code, the second return value is false
+// for an unknown currency in which case the code is used as its symbol.
+func LookupCurrency(code string) (*loader.Currency, bool) {
+	code = strings.ToUpper(code)
+
+	if cur, exists := loader.AppConfig().Currencies[code]; exists && cur != nil {
+		return cur, true
+	}
+
+	if cur, exists := DefaultCurrencies[code]; exists {
+		return cur, true
+	}
+
+	return &loader.Currency{Symbol: code + " ", DecimalPlaces: DEFAULT_DECIMAL_PLACES}, false
+}
+
+// String formats the money using the symbol and decimal places of its currency (e.g. $10.50, ¥1050).
+func (m Money) String() string {
+	cur, _ := LookupCurrency(m.Currency)
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	if cur.DecimalPlaces <= 0 {
+		return fmt.Sprintf("%s%s%d", sign, cur.Symbol, amount)
+	}
+
+	unit := pow10(cur.DecimalPlaces)
+	return fmt.Sprintf("%s%s%d.%0*d", sign, cur.Symbol, amount/unit, cur.DecimalPlaces, amount%unit)
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+
+	return p
+}
@@ -0,0 +1,118 @@
+package money_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/rommms07/idream-erp/core/models/money"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldFormatUsingTheSymbolAndDecimalPlacesOfTheCurrency(t *testing.T) {
+	assert.Equal(t, "$10.50", money.New(1050, "USD").String())
+	assert.Equal(t, "$0.05", money.New(5, "usd").String())
+	assert.Equal(t, "-$10.50", money.New(-1050, "USD").String())
+	assert.Equal(t, "¥1050", money.New(1050, "JPY").String(), "JPY has no minor unit.")
+}
+
+func Test_unknownCurrencyShouldDefaultToTwoDecimalPlaces(t *testing.T) {
+	assert.Equal(t, "XYZ 10.50", money.New(1050, "XYZ").String())
+}
+
+func Test_configuredCurrencyShouldOverrideTheBuiltInOne(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Currencies = map[string]*loader.Currency{"USD": {Symbol: "US$", DecimalPlaces: 2}}
+	})
+
+	assert.Equal(t, "US$10.50", money.New(1050, "USD").String())
+}
+
+func setRoundingMode(t *testing.T, mode string) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.RoundingMode = mode
+	})
+}
+
+func Test_mulShouldRoundTheHalfWithTheConfiguredMode(t *testing.T) {
+	half := big.NewRat(1, 2)
+
+	cases := []struct {
+		mode   string
+		amount int64
+		want   int64
+	}{
+		{money.ROUND_HALF_EVEN, 5, 2},
+		{money.ROUND_HALF_EVEN, 7, 4},
+		{money.ROUND_HALF_EVEN, -5, -2},
+		{money.ROUND_HALF_UP, 5, 3},
+		{money.ROUND_HALF_UP, 7, 4},
+		{money.ROUND_HALF_UP, -5, -3},
+		{money.ROUND_DOWN, 5, 2},
+		{money.ROUND_DOWN, 7, 3},
+		{money.ROUND_DOWN, -5, -2},
+	}
+
+	for _, c := range cases {
+		setRoundingMode(t, c.mode)
+		assert.Equal(t, c.want, money.New(c.amount, "USD").Mul(half).Amount, "%d/2 under %s", c.amount, c.mode)
+	}
+}
+
+func Test_roundingModeShouldDefaultToHalfEven(t *testing.T) {
+	setRoundingMode(t, "")
+
+	assert.Equal(t, money.ROUND_HALF_EVEN, money.RoundingMode())
+	assert.Equal(t, int64(12), money.New(125, "USD").Tax(big.NewRat(10, 100)).Amount, "12.5 cents of tax rounds to the even 12.")
+}
+
+func Test_allocateShouldAddUpToTheMoney(t *testing.T) {
+	setRoundingMode(t, money.ROUND_HALF_EVEN)
+
+	shares := money.New(100, "USD").Allocate(1, 1, 1)
+
+	assert.Equal(t, []money.Money{money.New(34, "USD"), money.New(33, "USD"), money.New(33, "USD")}, shares)
+}
+
+func Test_invoiceTotalShouldEqualTheSumOfTheDisplayedLines(t *testing.T) {
+	setRoundingMode(t, money.ROUND_HALF_UP)
+
+	// Each line is 33.333.. cents, the exact sum is a dollar but the displayed lines add up to 99 cents.
+	third := big.NewRat(1, 3)
+	lines := []money.Line{
+		{UnitPrice: money.New(100, "USD"), Quantity: third},
+		{UnitPrice: money.New(100, "USD"), Quantity: third},
+		{UnitPrice: money.New(100, ""), Quantity: third},
+	}
+
+	total, err := money.InvoiceTotal(lines)
+	assert.Nil(t, err)
+
+	displayed := int64(0)
+	for _, line := range lines {
+		displayed += line.Total().Amount
+	}
+
+	assert.Equal(t, money.New(99, "USD"), total)
+	assert.Equal(t, displayed, total.Amount)
+
+	// 2.5 units of $0.01 each round up to 3 cents, the 2 lines total 6 cents rather than the exact 5.
+	total, err = money.InvoiceTotal([]money.Line{
+		{UnitPrice: money.New(1, "USD"), Quantity: big.NewRat(5, 2)},
+		{UnitPrice: money.New(1, "USD"), Quantity: big.NewRat(5, 2)},
+		{UnitPrice: money.New(0, "USD")},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "$0.06", total.String())
+}
+
+func Test_invoiceTotalShouldRejectTheLinesInAnotherCurrency(t *testing.T) {
+	_, err := money.InvoiceTotal([]money.Line{
+		{UnitPrice: money.New(100, "USD")},
+		{UnitPrice: money.New(100, "EUR")},
+	})
+
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
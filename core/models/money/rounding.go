@@ -0,0 +1,96 @@
+package money
+
+import (
+	"math/big"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	ROUND_HALF_UP   = "half_up"
+	ROUND_HALF_EVEN = "half_even"
+	ROUND_DOWN      = "down"
+)
+
+// RoundingMode returns the configured `roundingMode`, it defaults to the banker's rounding.
+func RoundingMode() string {
+	if mode := loader.AppConfig().RoundingMode; len(mode) != 0 {
+		return mode
+	}
+
+	return ROUND_HALF_EVEN
+}
+
+// Mul multiplies the money by the factor (e.g. big.NewRat(3, 2) for 1.5), the fraction of the minor
+// unit is rounded with the configured rounding mode.
+func (m Money) Mul(factor *big.Rat) Money {
+	r := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), factor)
+	return Money{Amount: round(r, RoundingMode()), Currency: m.Currency}
+}
+
+// Tax returns the tax of the money at the rate (e.g. big.NewRat(12, 100) for 12%).
+func (m Money) Tax(rate *big.Rat) Money {
+	return m.Mul(rate)
+}
+
+// Allocate splits the money by the ratios, each share is rounded with the configured rounding mode and
+// what is left over (or taken in excess) is settled one minor unit at a time starting from the first
+// share, so the shares always add up to the money.
+func (m Money) Allocate(ratios ...int64) []Money {
+	shares := make([]Money, len(ratios))
+
+	total := int64(0)
+	for _, ratio := range ratios {
+		total += ratio
+	}
+
+	if total == 0 {
+		return shares
+	}
+
+	rest := m.Amount
+	for i, ratio := range ratios {
+		shares[i] = m.Mul(big.NewRat(ratio, total))
+		rest -= shares[i].Amount
+	}
+
+	step := int64(1)
+	if rest < 0 {
+		step = -1
+	}
+
+	for i := 0; rest != 0; i = (i + 1) % len(shares) {
+		shares[i].Amount += step
+		rest -= step
+	}
+
+	return shares
+}
+
+// round rounds the rational to an integer with the rounding mode, the `down` mode truncates towards zero.
+func round(r *big.Rat, mode string) int64 {
+	num, den := r.Num(), r.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if rem.Sign() == 0 || mode == ROUND_DOWN {
+		return q.Int64()
+	}
+
+	// Compare the twice of the remainder against the denominator to tell whether it is past the half.
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+
+	away := false
+	switch half.Cmp(den) {
+	case 1:
+		away = true
+	case 0:
+		away = mode == ROUND_HALF_UP || q.Bit(0) == 1
+	}
+
+	if away {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+
+	return q.Int64()
+}
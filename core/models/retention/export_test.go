@@ -0,0 +1,10 @@
+package retention
+
+import "time"
+
+// SetNow overrides the clock used by the purge, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
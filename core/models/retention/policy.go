@@ -0,0 +1,164 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/rommms07/idream-erp/internal/scheduler"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	POLICY_TASK_NAME = "apply_retention"
+)
+
+func init() {
+	scheduler.Default().Register(POLICY_TASK_NAME, func(ctx context.Context) error {
+		_, err := ApplyPolicies(ctx, source.Source[gorm.DB](), source.GormMigrator.Models()...)
+		return err
+	})
+}
+
+// ApplyPolicies deletes the rows of the models that were created before the retention of the model in
+// the `retention` of the app config, the models without a retention are skipped. The expired rows are
+// archived to the `retentionArchiveDir` (when set) before they are deleted, in the `retentionDryRun`
+// the expired rows are only counted. It returns the number of the expired rows of every model.
+func ApplyPolicies(ctx context.Context, db *gorm.DB, models ...any) (map[string]int64, error) {
+	conf := loader.AppConfig()
+	expired := make(map[string]int64)
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return expired, err
+		}
+
+		days := conf.Retention[stmt.Schema.Name]
+		if days <= 0 {
+			continue
+		}
+
+		n, err := applyPolicy(ctx, db, model, stmt.Schema, now().AddDate(0, 0, -days))
+		expired[stmt.Schema.Name] = n
+
+		if err != nil {
+			return expired, err
+		}
+
+		if conf.RetentionDryRun {
+			logging.Logger().Info("the retention would delete the expired rows", "model", stmt.Schema.Name, "rows", n)
+		}
+	}
+
+	return expired, nil
+}
+
+func applyPolicy(ctx context.Context, db *gorm.DB, model any, s *schema.Schema, cutoff time.Time) (int64, error) {
+	conf := loader.AppConfig()
+
+	createdAt := s.LookUpField("CreatedAt")
+	if createdAt == nil {
+		return 0, fmt.Errorf("error: %s has a retention but has no CreatedAt", s.Name)
+	}
+
+	if s.PrioritizedPrimaryField == nil {
+		return 0, fmt.Errorf("error: %s has a retention but has no primary key", s.Name)
+	}
+
+	pk := s.PrioritizedPrimaryField.DBName
+	tx := db.WithContext(ctx).Unscoped().Model(model).Clauses(clause.Lt{
+		Column: clause.Column{Table: clause.CurrentTable, Name: createdAt.DBName},
+		Value:  cutoff,
+	})
+
+	if conf.RetentionDryRun {
+		var n int64
+		return n, tx.Count(&n).Error
+	}
+
+	size := batchSize()
+
+	var total int64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		rows := []map[string]any{}
+		if err := tx.Session(&gorm.Session{}).Order(pk).Limit(size).Find(&rows).Error; err != nil {
+			return total, err
+		}
+
+		if len(rows) == 0 {
+			break
+		}
+
+		if len(conf.RetentionArchiveDir) != 0 {
+			if err := archive(conf.RetentionArchiveDir, s.Table, rows); err != nil {
+				return total, err
+			}
+		}
+
+		ids := make([]any, len(rows))
+		for i, row := range rows {
+			ids[i] = row[pk]
+		}
+
+		inBatch := clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk}, Values: ids}
+
+		res := db.WithContext(ctx).Unscoped().Where(inBatch).Delete(model)
+		if res.Error != nil {
+			return total, res.Error
+		}
+
+		total += res.RowsAffected
+
+		if len(rows) < size {
+			break
+		}
+	}
+
+	return total, nil
+}
+
+// archive appends the rows as JSON lines to the archive of the table of the day, e.g.
+// `<dir>/invoices-2006-01-02.jsonl`.
+func archive(dir, table string, rows []map[string]any) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+
+	name := filepath.Join(dir, fmt.Sprintf("%s-%s.jsonl", table, now().Format(time.DateOnly)))
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+
+	for _, row := range rows {
+		for column, val := range row {
+			if b, ok := val.([]byte); ok {
+				row[column] = string(b)
+			}
+		}
+
+		if err := enc.Encode(row); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	return f.Close()
+}
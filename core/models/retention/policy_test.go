@@ -0,0 +1,105 @@
+package retention_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/retention"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type invoice struct {
+	Id        uint64 `gorm:"primaryKey"`
+	Number    string
+	CreatedAt time.Time
+}
+
+type auditEntry struct {
+	Id        uint64 `gorm:"primaryKey"`
+	Action    string
+	CreatedAt time.Time
+}
+
+// unregulated has no retention, its rows are kept forever.
+type unregulated struct {
+	Id        uint64 `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func setPolicies(t *testing.T, dir string, dryRun bool) {
+	setRetention(t, 0, 100)
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Retention = map[string]int{"invoice": 10, "auditEntry": 2}
+		conf.RetentionArchiveDir, conf.RetentionDryRun = dir, dryRun
+	})
+}
+
+func Test_shouldApplyTheRetentionOfEveryModel(t *testing.T) {
+	dir := t.TempDir()
+	setPolicies(t, dir, false)
+
+	t0 := time.Now()
+	defer retention.SetNow(func() time.Time { return t0 })()
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `invoices` WHERE `invoices`.`created_at` < \\? ORDER BY id LIMIT 100").
+		WithArgs(t0.AddDate(0, 0, -10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "number"}).AddRow(1, "INV-0001").AddRow(2, "INV-0002"))
+	mock.ExpectExec("DELETE FROM `invoices` WHERE `invoices`.`id` IN \\(\\?,\\?\\)").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectQuery("SELECT \\* FROM `audit_entries` WHERE `audit_entries`.`created_at` < \\? ORDER BY id LIMIT 100").
+		WithArgs(t0.AddDate(0, 0, -2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action"}).AddRow(7, "login"))
+	mock.ExpectExec("DELETE FROM `audit_entries` WHERE `audit_entries`.`id` = \\?").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	expired, err := retention.ApplyPolicies(context.Background(), db, &invoice{}, &auditEntry{}, &unregulated{})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.Equal(t, map[string]int64{"invoice": 2, "auditEntry": 1}, expired)
+
+	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("invoices-%s.jsonl", t0.Format(time.DateOnly))))
+	if !assert.Nil(t, err, "The expired invoices should have been archived.") {
+		return
+	}
+
+	defer f.Close()
+
+	lines := []string{}
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		lines = append(lines, scanner.Text())
+	}
+
+	assert.Equal(t, []string{`{"id":1,"number":"INV-0001"}`, `{"id":2,"number":"INV-0002"}`}, lines)
+}
+
+func Test_aDryRunShouldPurgeNothing(t *testing.T) {
+	setPolicies(t, "", true)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `invoices` WHERE `invoices`.`created_at` < \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `audit_entries` WHERE `audit_entries`.`created_at` < \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	expired, err := retention.ApplyPolicies(context.Background(), db, &invoice{}, &auditEntry{})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet(), "A dry run must not delete any row.")
+	assert.Equal(t, map[string]int64{"invoice": 12, "auditEntry": 3}, expired)
+}
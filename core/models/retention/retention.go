@@ -0,0 +1,109 @@
+// This package hard-deletes the soft-deleted rows once they are older than the configured
+// `softDeleteRetentionDays`, only the models that opt in by implementing Purgeable are purged.
+// The purge runs on the scheduler as the `purge_soft_deleted` task.
+//
+// The `retention` policies of the models (see ApplyPolicies) run on the scheduler as the
+// `apply_retention` task.
+
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/scheduler"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	TASK_NAME = "purge_soft_deleted"
+
+	DEFAULT_PURGE_BATCH_SIZE = 1000
+)
+
+var (
+	// now is used to tell the age of the soft-deleted rows, the tests override it to move past the
+	// retention.
+	now = time.Now
+)
+
+func init() {
+	scheduler.Default().Register(TASK_NAME, func(ctx context.Context) error {
+		_, err := Purge(ctx, source.Source[gorm.DB](), source.GormMigrator.Models()...)
+		return err
+	})
+}
+
+// Purgeable is implemented by the models whose soft-deleted rows must be hard-deleted after the
+// retention, the model must have a `DeletedAt` field.
+type Purgeable interface {
+	PurgeSoftDeleted() bool
+}
+
+func batchSize() int {
+	if size := loader.AppConfig().SoftDeletePurgeBatchSize; size > 0 {
+		return size
+	}
+
+	return DEFAULT_PURGE_BATCH_SIZE
+}
+
+// Purge hard-deletes the rows of the given models that were soft-deleted before the retention, the
+// models that do not implement Purgeable are skipped. It returns the number of deleted rows.
+func Purge(ctx context.Context, db *gorm.DB, models ...any) (int64, error) {
+	days := loader.AppConfig().SoftDeleteRetentionDays
+	if days == 0 {
+		return 0, nil
+	}
+
+	cutoff := now().Add(-time.Duration(days) * 24 * time.Hour)
+	size := batchSize()
+
+	var total int64
+
+	for _, model := range models {
+		if p, ok := model.(Purgeable); !ok || !p.PurgeSoftDeleted() {
+			continue
+		}
+
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return total, err
+		}
+
+		field := stmt.Schema.LookUpField("DeletedAt")
+		if field == nil {
+			return total, fmt.Errorf("error: %s opted in to the purge but has no DeletedAt", stmt.Schema.Name)
+		}
+
+		expired := clause.Lt{
+			Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+			Value:  cutoff,
+		}
+
+		// Delete a batch at a time so that the table is never locked for long, a short batch means
+		// there is nothing left to delete.
+		for {
+			if err := ctx.Err(); err != nil {
+				return total, err
+			}
+
+			res := db.WithContext(ctx).Unscoped().Clauses(expired).Limit(size).Delete(model)
+			if res.Error != nil {
+				return total, res.Error
+			}
+
+			total += res.RowsAffected
+
+			if res.RowsAffected < int64(size) {
+				break
+			}
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,88 @@
+package retention_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/retention"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type archivedNote struct {
+	Id        uint64 `gorm:"primaryKey"`
+	Body      string
+	DeletedAt gorm.DeletedAt
+}
+
+func (*archivedNote) PurgeSoftDeleted() bool { return true }
+
+// keptNote did not opt in to the purge, its soft-deleted rows are kept forever.
+type keptNote struct {
+	Id        uint64 `gorm:"primaryKey"`
+	DeletedAt gorm.DeletedAt
+}
+
+func setRetention(t *testing.T, days uint64, batch int) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SoftDeleteRetentionDays, conf.SoftDeletePurgeBatchSize = days, batch
+	})
+}
+
+func Test_shouldHardDeleteTheRowsPastTheRetention(t *testing.T) {
+	setRetention(t, 30, 100)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("UPDATE `archived_notes` SET `deleted_at`=\\? WHERE `archived_notes`.`id` = \\? AND `archived_notes`.`deleted_at` IS NULL").
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, db.Delete(&archivedNote{Id: 1}).Error)
+
+	t0 := time.Now().Add(31 * 24 * time.Hour)
+	defer retention.SetNow(func() time.Time { return t0 })()
+
+	mock.ExpectExec("DELETE FROM `archived_notes` WHERE `archived_notes`.`deleted_at` < \\? LIMIT 100").
+		WithArgs(t0.Add(-30 * 24 * time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := retention.Purge(context.Background(), db, &archivedNote{}, &keptNote{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Nil(t, mock.ExpectationsWereMet(), "Only the rows of the opted-in model should have been hard-deleted.")
+}
+
+func Test_shouldPurgeInBatches(t *testing.T) {
+	setRetention(t, 30, 2)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	for _, affected := range []int64{2, 2, 1} {
+		mock.ExpectExec("DELETE FROM `archived_notes` WHERE `archived_notes`.`deleted_at` < \\? LIMIT 2").
+			WillReturnResult(sqlmock.NewResult(0, affected))
+	}
+
+	n, err := retention.Purge(context.Background(), db, &archivedNote{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.Nil(t, mock.ExpectationsWereMet(), "The purge should have stopped after the short batch.")
+}
+
+func Test_shouldKeepTheRowsWhenTheRetentionIsNotSet(t *testing.T) {
+	setRetention(t, 0, 100)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	n, err := retention.Purge(context.Background(), db, &archivedNote{})
+	assert.Nil(t, err)
+	assert.Zero(t, n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,79 @@
+// This package generates the gap-free numbers of the documents (e.g. the invoice numbers), a sequence
+// can be scoped to a period so that its counter starts over every year or month (see the
+// `sequencePeriods` of the app config).
+
+package sequence
+
+import (
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+const (
+	PERIOD_YEAR  = "year"
+	PERIOD_MONTH = "month"
+)
+
+var (
+	// now is used to tell the current period, the tests override it to move to the next period.
+	now = time.Now
+)
+
+func init() {
+	source.GormMigrator.Add(&Sequence{})
+}
+
+// Sequence is the counter of a sequence within a period, the Period is empty for a sequence that is
+// never reset.
+type Sequence struct {
+	Name   string `gorm:"primaryKey;size:64"`
+	Period string `gorm:"primaryKey;size:16"`
+	Value  uint64
+}
+
+// Period returns the period of the sequence at t (e.g. `2024` or `2024-05`), it is empty when the
+// sequence is not scoped to a period.
+func Period(name string, t time.Time) string {
+	switch loader.AppConfig().SequencePeriods[name] {
+	case PERIOD_YEAR:
+		return t.Format("2006")
+	case PERIOD_MONTH:
+		return t.Format("2006-01")
+	}
+
+	return ""
+}
+
+// NextSequence returns the next value of the sequence within its current period.
+func NextSequence(db *gorm.DB, name string) (uint64, error) {
+	return NextSequenceForPeriod(db, name, Period(name, now().In(loader.AppConfig().Location())))
+}
+
+// NextSequenceForPeriod returns the next value of the sequence within the period, the first value of a
+// period is 1. The counter is incremented and read back by a single statement, so the concurrent callers
+// never get the same value and no value is skipped. When called within a transaction the row of the
+// counter stays locked until it ends, a rolled back transaction gives its value back.
+func NextSequenceForPeriod(db *gorm.DB, name, period string) (uint64, error) {
+	// The statement is sent to the ConnPool directly since the insert id is not kept by gorm, it is the
+	// value of the counter thanks to the LAST_INSERT_ID(expr).
+	res, err := db.Statement.ConnPool.ExecContext(
+		db.Statement.Context,
+		"INSERT INTO `sequences` (`name`, `period`, `value`) VALUES (?, ?, LAST_INSERT_ID(1)) "+
+			"ON DUPLICATE KEY UPDATE `value` = LAST_INSERT_ID(`value` + 1)",
+		name, period,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(value), nil
+}
@@ -0,0 +1,96 @@
+package sequence_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/sequence"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+const nextValue = "INSERT INTO `sequences` \\(`name`, `period`, `value`\\) VALUES \\(\\?, \\?, LAST_INSERT_ID\\(1\\)\\) " +
+	"ON DUPLICATE KEY UPDATE `value` = LAST_INSERT_ID\\(`value` \\+ 1\\)"
+
+func setPeriods(t *testing.T, periods map[string]string) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SequencePeriods = periods
+	})
+}
+
+func Test_shouldResetTheCounterWhenThePeriodChanges(t *testing.T) {
+	setPeriods(t, map[string]string{"invoice": "year"})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec(nextValue).WithArgs("invoice", "2024").WillReturnResult(sqlmock.NewResult(41, 2))
+	mock.ExpectExec(nextValue).WithArgs("invoice", "2025").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	defer sequence.SetNow(func() time.Time { return time.Date(2024, 12, 31, 12, 0, 0, 0, loader.AppConfig().Location()) })()
+
+	value, err := sequence.NextSequence(db, "invoice")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(41), value)
+
+	defer sequence.SetNow(func() time.Time { return time.Date(2025, 1, 1, 0, 0, 1, 0, loader.AppConfig().Location()) })()
+
+	value, err = sequence.NextSequence(db, "invoice")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), value, "The counter of the new year must start over.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldScopeTheSequenceToTheConfiguredPeriod(t *testing.T) {
+	setPeriods(t, map[string]string{"invoice": "year", "receipt": "month"})
+
+	at := time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "2024", sequence.Period("invoice", at))
+	assert.Equal(t, "2024-05", sequence.Period("receipt", at))
+	assert.Equal(t, "", sequence.Period("customer", at), "A sequence that is not listed must never be reset.")
+}
+
+func Test_shouldHandOutGapFreeValuesToConcurrentCallers(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	// Each caller must increment and read the counter with a single statement, the database hands out
+	// the values in the order the statements reach the row.
+	mock.MatchExpectationsInOrder(false)
+	for i := 1; i <= 20; i++ {
+		mock.ExpectExec(nextValue).WithArgs("invoice", "2024").WillReturnResult(sqlmock.NewResult(int64(i), 1))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	values := []int{}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			value, err := sequence.NextSequenceForPeriod(db, "invoice", "2024")
+			assert.Nil(t, err)
+
+			mu.Lock()
+			values = append(values, int(value))
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	sort.Ints(values)
+
+	for i, value := range values {
+		assert.Equal(t, i+1, value)
+	}
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
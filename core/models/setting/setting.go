@@ -0,0 +1,114 @@
+// This package implements the optional `settings` table, each row of the table is overlaid on
+// top of the loaded app config which lets an admin change a few settings (like the `Message`
+// banner and the feature flags) without editing the app_config.json.
+
+package setting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"gorm.io/gorm"
+)
+
+const (
+	// The types of the values of the settings.
+	TYPE_STRING = "string"
+	TYPE_JSON   = "json"
+)
+
+func init() {
+	source.GormMigrator.Add(&Setting{})
+
+	source.OnMigrated(func(db *gorm.DB) error {
+		Watch(context.Background(), db)
+		return nil
+	})
+}
+
+// Setting is a single override of the app config, Key is the name of the field in the
+// loader.AppConfigType. The Type tells how the Value is read, a `string` is taken as is and a
+// `json` is the JSON encoding of the field.
+type Setting struct {
+	Key       string `gorm:"primaryKey;size:128"`
+	Value     string `gorm:"type:text"`
+	Type      string `gorm:"size:16;default:string"`
+	UpdatedAt time.Time
+}
+
+// Raw returns the JSON encoding of the value.
+func (s *Setting) Raw() (json.RawMessage, error) {
+	switch s.Type {
+	case TYPE_STRING:
+		return json.Marshal(s.Value)
+	case TYPE_JSON:
+		if !json.Valid([]byte(s.Value)) {
+			return nil, fmt.Errorf("error: the setting %s is not a valid JSON", s.Key)
+		}
+
+		return json.RawMessage(s.Value), nil
+	default:
+		return nil, fmt.Errorf("error: the setting %s has an unknown type %q", s.Key, s.Type)
+	}
+}
+
+// Refresh reads all the rows of the settings table and overlays them onto the app config, the
+// overrides of the rows that were deleted since the last refresh are dropped.
+func Refresh(db *gorm.DB) error {
+	rows := []*Setting{}
+
+	if err := db.Find(&rows).Error; err != nil {
+		return err
+	}
+
+	kv := make(map[string]json.RawMessage, len(rows))
+	for _, row := range rows {
+		raw, err := row.Raw()
+		if err != nil {
+			return err
+		}
+
+		kv[row.Key] = raw
+	}
+
+	return loader.ApplyOverrides(kv)
+}
+
+// Watch applies the settings table to the app config and keeps it refreshed every interval
+// until the ctx is cancelled. It does nothing when the overlay is disabled in the config.
+func Watch(ctx context.Context, db *gorm.DB) {
+	conf := loader.AppConfig().Settings
+
+	if !conf.Enabled {
+		return
+	}
+
+	if err := Refresh(db); err != nil {
+		logging.Logger().Error("error refreshing the settings", "error", err)
+	}
+
+	if conf.RefreshSeconds == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(conf.RefreshSeconds) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := Refresh(db); err != nil {
+					logging.Logger().Error("error refreshing the settings", "error", err)
+				}
+			}
+		}
+	}()
+}
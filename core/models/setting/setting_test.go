@@ -0,0 +1,84 @@
+package setting_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/setting"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// dropOverrides puts back the config from the file once the test is done.
+func dropOverrides(t *testing.T) {
+	t.Cleanup(func() { loader.ApplyOverrides(map[string]json.RawMessage{}) })
+}
+
+func Test_shouldOverlayTheMessageFromTheSettingsTable(t *testing.T) {
+	dropOverrides(t)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `settings`").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value", "type"}).
+			AddRow("Message", "Scheduled maintenance at 10PM", setting.TYPE_STRING).
+			AddRow("Features", `{"invoicing":true}`, setting.TYPE_JSON))
+
+	assert.Nil(t, setting.Refresh(db))
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	conf := loader.AppConfig()
+	assert.Equal(t, "Scheduled maintenance at 10PM", conf.Message, "The settings row did not override the message.")
+	assert.True(t, conf.Features["invoicing"], "The settings row did not override the features.")
+}
+
+func Test_aDeletedSettingShouldDropItsOverride(t *testing.T) {
+	dropOverrides(t)
+
+	message := loader.AppConfig().Message
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `settings`").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value", "type"}).AddRow("Message", "true", setting.TYPE_STRING))
+	mock.ExpectQuery("SELECT \\* FROM `settings`").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value", "type"}))
+
+	assert.Nil(t, setting.Refresh(db))
+	assert.Equal(t, "true", loader.AppConfig().Message, "A string setting must be taken as is even if it looks like a JSON.")
+
+	assert.Nil(t, setting.Refresh(db))
+	assert.Equal(t, message, loader.AppConfig().Message, "The message of the file should be back.")
+}
+
+func Test_aTimezoneSettingShouldChangeTheLocation(t *testing.T) {
+	dropOverrides(t)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `settings`").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value", "type"}).AddRow("Timezone", "America/New_York", setting.TYPE_STRING))
+
+	assert.Nil(t, setting.Refresh(db))
+
+	conf := loader.AppConfig()
+	assert.Equal(t, "America/New_York", conf.Location().String())
+	assert.Equal(t, "America/New_York", conf.GormConfig.NowFunc().Location().String())
+}
+
+func Test_shouldRejectAnOverrideThatDoesNotFitTheConfig(t *testing.T) {
+	dropOverrides(t)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `settings`").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value", "type"}).AddRow("Features", `"yes"`, setting.TYPE_JSON))
+
+	assert.NotNil(t, setting.Refresh(db), "An override of the wrong type must be rejected.")
+}
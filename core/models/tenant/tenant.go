@@ -0,0 +1,83 @@
+// This package implements the `tenants` table, a single-tenant deployment gets its tenant created on the
+// first boot from the `defaultTenant` of the app config (see SeedDefaultTenant).
+
+package tenant
+
+import (
+	"time"
+
+	"github.com/rommms07/idream-erp/core/models/user"
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/email"
+	"gorm.io/gorm"
+)
+
+func init() {
+	source.GormMigrator.Add(&Tenant{})
+
+	source.OnMigrated(func(db *gorm.DB) error {
+		_, err := SeedDefaultTenant(db)
+		return err
+	})
+}
+
+type Tenant struct {
+	Id        uint64 `gorm:"primaryKey"`
+	Name      string `gorm:"size:128;unique"`
+	CreatedAt time.Time
+}
+
+// SeedDefaultTenant creates the `defaultTenant` of the app config when there is no tenant yet and
+// assigns the `adminUsers` to it, so it must run after the user.SeedAdminUsers. It is safe to call it on
+// every boot, nothing is written once any tenant exists. The created tenant is returned, a nil tenant
+// when nothing was created.
+func SeedDefaultTenant(db *gorm.DB) (*Tenant, error) {
+	conf := loader.AppConfig()
+	if len(conf.DefaultTenant) == 0 {
+		return nil, nil
+	}
+
+	var count int64
+	if err := db.Model(&Tenant{}).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	if count != 0 {
+		return nil, nil
+	}
+
+	// The emails of the admins are looked up the way they were saved by the user.SeedAdminUsers.
+	emails := []string{}
+	for _, admin := range conf.AdminUsers {
+		if len(admin.Email) == 0 {
+			continue
+		}
+
+		if conf.NormalizeEmails {
+			emails = append(emails, email.Normalize(admin.Email))
+		} else {
+			emails = append(emails, admin.Email)
+		}
+	}
+
+	tenant := &Tenant{Name: conf.DefaultTenant}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tenant).Error; err != nil {
+			return err
+		}
+
+		if len(emails) == 0 {
+			return nil
+		}
+
+		return tx.Model(&user.User{}).Where("email IN ? AND tenant_id = 0", emails).Update("tenant_id", tenant.Id).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tenant, nil
+}
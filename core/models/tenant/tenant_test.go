@@ -0,0 +1,92 @@
+package tenant_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/tenant"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setDefaultTenant(t *testing.T, name, admins string) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DefaultTenant, conf.AdminUsers = name, nil
+		assert.Nil(t, json.Unmarshal([]byte(`{"adminUsers":`+admins+`}`), conf))
+	})
+}
+
+func Test_shouldCreateTheDefaultTenantOnlyOnce(t *testing.T) {
+	setDefaultTenant(t, "iDream", `[{"email":"admin@idream.local","password":"s3cret"}]`)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	// First boot, the database has no tenant.
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tenants`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `tenants`").
+		WithArgs("iDream", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE `users` SET `tenant_id`=\\? WHERE email IN \\(\\?\\) AND tenant_id = 0").
+		WithArgs(1, "admin@idream.local").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	created, err := tenant.SeedDefaultTenant(db)
+	assert.Nil(t, err)
+
+	if assert.NotNil(t, created) {
+		assert.Equal(t, uint64(1), created.Id)
+	}
+
+	// Second boot, the tenant exists so nothing must be written.
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tenants`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	created, err = tenant.SeedDefaultTenant(db)
+	assert.Nil(t, err)
+	assert.Nil(t, created)
+	assert.Nil(t, mock.ExpectationsWereMet(), "The default tenant must not be duplicated.")
+}
+
+func Test_shouldNotCreateATenantWithoutTheConfig(t *testing.T) {
+	setDefaultTenant(t, "", `[]`)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	created, err := tenant.SeedDefaultTenant(db)
+	assert.Nil(t, err)
+	assert.Nil(t, created)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldAssignTheAdminsByTheirNormalizedEmail(t *testing.T) {
+	setDefaultTenant(t, "iDream", `[{"email":" Admin@iDream.local ","password":"c0rrect-h0rse"}]`)
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.NormalizeEmails = true
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `tenants`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `tenants`").
+		WithArgs("iDream", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE `users` SET `tenant_id`=\\? WHERE email IN \\(\\?\\) AND tenant_id = 0").
+		WithArgs(1, "admin@idream.local").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, err = tenant.SeedDefaultTenant(db)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet(), "The admins must be looked up by their normalized email.")
+}
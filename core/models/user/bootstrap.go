@@ -0,0 +1,63 @@
+package user
+
+import (
+	"fmt"
+
+	"github.com/rommms07/idream-erp/core/pb/user_schema"
+	"github.com/rommms07/idream-erp/core/security/password"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/email"
+	"gorm.io/gorm"
+)
+
+// MIN_ADMIN_PASSWORD_LENGTH is the shortest password accepted for a seeded admin, a shorter one is most
+// likely an environment variable that was not set.
+const MIN_ADMIN_PASSWORD_LENGTH = 12
+
+// SeedAdminUsers creates the `adminUsers` defined in the app config so that a brand-new deployment
+// has someone who can log in. It is safe to call it on every boot, a user whose email already
+// exists is skipped and its password is never overwritten.
+func SeedAdminUsers(db *gorm.DB) error {
+	for _, admin := range loader.AppConfig().AdminUsers {
+		if len(admin.Email) == 0 {
+			continue
+		}
+
+		// The email of the admin is looked up the way it was saved.
+		lookup := admin.Email
+		if loader.AppConfig().NormalizeEmails {
+			lookup = email.Normalize(lookup)
+		}
+
+		var count int64
+		if err := db.Model(&User{}).Where("email = ?", lookup).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count != 0 {
+			continue
+		}
+
+		if len(admin.Password) < MIN_ADMIN_PASSWORD_LENGTH {
+			return fmt.Errorf("error: the password of the admin %s must have at least %d characters", admin.Email, MIN_ADMIN_PASSWORD_LENGTH)
+		}
+
+		hash, err := password.Hash(admin.Password)
+		if err != nil {
+			return err
+		}
+
+		err = db.Create(&User{
+			Uname:        admin.Email,
+			Email:        admin.Email,
+			Type:         user_schema.UserType_PRIVILEGED,
+			PasswordHash: hash,
+		}).Error
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
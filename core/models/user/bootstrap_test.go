@@ -0,0 +1,65 @@
+package user_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/models/user"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setAdminUsers(t *testing.T, admins string) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.AdminUsers = nil
+		assert.Nil(t, json.Unmarshal([]byte(`{"adminUsers":`+admins+`}`), conf))
+	})
+}
+
+func Test_shouldSeedTheAdminUserOnlyOnce(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.PasswordHashCost = bcrypt.MinCost
+	})
+	setAdminUsers(t, `[{"email":"admin@idream.local","password":"c0rrect-h0rse"}]`)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	// First boot, the admin does not exist yet.
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE email = \\?").
+		WithArgs("admin@idream.local").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO `users`").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.Nil(t, user.SeedAdminUsers(db))
+
+	// Second boot, the admin was already created so nothing must be written.
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE email = \\?").
+		WithArgs("admin@idream.local").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	assert.Nil(t, user.SeedAdminUsers(db))
+	assert.Nil(t, mock.ExpectationsWereMet(), "The admin user must not be re-created or overwritten.")
+}
+
+func Test_shouldRejectAnEmptyAdminPassword(t *testing.T) {
+	// The `$ADMIN_PASSWORD` expanded to nothing.
+	setAdminUsers(t, `[{"email":"admin@idream.local","password":""}]`)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `users` WHERE email = \\?").
+		WithArgs("admin@idream.local").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	err = user.SeedAdminUsers(db)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "admin@idream.local")
+	}
+
+	assert.Nil(t, mock.ExpectationsWereMet(), "No admin must be created without a password.")
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/rommms07/idream-erp/core/pb/user_schema"
+	"github.com/rommms07/idream-erp/core/security/password"
 	"github.com/rommms07/idream-erp/core/source"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"gorm.io/gorm"
@@ -11,6 +12,10 @@ import (
 
 func init() {
 	source.GormMigrator.Add(&User{}).Add(&UserAuthToken{})
+
+	// The admins are seeded before the tenant.SeedDefaultTenant assigns them, the tenant package
+	// imports this one so its hook is always registered after this one.
+	source.OnMigrated(SeedAdminUsers)
 }
 
 type User struct {
@@ -28,6 +33,11 @@ type User struct {
 	CreatedAt                       time.Time
 	Uflags                          uint64
 	State                           user_schema.UserState
+	TenantId                        uint64 `gorm:"index"`
+
+	// PasswordHash is only used by the offline (JWT) login, users that are signing in with
+	// Facebook do not have a password.
+	PasswordHash string
 }
 
 type UserAuthToken struct {
@@ -45,6 +55,24 @@ func (authToken *UserAuthToken) Owner() *User {
 	return user
 }
 
+// CheckPassword verifies the password of the offline login, a hash made with an outdated cost is
+// upgraded in place (see the VerifyAndRehash of the password package).
+func (u *User) CheckPassword(db *gorm.DB, plain string) error {
+	return password.VerifyAndRehash(u.PasswordHash, plain, func(hash string) error {
+		if err := db.Model(u).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+
+		u.PasswordHash = hash
+		return nil
+	})
+}
+
+// EmailField makes the email of the users normalized on save, see the internal/db/email package.
+func (u *User) EmailField() string {
+	return "Email"
+}
+
 func (u *User) Proto() *user_schema.User {
 	return &user_schema.User{
 		Id:    u.Id,
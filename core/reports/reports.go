@@ -0,0 +1,82 @@
+// This package gates the generation of the reports, only the configured `maxConcurrentReports` are
+// generated at the same time since a report can hold a large part of a table in the memory.
+
+package reports
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	OVERFLOW_QUEUE  = "queue"
+	OVERFLOW_REJECT = "reject"
+)
+
+var (
+	ErrTooManyReports = errors.New("error: too many reports are being generated, try again later")
+
+	_default *Limiter
+	once     sync.Once
+)
+
+// Limiter is a semaphore of the reports, a nil slots does not limit them.
+type Limiter struct {
+	slots chan struct{}
+	queue bool
+}
+
+// NewLimiter creates a limiter of max concurrent reports, the excess reports wait for their turn when
+// queue is set and are rejected with the ErrTooManyReports otherwise.
+func NewLimiter(max int, queue bool) *Limiter {
+	l := &Limiter{queue: queue}
+
+	if max > 0 {
+		l.slots = make(chan struct{}, max)
+	}
+
+	return l
+}
+
+// Default returns the limiter of the app configured by the `maxConcurrentReports` and the `reportOverflow`.
+func Default() *Limiter {
+	once.Do(func() {
+		conf := loader.AppConfig()
+		_default = NewLimiter(conf.MaxConcurrentReports, conf.ReportOverflow == OVERFLOW_QUEUE)
+	})
+
+	return _default
+}
+
+// Run runs the fn once a slot is free, a queued report gives up with the error of the ctx when it is
+// cancelled while waiting.
+func (l *Limiter) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if l.slots == nil {
+		return fn(ctx)
+	}
+
+	if l.queue {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			return ErrTooManyReports
+		}
+	}
+
+	defer func() { <-l.slots }()
+	return fn(ctx)
+}
+
+// RunReport runs the fn with the Default limiter.
+func RunReport(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Default().Run(ctx, fn)
+}
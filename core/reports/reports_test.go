@@ -0,0 +1,89 @@
+package reports_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldQueueTheReportsPastTheCap(t *testing.T) {
+	limiter := reports.NewLimiter(2, true)
+
+	var running, peak, done atomic.Int64
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := limiter.Run(context.Background(), func(ctx context.Context) error {
+				n := running.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+
+				time.Sleep(10 * time.Millisecond)
+				running.Add(-1)
+				done.Add(1)
+				return nil
+			})
+
+			assert.Nil(t, err)
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int64(10), done.Load(), "Every queued report should have run.")
+	assert.LessOrEqual(t, peak.Load(), int64(2))
+}
+
+func Test_shouldRejectTheReportsPastTheCap(t *testing.T) {
+	limiter := reports.NewLimiter(2, false)
+
+	started, release := make(chan struct{}), make(chan struct{})
+
+	for i := 0; i < 2; i++ {
+		go limiter.Run(context.Background(), func(ctx context.Context) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+
+		<-started
+	}
+
+	err := limiter.Run(context.Background(), func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, reports.ErrTooManyReports)
+
+	close(release)
+}
+
+func Test_aQueuedReportShouldGiveUpOnceCancelled(t *testing.T) {
+	limiter := reports.NewLimiter(1, true)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	go limiter.Run(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	ran := false
+	err := limiter.Run(ctx, func(ctx context.Context) error { ran = true; return nil })
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, ran)
+}
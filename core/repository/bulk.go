@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrFieldNotAllowed = errors.New("error: the field is not allowed to be bulk updated")
+
+// BulkUpdateFields returns the fields of the model T that can be bulk updated, they are the
+// `bulkUpdateFields` of the app config keyed by the name of the model.
+func BulkUpdateFields[T any]() []string {
+	return loader.AppConfig().BulkUpdateFields[reflect.TypeOf((*T)(nil)).Elem().Name()]
+}
+
+// BulkUpdate applies the updates to the rows of the model T with the ids in a single statement, every
+// key of the updates must be one of the allowed fields (the BulkUpdateFields of T when allowed is
+// nil). It returns the number of the updated rows, an empty ids is a no-op. The rows are updated with
+// the UpdateColumns, their UpdatedAt is stamped by the timestamps plugin (see the `stampBulkUpdates`).
+func BulkUpdate[T any](db *gorm.DB, ids []uint, updates map[string]any, allowed []string) (int64, error) {
+	if allowed == nil {
+		allowed = BulkUpdateFields[T]()
+	}
+
+	rejected := []string{}
+
+	for key := range updates {
+		if oneOfFields(allowed, key) {
+			continue
+		}
+
+		rejected = append(rejected, key)
+	}
+
+	if len(rejected) != 0 {
+		sort.Strings(rejected)
+		return 0, fmt.Errorf("%w: %v", ErrFieldNotAllowed, rejected)
+	}
+
+	if len(ids) == 0 || len(updates) == 0 {
+		return 0, nil
+	}
+
+	res := db.Model(new(T)).Where(clause.Eq{Column: clause.PrimaryColumn, Value: ids}).UpdateColumns(updates)
+	return res.RowsAffected, res.Error
+}
+
+func oneOfFields(fields []string, key string) bool {
+	for _, field := range fields {
+		if field == key {
+			return true
+		}
+	}
+
+	return false
+}
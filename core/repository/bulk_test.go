@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/repository"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/timestamps"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Product struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Status string
+	Price  int64
+	Owner  string
+}
+
+func Test_shouldBulkUpdateTheAllowedFields(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("UPDATE `products` SET `status`=\\? WHERE `products`.`id` IN \\(\\?,\\?,\\?\\)").
+		WithArgs("archived", 1, 2, 3).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := repository.BulkUpdate[Product](db, []uint{1, 2, 3}, map[string]any{"status": "archived"}, []string{"status", "price"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldRejectADisallowedField(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.BulkUpdateFields = map[string][]string{"Product": {"status"}}
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	n, err := repository.BulkUpdate[Product](db, []uint{1}, map[string]any{"status": "archived", "owner": "mallory"}, nil)
+	assert.ErrorIs(t, err, repository.ErrFieldNotAllowed)
+	assert.Contains(t, err.Error(), "owner")
+	assert.Zero(t, n)
+	assert.Nil(t, mock.ExpectationsWereMet(), "Nothing must be updated when a field is rejected.")
+}
+
+func Test_anEmptyIdListShouldBeANoop(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	n, err := repository.BulkUpdate[Product](db, nil, map[string]any{"status": "archived"}, []string{"status"})
+	assert.Nil(t, err)
+	assert.Zero(t, n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+type Ticket struct {
+	Id        uint64 `gorm:"primaryKey"`
+	Status    string
+	UpdatedAt time.Time
+}
+
+func Test_shouldBumpTheUpdatedAtOfTheBulkUpdatedRows(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.StampBulkUpdates = true
+	})
+	stampedAt := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+
+	db, mock, err := mocks.NewGormMockWithConfig(&gorm.Config{
+		SkipDefaultTransaction: true,
+		NowFunc:                func() time.Time { return stampedAt },
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(timestamps.New()))
+
+	mock.ExpectExec("UPDATE `tickets` SET `status`=\\?,`updated_at`=\\? WHERE `tickets`.`id` IN \\(\\?,\\?\\)").
+		WithArgs("void", stampedAt, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := repository.BulkUpdate[Ticket](db, []uint{1, 2}, map[string]any{"status": "void"}, []string{"status"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"gorm.io/gorm"
+)
+
+// DualWrite writes the model T to both its legacy table and the new table configured in the
+// `dualWriteTables` while its storage is being migrated, both writes are done within a transaction so
+// the tables never diverge on a failed write. Only the legacy table is written when no new table is
+// configured for the model.
+type DualWrite[T any] struct {
+	db     *gorm.DB
+	table  string
+	logger *slog.Logger
+}
+
+// NewDualWrite creates the dual-write of the model T, the mismatches are logged to the logger (the app
+// logger when nil).
+func NewDualWrite[T any](db *gorm.DB, logger *slog.Logger) *DualWrite[T] {
+	if logger == nil {
+		logger = logging.Logger()
+	}
+
+	return &DualWrite[T]{
+		db:     db,
+		table:  loader.AppConfig().DualWriteTables[reflect.TypeOf((*T)(nil)).Elem().Name()],
+		logger: logger,
+	}
+}
+
+func (d *DualWrite[T]) write(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if len(d.table) == 0 {
+		return fn(d.db.WithContext(ctx))
+	}
+
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		return fn(tx.Table(d.table))
+	})
+}
+
+// Create inserts the model to both tables, the primary key assigned by the legacy table is kept in the
+// new table.
+func (d *DualWrite[T]) Create(ctx context.Context, model *T) error {
+	return d.write(ctx, func(tx *gorm.DB) error {
+		return tx.Create(model).Error
+	})
+}
+
+// Save updates (or inserts) the model in both tables.
+func (d *DualWrite[T]) Save(ctx context.Context, model *T) error {
+	return d.write(ctx, func(tx *gorm.DB) error {
+		return tx.Save(model).Error
+	})
+}
+
+// FindByID reads the model from the legacy table, it is compared against the row of the new table when
+// the `dualWriteVerify` is set. A mismatch is only logged, the legacy row is returned regardless.
+func (d *DualWrite[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	model := new(T)
+
+	if err := d.db.WithContext(ctx).First(model, id).Error; err != nil {
+		return nil, err
+	}
+
+	if len(d.table) == 0 || !loader.AppConfig().DualWriteVerify {
+		return model, nil
+	}
+
+	mirrored := new(T)
+	err := d.db.WithContext(ctx).Table(d.table).First(mirrored, id).Error
+
+	switch {
+	case err != nil:
+		d.logger.Warn("dual-write: failed to read the row of the new table", "table", d.table, "id", id, "error", err)
+	case !reflect.DeepEqual(model, mirrored):
+		d.logger.Warn("dual-write: the row of the new table does not match the legacy row",
+			"table", d.table, "id", id, "legacy", model, "new", mirrored)
+	}
+
+	return model, nil
+}
@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/repository"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Invoice struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Number string
+	Total  int64
+}
+
+func setDualWrite(t *testing.T, tables map[string]string, verify bool) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DualWriteTables, conf.DualWriteVerify = tables, verify
+	})
+}
+
+func Test_shouldWriteToBothTables(t *testing.T) {
+	setDualWrite(t, map[string]string{"Invoice": "invoices_v2"}, false)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `invoices` \\(`number`,`total`\\)").
+		WithArgs("INV-001", 1050).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec("INSERT INTO `invoices_v2` \\(`number`,`total`,`id`\\)").
+		WithArgs("INV-001", 1050, 7).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectCommit()
+
+	invoice := &Invoice{Number: "INV-001", Total: 1050}
+	assert.Nil(t, repository.NewDualWrite[Invoice](db, nil).Create(context.Background(), invoice))
+	assert.Equal(t, uint64(7), invoice.Id)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldLogADivergenceOfTheNewTable(t *testing.T) {
+	setDualWrite(t, map[string]string{"Invoice": "invoices_v2"}, true)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `invoices` WHERE `invoices`.`id` = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "number", "total"}).AddRow(7, "INV-001", 1050))
+	mock.ExpectQuery("SELECT \\* FROM `invoices_v2` WHERE `invoices_v2`.`id` = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "number", "total"}).AddRow(7, "INV-001", 999))
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	invoice, err := repository.NewDualWrite[Invoice](db, logger).FindByID(context.Background(), 7)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1050), invoice.Total, "The legacy row must be returned.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	assert.Contains(t, buf.String(), "does not match the legacy row")
+	assert.Contains(t, buf.String(), `"table":"invoices_v2"`)
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+var ErrUnknownField = errors.New("error: unknown field")
+
+// SparseFields returns the fields of the model T that the API clients can select, they are the
+// `sparseFields` of the app config keyed by the name of the model.
+func SparseFields[T any]() []string {
+	return loader.AppConfig().SparseFields[reflect.TypeOf((*T)(nil)).Elem().Name()]
+}
+
+// ParseFields parses the comma separated `fields` query param against the SparseFields of the model T,
+// an empty param (or one without any field, e.g. `?fields=,`) selects every field (a nil slice). The
+// unknown fields are all reported at once.
+func ParseFields[T any](raw string) ([]string, error) {
+	if len(strings.TrimSpace(raw)) == 0 {
+		return nil, nil
+	}
+
+	allowed := SparseFields[T]()
+	fields, unknown := []string{}, []string{}
+
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+
+		switch {
+		case len(field) == 0, oneOfFields(fields, field):
+			continue
+		case !oneOfFields(allowed, field):
+			unknown = append(unknown, field)
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: %v, a field must be one of %v", ErrUnknownField, unknown, allowed)
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return fields, nil
+}
+
+// WithFields selects only the fields (their column or field names) for the query, the default preloads
+// of the model are skipped since the associations are not part of a sparse response.
+func WithFields(fields []string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.fields = fields
+	}
+}
+
+// Pick returns the rows of the models restricted to the fields, it is used to render the sparse response
+// of the models read WithFields. The rows are keyed like the JSON encoding of the models so that a sparse
+// response has the same keys as a full one.
+func (r *Repository[T]) Pick(models []*T, fields []string) ([]map[string]any, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string]any, 0, len(models))
+
+	for _, model := range models {
+		row := make(map[string]any, len(fields))
+
+		for _, name := range fields {
+			field := stmt.Schema.LookUpField(name)
+			if field == nil {
+				return nil, fmt.Errorf("%w: %s has no field %s", ErrUnknownField, stmt.Schema.Name, name)
+			}
+
+			key, _, _ := strings.Cut(field.StructField.Tag.Get("json"), ",")
+			if key == "-" {
+				continue
+			}
+
+			if len(key) == 0 {
+				key = field.Name
+			}
+
+			row[key], _ = field.ValueOf(context.Background(), reflect.ValueOf(model).Elem())
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPreloadTooDeep     = errors.New("error: the include is nested too deeply")
+	ErrUnknownAssociation = errors.New("error: unknown association")
+)
+
+// ParseIncludes parses the comma separated `include` query param into the associations of the model T
+// to preload (e.g. `?include=Items,Items.Product`), an empty param includes nothing. An include nested
+// deeper than the `maxPreloadDepth` is rejected before its associations are even looked up.
+func (r *Repository[T]) ParseIncludes(raw string) ([]string, error) {
+	if len(strings.TrimSpace(raw)) == 0 {
+		return nil, nil
+	}
+
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+
+	max := loader.AppConfig().MaxPreloadDepth
+	includes := []string{}
+
+	for _, include := range strings.Split(raw, ",") {
+		include = strings.TrimSpace(include)
+		if len(include) == 0 || oneOfFields(includes, include) {
+			continue
+		}
+
+		if depth := uint64(strings.Count(include, ".") + 1); max != 0 && depth > max {
+			return nil, fmt.Errorf("%w: %s has a depth of %d, the maximum is %d", ErrPreloadTooDeep, include, depth, max)
+		}
+
+		if err := validateAssociation(stmt.Schema, include); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAssociation, include)
+		}
+
+		includes = append(includes, include)
+	}
+
+	return includes, nil
+}
+
+// WithIncludes preloads the associations (as parsed by the ParseIncludes) on top of the default
+// preloads of the model.
+func WithIncludes(includes []string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.includes = includes
+	}
+}
@@ -0,0 +1,142 @@
+// This package implements the generic repository used to read the models, the associations listed
+// in the `defaultPreloads` of the app config are eager-loaded so that reading a model with its
+// associations does not end up with an N+1 queries.
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type queryOptions struct {
+	preload  bool
+	fields   []string
+	includes []string
+}
+
+// QueryOption changes a single query of the repository.
+type QueryOption func(opts *queryOptions)
+
+// WithoutPreloads skips the default preloads of the model for the query.
+func WithoutPreloads() QueryOption {
+	return func(opts *queryOptions) {
+		opts.preload = false
+	}
+}
+
+type Repository[T any] struct {
+	db *gorm.DB
+}
+
+func New[T any](db *gorm.DB) *Repository[T] {
+	return &Repository[T]{db: db}
+}
+
+// Preloads returns the configured preloads of the model T.
+func Preloads[T any]() []string {
+	return loader.AppConfig().DefaultPreloads[reflect.TypeOf((*T)(nil)).Elem().Name()]
+}
+
+func (r *Repository[T]) query(ctx context.Context, options []QueryOption) *gorm.DB {
+	opts := &queryOptions{preload: true}
+	for _, option := range options {
+		option(opts)
+	}
+
+	tx := r.db.WithContext(ctx)
+
+	if len(opts.fields) != 0 {
+		return tx.Select(opts.fields)
+	}
+
+	if opts.preload {
+		for _, association := range Preloads[T]() {
+			tx = tx.Preload(association)
+		}
+	}
+
+	for _, association := range opts.includes {
+		tx = tx.Preload(association)
+	}
+
+	return tx
+}
+
+// FindByID returns the model with the given primary key.
+func (r *Repository[T]) FindByID(ctx context.Context, id any, opts ...QueryOption) (*T, error) {
+	model := new(T)
+
+	if err := r.query(ctx, opts).First(model, id).Error; err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// List returns all of the rows of the model.
+func (r *Repository[T]) List(ctx context.Context, opts ...QueryOption) ([]*T, error) {
+	models := []*T{}
+
+	if err := r.query(ctx, opts).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+// ValidatePreloads checks the `defaultPreloads` of the app config against the given models, every
+// configured model must be one of them and every association must be a relationship of the model.
+func ValidatePreloads(db *gorm.DB, models ...any) error {
+	schemas := make(map[string]*schema.Schema, len(models))
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		schemas[stmt.Schema.Name] = stmt.Schema
+	}
+
+	errs := []error{}
+
+	for name, associations := range loader.AppConfig().DefaultPreloads {
+		s, exists := schemas[name]
+		if !exists {
+			errs = append(errs, fmt.Errorf("error: preloads of an unknown model %s", name))
+			continue
+		}
+
+		for _, association := range associations {
+			if err := validateAssociation(s, association); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateAssociation walks the (dot separated) association through the relationships of the schema.
+func validateAssociation(s *schema.Schema, association string) error {
+	current := s
+
+	for _, name := range strings.Split(association, ".") {
+		rel, exists := current.Relationships.Relations[name]
+		if !exists {
+			return fmt.Errorf("error: %s has no association %s (preload %s)", current.Name, name, association)
+		}
+
+		current = rel.FieldSchema
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/repository"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Order struct {
+	Id    uint64 `gorm:"primaryKey"`
+	Items []*LineItem
+}
+
+type LineItem struct {
+	Id        uint64 `gorm:"primaryKey"`
+	OrderId   uint64
+	Sku       string
+	ProductId uint64
+	Product   *Product
+}
+
+func setPreloads(t *testing.T, preloads map[string][]string) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DefaultPreloads = preloads
+	})
+}
+
+func Test_shouldEagerLoadTheConfiguredAssociations(t *testing.T) {
+	setPreloads(t, map[string][]string{"Order": {"Items"}})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `orders` WHERE `orders`.`id` = \\? ORDER BY `orders`.`id` LIMIT 1").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery("SELECT \\* FROM `line_items` WHERE `line_items`.`order_id` = \\?").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "sku"}).
+			AddRow(1, 7, "CHAIR-01").
+			AddRow(2, 7, "TABLE-01"))
+
+	order, err := repository.New[Order](db).FindByID(context.Background(), 7)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	if assert.Len(t, order.Items, 2, "The line items should have been preloaded.") {
+		assert.Equal(t, "TABLE-01", order.Items[1].Sku)
+	}
+}
+
+func Test_shouldSkipThePreloadsWhenOptedOut(t *testing.T) {
+	setPreloads(t, map[string][]string{"Order": {"Items"}})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `orders`").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7).AddRow(8))
+
+	orders, err := repository.New[Order](db).List(context.Background(), repository.WithoutPreloads())
+	assert.Nil(t, err)
+	assert.Len(t, orders, 2)
+	assert.Nil(t, mock.ExpectationsWereMet(), "The line items must not have been queried.")
+}
+
+func Test_shouldRejectAnInvalidAssociationName(t *testing.T) {
+	db, _, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	setPreloads(t, map[string][]string{"Order": {"Items"}})
+	assert.Nil(t, repository.ValidatePreloads(db, &Order{}, &LineItem{}))
+
+	setPreloads(t, map[string][]string{"Order": {"Itemz"}, "Invoice": {"Items"}})
+	err = repository.ValidatePreloads(db, &Order{}, &LineItem{})
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "Order has no association Itemz")
+		assert.Contains(t, err.Error(), "unknown model Invoice")
+	}
+}
+
+func setMaxPreloadDepth(t *testing.T, depth uint64) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.MaxPreloadDepth = depth
+	})
+}
+
+func Test_shouldRejectAnIncludeDeeperThanTheMaximum(t *testing.T) {
+	setMaxPreloadDepth(t, 1)
+
+	db, _, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	repo := repository.New[Order](db)
+
+	includes, err := repo.ParseIncludes("Items, Items")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Items"}, includes)
+
+	_, err = repo.ParseIncludes("Items,Items.Product")
+	assert.ErrorIs(t, err, repository.ErrPreloadTooDeep)
+
+	setMaxPreloadDepth(t, 2)
+
+	includes, err = repo.ParseIncludes("Items.Product")
+	assert.Nil(t, err, "An include within the maximum depth should be allowed.")
+	assert.Equal(t, []string{"Items.Product"}, includes)
+
+	_, err = repo.ParseIncludes("Items.Customer")
+	assert.ErrorIs(t, err, repository.ErrUnknownAssociation)
+}
@@ -0,0 +1,69 @@
+// github.com/rommms07/idream-erp/core/security/password
+//
+// This package is the password hasher used by the offline (JWT) login, the cost of the hash is
+// taken from the `passwordHashCost` of the app config.
+package password
+
+import (
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cost returns the configured bcrypt cost, falling back to bcrypt.DefaultCost when it is not set.
+func Cost() int {
+	cost := loader.AppConfig().PasswordHashCost
+
+	if cost < bcrypt.MinCost {
+		return bcrypt.DefaultCost
+	}
+
+	return cost
+}
+
+// Hash returns the bcrypt hash of the plain password using the configured cost.
+func Hash(plain string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(plain), Cost())
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// VerifyPassword returns a nil error when the plain password matches the hash.
+func VerifyPassword(hash, plain string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(plain))
+}
+
+// NeedsRehash reports whether the hash was made with a lower cost than the configured one, e.g. after
+// the `passwordHashCost` was raised.
+func NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < Cost()
+}
+
+// VerifyAndRehash verifies the plain password like the VerifyPassword, a matching password whose hash
+// needs a rehash is hashed again with the configured cost and handed to the save (when the
+// `rehashPasswordsOnLogin` of the app config is set). The login still succeeds when the rehash fails,
+// the hash is then upgraded on a later login.
+func VerifyAndRehash(hash, plain string, save func(hash string) error) error {
+	if err := VerifyPassword(hash, plain); err != nil {
+		return err
+	}
+
+	if !loader.AppConfig().RehashPasswordsOnLogin || !NeedsRehash(hash) {
+		return nil
+	}
+
+	rehashed, err := Hash(plain)
+	if err == nil {
+		err = save(rehashed)
+	}
+
+	if err != nil {
+		logging.Logger().Warn("error upgrading the cost of a password hash", "error", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package password_test
+
+import (
+	"testing"
+
+	"github.com/rommms07/idream-erp/core/security/password"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_shouldHashAndVerifyAPassword(t *testing.T) {
+	hash, err := password.Hash("s3cret")
+
+	assert.Nil(t, err)
+	assert.Nil(t, password.VerifyPassword(hash, "s3cret"), "The password should match its own hash.")
+	assert.NotNil(t, password.VerifyPassword(hash, "wrong"), "A different password must not match.")
+}
+
+func Test_shouldHashWithTheConfiguredCost(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.PasswordHashCost = bcrypt.MinCost
+	})
+	hash, _ := password.Hash("s3cret")
+	cost, _ := bcrypt.Cost([]byte(hash))
+
+	assert.Equal(t, bcrypt.MinCost, cost, "The hash did not use the configured cost.")
+}
+
+func Test_aLoginShouldUpgradeTheCostOfTheHash(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.PasswordHashCost = bcrypt.MinCost
+	})
+	stored, _ := password.Hash("s3cret")
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.PasswordHashCost, conf.RehashPasswordsOnLogin = bcrypt.MinCost+1, true
+	})
+	assert.True(t, password.NeedsRehash(stored))
+
+	saved := ""
+	save := func(hash string) error {
+		saved = hash
+		return nil
+	}
+
+	assert.NotNil(t, password.VerifyAndRehash(stored, "wrong", save))
+	assert.Empty(t, saved, "A failed login must not rehash the password.")
+
+	assert.Nil(t, password.VerifyAndRehash(stored, "s3cret", save))
+
+	cost, _ := bcrypt.Cost([]byte(saved))
+	assert.Equal(t, bcrypt.MinCost+1, cost, "The hash should be upgraded to the configured cost.")
+	assert.Nil(t, password.VerifyPassword(saved, "s3cret"))
+
+	assert.Nil(t, password.VerifyAndRehash(stored, "s3cret", func(hash string) error { return assert.AnError }),
+		"A failing save should not fail the login.")
+}
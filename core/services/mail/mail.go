@@ -0,0 +1,138 @@
+// This package sends the emails of the app through the server of the `smtp` config, the transient
+// failures of the server (e.g. the 4xx of a greylisting) are retried with the `smtp.retry` policy and an
+// email that still cannot be sent is queued as a `send_email` job to be retried later.
+
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+
+	"github.com/rommms07/idream-erp/core/models/job"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/rommms07/idream-erp/internal/retry"
+	"gorm.io/gorm"
+)
+
+const (
+	JOB_SEND_EMAIL = "send_email"
+)
+
+// ErrEmailQueued is returned by the SendEmail when the email was queued instead of being sent.
+var ErrEmailQueued = errors.New("error: the email could not be sent, it was queued for a later retry")
+
+type Email struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+// Sender delivers an email, the SMTPSender is the one used by the app.
+type Sender interface {
+	Send(ctx context.Context, email *Email) error
+}
+
+// SMTPSender sends the emails through an SMTP server.
+type SMTPSender struct {
+	Addr string
+	Auth smtp.Auth
+}
+
+// NewSMTPSender creates a sender with the server of the `smtp` config, the PLAIN auth is only used
+// when the username is set.
+func NewSMTPSender() *SMTPSender {
+	conf := loader.AppConfig().SMTP
+	sender := &SMTPSender{Addr: fmt.Sprintf("%s:%d", conf.Host, conf.Port)}
+
+	if len(conf.Username) != 0 {
+		sender.Auth = smtp.PlainAuth("", conf.Username, conf.Password, conf.Host)
+	}
+
+	return sender
+}
+
+// headerValue drops the line breaks of a header value, they would let the value inject headers.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// Message returns the RFC 5322 message of the email.
+func (email *Email) Message() []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(email.From))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(strings.Join(email.To, ", ")))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(email.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(email.Body)
+
+	return []byte(b.String())
+}
+
+func (s *SMTPSender) Send(ctx context.Context, email *Email) error {
+	return smtp.SendMail(s.Addr, s.Auth, email.From, email.To, email.Message())
+}
+
+// IsTransient reports whether the failed send can be retried, that is a 4xx reply of the server or a
+// timeout reaching it.
+func IsTransient(err error) bool {
+	var replyErr *textproto.Error
+	if errors.As(err, &replyErr) {
+		return 400 <= replyErr.Code && replyErr.Code < 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// send sends the email with the retry policy of the `smtp` config, the From defaults to the one of the
+// config.
+func send(ctx context.Context, sender Sender, email *Email) error {
+	conf := loader.AppConfig().SMTP
+	if len(email.From) == 0 {
+		email.From = conf.From
+	}
+
+	return retry.Do(ctx, conf.Retry, IsTransient, func(ctx context.Context) error {
+		return sender.Send(ctx, email)
+	})
+}
+
+// SendEmail sends the email, it is queued as a `send_email` job when it still cannot be sent once the
+// retries are exhausted (or the failure is permanent) and the ErrEmailQueued is returned.
+func SendEmail(ctx context.Context, db *gorm.DB, sender Sender, email *Email) error {
+	err := send(ctx, sender, email)
+	if err == nil {
+		return nil
+	}
+
+	queued, qerr := job.Enqueue(ctx, db, JOB_SEND_EMAIL, email, job.PRIORITY_NORMAL)
+	if qerr != nil {
+		return errors.Join(err, qerr)
+	}
+
+	logging.Logger().Warn("queued an email that could not be sent", "job_id", queued.Id, "error", err)
+	return fmt.Errorf("%w: %w", ErrEmailQueued, err)
+}
+
+// SendEmailJob is the handler of the `send_email` jobs, a failing send marks the job as failed instead
+// of queuing the email again.
+func SendEmailJob(sender Sender) job.Handler {
+	return func(ctx context.Context, j *job.Job) error {
+		email := &Email{}
+		if err := json.Unmarshal([]byte(j.Payload), email); err != nil {
+			return err
+		}
+
+		return send(ctx, sender, email)
+	}
+}
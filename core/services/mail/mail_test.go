@@ -0,0 +1,79 @@
+package mail_test
+
+import (
+	"context"
+	"net/textproto"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/services/mail"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSender fails the sends with its errs in order, the sends past the errs succeed.
+type fakeSender struct {
+	errs  []error
+	sends int
+}
+
+func (s *fakeSender) Send(ctx context.Context, email *mail.Email) error {
+	s.sends++
+	if s.sends <= len(s.errs) {
+		return s.errs[s.sends-1]
+	}
+
+	return nil
+}
+
+var greylisted = &textproto.Error{Code: 451, Msg: "4.7.1 Greylisted, please try again later"}
+
+func withRetry(t *testing.T, attempts int) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SMTP.Retry = &loader.RetryPolicy{MaxAttempts: attempts}
+	})
+}
+
+func Test_shouldRetryAGreylistedEmail(t *testing.T) {
+	withRetry(t, 3)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	sender := &fakeSender{errs: []error{greylisted}}
+	email := &mail.Email{From: "billing@idream.local", To: []string{"alice@example.com"}, Subject: "Invoice", Body: "..."}
+
+	assert.Nil(t, mail.SendEmail(context.Background(), db, sender, email))
+	assert.Equal(t, 2, sender.sends)
+	assert.Nil(t, mock.ExpectationsWereMet(), "A sent email must not be queued.")
+}
+
+func Test_shouldQueueAnEmailThatCannotBeSent(t *testing.T) {
+	withRetry(t, 3)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("INSERT INTO `jobs`").
+		WithArgs(mail.JOB_SEND_EMAIL, `{"from":"billing@idream.local","to":["nobody@example.com"],"subject":"Invoice","body":"..."}`,
+			"pending", 5, "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	rejected := &textproto.Error{Code: 550, Msg: "5.1.1 No such user"}
+	sender := &fakeSender{errs: []error{rejected}}
+	email := &mail.Email{From: "billing@idream.local", To: []string{"nobody@example.com"}, Subject: "Invoice", Body: "..."}
+
+	err = mail.SendEmail(context.Background(), db, sender, email)
+	assert.ErrorIs(t, err, mail.ErrEmailQueued)
+	assert.ErrorIs(t, err, rejected)
+	assert.Equal(t, 1, sender.sends, "A permanent failure must not be retried.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldDropTheLineBreaksOfTheHeaders(t *testing.T) {
+	email := &mail.Email{From: "a@idream.local", To: []string{"b@example.com"}, Subject: "Hi\r\nBcc: x@evil.test", Body: "Hello"}
+
+	assert.Equal(t, "From: a@idream.local\r\nTo: b@example.com\r\nSubject: HiBcc: x@evil.test\r\n"+
+		"MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\nHello", string(email.Message()))
+}
@@ -1,12 +1,17 @@
 package source
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/rommms07/idream-erp/config/app_config"
+	"github.com/rommms07/idream-erp/core/repository"
 	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/logging"
 	"github.com/rommms07/idream-erp/internal/db/migrator/gorm"
+	"github.com/rommms07/idream-erp/internal/scheduler"
 
 	_gorm "gorm.io/gorm"
 )
@@ -14,9 +19,30 @@ import (
 var (
 	dataSourceName = app_config.AppConfig().InuseDataSource
 	GormMigrator   = gorm.NewGormMigrator()
+
+	// migratedHooks are called once the source was migrated, see the OnMigrated.
+	migratedHooks []func(db *_gorm.DB) error
 )
 
+// OnMigrated registers the fn to be called with the db once the MigrateSchemaToSource succeeded, the
+// packages of the models use it to seed their rows or to start watching their tables. The fns are called
+// in the order they were registered.
+func OnMigrated(fn func(db *_gorm.DB) error) {
+	migratedHooks = append(migratedHooks, fn)
+}
+
 func Source[T any]() *T {
+	src, err := Open[T]()
+	if err != nil {
+		log.Fatalf(err.Error())
+		return nil
+	}
+
+	return src
+}
+
+// Open is the same as Source but the error of the connection is returned instead of exiting.
+func Open[T any]() (*T, error) {
 	var src any
 	var err error
 
@@ -28,24 +54,76 @@ func Source[T any]() *T {
 	}
 
 	if err != nil {
-		log.Fatalf(err.Error())
-		return nil
+		return nil, err
 	}
 
-	return src.(*T)
+	return src.(*T), nil
 }
 
 // MigrateSchemaToSource will automatically migrate all the schema added to the default
 // migrator. INUSE_DATA_SOURCE will decide where or what type of the data source the schema
 // will be migrated.
 func MigrateSchemaToSource() (err error) {
+	lockConf := app_config.AppConfig().MigrationLock
+	GormMigrator.ValidateTags = app_config.AppConfig().ValidateModelTags
+	GormMigrator.StrictJSONTags = app_config.AppConfig().StrictJSONTags
+	GormMigrator.Partitioning = app_config.AppConfig().EnablePartitioning
+	GormMigrator.SnapshotSchema = app_config.AppConfig().SnapshotSchema
+	GormMigrator.Mode = app_config.AppConfig().MigrationMode
+	GormMigrator.AllowDestructive = app_config.AppConfig().AllowDestructive
+	GormMigrator.Retry = app_config.AppConfig().MigrationRetry
 
 	switch dataSourceName {
 	case "mysql":
+		if lockConf.Enabled {
+			GormMigrator.Locker = gorm.MysqlLocker{}
+			GormMigrator.LockName = lockConf.Name
+			GormMigrator.LockTimeout = time.Duration(lockConf.WaitTimeoutSeconds) * time.Second
+		}
+
 		err = GormMigrator.SetDB(Source[_gorm.DB]()).Migrate()
 	default:
 		err = fmt.Errorf("error: data source [%s] is not implemented yet", dataSourceName)
 	}
 
+	// Another instance is already migrating the same models, there is nothing left to do.
+	if errors.Is(err, gorm.ErrMigrationLocked) {
+		logging.Logger().Warn("skipped the migration", "error", err)
+		err = nil
+	}
+
+	// The old version of the app is still running on the same database, the destructive migration
+	// has to wait for the next deploy.
+	if errors.Is(err, gorm.ErrDestructiveMigration) {
+		logging.Logger().Error("blocked a destructive migration", "error", err)
+	}
+
+	if err == nil {
+		err = repository.ValidatePreloads(Source[_gorm.DB](), GormMigrator.Models()...)
+	}
+
+	if err == nil && dataSourceName == "mysql" {
+		err = gorm.IndexForeignKeys(Source[_gorm.DB](), logging.Logger(),
+			app_config.AppConfig().AutoIndexForeignKeys, GormMigrator.Models()...)
+	}
+
+	if err == nil && dataSourceName == "mysql" {
+		err = gorm.CheckNotNullColumns(Source[_gorm.DB](), logging.Logger(),
+			app_config.AppConfig().StrictNotNullColumns, app_config.AppConfig().NotNullColumns)
+	}
+
+	for _, hook := range migratedHooks {
+		if err != nil {
+			break
+		}
+
+		err = hook(Source[_gorm.DB]())
+	}
+
+	// The database is connected and migrated, the scheduled tasks may start firing.
+	if err == nil {
+		scheduler.Default().SetReady()
+	}
+
 	return
 }
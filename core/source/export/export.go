@@ -0,0 +1,150 @@
+// This package exports the rows of a table to a spreadsheet friendly format (CSV or JSON lines),
+// the rows are read in batches of the configured `exportBatchSize` so that exporting a large
+// table does not load the entire table into the memory.
+
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	FORMAT_CSV   = "csv"
+	FORMAT_JSONL = "jsonl"
+
+	DEFAULT_BATCH_SIZE = 500
+)
+
+// BatchSize returns the configured number of rows read at a time, falling back to
+// DEFAULT_BATCH_SIZE when it is not set.
+func BatchSize() int {
+	if size := loader.AppConfig().ExportBatchSize; size > 0 {
+		return size
+	}
+
+	return DEFAULT_BATCH_SIZE
+}
+
+// Export streams all the rows of the model T to w in the given format. The columns (and the header
+// row of a CSV) are the column names derived from the gorm tags of T. The export stops with the
+// error of the ctx once it is cancelled.
+func Export[T any](ctx context.Context, db *gorm.DB, w io.Writer, format string) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return err
+	}
+
+	fields := columns(stmt.Schema)
+
+	var write func(row map[string]any, values []string) error
+	var flush func() error
+
+	switch format {
+	case FORMAT_CSV:
+		cw := csv.NewWriter(w)
+		header := make([]string, len(fields))
+
+		for i, field := range fields {
+			header[i] = field.DBName
+		}
+
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+
+		write = func(_ map[string]any, values []string) error { return cw.Write(values) }
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case FORMAT_JSONL:
+		enc := json.NewEncoder(w)
+
+		write = func(row map[string]any, _ []string) error { return enc.Encode(row) }
+		flush = func() error { return nil }
+	default:
+		return fmt.Errorf("error: unsupported export format %q", format)
+	}
+
+	rows := []*T{}
+
+	err := db.WithContext(ctx).FindInBatches(&rows, BatchSize(), func(tx *gorm.DB, batch int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range rows {
+			rv := reflect.ValueOf(r).Elem()
+			row := make(map[string]any, len(fields))
+			values := make([]string, len(fields))
+
+			for i, field := range fields {
+				v, _ := field.ValueOf(ctx, rv)
+				row[field.DBName] = v
+				values[i] = formatValue(v)
+			}
+
+			if err := write(row, values); err != nil {
+				return err
+			}
+		}
+
+		// Flush every batch so that the rows reach the writer as soon as they are read.
+		return flush()
+	}).Error
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// columns returns the fields of the schema that are backed by a column, in the order they are
+// declared in the model.
+func columns(s *schema.Schema) []*schema.Field {
+	fields := []*schema.Field{}
+
+	for _, field := range s.Fields {
+		if field.DBName != "" && field.Readable {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func formatValue(v any) string {
+	rv := reflect.ValueOf(v)
+
+	if v == nil || (rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		return ""
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		v = rv.Elem().Interface()
+	}
+
+	switch v := v.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+
+		return v.Format(time.RFC3339)
+	case []byte:
+		return string(v)
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,139 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/source/export"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type customer struct {
+	Id        uint64 `gorm:"primaryKey"`
+	Name      string `gorm:"column:full_name"`
+	Email     string
+	CreatedAt time.Time
+}
+
+var (
+	createdAt = time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	columns   = []string{"id", "full_name", "email", "created_at"}
+)
+
+// expectCustomers seeds the customers table as 3 batches of at most 2 rows, every batch is a
+// separate query which is only answered once the previous batch was exported.
+func expectCustomers(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT \\* FROM `customers` ORDER BY `customers`.`id` LIMIT 2").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Juan Dela Cruz", "juan@example.com", createdAt).
+			AddRow(2, "Maria, Clara", "maria@example.com", createdAt))
+
+	mock.ExpectQuery("SELECT \\* FROM `customers` WHERE `customers`.`id` > \\? ORDER BY `customers`.`id` LIMIT 2").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, "Jose Rizal", "jose@example.com", createdAt).
+			AddRow(4, "Andres Bonifacio", "andres@example.com", createdAt))
+
+	mock.ExpectQuery("SELECT \\* FROM `customers` WHERE `customers`.`id` > \\? ORDER BY `customers`.`id` LIMIT 2").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(5, "Gabriela Silang", "gabriela@example.com", createdAt))
+}
+
+// batchWriter records the number of writes made to it by the time of each flushed batch.
+type batchWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *batchWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+// setBatchSize reads the rows in batches of two for the duration of the test.
+func setBatchSize(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.ExportBatchSize = 2
+	})
+}
+
+func Test_shouldExportATableToCsv(t *testing.T) {
+	setBatchSize(t)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectCustomers(mock)
+
+	w := &batchWriter{}
+	assert.Nil(t, export.Export[customer](context.Background(), db, w, export.FORMAT_CSV))
+	assert.Nil(t, mock.ExpectationsWereMet(), "The rows must be read in batches of the configured size.")
+
+	assert.Equal(t, ""+
+		"id,full_name,email,created_at\n"+
+		"1,Juan Dela Cruz,juan@example.com,2023-01-02T03:04:05Z\n"+
+		"2,\"Maria, Clara\",maria@example.com,2023-01-02T03:04:05Z\n"+
+		"3,Jose Rizal,jose@example.com,2023-01-02T03:04:05Z\n"+
+		"4,Andres Bonifacio,andres@example.com,2023-01-02T03:04:05Z\n"+
+		"5,Gabriela Silang,gabriela@example.com,2023-01-02T03:04:05Z\n", w.String())
+
+	assert.Equal(t, 3, w.writes, "Every batch should have been flushed to the writer on its own.")
+}
+
+func Test_shouldExportATableToJsonLines(t *testing.T) {
+	setBatchSize(t)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectCustomers(mock)
+
+	w := &batchWriter{}
+	assert.Nil(t, export.Export[customer](context.Background(), db, w, export.FORMAT_JSONL))
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	lines := bytes.Split(bytes.TrimSpace(w.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 5)
+	assert.JSONEq(t, `{"id":1,"full_name":"Juan Dela Cruz","email":"juan@example.com","created_at":"2023-01-02T03:04:05Z"}`, string(lines[0]))
+	assert.JSONEq(t, `{"id":5,"full_name":"Gabriela Silang","email":"gabriela@example.com","created_at":"2023-01-02T03:04:05Z"}`, string(lines[4]))
+}
+
+// cancellingWriter cancels the export as soon as the first batch reaches it.
+type cancellingWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancellingWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	return w.Buffer.Write(p)
+}
+
+func Test_shouldStopTheExportOnceTheContextIsCancelled(t *testing.T) {
+	setBatchSize(t)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectCustomers(mock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &cancellingWriter{cancel: cancel}
+
+	err = export.Export[customer](ctx, db, w, export.FORMAT_JSONL)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotNil(t, mock.ExpectationsWereMet(), "The remaining batches should not have been read.")
+}
+
+func Test_shouldRejectAnUnsupportedFormat(t *testing.T) {
+	db, _, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	assert.NotNil(t, export.Export[customer](context.Background(), db, &bytes.Buffer{}, "xlsx"))
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+const DEFAULT_FLUSH_ROWS = 100
+
+// FlushRows returns the configured number of rows written between two flushes of a stream, falling
+// back to DEFAULT_FLUSH_ROWS when it is not set.
+func FlushRows() int {
+	if rows := loader.AppConfig().StreamFlushRows; rows > 0 {
+		return rows
+	}
+
+	return DEFAULT_FLUSH_ROWS
+}
+
+// StreamJSON writes the rows of the model (e.g. `&Order{}`, optionally scoped by the db) to w as a JSON
+// array, the rows are read one at a time from a cursor so that the memory used does not grow with the
+// number of rows. The w is flushed every FlushRows rows when it is an http.Flusher. The stream stops
+// with the error of the ctx once it is cancelled, leaving the array unterminated so that the client
+// can not mistake a partial response for a complete one.
+func StreamJSON(ctx context.Context, db *gorm.DB, w io.Writer, model any) error {
+	rows, err := db.WithContext(ctx).Model(model).Rows()
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	flusher, _ := w.(http.Flusher)
+	flushRows := FlushRows()
+	typ := reflect.TypeOf(model)
+
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+
+	for n := 0; rows.Next(); n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		item := reflect.New(typ).Interface()
+		if err := db.ScanRows(rows, item); err != nil {
+			return err
+		}
+
+		if n != 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+
+		if flusher != nil && (n+1)%flushRows == 0 {
+			flusher.Flush()
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return err
+	}
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package export_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/source/export"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectManyCustomers(mock sqlmock.Sqlmock, n int) {
+	rows := sqlmock.NewRows(columns)
+	for i := 1; i <= n; i++ {
+		rows.AddRow(i, "Juan Dela Cruz", "juan@example.com", createdAt)
+	}
+
+	mock.ExpectQuery("SELECT \\* FROM `customers`").WillReturnRows(rows)
+}
+
+// cancellingRecorder cancels the stream once the n-th row was written.
+type cancellingRecorder struct {
+	*httptest.ResponseRecorder
+	rows   int
+	n      int
+	cancel context.CancelFunc
+}
+
+func (w *cancellingRecorder) Write(b []byte) (int, error) {
+	if b[0] == '{' {
+		if w.rows++; w.rows == w.n {
+			w.cancel()
+		}
+	}
+
+	return w.ResponseRecorder.Write(b)
+}
+
+func Test_shouldStreamALargeResultAsAJsonArray(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.StreamFlushRows = 100
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectManyCustomers(mock, 5000)
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, export.StreamJSON(context.Background(), db, w, &customer{}))
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.True(t, w.Flushed)
+
+	customers := []*customer{}
+	if assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &customers), "The stream must be a valid JSON.") {
+		assert.Len(t, customers, 5000)
+		assert.Equal(t, uint64(5000), customers[4999].Id)
+	}
+}
+
+func Test_shouldStreamAnEmptyArray(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectManyCustomers(mock, 0)
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, export.StreamJSON(context.Background(), db, w, &customer{}))
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func Test_shouldStopStreamingOnceCancelled(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectManyCustomers(mock, 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := &cancellingRecorder{ResponseRecorder: httptest.NewRecorder(), n: 10, cancel: cancel}
+
+	err = export.StreamJSON(ctx, db, w, &customer{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 10, w.rows, "No row must be written after the cancellation.")
+	assert.False(t, json.Valid(w.Body.Bytes()), "A partial stream must not be a valid JSON.")
+}
@@ -1,24 +1,385 @@
 package mysql
 
 import (
+	"database/sql"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	_mysql "github.com/go-sql-driver/mysql"
 	"github.com/rommms07/idream-erp/config/app_config"
 	"github.com/rommms07/idream-erp/config/gorm_config"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/rommms07/idream-erp/internal/db/acquire"
+	"github.com/rommms07/idream-erp/internal/db/advisor"
+	"github.com/rommms07/idream-erp/internal/db/audit"
+	"github.com/rommms07/idream-erp/internal/db/cascade"
+	"github.com/rommms07/idream-erp/internal/db/conntrace"
+	"github.com/rommms07/idream-erp/internal/db/email"
+	"github.com/rommms07/idream-erp/internal/db/indexhint"
+	"github.com/rommms07/idream-erp/internal/db/nplusone"
+	"github.com/rommms07/idream-erp/internal/db/preping"
+	"github.com/rommms07/idream-erp/internal/db/readsplit"
+	"github.com/rommms07/idream-erp/internal/db/reconnect"
+	"github.com/rommms07/idream-erp/internal/db/slug"
+	"github.com/rommms07/idream-erp/internal/db/sqlcomment"
+	"github.com/rommms07/idream-erp/internal/db/timestamps"
+	"github.com/rommms07/idream-erp/internal/db/tracing"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-var _default *gorm.DB
+const (
+	DEFAULT_PREPING_IDLE = 30 * time.Second
+)
+
+var (
+	// _default is the db handed out by the Default, the defaultMu serializes its connect.
+	_default  *gorm.DB
+	defaultMu sync.Mutex
+
+	// fatalErr is the error of a connect that can not succeed until the app is restarted (e.g. the
+	// ErrEncryptionKeyChanged), the Default returns it instead of connecting again.
+	fatalErr error
+
+	// numCPU sizes the connection pool per the `maxOpenConnsPerCPU`, the tests override it.
+	numCPU = runtime.NumCPU
+)
+
+// connPool is the part of the *sql.DB that is used to tune the connection pool.
+type connPool interface {
+	SetConnMaxLifetime(d time.Duration)
+	SetConnMaxIdleTime(d time.Duration)
+	SetMaxOpenConns(n int)
+}
+
+// Connect connects the default db, it replaces the one that is already connected.
+func Connect() error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	return connect()
+}
+
+func connect() (err error) {
+	dsn := app_config.Dsn()
+	dialector := mysql.Open(dsn)
+
+	// The pool of a failed connect is closed, it would otherwise leak since the next call of the
+	// Default opens a new one.
+	var closer io.Closer
+	defer func() {
+		if err != nil && closer != nil {
+			closer.Close()
+		}
+	}()
+
+	var pool *reconnect.Pool
+
+	switch conf := app_config.AppConfig(); {
+	case conf.DbPool.AutoReconnect:
+		if pool, err = reconnectPool(dsn); err != nil {
+			return
+		}
+
+		closer = pool
+		dialector = mysql.New(mysql.Config{DSN: dsn, Conn: pool})
+	case conf.DbPool.PrePingIdleConns || conf.DbConnTrace:
+		var sqlDB *sql.DB
+		if sqlDB, err = openDB(dsn); err != nil {
+			return
+		}
+
+		closer = sqlDB
+		dialector = mysql.New(mysql.Config{DSN: dsn, Conn: sqlDB})
+	}
+
+	db, err := gorm.Open(dialector, gorm_config.DEFAULT)
+	if err != nil {
+		return
+	}
+
+	if closer == nil {
+		if closer, err = db.DB(); err != nil {
+			return
+		}
+	}
+
+	err = ApplyPoolSettings(db)
+	if err != nil {
+		return
+	}
+
+	// An unencrypted connection must never be handed out, so the db only becomes the default once
+	// it passed the check.
+	if app_config.AppConfig().RequireTLS {
+		if err = CheckTLS(db); err != nil {
+			return
+		}
+	}
+
+	if err = CheckEncryptionKey(db); err != nil {
+		return
+	}
+
+	if app_config.AppConfig().IndexAdvisor.Enabled {
+		if err = db.Use(advisor.Default()); err != nil {
+			return
+		}
+	}
+
+	if app_config.AppConfig().DbTracing {
+		exporter := &tracing.LogExporter{Logger: logging.Logger()}
+		if err = db.Use(tracing.New(exporter, dsn)); err != nil {
+			return
+		}
+	}
+
+	if app_config.AppConfig().DbSqlComments {
+		if err = db.Use(sqlcomment.New()); err != nil {
+			return
+		}
+	}
+
+	if app_config.AppConfig().DbIndexHints {
+		if err = db.Use(indexhint.New()); err != nil {
+			return
+		}
+	}
+
+	if nplusone.Enabled() {
+		if err = db.Use(nplusone.New(nplusone.Threshold(), logging.Logger())); err != nil {
+			return
+		}
+	}
+
+	if err = db.Use(slug.New()); err != nil {
+		return
+	}
+
+	if err = db.Use(email.New()); err != nil {
+		return
+	}
+
+	if err = db.Use(timestamps.New()); err != nil {
+		return
+	}
+
+	if err = db.Use(audit.New()); err != nil {
+		return
+	}
+
+	if err = db.Use(cascade.New()); err != nil {
+		return
+	}
+
+	if pool != nil {
+		if err = db.Use(reconnect.New(pool)); err != nil {
+			return
+		}
+	}
+
+	if ms := app_config.AppConfig().DbPool.ConnAcquireTimeoutMs; ms != 0 {
+		if err = db.Use(acquire.New(time.Duration(ms) * time.Millisecond)); err != nil {
+			return
+		}
+	}
+
+	if conf := app_config.AppConfig(); conf.AutoReadSplit && len(conf.MysqlReplicas) != 0 {
+		if err = useReplicas(db, conf.MysqlReplicas); err != nil {
+			return
+		}
+	}
+
+	// The instance is only recorded once every other check passed, a failed connect is retried by the
+	// next call of the Default and would record it again.
+	if app_config.AppConfig().RecordAppInstances {
+		if err = RecordInstance(db); err != nil {
+			return
+		}
+	}
+
+	_default = db
+
+	// The version history is only informational, it is recorded once the db is handed out so that a
+	// failed connect never records it.
+	if app_config.AppConfig().RecordVersionHistory {
+		if recordErr := RecordVersionSeen(db); recordErr != nil {
+			logging.Logger().Warn("db: the version of the app could not be recorded", "error", recordErr)
+		}
+	}
 
-func Connect() (err error) {
-	_default, err = gorm.Open(mysql.Open(app_config.Dsn()), gorm_config.DEFAULT)
 	return
 }
 
+// useReplicas sends the plain reads of the db to the replicas at the addrs.
+func useReplicas(db *gorm.DB, addrs []string) error {
+	replicas := make([]gorm.ConnPool, len(addrs))
+
+	for i, addr := range addrs {
+		replica, err := sql.Open("mysql", app_config.ReplicaDsn(addr))
+		if err != nil {
+			return err
+		}
+
+		apply_pool_settings(replica)
+		replicas[i] = replica
+	}
+
+	return db.Use(readsplit.New(replicas...))
+}
+
+// reconnectPool opens the dsn with a pool that is reopened once the database is reachable again after
+// the `reconnectAfterErrors` connection errors in a row.
+func reconnectPool(dsn string) (*reconnect.Pool, error) {
+	pool, err := reconnect.NewPool(func() (*sql.DB, error) {
+		sqlDB, err := openDB(dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		apply_pool_settings(sqlDB)
+		return sqlDB, nil
+	}, logging.Logger())
+
+	if err != nil {
+		return nil, err
+	}
+
+	conf := app_config.AppConfig().DbPool
+
+	if conf.ReconnectAfterErrors != 0 {
+		pool.ErrorThreshold = int(conf.ReconnectAfterErrors)
+	}
+
+	if conf.ReconnectMaxBackoffMs != 0 {
+		pool.MaxBackoff = time.Duration(conf.ReconnectMaxBackoffMs) * time.Millisecond
+	}
+
+	return pool, nil
+}
+
+// openDB opens the dsn, when the `dbConnTrace` or the `prePingIdleConns` is set it is opened with a connector
+// wrapping the one of the driver, it traces the physical connections and pings the connections that were idle
+// for longer than the `prePingIdleSeconds` of the pool config before reusing them.
+func openDB(dsn string) (*sql.DB, error) {
+	conf := app_config.AppConfig()
+	if !conf.DbConnTrace && !conf.DbPool.PrePingIdleConns {
+		return sql.Open("mysql", dsn)
+	}
+
+	cfg, err := _mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	mysqlConnector, err := _mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	connector := mysqlConnector
+
+	// The trace wraps the connector of the driver so that it sees every physical connection, including
+	// the ones discarded by a failed pre-ping.
+	if conf.DbConnTrace {
+		connector = conntrace.NewConnector(connector, logging.Logger())
+	}
+
+	if conf.DbPool.PrePingIdleConns {
+		threshold := DEFAULT_PREPING_IDLE
+		if seconds := conf.DbPool.PrePingIdleSeconds; seconds != 0 {
+			threshold = time.Duration(seconds) * time.Second
+		}
+
+		connector = preping.NewConnector(connector, threshold)
+	}
+
+	return sql.OpenDB(connector), nil
+}
+
+// ApplyPoolSettings applies the `dbPool` section of the app config to the connection pool of the db.
+func ApplyPoolSettings(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	apply_pool_settings(sqlDB)
+	return nil
+}
+
+func apply_pool_settings(pool connPool) {
+	conf := app_config.AppConfig().DbPool
+
+	if conf.ConnMaxLifetimeSeconds != 0 {
+		pool.SetConnMaxLifetime(time.Duration(conf.ConnMaxLifetimeSeconds) * time.Second)
+	}
+
+	if conf.ConnMaxIdleTimeSeconds != 0 {
+		pool.SetConnMaxIdleTime(time.Duration(conf.ConnMaxIdleTimeSeconds) * time.Second)
+	}
+
+	if n := maxOpenConns(); n != 0 {
+		pool.SetMaxOpenConns(n)
+	}
+}
+
+// maxOpenConns returns the cap of the open connections per the `dbPool` of the app config, an explicit
+// `maxOpenConns` takes precedence over the one derived from the CPUs.
+func maxOpenConns() int {
+	conf := app_config.AppConfig().DbPool
+
+	if conf.MaxOpenConns != 0 {
+		return int(conf.MaxOpenConns)
+	}
+
+	n := uint64(numCPU()) * conf.MaxOpenConnsPerCPU
+	if conf.MaxOpenConnsCeiling != 0 && n > conf.MaxOpenConnsCeiling {
+		n = conf.MaxOpenConnsCeiling
+	}
+
+	return int(n)
+}
+
+// Default returns the default db, it is connected on the first call. The calls are serialized so that
+// the concurrent callers (e.g. the health probes) never connect more than once at a time.
 func Default() (def *gorm.DB, err error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if fatalErr != nil {
+		return nil, fatalErr
+	}
+
 	if _default == nil {
-		err = Connect()
+		err = connect()
+	}
+
+	if errors.Is(err, ErrEncryptionKeyChanged) {
+		fatalErr = err
 	}
 
 	def = _default
 	return
 }
+
+// Stats returns the stats of the connection pool of the default db, the second return value is false
+// when the default db is not connected yet.
+func Stats() (sql.DBStats, bool) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if _default == nil {
+		return sql.DBStats{}, false
+	}
+
+	sqlDB, err := _default.DB()
+	if err != nil {
+		return sql.DBStats{}, false
+	}
+
+	return sqlDB.Stats(), true
+}
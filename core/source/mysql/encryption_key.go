@@ -0,0 +1,72 @@
+package mysql
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rommms07/idream-erp/config/app_config"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"gorm.io/gorm"
+)
+
+// ErrEncryptionKeyChanged is returned by the CheckEncryptionKey when the DataEncryptionKey is not the
+// key the encrypted columns of the database were written with.
+var ErrEncryptionKeyChanged = errors.New("error: the data encryption key differs from the key of the database")
+
+// EncryptionKeyFingerprint is the single row of the `encryption_key_fingerprints` table, it holds the
+// fingerprint of the DataEncryptionKey the encrypted columns were written with.
+type EncryptionKeyFingerprint struct {
+	Id          uint8  `gorm:"primaryKey"`
+	Fingerprint string `gorm:"size:64"`
+	UpdatedAt   time.Time
+}
+
+func (*EncryptionKeyFingerprint) TableName() string {
+	return "encryption_key_fingerprints"
+}
+
+// KeyFingerprint returns the fingerprint of the key, it is a keyed hash so the key cannot be told from it.
+func KeyFingerprint(key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("idream-erp data encryption key fingerprint"))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckEncryptionKey compares the fingerprint of the DataEncryptionKey with the one stored in the
+// database, the fingerprint is stored on the first use of a key. A different key fails with the
+// ErrEncryptionKeyChanged unless the AllowKeyRotation is set, the new fingerprint is then stored.
+func CheckEncryptionKey(db *gorm.DB) error {
+	conf := app_config.AppConfig()
+	if len(conf.DataEncryptionKey) == 0 {
+		return nil
+	}
+
+	if !db.Migrator().HasTable(&EncryptionKeyFingerprint{}) {
+		if err := db.Migrator().CreateTable(&EncryptionKeyFingerprint{}); err != nil {
+			return err
+		}
+	}
+
+	current := &EncryptionKeyFingerprint{Id: 1, Fingerprint: KeyFingerprint(conf.DataEncryptionKey), UpdatedAt: now()}
+	stored := &EncryptionKeyFingerprint{}
+
+	err := db.Take(stored, 1).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return db.Create(current).Error
+	case err != nil:
+		return err
+	case hmac.Equal([]byte(stored.Fingerprint), []byte(current.Fingerprint)):
+		return nil
+	case !conf.AllowKeyRotation:
+		return fmt.Errorf("%w, the encrypted columns cannot be decrypted with it (set the allowKeyRotation to rotate the key)", ErrEncryptionKeyChanged)
+	}
+
+	logging.Logger().Warn("the data encryption key was rotated, the encrypted columns must be re-encrypted with it")
+	return db.Save(current).Error
+}
@@ -0,0 +1,60 @@
+package mysql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setEncryptionKey(t *testing.T, key string, rotate bool) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DataEncryptionKey, conf.AllowKeyRotation = key, rotate
+	})
+}
+
+func newFingerprintDb(t *testing.T, stored string) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectVersionsSeenTable(mock)
+	mock.ExpectQuery("SELECT \\* FROM `encryption_key_fingerprints` WHERE `encryption_key_fingerprints`.`id` = \\? LIMIT 1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "fingerprint"}).AddRow(1, stored))
+
+	t.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+	return db, mock
+}
+
+func Test_aMatchingEncryptionKeyShouldProceed(t *testing.T) {
+	setEncryptionKey(t, "k3y", false)
+
+	db, _ := newFingerprintDb(t, mysql.KeyFingerprint("k3y"))
+	assert.Nil(t, mysql.CheckEncryptionKey(db))
+}
+
+func Test_aChangedEncryptionKeyShouldAbort(t *testing.T) {
+	setEncryptionKey(t, "n3w-k3y", false)
+
+	db, _ := newFingerprintDb(t, mysql.KeyFingerprint("k3y"))
+	assert.ErrorIs(t, mysql.CheckEncryptionKey(db), mysql.ErrEncryptionKeyChanged)
+}
+
+func Test_aRotatedEncryptionKeyShouldBeStored(t *testing.T) {
+	t0 := time.Now()
+	defer mysql.SetNow(func() time.Time { return t0 })()
+
+	setEncryptionKey(t, "n3w-k3y", true)
+
+	db, mock := newFingerprintDb(t, mysql.KeyFingerprint("k3y"))
+	mock.ExpectExec("UPDATE `encryption_key_fingerprints` SET `fingerprint`=\\?,`updated_at`=\\? WHERE `id` = \\?").
+		WithArgs(mysql.KeyFingerprint("n3w-k3y"), sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, mysql.CheckEncryptionKey(db))
+}
@@ -0,0 +1,25 @@
+package mysql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	_mysql "github.com/go-sql-driver/mysql"
+)
+
+// IsConnectionError reports whether the err was caused by the database being unreachable, as opposed
+// to an error of the query itself (e.g. a duplicate key).
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, _mysql.ErrInvalidConn) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
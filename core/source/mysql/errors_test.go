@@ -0,0 +1,20 @@
+package mysql_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldTellTheConnectionErrorsApart(t *testing.T) {
+	assert.True(t, mysql.IsConnectionError(fmt.Errorf("query: %w", driver.ErrBadConn)))
+	assert.True(t, mysql.IsConnectionError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}))
+
+	assert.False(t, mysql.IsConnectionError(nil))
+	assert.False(t, mysql.IsConnectionError(errors.New("Error 1062: Duplicate entry")))
+}
@@ -0,0 +1,19 @@
+package mysql
+
+import "time"
+
+var ApplyPoolSettingsTo = func(pool connPool) { apply_pool_settings(pool) }
+
+// SetNow overrides the clock used to stamp the connect of the instances, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
+
+// SetNumCPU overrides the CPU count the connection pool is sized per, the returned func restores it.
+func SetNumCPU(fn func() int) func() {
+	bak := numCPU
+	numCPU = fn
+	return func() { numCPU = bak }
+}
@@ -0,0 +1,50 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+
+	_mysql "github.com/go-sql-driver/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+// dialFunc is the signature of the net.Dialer.DialContext, it is swapped in the tests.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func init() {
+	_mysql.RegisterDialContext(loader.MYSQL_FAILOVER_NET, FailoverDialer((&net.Dialer{}).DialContext))
+}
+
+// FailoverDialer returns a dialer of the driver for the comma separated hosts of the DSN (see the
+// `MysqlHosts` of the app config). It starts from the host that was last reachable and cycles through
+// the rest of the hosts whenever a host fails, the error of every host is returned when all of them fail.
+func FailoverDialer(dial dialFunc) _mysql.DialContextFunc {
+	var current atomic.Int64
+
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		hosts := strings.Split(addr, ",")
+		start := int(current.Load())
+		errs := []error{}
+
+		for i := range hosts {
+			n := (start + i) % len(hosts)
+
+			conn, err := dial(ctx, "tcp", strings.TrimSpace(hosts[n]))
+			if err == nil {
+				current.Store(int64(n))
+				return conn, nil
+			}
+
+			errs = append(errs, err)
+
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		return nil, errors.Join(errs...)
+	}
+}
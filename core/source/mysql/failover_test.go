@@ -0,0 +1,72 @@
+package mysql_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldBuildAMultiHostDsn(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.MysqlType = "tcp"
+		conf.MysqlHosts = []string{"db-1:3306", "db-2:3306", "db-3:3306"}
+	})
+
+	assert.Contains(t, loader.Dsn(), "@failover(db-1:3306,db-2:3306,db-3:3306)/")
+}
+
+func Test_shouldFailOverToTheNextHost(t *testing.T) {
+	dialed := []string{}
+	down := map[string]bool{"db-1:3306": true}
+
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+
+		if down[addr] {
+			return nil, errors.New("connection refused")
+		}
+
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+
+	dialer := mysql.FailoverDialer(dial)
+
+	conn, err := dialer(context.Background(), "db-1:3306,db-2:3306")
+	assert.Nil(t, err)
+	assert.NotNil(t, conn)
+	assert.Equal(t, []string{"db-1:3306", "db-2:3306"}, dialed)
+
+	// The next connection starts from the host that was last reachable.
+	dialed = nil
+	_, err = dialer(context.Background(), "db-1:3306,db-2:3306")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"db-2:3306"}, dialed)
+
+	// Once it goes down, the dialer cycles back to the first host.
+	dialed = nil
+	down = map[string]bool{"db-2:3306": true}
+	_, err = dialer(context.Background(), "db-1:3306,db-2:3306")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"db-2:3306", "db-1:3306"}, dialed)
+}
+
+func Test_shouldReportEveryHostWhenAllAreDown(t *testing.T) {
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New(addr + " is unreachable")
+	}
+
+	_, err := mysql.FailoverDialer(dial)(context.Background(), "db-1:3306,db-2:3306")
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "db-1:3306 is unreachable")
+		assert.Contains(t, err.Error(), "db-2:3306 is unreachable")
+	}
+}
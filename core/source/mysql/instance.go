@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rommms07/idream-erp/config/app_config"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"gorm.io/gorm"
+)
+
+const (
+	OLDER_VERSION_WARN  = "warn"
+	OLDER_VERSION_ERROR = "error"
+)
+
+var (
+	ErrOlderVersion = errors.New("error: an older version of the app connected after a newer one ran")
+
+	// now is used to stamp the connect of the instances, the tests override it.
+	now = time.Now
+)
+
+// AppInstance is a row of the `app_instances` table, every instance of the app that connects to the
+// database is recorded with its version.
+type AppInstance struct {
+	Id          uint64 `gorm:"primaryKey"`
+	Version     string `gorm:"size:64"`
+	Major       uint64 `gorm:"index:idx_app_instances_version,priority:1"`
+	Minor       uint64 `gorm:"index:idx_app_instances_version,priority:2"`
+	Build       uint64 `gorm:"index:idx_app_instances_version,priority:3"`
+	Hostname    string `gorm:"size:255"`
+	ConnectedAt time.Time
+}
+
+// version returns the version of the instance, its Release is the suffix of the Version.
+func (ai *AppInstance) version() *loader.AppVersion {
+	_, release, _ := strings.Cut(ai.Version, "-")
+	return &loader.AppVersion{Major: ai.Major, Minor: ai.Minor, Build: ai.Build, Release: release}
+}
+
+// olderThan reports whether the version of the instance is older than the one of the other.
+func (ai *AppInstance) olderThan(other *AppInstance) bool {
+	return ai.version().Compare(other.version()) < 0
+}
+
+// RecordInstance records the version of the app to the `app_instances` table, the table is created on
+// the first connect. When the version is older than the latest one recorded (e.g. a rolled back deploy
+// connecting after the newer one migrated the schema) it is logged as a warning, or it fails with an
+// ErrOlderVersion when the `olderVersionPolicy` of the app config is `error`.
+func RecordInstance(db *gorm.DB) error {
+	conf := app_config.AppConfig()
+
+	if !db.Migrator().HasTable(&AppInstance{}) {
+		if err := db.Migrator().CreateTable(&AppInstance{}); err != nil {
+			return err
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	instance := &AppInstance{
+		Version:     conf.VersionInfo.String(),
+		Hostname:    hostname,
+		ConnectedAt: now(),
+	}
+
+	if v := conf.VersionInfo; v != nil {
+		instance.Major, instance.Minor, instance.Build = v.Major, v.Minor, v.Build
+	}
+
+	latest := &AppInstance{}
+	res := db.Order("major DESC, minor DESC, build DESC").Limit(1).Find(latest)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected != 0 && instance.olderThan(latest) {
+		if conf.OlderVersionPolicy == OLDER_VERSION_ERROR {
+			return fmt.Errorf("%w (%s, the latest is %s)", ErrOlderVersion, instance.Version, latest.Version)
+		}
+
+		logging.Logger().Warn("db: an older version of the app connected after a newer one ran",
+			"version", instance.Version, "latest", latest.Version, "latest_hostname", latest.Hostname)
+	}
+
+	return db.Create(instance).Error
+}
@@ -0,0 +1,87 @@
+package mysql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+var instanceColumns = []string{"id", "version", "major", "minor", "build", "hostname", "connected_at"}
+
+func expectLatestInstance(mock sqlmock.Sqlmock, major uint64) {
+	mock.ExpectQuery("SELECT DATABASE\\(\\)").WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("erp"))
+	mock.ExpectQuery("SELECT SCHEMA_NAME from Information_schema.SCHEMATA").
+		WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME"}).AddRow("erp"))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `app_instances` ORDER BY major DESC, minor DESC, build DESC LIMIT 1").
+		WillReturnRows(sqlmock.NewRows(instanceColumns).AddRow(1, "latest", major, 0, 0, "web-1", time.Now()))
+}
+
+func Test_shouldRecordTheVersionOfTheInstance(t *testing.T) {
+	t0 := time.Now()
+	defer mysql.SetNow(func() time.Time { return t0 })()
+
+	conf := loader.AppConfig()
+	v := conf.VersionInfo
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectLatestInstance(mock, v.Major)
+	mock.ExpectExec("INSERT INTO `app_instances`").
+		WithArgs(v.String(), v.Major, v.Minor, v.Build, sqlmock.AnyArg(), t0).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	assert.Nil(t, mysql.RecordInstance(db))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_anOlderVersionShouldWarnOrFailPerThePolicy(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	// A newer major version already ran, the warning lets the older one still connect.
+	conf := mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.OlderVersionPolicy = mysql.OLDER_VERSION_WARN
+	})
+
+	expectLatestInstance(mock, conf.VersionInfo.Major+1)
+	mock.ExpectExec("INSERT INTO `app_instances`").WillReturnResult(sqlmock.NewResult(2, 1))
+
+	assert.Nil(t, mysql.RecordInstance(db))
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.OlderVersionPolicy = mysql.OLDER_VERSION_ERROR
+	})
+
+	expectLatestInstance(mock, conf.VersionInfo.Major+1)
+	assert.ErrorIs(t, mysql.RecordInstance(db), mysql.ErrOlderVersion)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_anEarlierReleaseOfTheSameBuildShouldBeOlder(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.VersionInfo = &loader.AppVersion{Major: 1, Minor: 2, Build: 3, Release: "beta"}
+		conf.OlderVersionPolicy = mysql.OLDER_VERSION_ERROR
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT DATABASE\\(\\)").WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("erp"))
+	mock.ExpectQuery("SELECT SCHEMA_NAME from Information_schema.SCHEMATA").
+		WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME"}).AddRow("erp"))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `app_instances` ORDER BY major DESC, minor DESC, build DESC LIMIT 1").
+		WillReturnRows(sqlmock.NewRows(instanceColumns).AddRow(1, "1.2.3-build", 1, 2, 3, "web-1", time.Now()))
+
+	assert.ErrorIs(t, mysql.RecordInstance(db), mysql.ErrOlderVersion, "The beta is older than the final build.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,78 @@
+package mysql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePool records the settings applied to it, a nil duration means it was never set.
+type fakePool struct {
+	lifetime, idleTime *time.Duration
+	maxOpen            *int
+}
+
+func (p *fakePool) SetConnMaxLifetime(d time.Duration) { p.lifetime = &d }
+func (p *fakePool) SetConnMaxIdleTime(d time.Duration) { p.idleTime = &d }
+func (p *fakePool) SetMaxOpenConns(n int)              { p.maxOpen = &n }
+
+func Test_shouldApplyTheConnLifetimeAndIdleTime(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DbPool.ConnMaxLifetimeSeconds = 50
+		conf.DbPool.ConnMaxIdleTimeSeconds = 30
+	})
+
+	pool := &fakePool{}
+	mysql.ApplyPoolSettingsTo(pool)
+
+	if assert.NotNil(t, pool.lifetime) && assert.NotNil(t, pool.idleTime) {
+		assert.Equal(t, 50*time.Second, *pool.lifetime)
+		assert.Equal(t, 30*time.Second, *pool.idleTime)
+	}
+}
+
+func Test_zeroPoolSettingsShouldLeaveTheDriverDefaults(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DbPool.ConnMaxLifetimeSeconds = 0
+		conf.DbPool.ConnMaxIdleTimeSeconds = 0
+		conf.DbPool.MaxOpenConns, conf.DbPool.MaxOpenConnsPerCPU = 0, 0
+	})
+
+	pool := &fakePool{}
+	mysql.ApplyPoolSettingsTo(pool)
+
+	assert.Nil(t, pool.lifetime, "A zero lifetime must not be applied.")
+	assert.Nil(t, pool.idleTime, "A zero idle time must not be applied.")
+	assert.Nil(t, pool.maxOpen, "The pool must be left unbounded.")
+}
+
+func Test_shouldSizeTheMaxOpenConnsPerTheCPUs(t *testing.T) {
+	defer mysql.SetNumCPU(func() int { return 8 })()
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DbPool.MaxOpenConns, conf.DbPool.MaxOpenConnsPerCPU, conf.DbPool.MaxOpenConnsCeiling = 0, 4, 100
+	})
+
+	pool := &fakePool{}
+	mysql.ApplyPoolSettingsTo(pool)
+
+	if assert.NotNil(t, pool.maxOpen) {
+		assert.Equal(t, 32, *pool.maxOpen)
+	}
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DbPool.MaxOpenConnsCeiling = 20
+	})
+	mysql.ApplyPoolSettingsTo(pool)
+	assert.Equal(t, 20, *pool.maxOpen, "The computed value must be clamped to the ceiling.")
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DbPool.MaxOpenConns = 50
+	})
+	mysql.ApplyPoolSettingsTo(pool)
+	assert.Equal(t, 50, *pool.maxOpen, "An explicit maxOpenConns must take precedence.")
+}
@@ -0,0 +1,31 @@
+package mysql
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrConnectionNotEncrypted = errors.New("error: the connection to the database is not encrypted (set the tls parameter of the MYSQL_FLAGS)")
+
+// sslStatus is a row of the `SHOW STATUS`.
+type sslStatus struct {
+	Variable_name string
+	Value         string
+}
+
+// CheckTLS returns an ErrConnectionNotEncrypted when the connection to the database does not use a
+// cipher, the `Ssl_cipher` status of the session is empty for an unencrypted connection.
+func CheckTLS(db *gorm.DB) error {
+	status := &sslStatus{}
+
+	if err := db.Raw("SHOW STATUS LIKE 'Ssl_cipher'").Scan(status).Error; err != nil {
+		return err
+	}
+
+	if len(status.Value) == 0 {
+		return ErrConnectionNotEncrypted
+	}
+
+	return nil
+}
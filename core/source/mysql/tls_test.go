@@ -0,0 +1,35 @@
+package mysql_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectSslCipher(mock sqlmock.Sqlmock, cipher string) {
+	mock.ExpectQuery("SHOW STATUS LIKE 'Ssl_cipher'").
+		WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).AddRow("Ssl_cipher", cipher))
+}
+
+func Test_shouldRejectAnUnencryptedConnection(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectSslCipher(mock, "")
+
+	assert.ErrorIs(t, mysql.CheckTLS(db), mysql.ErrConnectionNotEncrypted)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldAcceptAnEncryptedConnection(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectSslCipher(mock, "TLS_AES_256_GCM_SHA384")
+
+	assert.Nil(t, mysql.CheckTLS(db))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
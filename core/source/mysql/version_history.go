@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rommms07/idream-erp/config/app_config"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AppVersionSeen is a row of the `app_versions_seen` table, every distinct version of the app that
+// connected to the database is recorded once along with when it was first and last seen.
+type AppVersionSeen struct {
+	Version     string    `gorm:"primaryKey;size:64" json:"version"`
+	Major       uint64    `json:"major"`
+	Minor       uint64    `json:"minor"`
+	Build       uint64    `json:"build"`
+	Release     string    `gorm:"size:32" json:"release"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+func (*AppVersionSeen) TableName() string {
+	return "app_versions_seen"
+}
+
+func (vs *AppVersionSeen) AppVersion() *loader.AppVersion {
+	return &loader.AppVersion{Major: vs.Major, Minor: vs.Minor, Build: vs.Build, Release: vs.Release}
+}
+
+// RecordVersionSeen records the version of the app to the `app_versions_seen` table, the table is
+// created on the first connect. A version that was already seen only has its LastSeenAt updated.
+func RecordVersionSeen(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&AppVersionSeen{}) {
+		if err := db.Migrator().CreateTable(&AppVersionSeen{}); err != nil {
+			return err
+		}
+	}
+
+	t := now()
+	seen := &AppVersionSeen{FirstSeenAt: t, LastSeenAt: t}
+
+	if v := app_config.AppConfig().VersionInfo; v != nil {
+		seen.Version = v.String()
+		seen.Major, seen.Minor, seen.Build, seen.Release = v.Major, v.Minor, v.Build, v.Release
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "version"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).Create(seen).Error
+}
+
+// VersionHistory returns the versions of the app that connected to the database, from the oldest to the
+// newest as ordered by the Compare of the loader.AppVersion.
+func VersionHistory(db *gorm.DB) ([]*AppVersionSeen, error) {
+	history := []*AppVersionSeen{}
+	if err := db.Find(&history).Error; err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].AppVersion().Compare(history[j].AppVersion()) < 0
+	})
+
+	return history, nil
+}
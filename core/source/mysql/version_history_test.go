@@ -0,0 +1,60 @@
+package mysql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/source/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectVersionsSeenTable(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT DATABASE\\(\\)").WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("erp"))
+	mock.ExpectQuery("SELECT SCHEMA_NAME from Information_schema.SCHEMATA").
+		WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME"}).AddRow("erp"))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+}
+
+func Test_shouldRecordEveryVersionThatConnected(t *testing.T) {
+	t0 := time.Now()
+	defer mysql.SetNow(func() time.Time { return t0 })()
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	for _, v := range []*loader.AppVersion{{Major: 1, Minor: 10, Build: 0, Release: "build"}, {Major: 1, Minor: 9, Build: 3, Release: "build"}} {
+		mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+			conf.VersionInfo = v
+		})
+
+		expectVersionsSeenTable(mock)
+		mock.ExpectExec("INSERT INTO `app_versions_seen` .* ON DUPLICATE KEY UPDATE `last_seen_at`=VALUES\\(`last_seen_at`\\)").
+			WithArgs(v.String(), v.Major, v.Minor, v.Build, v.Release, t0, t0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.Nil(t, mysql.RecordVersionSeen(db))
+	}
+
+	columns := []string{"version", "major", "minor", "build", "release", "first_seen_at", "last_seen_at"}
+	mock.ExpectQuery("SELECT \\* FROM `app_versions_seen`").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("1.10.0-build", 1, 10, 0, "build", t0, t0).
+			AddRow("1.10.0-beta", 1, 10, 0, "beta", t0, t0).
+			AddRow("1.9.3-build", 1, 9, 3, "build", t0, t0))
+
+	history, err := mysql.VersionHistory(db)
+	assert.Nil(t, err)
+
+	versions := []string{}
+	for _, seen := range history {
+		versions = append(versions, seen.Version)
+	}
+
+	assert.Equal(t, []string{"1.9.3-build", "1.10.0-beta", "1.10.0-build"}, versions,
+		"The versions should be ordered by their numbers rather than lexically.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
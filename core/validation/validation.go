@@ -0,0 +1,289 @@
+// This package validates the models against their `validate` tags, the error messages are translated
+// to the locale of the request. The locales are taken from the `validationLocales` of the app config.
+
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/id"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/pt_BR"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	DEFAULT_LOCALE = "en"
+
+	// NON_FIELD_KEY is the key of the errors that are not about a single field (e.g. a struct-level
+	// error) in the FieldErrors.
+	NON_FIELD_KEY = "_"
+)
+
+type localeKey struct{}
+
+type translation struct {
+	locale   func() locales.Translator
+	register func(v *validator.Validate, trans ut.Translator) error
+}
+
+var (
+	// translations are the locales that can be listed in the `validationLocales`.
+	translations = map[string]translation{
+		"en":    {en.New, en_translations.RegisterDefaultTranslations},
+		"es":    {es.New, es_translations.RegisterDefaultTranslations},
+		"fr":    {fr.New, fr_translations.RegisterDefaultTranslations},
+		"id":    {id.New, id_translations.RegisterDefaultTranslations},
+		"ja":    {ja.New, ja_translations.RegisterDefaultTranslations},
+		"pt_BR": {pt_BR.New, pt_BR_translations.RegisterDefaultTranslations},
+		"zh":    {zh.New, zh_translations.RegisterDefaultTranslations},
+	}
+
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+	initErr  error
+	once     sync.Once
+)
+
+// ValidationError holds the translated message of every invalid field, keyed by the namespace of the
+// field (e.g. `User.Email`). The Fields hold the same messages keyed by the JSON path of the field
+// (e.g. `email`) as they are rendered to the clients.
+type ValidationError struct {
+	Messages map[string]string
+	Fields   map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Messages))
+	for field := range e.Messages {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = e.Messages[field]
+	}
+
+	return "error: " + strings.Join(messages, "; ")
+}
+
+// FieldError is the error of a single field that is not reported by the validator (e.g. an email that
+// is already taken), the Field is the JSON path of the field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("error: %s %s", e.Field, e.Message)
+}
+
+// FieldErrors flattens the err into the messages keyed by the JSON path of their field, the err can be
+// a ValidationError, a FieldError or the errors.Join of them. Any other error is keyed by the
+// NON_FIELD_KEY, the messages of a key are joined when there are more than one.
+func FieldErrors(err error) map[string]string {
+	fields := make(map[string]string)
+	collectFieldErrors(err, fields)
+	return fields
+}
+
+func collectFieldErrors(err error, fields map[string]string) {
+	add := func(key, message string) {
+		if existing, exists := fields[key]; exists {
+			message = existing + "; " + message
+		}
+
+		fields[key] = message
+	}
+
+	var verr *ValidationError
+	var ferr *FieldError
+
+	switch joined, isJoined := err.(interface{ Unwrap() []error }); {
+	case err == nil:
+	case isJoined:
+		for _, err := range joined.Unwrap() {
+			collectFieldErrors(err, fields)
+		}
+	case errors.As(err, &verr):
+		keys := make([]string, 0, len(verr.Fields))
+		for key := range verr.Fields {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			add(key, verr.Fields[key])
+		}
+	case errors.As(err, &ferr):
+		add(ferr.Field, ferr.Message)
+	default:
+		add(NON_FIELD_KEY, err.Error())
+	}
+}
+
+// jsonPath returns the JSON path of the field at the struct namespace (e.g. `User.Addresses[0].City`) of
+// the typ, the NON_FIELD_KEY is returned when the namespace is not a field of the typ (i.e. it was
+// reported by a struct-level validation).
+func jsonPath(typ reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")
+	path := make([]string, 0, len(segments))
+
+	for _, segment := range segments[1:] {
+		name, index, _ := strings.Cut(segment, "[")
+		if len(index) != 0 {
+			index = "[" + index
+		}
+
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+
+		if typ.Kind() != reflect.Struct {
+			return NON_FIELD_KEY
+		}
+
+		field, exists := typ.FieldByName(name)
+		if !exists {
+			return NON_FIELD_KEY
+		}
+
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); len(tag) != 0 && tag != "-" {
+			name = tag
+		}
+
+		path = append(path, name+index)
+		typ = field.Type
+	}
+
+	if len(path) == 0 {
+		return NON_FIELD_KEY
+	}
+
+	return strings.Join(path, ".")
+}
+
+// WithLocale returns a copy of the ctx carrying the locale of the request.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale returns the locale carried by the ctx, or the configured `defaultLocale`.
+func Locale(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok && len(locale) != 0 {
+		return locale
+	}
+
+	return defaultLocale()
+}
+
+func defaultLocale() string {
+	if locale := loader.AppConfig().DefaultLocale; len(locale) != 0 {
+		return locale
+	}
+
+	return DEFAULT_LOCALE
+}
+
+// setup creates the validator and registers the translations of the configured locales.
+func setup() {
+	conf := loader.AppConfig()
+	validate = validator.New()
+
+	def, exists := translations[defaultLocale()]
+	if !exists {
+		initErr = fmt.Errorf("error: unsupported default locale %s", defaultLocale())
+		return
+	}
+
+	uni = ut.New(def.locale())
+
+	registered := make(map[string]bool)
+
+	for _, name := range append([]string{defaultLocale()}, conf.ValidationLocales...) {
+		t, exists := translations[name]
+		if !exists {
+			initErr = fmt.Errorf("error: unsupported validation locale %s", name)
+			return
+		}
+
+		if registered[name] {
+			continue
+		}
+
+		registered[name] = true
+
+		if err := uni.AddTranslator(t.locale(), true); err != nil {
+			initErr = err
+			return
+		}
+
+		trans, _ := uni.GetTranslator(name)
+		if err := t.register(validate, trans); err != nil {
+			initErr = err
+			return
+		}
+	}
+}
+
+// translator returns the translator of the locale, a regional locale (e.g. `es-MX`) falls back to its
+// language before falling back to the default locale.
+func translator(locale string) ut.Translator {
+	locale = strings.ReplaceAll(locale, "-", "_")
+	lang, _, _ := strings.Cut(locale, "_")
+
+	trans, _ := uni.FindTranslator(locale, lang, defaultLocale())
+	return trans
+}
+
+// ValidateModel validates the model against its `validate` tags, the returned ValidationError holds
+// the messages in the locale of the ctx.
+func ValidateModel(ctx context.Context, model any) error {
+	once.Do(setup)
+
+	if initErr != nil {
+		return initErr
+	}
+
+	err := validate.StructCtx(ctx, model)
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	trans := translator(Locale(ctx))
+	verr := &ValidationError{Messages: make(map[string]string, len(errs)), Fields: make(map[string]string, len(errs))}
+
+	for _, fieldErr := range errs {
+		message := fieldErr.Translate(trans)
+
+		verr.Messages[fieldErr.Namespace()] = message
+		verr.Fields[jsonPath(reflect.TypeOf(model), fieldErr.StructNamespace())] = message
+	}
+
+	return verr
+}
@@ -0,0 +1,49 @@
+package validation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rommms07/idream-erp/core/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type customer struct {
+	Name  string `validate:"required"`
+	Email string `validate:"required,email"`
+}
+
+func messageOf(t *testing.T, err error, field string) string {
+	var verr *validation.ValidationError
+
+	if !assert.True(t, errors.As(err, &verr), "The model should have failed the validation.") {
+		return ""
+	}
+
+	return verr.Messages[field]
+}
+
+func Test_shouldTranslateTheMessagesToTheLocaleOfTheContext(t *testing.T) {
+	model := &customer{Email: "juan@example.com"}
+
+	en := messageOf(t, validation.ValidateModel(validation.WithLocale(context.Background(), "en"), model), "customer.Name")
+	es := messageOf(t, validation.ValidateModel(validation.WithLocale(context.Background(), "es-MX"), model), "customer.Name")
+
+	assert.Equal(t, "Name is a required field", en)
+	assert.Equal(t, "Name es un campo requerido", es, "A regional locale should fallback to its language.")
+}
+
+func Test_shouldFallbackToTheDefaultLocale(t *testing.T) {
+	model := &customer{Name: "Juan", Email: "juan"}
+
+	msg := messageOf(t, validation.ValidateModel(validation.WithLocale(context.Background(), "de"), model), "customer.Email")
+	assert.Equal(t, "Email must be a valid email address", msg)
+
+	msg = messageOf(t, validation.ValidateModel(context.Background(), model), "customer.Email")
+	assert.Equal(t, "Email must be a valid email address", msg)
+}
+
+func Test_validModelShouldPass(t *testing.T) {
+	assert.Nil(t, validation.ValidateModel(context.Background(), &customer{Name: "Juan", Email: "juan@example.com"}))
+}
@@ -1,12 +1,27 @@
 package loader
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	// The timezone database is embedded so that the `timezone` can be loaded on hosts lacking it.
+	_ "time/tzdata"
+
+	"github.com/go-sql-driver/mysql"
 	"github.com/rommms07/idream-erp/config"
 	"gorm.io/gorm"
 )
@@ -19,10 +34,242 @@ type mysqlConfig struct {
 	DisableDateTimePrecision, DontSupportRenameIndex, DontSupportRenameColumn, SkipInitVersion bool
 }
 
-// appVersion struct is the schema for the parsed version defined in the app_config.json if the version
+// dbPoolConfig is used to tune the connection pool of the database, a zero value for any of the fields
+// leaves the default of the driver in place.
+type dbPoolConfig struct {
+	// ConnMaxLifetimeSeconds must be kept below the idle timeout of any proxy sitting between the app
+	// and the database, otherwise the first query after an idle period fails on a stale connection.
+	ConnMaxLifetimeSeconds uint64
+	ConnMaxIdleTimeSeconds uint64
+
+	// MaxOpenConns caps the open connections of the pool, when it is zero the cap is derived from the
+	// CPUs of the host as the MaxOpenConnsPerCPU times the runtime.NumCPU, clamped to the
+	// MaxOpenConnsCeiling (when set). The pool is left unbounded when both are zero.
+	MaxOpenConns        uint64
+	MaxOpenConnsPerCPU  uint64
+	MaxOpenConnsCeiling uint64
+
+	// PrePingIdleConns pings the connections that were idle for longer than the PrePingIdleSeconds
+	// before they are reused, the connections failing the ping are replaced by fresh ones.
+	PrePingIdleConns   bool
+	PrePingIdleSeconds uint64
+
+	// ConnAcquireTimeoutMs is how long a statement waits for a connection of the exhausted pool before it
+	// fails with the ErrPoolExhausted of the internal/db/acquire package, a zero value waits forever.
+	ConnAcquireTimeoutMs uint64
+
+	// AutoReconnect reopens the connection pool once the database is reachable again after the
+	// ReconnectAfterErrors connection errors in a row, the reopen is retried with a backoff doubling up
+	// to the ReconnectMaxBackoffMs.
+	AutoReconnect         bool
+	ReconnectAfterErrors  uint64
+	ReconnectMaxBackoffMs uint64
+}
+
+// securityHeadersConfig contains the values of the security headers set on every response, an empty
+// value falls back to the default of the SecurityHeadersMiddleware. Strict-Transport-Security is only
+// set when it is configured and any header listed in Disabled is never set.
+type securityHeadersConfig struct {
+	ContentSecurityPolicy   string
+	ContentTypeOptions      string
+	FrameOptions            string
+	ReferrerPolicy          string
+	StrictTransportSecurity string
+
+	Disabled []string
+}
+
+// loggingConfig is used by the helpers/logging package to create the app logger.
+type loggingConfig struct {
+	// Level is the minimum level (debug, info, warn or error) of the records that are logged.
+	Level string
+
+	// Only 1 in SampleRate records below the SampleLevel are logged, the records at or above the
+	// SampleLevel are always logged. A SampleRate of 0 or 1 disables the sampling.
+	SampleRate  uint64
+	SampleLevel string
+
+	// PIIKeys are the attribute keys whose values are masked before being logged (e.g. email).
+	PIIKeys []string
+
+	// LogRequestBody logs the body of the requests at the debug level, the values of the
+	// RedactedBodyFields are masked and the bodies longer than the MaxLoggedBodyBytes are truncated.
+	// A field is a dotted JSON path (e.g. `card.number`), a single key (e.g. `password`) is masked at
+	// any depth of the body.
+	LogRequestBody     bool
+	RedactedBodyFields []string
+	MaxLoggedBodyBytes int
+}
+
+// migrationLockConfig guards the migration with an advisory lock of the database so that only a single
+// instance migrates the models when several instances are started at the same time.
+type migrationLockConfig struct {
+	Enabled bool
+	Name    string
+
+	// WaitTimeoutSeconds is how long an instance waits for the lock, once it expires the instance
+	// skips the migration.
+	WaitTimeoutSeconds uint64
+}
+
+// httpClientConfig tunes the pooled transport shared by the outbound http calls (e.g. the Graph API), a
+// zero value for any of the fields leaves the default of the net/http package in place.
+type httpClientConfig struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleTimeoutMs       uint64
+
+	// TimeoutMs is the timeout of a whole call, the clients with a timeout of their own (e.g. the
+	// `fbTimeoutMs`) still share the transport.
+	TimeoutMs uint64
+}
+
+// RetryPolicy is how an operation is retried, the MaxAttempts include the first attempt and the wait
+// between the attempts starts at the InitialBackoffMs and is multiplied by the Multiplier (2 when unset)
+// after every attempt, up to the MaxBackoffMs.
+type RetryPolicy struct {
+	MaxAttempts      int
+	InitialBackoffMs uint64
+	MaxBackoffMs     uint64
+	Multiplier       float64
+}
+
+// smtpConfig is the server the emails are sent through, the Password is read from the `SMTP_PASSWORD`
+// environment variable. The transient failures of a send (e.g. a greylisting 4xx) are retried with the
+// Retry policy.
+type smtpConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Retry    *RetryPolicy
+}
+
+// degradedModeConfig lets the server answer the GET requests of the CacheablePaths with their last
+// successful response while the database is unavailable, instead of failing with a 500.
+type degradedModeConfig struct {
+	Enabled        bool
+	CacheablePaths []string
+
+	// MaxEntries bounds the number of responses kept in memory.
+	MaxEntries int
+}
+
+// poolSaturationConfig sheds the write requests with a 503 while the connection pool of the database
+// is saturated, i.e. once WaitCountThreshold requests had to wait for a connection within a window.
+type poolSaturationConfig struct {
+	Enabled            bool
+	WaitCountThreshold int64
+	WindowMs           uint64
+
+	// MaxRetryAfterSeconds caps the `Retry-After` computed from the average wait for a connection.
+	MaxRetryAfterSeconds uint64
+
+	// ShedReads also sheds the GET, HEAD and OPTIONS requests.
+	ShedReads bool
+}
+
+// webhookDedupConfig controls the deduplication of the webhook deliveries, the delivery id is taken
+// from the Header when it is present otherwise from the (dot separated) Field of the JSON payload.
+type webhookDedupConfig struct {
+	Header        string
+	Field         string
+	WindowSeconds uint64
+}
+
+// webhookBatchingConfig controls the batching of the outgoing webhook deliveries, the events of a
+// batching subscription (either flagged by its Batch or listed in the Subscriptions) are delivered
+// together as a single array once the WindowMs since the first of them elapsed.
+type webhookBatchingConfig struct {
+	WindowMs      uint64
+	Subscriptions []string
+}
+
+// indexAdvisorConfig controls the index advisor, the queries taking at least SlowQueryMs are checked
+// with an EXPLAIN (when Explain is set) and the columns of the repeated full table scans are logged as
+// index suggestions once they were seen MinOccurrences times.
+type indexAdvisorConfig struct {
+	Enabled        bool
+	SlowQueryMs    uint64
+	Explain        bool
+	MinOccurrences int
+}
+
+// auditCompactionConfig controls the compaction of the audit log, the entries older than the AfterDays
+// are archived to the ArchiveDir a batch of the BatchSize at a time and removed from the `audit_logs`. A
+// zero AfterDays keeps the entries forever.
+type auditCompactionConfig struct {
+	AfterDays  int
+	ArchiveDir string
+	BatchSize  int
+}
+
+// orphanCleanupConfig controls the cleanup of the files of the Dir that no row references anymore, the
+// References map a table to its column holding the keys of the files (e.g. `"attachments": "file_key"`).
+// The orphans younger than the GraceMinutes are kept since the file of an upload is stored before its row
+// is created, the DryRun only reports the orphans. An empty Dir disables the cleanup.
+type orphanCleanupConfig struct {
+	Dir          string
+	References   map[string]string
+	GraceMinutes int
+	DryRun       bool
+}
+
+// configDriftConfig controls the check of the loaded config against a baseline config file, the check
+// is skipped when the Baseline is empty. The drift is reported as warnings unless the Severity is
+// `error`, which aborts the loading of the config. The IgnoredFields are the dotted names of the fields
+// expected to vary between the instances (e.g. `DbPool.MaxOpenConns`).
+type configDriftConfig struct {
+	Baseline      string
+	Severity      string
+	IgnoredFields []string
+}
+
+// settingsConfig controls the optional overlay of the `settings` table on top of the loaded config,
+// see the core/models/setting package for the layer that reads the rows from the database.
+type settingsConfig struct {
+	Enabled bool
+
+	// RefreshSeconds is the interval of how often the settings table is re-read, a zero value
+	// means that the overlay is only applied once.
+	RefreshSeconds uint64
+}
+
+// dynamicFlagsConfig controls the `flags` table whose flags are toggled live by the admins, see the
+// core/models/flag package. A flag of the table overrides the one of the Features.
+type dynamicFlagsConfig struct {
+	Enabled bool
+
+	// RefreshSeconds is the interval of how often the flags table is re-read, a zero value means that
+	// the table is only read once (the flags set by this instance are still applied right away).
+	RefreshSeconds uint64
+}
+
+// RateLimit is the config of a token bucket, Rate is the number of tokens refilled per second and
+// Burst is the maximum number of tokens the bucket can hold.
+type RateLimit struct {
+	Rate  float64
+	Burst uint64
+}
+
+// adminUser is an admin account created by the bootstrap seed, the Password can reference an environment
+// variable (e.g. `$ADMIN_PASSWORD`) which is expanded when the config is loaded.
+type adminUser struct {
+	Email    string
+	Password string
+}
+
+// Currency is the formatting info of a currency, see the Money.String of the core/models/money package.
+type Currency struct {
+	Symbol        string
+	DecimalPlaces int
+}
+
+// AppVersion struct is the schema for the parsed version defined in the app_config.json if the version
 // is not formatted properly `<major>.<minor>.<build>-<release>` the output will get truncated by the
 // `loadConfig`.
-type appVersion struct {
+type AppVersion struct {
 	Major   uint64
 	Minor   uint64
 	Build   uint64
@@ -33,8 +280,25 @@ type appVersion struct {
 // any field in the app_config.json that does not corresponds to any of the fields of appConfigType
 // will inevitably ignored by the `loadConfig`
 type AppConfigType struct {
-	Version        string
-	VersionInfo    *appVersion
+	Version     string
+	VersionInfo *AppVersion
+
+	// Timezone is the IANA name of the location used by the app (e.g. the timestamps stamped by
+	// gorm), an empty value means UTC.
+	Timezone string
+	location *time.Location
+
+	// provenance maps the name of a field to the source of its value, see the Provenance.
+	provenance map[string]string
+
+	// ResponseTimezone is the IANA name of the zone the timestamps of the API responses are converted
+	// to when the request has no valid `X-Timezone` header, an empty value means UTC.
+	ResponseTimezone string
+
+	// FbEnabled enables the Facebook login, the `FB_*` environment variables are only required (and
+	// the Graph API only called) when it is set.
+	FbEnabled bool
+
 	FbSdkVersion   string
 	FbClientId     string
 	FbClientSecret string
@@ -44,13 +308,194 @@ type AppConfigType struct {
 	FbBusinessClientSecret string
 	FbBusinessClientScope  string
 
+	// FbTimeoutMs is the timeout of every call to the Graph API, a call can still use a shorter
+	// deadline through its context.
+	FbTimeoutMs uint64
+
+	HttpClient *httpClientConfig
+	SMTP       *smtpConfig
+
 	ServerAddr       string
 	ServerProto      string
 	ServerCertFile   string
 	ServerKeyFile    string
 	ServerPassphrase string
 
-	Message string
+	// MetricsAddr is the address of the internal server exposing the prometheus metrics (and the
+	// pprof endpoints when EnablePprof is set), the metrics server is disabled when it is empty.
+	MetricsAddr string
+	EnablePprof bool
+
+	// BuildCommit is the commit the app was built from (the `BUILD_COMMIT` env) and Environment is the
+	// name of the environment it is deployed to (the `ENV` env, e.g. devel), both label the build info
+	// metric.
+	BuildCommit string
+	Environment string
+
+	// PayloadSizeMetrics records the sizes of the request and response bodies of every route.
+	PayloadSizeMetrics bool
+
+	// PerUserRateLimit is applied to authenticated requests while PerIpRateLimit is applied to the
+	// anonymous ones, a nil value disables the limit.
+	PerUserRateLimit *RateLimit
+	PerIpRateLimit   *RateLimit
+
+	// TenantRateLimits maps the id of a tenant to the limit shared by all of its users, the `default`
+	// limit applies to the tenants that are not listed. The users of a tenant without a limit are
+	// limited by the PerUserRateLimit.
+	TenantRateLimits map[string]*RateLimit
+
+	// RateLimitHeaders exposes the quota left in the bucket of the client with the `X-RateLimit-*`
+	// headers of every rate limited response.
+	RateLimitHeaders bool
+
+	// Policies maps the patterns of the routes to the roles allowed to access them, see the
+	// PolicyMiddleware of the api/middleware package. A pattern ending with "/*" guards every route
+	// below it.
+	Policies map[string][]string
+
+	// RequestSchemas maps a route (its method and the pattern of its path, e.g. `POST /v1/users`) to the
+	// file of the JSON Schema its body is validated against, the files are read from the
+	// RequestSchemaDir. See the JSONSchemaMiddleware of the api/middleware package.
+	RequestSchemas   map[string]string
+	RequestSchemaDir string
+
+	// ForceHTTPS redirects the plain-http requests to https, the scheme of a request is told by the
+	// `X-Forwarded-Proto` header of the TLS-terminating proxy. The ForceHTTPSExemptPaths (e.g. the health
+	// check) are never redirected.
+	ForceHTTPS            bool
+	ForceHTTPSExemptPaths []string
+
+	SecurityHeaders *securityHeadersConfig
+	DegradedMode    *degradedModeConfig
+	PoolSaturation  *poolSaturationConfig
+	WebhookDedup    *webhookDedupConfig
+	WebhookBatching *webhookBatchingConfig
+
+	// ApiVersions are the versions of the api mounted under their prefix (e.g. `/v1`), the requests to
+	// the RetiredApiVersions are answered with a 410.
+	ApiVersions        []string
+	RetiredApiVersions []string
+
+	// ETagPaths are the path prefixes of the GET endpoints answered with an ETag, a conditional
+	// request whose `If-None-Match` matches the ETag is answered with a 304.
+	ETagPaths []string
+
+	// Singleflight makes the concurrent misses of a same key of the read-through cache share a single
+	// load, see the Load of the internal/cache package.
+	Singleflight bool
+
+	// WarmupPeriodSeconds is the duration after the start of the server to which the readiness weight
+	// ramps from 0 up to 100, this lets the load balancers slowly route traffic to a cold instance.
+	WarmupPeriodSeconds uint64
+
+	// RequestTimeoutMs is the deadline of every request, the server responds with a 503 and the
+	// RequestTimeoutMessage once it expires. A zero value disables the timeout.
+	RequestTimeoutMs      uint64
+	RequestTimeoutMessage string
+
+	// PropagateCancellation makes the gin.Context of a request usable as its context.Context, the
+	// handlers passing it to the repositories, the gorm sessions and the Graph helpers then have them
+	// cancelled as soon as the client disconnects (or the RequestTimeoutMs expires).
+	PropagateCancellation bool
+
+	// HealthTimeouts maps a component of the health check (`database`, `facebook` or `config`) to the
+	// deadline of its check in milliseconds, a component that does not answer in time is reported as
+	// failed. The components that are not listed get the DEFAULT_HEALTH_TIMEOUT of the api package.
+	HealthTimeouts map[string]uint64
+
+	// ErrorRateThreshold is the rate of the server errors (per second, over the last
+	// ErrorRateWindowSeconds) past which the readiness check answers with a 503 so that the instance is
+	// taken out of the rotation until the rate drops. A zero disables it.
+	ErrorRateThreshold     float64
+	ErrorRateWindowSeconds uint64
+
+	PasswordHashCost int
+	AdminUsers       []*adminUser
+
+	// RehashPasswordsOnLogin upgrades the hash of a password made with a lower cost than the
+	// PasswordHashCost when the user logs in with it, see the VerifyAndRehash of the password package.
+	RehashPasswordsOnLogin bool
+
+	// DefaultTenant is the name of the tenant created on the first boot of a single-tenant deployment, the
+	// AdminUsers are assigned to it. No tenant is created when it is empty.
+	DefaultTenant string
+
+	// ExposeConfigEndpoint serves the redacted dump of the effective config to the admins, see the
+	// ConfigHandler of the api package.
+	ExposeConfigEndpoint bool
+
+	// ConfigDrift checks the loaded config against a signed baseline, see the CheckDrift.
+	ConfigDrift *configDriftConfig
+
+	// ReloadRetrySeconds is how long the ReloadSection waits before it retries a reload that could not read
+	// the config file, the current config is kept meanwhile. A zero disables the retries.
+	ReloadRetrySeconds uint64
+
+	// DuplicateKeyMode is how a key repeated within an object of the app_config.json is reported, either
+	// `warn` (the default) or `error`. The last of the repeated values is the one that is loaded.
+	DuplicateKeyMode string
+
+	// WarnUnusedKeys warns of the keys of the app_config.json that do not map to any field of the config
+	// (e.g. the stale keys left over after a schema change), they are ignored either way.
+	WarnUnusedKeys bool
+
+	// Currencies maps an ISO 4217 code to its formatting info, it extends (or overrides) the
+	// built-in currencies of the money package.
+	Currencies map[string]*Currency
+
+	// RoundingMode is how the money computations round a fraction of the minor unit, either `half_up`,
+	// `half_even` (banker's rounding, the default) or `down`.
+	RoundingMode string
+
+	// BaseCurrency is the ISO 4217 code of the currency the invoices are totaled in, it defaults to USD.
+	BaseCurrency string
+
+	// Schedules maps the name of a task registered to the scheduler to its cron spec, the standard
+	// 5-field specs and the `@every <duration>` descriptor are supported.
+	Schedules map[string]string
+
+	// SchedulerWaitForReady holds the scheduled tasks until the instance is ready, that is once the
+	// database is connected and migrated.
+	SchedulerWaitForReady bool
+
+	// ValidationLocales are the locales of the validation error messages, DefaultLocale is used when
+	// the locale of a request is not one of them.
+	ValidationLocales []string
+	DefaultLocale     string
+
+	// LocaleCollations maps a locale to the MySQL collation the locale-aware sorts of its requests use
+	// (e.g. `"es": "utf8mb4_es_0900_ai_ci"`), a regional locale falls back to its language. See the
+	// internal/db/collation package.
+	LocaleCollations map[string]string
+
+	// SessionStore is where the sessions are kept, either `memory` (lost on a restart) or `database`.
+	SessionStore string
+
+	// SessionCleanupBatchSize is how many expired sessions of the `sessions` table are deleted at a time.
+	SessionCleanupBatchSize int
+
+	// SelfTestChecks toggles the checks of the `--selftest` mode by their name, the checks that are
+	// not listed are run.
+	SelfTestChecks map[string]bool
+
+	// Modules toggles the modules of the app by their name, the modules that are not listed are started.
+	// A module must not be disabled while an enabled module depends on it.
+	Modules map[string]bool
+
+	// AccessLogFields are the fields of the access log records (see the AccessLogFieldNames), the
+	// access log is disabled when it is empty.
+	AccessLogFields []string
+
+	// ScrubPanicSecrets masks the passwords of the DSNs and the secrets of the config found in the
+	// message and the stack trace of a recovered panic before it is logged.
+	ScrubPanicSecrets bool
+
+	Message      string
+	Features     map[string]bool
+	Settings     *settingsConfig
+	DynamicFlags *dynamicFlagsConfig
+	Logging      *loggingConfig
 
 	InuseDataSource string
 
@@ -62,8 +507,259 @@ type AppConfigType struct {
 	MysqlDbName   string
 	MysqlFlags    string
 
-	MysqlConfig *mysqlConfig
-	GormConfig  *gorm.Config
+	// MysqlHosts are the addresses of the MySQL hosts (taken from the comma separated MYSQL_HOSTS),
+	// when set the connection fails over to the next host once a host is unreachable.
+	MysqlHosts []string
+
+	// MysqlReplicas are the addresses of the replicas (taken from the comma separated MYSQL_REPLICAS),
+	// the plain reads are sent to them when AutoReadSplit is set.
+	MysqlReplicas []string
+	AutoReadSplit bool
+
+	// TreatEmptyEnvAsValue makes an environment variable set to an empty string override the value of the
+	// file, by default an empty variable is treated as unset.
+	TreatEmptyEnvAsValue bool
+
+	// ConsistentReadHeader is the header pinning the statements of a request to the primary so that it
+	// reads its own writes (see the ConsistentReadMiddleware), the writes are always pinned. It defaults
+	// to the `X-Consistent-Read`.
+	ConsistentReadHeader string
+
+	// RequireTLS aborts the connection to the database when it is not encrypted.
+	RequireTLS bool
+
+	// RecordAppInstances records the version of the app to the `app_instances` table on connect, an
+	// older version connecting after a newer one ran is handled by the OlderVersionPolicy, either `warn`
+	// (the default) or `error` which aborts the connection.
+	RecordAppInstances bool
+	OlderVersionPolicy string
+
+	// RecordVersionHistory records every distinct version of the app that connected to the database
+	// (with when it was first and last seen) to the `app_versions_seen` table, the history is served on
+	// the `/version/history` endpoint.
+	RecordVersionHistory bool
+
+	// DataEncryptionKey is the key of the encrypted columns, it is read from the `DATA_ENCRYPTION_KEY`
+	// environment variable. Its fingerprint is stored in the database on the first connect and the app
+	// refuses to connect with a different key, unless the AllowKeyRotation is set while the columns are
+	// re-encrypted with the new key. See the CheckEncryptionKey of the mysql source.
+	DataEncryptionKey string
+	AllowKeyRotation  bool
+
+	// DbSqlComments prepends the id of the request to the SQL of its queries (`/* req=<id> */`).
+	DbSqlComments bool
+
+	// IndexHints maps a query tag to the MySQL index hint injected into the queries tagged with it (e.g.
+	// `"orders_by_status": "USE INDEX (idx_orders_status)"`), see the internal/db/indexhint package. The
+	// hints are only injected when the DbIndexHints is set.
+	IndexHints   map[string]string
+	DbIndexHints bool
+
+	// DbConnTrace logs every physical connection to the database that is established or closed.
+	DbConnTrace bool
+
+	// DbTracing records a span of every statement (see the internal/db/tracing package), the DSN of
+	// the spans is always masked. The bind parameters are left out of the spans unless the
+	// DbTraceBindParameters is set since they may hold personal data.
+	DbTracing             bool
+	DbTraceBindParameters bool
+
+	// DetectNPlusOne warns about the queries of the same shape made more than the NPlusOneThreshold
+	// times within a request, it only runs in the development environment (see IsDevelopment).
+	DetectNPlusOne    bool
+	NPlusOneThreshold int
+
+	// RequireActor fails the writes of the models with the audit stamps (see the internal/db/audit
+	// package) that were made without an actor set on the session.
+	RequireActor bool
+
+	MysqlConfig   *mysqlConfig
+	DbPool        *dbPoolConfig
+	MigrationLock *migrationLockConfig
+	IndexAdvisor  *indexAdvisorConfig
+	GormConfig    *gorm.Config
+
+	// SoftDeleteRetentionDays is how long the soft-deleted rows of the opted-in models are kept before
+	// they are hard-deleted (a zero value keeps them forever), they are deleted in batches of the
+	// SoftDeletePurgeBatchSize to avoid locking the table for long.
+	SoftDeleteRetentionDays  uint64
+	SoftDeletePurgeBatchSize int
+
+	// SoftDeleteCascade maps the name of a model to its has-one and has-many relations whose rows are
+	// soft-deleted (and restored) along with it, see the internal/db/cascade package.
+	SoftDeleteCascade map[string][]string
+
+	// BulkUpdateFields maps the name of a model to the fields that the admins can bulk update.
+	BulkUpdateFields map[string][]string
+
+	// StampBulkUpdates stamps the UpdatedAt of the models on the updates skipping the hooks of gorm (e.g.
+	// the UpdateColumns), see the internal/db/timestamps package.
+	StampBulkUpdates bool
+
+	// SparseFields maps the name of a model to the fields that the API clients can select with the
+	// `fields` query param (e.g. `?fields=id,name`), a model that is not listed cannot be sparse.
+	SparseFields map[string][]string
+
+	// FieldACL maps the name of a model to its restricted fields and the roles allowed to see them (e.g.
+	// `"Product": {"costPrice": ["admin"]}`), the field is omitted from the responses to the other roles.
+	// The fields are matched case-insensitively against the keys of the JSON of the model.
+	FieldACL map[string]map[string][]string
+
+	// ListEnvelope wraps the rows answered by the list endpoints in an object carrying the pagination
+	// (`{"data": [...], "page": 1, "page_size": 20, "total": 42}`) instead of answering a bare array.
+	ListEnvelope bool
+
+	// DualWriteTables maps the name of a model to the new table that its writes are duplicated to while
+	// its storage is being migrated, the reads are still served from the legacy table. The reads are
+	// compared against the new table and the mismatches are logged when the DualWriteVerify is set.
+	DualWriteTables map[string]string
+	DualWriteVerify bool
+
+	// SlugSources maps the name of a model to the field its slug is derived from, it takes precedence
+	// over the SlugSource of the model (see the internal/db/slug package).
+	SlugSources map[string]string
+
+	// SlugTransliterations maps a non-ASCII character to its ASCII spelling in the slugs (e.g. `"ß": "ss"`),
+	// it extends (or overrides) the built-in transliterations.
+	SlugTransliterations map[string]string
+
+	// LikeEscapeChar escapes the wildcards of the search terms in the LIKE clauses (see the
+	// internal/db/search package), it defaults to `!` which unlike the backslash needs no escaping of
+	// its own in the SQL strings.
+	LikeEscapeChar string
+
+	// Retention maps the name of a model to the days its rows are kept (by their CreatedAt), the
+	// expired rows are archived to the RetentionArchiveDir (when set) before they are deleted. The
+	// RetentionDryRun only reports the number of the expired rows.
+	Retention           map[string]int
+	RetentionArchiveDir string
+	RetentionDryRun     bool
+
+	AuditCompaction *auditCompactionConfig
+	OrphanCleanup   *orphanCleanupConfig
+
+	// DefaultPreloads maps the name of a model to the associations that are eager-loaded by the
+	// repositories (e.g. `"Order": ["Items", "Items.Product"]`).
+	DefaultPreloads map[string][]string
+
+	// MaxPreloadDepth is how deeply the associations of the `include` query param may be nested (e.g.
+	// `Items.Product` has a depth of 2), a deeper include is answered with a 400. A zero disables the limit.
+	MaxPreloadDepth uint64
+
+	// DuplicateMatchThreshold is the score (from 0 to 1) past which a customer is reported as a likely
+	// duplicate of another, see the MatchDuplicates of the core/models/customer package.
+	DuplicateMatchThreshold float64
+
+	// CaseInsensitiveEmails lowercases the emails of the customers before they are saved, so that an email
+	// differing only by its case is rejected as a duplicate within the tenant.
+	CaseInsensitiveEmails bool
+
+	// NormalizeEmails trims and lowercases the emails of the models implementing the EmailField before
+	// they are saved, EmailFields maps the name of a model to its email field and takes precedence over
+	// the EmailField of the model (see the internal/db/email package).
+	NormalizeEmails bool
+	EmailFields     map[string]string
+
+	// QueueHighWaterMark is the number of pending jobs past which only the high-priority jobs are run,
+	// until the pending jobs drain down to the QueueLowWaterMark. A zero value disables the shedding.
+	QueueHighWaterMark int64
+	QueueLowWaterMark  int64
+
+	// JobCorrelation keeps the id of the request (and the actor) enqueuing a job in its metadata, so that
+	// the logs of the job tie back to the request.
+	JobCorrelation bool
+
+	// ExportBatchSize is the number of rows read at a time when exporting a table, this bounds the
+	// memory used by an export regardless of the size of the table.
+	ExportBatchSize int
+
+	// StreamFlushRows is the number of rows a streamed JSON response writes between two flushes, see the
+	// StreamJSON of the core/source/export package and the StreamList of the api package.
+	StreamFlushRows int
+
+	// MaxConcurrentReports is the number of reports generated at the same time (a zero value does not
+	// limit them), the ReportOverflow tells whether the excess reports `queue` for their turn or are
+	// rejected (`reject`) with a 429.
+	MaxConcurrentReports int
+	ReportOverflow       string
+
+	// SequencePeriods maps the name of a sequence to the period its counter is reset on, either `year`
+	// (e.g. INV-2024-0001) or `month`. The sequences that are not listed are never reset.
+	SequencePeriods map[string]string
+
+	// IDStrategy is how the models embedding the ids.Model get their ids on create, either
+	// `autoincrement` (by the database), `uuid` (the models must embed the ids.UUIDModel instead) or
+	// `snowflake`. The SnowflakeNodeId (0 to 1023) must be unique to every instance of the app.
+	IDStrategy      string
+	SnowflakeNodeId uint64
+
+	// MigrationMode is either `auto` (AutoMigrate the models) or `versioned` (run the pending
+	// migrations), AllowDestructive lets the versioned mode run the destructive migrations.
+	MigrationMode    string
+	AllowDestructive bool
+
+	// ValidateModelTags makes the migration check the struct tags of the models before migrating
+	// them, see the ValidateModelTags of the internal/db/migrator/gorm package.
+	ValidateModelTags bool
+
+	// StrictJSONTags makes the migration fail when an exported field of a model has no json tag, so that
+	// the Go names of the fields do not leak into the api. See the ValidateJSONTags of the
+	// internal/db/migrator/gorm package.
+	StrictJSONTags bool
+
+	// SnapshotSchema records the DDL of the schema to the `schema_snapshots` (tagged with the version of
+	// the app) after every successful migration, see the RecordSchemaSnapshot of the
+	// internal/db/migrator/gorm package.
+	SnapshotSchema bool
+
+	// EnablePartitioning makes the migration partition the tables of the models that declare their
+	// partitioning, see the Partitioned of the internal/db/migrator/gorm package.
+	EnablePartitioning bool
+
+	// AutoIndexForeignKeys makes the migration create the missing indexes of the foreign keys of the
+	// models, otherwise they are only logged as warnings. See the IndexForeignKeys of the
+	// internal/db/migrator/gorm package.
+	AutoIndexForeignKeys bool
+
+	// NotNullColumns maps a table to its critical columns that the code assumes are never NULL, they are
+	// checked to be NOT NULL in the database after the migration. The nullable ones are logged as warnings
+	// unless StrictNotNullColumns is set, which fails the migration. See the CheckNotNullColumns of the
+	// internal/db/migrator/gorm package.
+	NotNullColumns       map[string][]string
+	StrictNotNullColumns bool
+
+	// MigrationRetry is how a versioned migration failing with a transient error (e.g. a deadlock) is
+	// retried. A migration that was partially applied by a database without transactional DDL (MySQL)
+	// is never retried.
+	MigrationRetry *RetryPolicy
+
+	// RetryBudget is shared by all of the retries of the internal/retry package, every retry takes a token
+	// of it and no retry is made once it is empty so that a dependency that is down is not stormed by the
+	// retries of every caller. The Rate is the tokens refilled per second, a nil value disables the budget.
+	RetryBudget *RateLimit
+
+	// OptimisticRetryAttempts is how many times the RetryOptimistic of the internal/db/optimistic package
+	// runs an operation whose writes keep conflicting, when it is not given a max of its own.
+	OptimisticRetryAttempts int
+}
+
+// IsDevelopment reports whether the app is deployed to the development environment.
+func (conf *AppConfigType) IsDevelopment() bool {
+	switch strings.ToLower(conf.Environment) {
+	case "devel", "development":
+		return true
+	}
+
+	return false
+}
+
+// Location returns the location of the configured `timezone`.
+func (conf *AppConfigType) Location() *time.Location {
+	if conf.location == nil {
+		return time.UTC
+	}
+
+	return conf.location
 }
 
 func (conf *AppConfigType) GetFbClientId(typ uint) (client_id string) {
@@ -94,13 +790,99 @@ func (conf *AppConfigType) GetFbClientSecret(typ uint) (client_secret string) {
 	return
 }
 
+const (
+	// MYSQL_FAILOVER_NET is the network of the dialer that fails over across the MysqlHosts, it is
+	// registered to the driver by the mysql source.
+	MYSQL_FAILOVER_NET = "failover"
+)
+
 var (
-	loadedConfig *AppConfigType
+	// loaded is the config returned by the AppConfig, a change of the overrides builds a new config and
+	// swaps it in so that the readers never see a half applied one.
+	loaded atomic.Pointer[AppConfigType]
+
+	// base is the config from the app_config.json and the environment variables, the loaded config is
+	// a copy of it with the overrides applied on top.
+	base *AppConfigType
+
+	// configMu serializes the changes of the loaded config.
+	configMu sync.Mutex
+
+	// overrides holds the values that were overlaid by `ApplyOverrides`, we keep them around so that
+	// they are reapplied whenever the config gets reloaded from the file.
+	overrides = make(map[string]json.RawMessage)
+
+	// sectionAppliers maps the name of a section to the funcs applying it once it was reloaded by
+	// `ReloadSection`.
+	sectionAppliers = make(map[string][]func(conf *AppConfigType))
+
+	ErrUnknownSection = errors.New("error: unknown config section")
+
+	// ErrConfigUnavailable is returned by the ReloadSection when the config file could not be read, the
+	// current config is kept and the reload is retried after the `reloadRetrySeconds`.
+	ErrConfigUnavailable = errors.New("error: the config source is unavailable")
+
+	// afterFunc schedules the retries of the reloads, the tests override it.
+	afterFunc = time.AfterFunc
+
+	// reloadRetries holds the pending retry of every section so that the failing reloads of a section do
+	// not pile up their retries.
+	reloadRetries   = make(map[string]*time.Timer)
+	reloadRetriesMu sync.Mutex
 )
 
+// String formats the version as `<major>.<minor>.<build>-<release>`, the release is omitted when empty.
+func (v *AppVersion) String() string {
+	if v == nil {
+		return ""
+	}
+
+	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Build)
+	if len(v.Release) != 0 {
+		version += "-" + v.Release
+	}
+
+	return version
+}
+
+// releaseRanks orders the releases of a same version, from the earliest to the final one.
+var releaseRanks = map[string]int{"alpha": 0, "beta": 1, "testing": 2, "build": 3}
+
+// Compare returns -1 when the version is older than the other, 1 when it is newer and 0 when they are
+// the same. The Major, Minor and Build are compared first, then the Release (alpha < beta < testing <
+// build), an unknown release is ordered after the known ones.
+func (v *AppVersion) Compare(other *AppVersion) int {
+	for _, pair := range [][2]uint64{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Build, other.Build}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	rank := func(release string) int {
+		if r, ok := releaseRanks[release]; ok {
+			return r
+		}
+
+		return len(releaseRanks)
+	}
+
+	switch a, b := rank(v.Release), rank(other.Release); {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
 // parseVersion parses the version defined in the app_config.json, since this function can be called anywhere
 // in the local scope of this package, it can be used to parse any string that satisfies the defined format.
-func parseVersion(v string) *appVersion {
+func parseVersion(v string) *AppVersion {
 	const (
 		MAJOR   = "major"
 		MINOR   = "minor"
@@ -118,94 +900,1598 @@ func parseVersion(v string) *appVersion {
 	minor, _ := strconv.ParseUint(string(vpatt.ExpandString([]byte{}, "$"+MINOR, v, dmatch)), 10, 64)
 	build, _ := strconv.ParseUint(string(vpatt.ExpandString([]byte{}, "$"+BUILD, v, dmatch)), 10, 64)
 
-	return &appVersion{major, minor, build, string(vpatt.ExpandString([]byte{}, "$"+RELEASE, v, dmatch))}
+	return &AppVersion{major, minor, build, string(vpatt.ExpandString([]byte{}, "$"+RELEASE, v, dmatch))}
 }
 
-// loadConfig is the function that will be called by `AppConfig` to load the app_config.json file and parse its
-// content to fit into the appConfigType struct. This can be called by any batch codes that modifies the
-// app_config.json at runtime to rehydrate the `loadedConfig` struct.
-func loadConfig() {
-	loadedConfig = &AppConfigType{
-		GormConfig: &gorm.Config{},
+// CheckDsn checks that the DSN can be parsed by the driver, only the mysql DSNs are checked. The DSN is
+// never part of the error since it carries the password.
+func CheckDsn(driver, dsn string) error {
+	if driver != "mysql" {
+		return nil
 	}
 
-	b, err := os.ReadFile(config.DEFAULT)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error loading app_config.json: %s", err.Error())
-		os.Exit(1)
+	if _, err := mysql.ParseDSN(dsn); err != nil {
+		return errors.New("is mysql but the dsn is invalid, it must look like user:password@tcp(host:3306)/dbname?flags")
 	}
 
-	err = json.Unmarshal(b, &loadedConfig)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error unmarshaling app_config.json: %s", err.Error())
-		os.Exit(1)
-	}
+	return nil
+}
 
-	sdkverpatt := regexp.MustCompile(`^v\d{2,}[.]\d{1}$`)
+// envRule describes an environment variable read by the `loadConfig`, the rule checks the value of the
+// config resolved from the file and the environment. A rule is only checked when its `when` func returns
+// true (or is nil) and the `valid` func can be used to validate the value further.
+type envRule struct {
+	name  string
+	value func(conf *AppConfigType) string
+	when  func(conf *AppConfigType) bool
+	valid func(string) error
+}
 
-	fbSdkVer := os.Getenv("FB_SDK_VERSION")
-	fbClientId := os.Getenv("FB_CLIENT_ID")
-	fbClientSecret := os.Getenv("FB_CLIENT_SECRET")
-	fbRedirectUri := os.Getenv("FB_REDIRECT_URI")
+var (
+	sdkverpatt = regexp.MustCompile(`^v\d{2,}[.]\d{1}$`)
 
-	if !sdkverpatt.MatchString(fbSdkVer) {
-		fmt.Fprintf(os.Stderr, "FB_SDK_VERSION did not satisfy the expected version regexp.")
-		os.Exit(1)
+	// validCollation is a MySQL collation name, the collations are spliced into the SQL as is.
+	validCollation = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+	envRules = []envRule{
+		{name: "FB_SDK_VERSION", value: func(conf *AppConfigType) string { return conf.FbSdkVersion }, when: fbEnabled, valid: func(v string) error {
+			if !sdkverpatt.MatchString(v) {
+				return errors.New("did not satisfy the expected version regexp")
+			}
+
+			return nil
+		}},
+		{name: "FB_CLIENT_ID", value: func(conf *AppConfigType) string { return conf.FbClientId }, when: fbEnabled},
+		{name: "FB_CLIENT_SECRET", value: func(conf *AppConfigType) string { return conf.FbClientSecret }, when: fbEnabled},
+		{name: "FB_REDIRECT_URI", value: func(conf *AppConfigType) string { return conf.FbRedirectUri }, when: fbEnabled},
+		{name: "SERVER_ADDR", value: func(conf *AppConfigType) string { return conf.ServerAddr }},
+		{name: "SERVER_PROTO", value: func(conf *AppConfigType) string { return conf.ServerProto }, valid: oneOf("http", "https")},
+		{name: "SERVER_CERT_FILE", value: func(conf *AppConfigType) string { return conf.ServerCertFile }, when: serverProtoIs("https")},
+		{name: "SERVER_KEY_FILE", value: func(conf *AppConfigType) string { return conf.ServerKeyFile }, when: serverProtoIs("https")},
+		{name: "INUSE_DATA_SOURCE", value: func(conf *AppConfigType) string { return conf.InuseDataSource }, valid: oneOf("mysql")},
+		{name: "MYSQL_USER", value: func(conf *AppConfigType) string { return conf.MysqlUser }, when: dataSourceIs("mysql")},
+		{name: "MYSQL_TYPE", value: func(conf *AppConfigType) string { return conf.MysqlType }, when: dataSourceIs("mysql"), valid: oneOf("tcp", "unix")},
+		{name: "MYSQL_ADDR", value: func(conf *AppConfigType) string { return conf.MysqlAddr }, when: mysqlTypeIs("tcp")},
+		{name: "MYSQL_SOCK", value: func(conf *AppConfigType) string { return conf.MysqlSock }, when: mysqlTypeIs("unix")},
+		{name: "MYSQL_DB_NAME", value: func(conf *AppConfigType) string { return conf.MysqlDbName }, when: dataSourceIs("mysql")},
 	}
+)
 
-	loadedConfig.VersionInfo = parseVersion(loadedConfig.Version)
-	loadedConfig.FbClientId = fbClientId
-	loadedConfig.FbClientSecret = fbClientSecret
-	loadedConfig.FbSdkVersion = fbSdkVer
-	loadedConfig.FbRedirectUri = fbRedirectUri
+func fbEnabled(conf *AppConfigType) bool {
+	return conf.FbEnabled
+}
 
-	loadedConfig.FbBusinessClientId = os.Getenv("FB_BUSINESS_CLIENT_ID")
-	loadedConfig.FbBusinessClientSecret = os.Getenv("FB_BUSINESS_CLIENT_SECRET")
-	loadedConfig.FbBusinessClientScope = os.Getenv("FB_BUSINESS_CLIENT_SCOPE")
+func serverProtoIs(val string) func(conf *AppConfigType) bool {
+	return func(conf *AppConfigType) bool { return conf.ServerProto == val }
+}
 
-	loadedConfig.ServerAddr = os.Getenv("SERVER_ADDR")
-	loadedConfig.ServerProto = os.Getenv("SERVER_PROTO")
-	loadedConfig.ServerCertFile = os.Getenv("SERVER_CERT_FILE")
-	loadedConfig.ServerKeyFile = os.Getenv("SERVER_KEY_FILE")
-	loadedConfig.ServerPassphrase = os.Getenv("SERVER_PASSPHRASE")
+func dataSourceIs(val string) func(conf *AppConfigType) bool {
+	return func(conf *AppConfigType) bool { return conf.InuseDataSource == val }
+}
 
-	loadedConfig.MysqlUser = os.Getenv("MYSQL_USER")
-	loadedConfig.MysqlPassword = os.Getenv("MYSQL_PASSWORD")
-	loadedConfig.MysqlType = os.Getenv("MYSQL_TYPE")
-	loadedConfig.MysqlSock = os.Getenv("MYSQL_SOCK")
-	loadedConfig.MysqlAddr = os.Getenv("MYSQL_ADDR")
-	loadedConfig.MysqlDbName = os.Getenv("MYSQL_DB_NAME")
-	loadedConfig.MysqlFlags = os.Getenv("MYSQL_FLAGS")
-	loadedConfig.InuseDataSource = os.Getenv("INUSE_DATA_SOURCE")
+func mysqlTypeIs(val string) func(conf *AppConfigType) bool {
+	return func(conf *AppConfigType) bool { return conf.MysqlType == val }
 }
 
-func Dsn() string {
-	AppConfig()
+func oneOf(vals ...string) func(string) error {
+	return func(v string) error {
+		for _, val := range vals {
+			if v == val {
+				return nil
+			}
+		}
 
-	connAddr := ""
+		return fmt.Errorf("must be one of [%s]", strings.Join(vals, ", "))
+	}
+}
+
+// CheckRequiredEnv inspects all the environment variables used by the conf at once, instead of failing
+// on the first one, the returned error lists every missing or invalid variable. The values are the ones
+// of the conf once the environment was applied (see the TreatEmptyEnvAsValue), so a variable may also be
+// set by the file.
+func CheckRequiredEnv(conf *AppConfigType) error {
+	problems := []string{}
+
+	for _, rule := range envRules {
+		if rule.when != nil && !rule.when(conf) {
+			continue
+		}
+
+		val := rule.value(conf)
+
+		if len(val) == 0 {
+			problems = append(problems, fmt.Sprintf("%s is not set", rule.name))
+			continue
+		}
+
+		if rule.valid == nil {
+			continue
+		}
 
-	if loadedConfig.MysqlType == "tcp" {
-		connAddr = loadedConfig.MysqlAddr
-	} else if loadedConfig.MysqlType == "unix" {
-		connAddr = loadedConfig.MysqlSock
+		if err := rule.valid(val); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s", rule.name, err.Error()))
+		}
 	}
 
-	return fmt.Sprintf(
-		`%s%s@%s(%s)/%s?%s`,
-		loadedConfig.MysqlUser,
-		":"+loadedConfig.MysqlPassword,
-		loadedConfig.MysqlType,
-		connAddr,
-		loadedConfig.MysqlDbName,
-		loadedConfig.MysqlFlags,
-	)
+	if len(problems) != 0 {
+		return fmt.Errorf("error: invalid environment (%s)", strings.Join(problems, "; "))
+	}
+
+	return nil
 }
 
-// AppConfig returns the `loadedConfig` struct locally defined in this scope.
-func AppConfig() *AppConfigType {
-	if loadedConfig == nil {
+type Severity int
+
+const (
+	// SEVERITY_WARN rules are reported but the config is still loaded.
+	SEVERITY_WARN Severity = iota
+	// SEVERITY_ERROR rules abort the loading of the config.
+	SEVERITY_ERROR
+)
+
+// configRule validates the loaded config, the `check` returns the problem of the config or nil.
+type configRule struct {
+	name     string
+	severity Severity
+	check    func(conf *AppConfigType) error
+}
+
+// AccessLogFieldNames are the fields that can be listed in the `accessLogFields`.
+var AccessLogFieldNames = []string{
+	"method", "path", "route", "query", "status", "duration", "bytes", "ip", "user_agent", "user_id", "request_id",
+}
+
+var configRules = []configRule{
+	{name: "version", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.Version) == 0 {
+			return errors.New("is not set")
+		}
+
+		return nil
+	}},
+	{name: "responseTimezone", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		_, err := time.LoadLocation(conf.ResponseTimezone)
+		return err
+	}},
+	{name: "smtp.retry", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if retry := conf.SMTP.Retry; retry != nil && (retry.MaxAttempts < 0 || retry.Multiplier < 0) {
+			return errors.New("the maxAttempts and the multiplier must not be negative")
+		}
+
+		return nil
+	}},
+	{name: "optimisticRetryAttempts", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.OptimisticRetryAttempts < 0 {
+			return errors.New("must not be negative")
+		}
+
+		return nil
+	}},
+	{name: "migrationRetry", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if retry := conf.MigrationRetry; retry != nil && (retry.MaxAttempts < 0 || retry.Multiplier < 0) {
+			return errors.New("the maxAttempts and the multiplier must not be negative")
+		}
+
+		return nil
+	}},
+	{name: "retryBudget", severity: SEVERITY_WARN, check: func(conf *AppConfigType) error {
+		if budget := conf.RetryBudget; budget != nil && budget.Rate <= 0 {
+			return errors.New("the rate is not positive, the budget is never refilled")
+		}
+
+		return nil
+	}},
+	{name: "orphanCleanup", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.OrphanCleanup.GraceMinutes < 0 {
+			return errors.New("the graceMinutes must not be negative")
+		}
+
+		if len(conf.OrphanCleanup.Dir) != 0 && len(conf.OrphanCleanup.References) == 0 {
+			return errors.New("the references must not be empty when the dir is set")
+		}
+
+		return nil
+	}},
+	{name: "errorRateThreshold", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.ErrorRateThreshold < 0 {
+			return errors.New("must not be negative")
+		}
+
+		if conf.ErrorRateThreshold > 0 && conf.ErrorRateWindowSeconds == 0 {
+			return errors.New("needs the errorRateWindowSeconds to be set")
+		}
+
+		return nil
+	}},
+	{name: "softDeletePurgeBatchSize", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.SoftDeletePurgeBatchSize < 0 {
+			return errors.New("must not be negative")
+		}
+
+		return nil
+	}},
+	{name: "streamFlushRows", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.StreamFlushRows < 0 {
+			return errors.New("must not be negative")
+		}
+
+		return nil
+	}},
+	{name: "exportBatchSize", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.ExportBatchSize < 0 {
+			return errors.New("must not be negative")
+		}
+
+		return nil
+	}},
+	{name: "queueLowWaterMark", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.QueueHighWaterMark > 0 && conf.QueueLowWaterMark >= conf.QueueHighWaterMark {
+			return errors.New("must be below the queueHighWaterMark")
+		}
+
+		return nil
+	}},
+	{name: "roundingMode", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.RoundingMode) == 0 {
+			return nil
+		}
+
+		return oneOf("half_up", "half_even", "down")(conf.RoundingMode)
+	}},
+	{name: "reportOverflow", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.ReportOverflow) == 0 {
+			return nil
+		}
+
+		return oneOf("queue", "reject")(conf.ReportOverflow)
+	}},
+	{name: "INUSE_DATA_SOURCE", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.InuseDataSource != "mysql" {
+			return nil
+		}
+
+		return CheckDsn(conf.InuseDataSource, conf.mysqlDsn())
+	}},
+	{name: "sequencePeriods", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		for name, period := range conf.SequencePeriods {
+			if err := oneOf("year", "month")(period); err != nil {
+				return fmt.Errorf("of the sequence %s %s", name, err.Error())
+			}
+		}
+
+		return nil
+	}},
+	{name: "auditCompaction.afterDays", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.AuditCompaction.AfterDays > 0 && len(conf.AuditCompaction.ArchiveDir) == 0 {
+			return errors.New("requires the auditCompaction.archiveDir")
+		}
+
+		return nil
+	}},
+	{name: "configDrift.severity", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.ConfigDrift.Severity) == 0 {
+			return nil
+		}
+
+		return oneOf("warn", "error")(conf.ConfigDrift.Severity)
+	}},
+	{name: "idStrategy", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.IDStrategy) == 0 {
+			return nil
+		}
+
+		return oneOf("autoincrement", "uuid", "snowflake")(conf.IDStrategy)
+	}},
+	{name: "snowflakeNodeId", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.SnowflakeNodeId > 1023 {
+			return errors.New("must be from 0 to 1023")
+		}
+
+		return nil
+	}},
+	{name: "olderVersionPolicy", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.OlderVersionPolicy) == 0 {
+			return nil
+		}
+
+		return oneOf("warn", "error")(conf.OlderVersionPolicy)
+	}},
+	{name: "nPlusOneThreshold", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.NPlusOneThreshold < 0 {
+			return errors.New("must not be negative")
+		}
+
+		return nil
+	}},
+	{name: "sessionCleanupBatchSize", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if conf.SessionCleanupBatchSize < 0 {
+			return errors.New("must not be negative")
+		}
+
+		return nil
+	}},
+	{name: "sessionStore", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.SessionStore) == 0 {
+			return nil
+		}
+
+		return oneOf("memory", "database")(conf.SessionStore)
+	}},
+	{name: "retiredApiVersions", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		for _, retired := range conf.RetiredApiVersions {
+			if oneOf(conf.ApiVersions...)(retired) == nil {
+				return fmt.Errorf("has the version %s that is also one of the apiVersions", retired)
+			}
+		}
+
+		return nil
+	}},
+	{name: "accessLogFields", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		for _, field := range conf.AccessLogFields {
+			if err := oneOf(AccessLogFieldNames...)(field); err != nil {
+				return fmt.Errorf("has an unknown field %s, a field %s", field, err.Error())
+			}
+		}
+
+		return nil
+	}},
+	{name: "duplicateKeyMode", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.DuplicateKeyMode) == 0 {
+			return nil
+		}
+
+		return oneOf("warn", "error")(conf.DuplicateKeyMode)
+	}},
+	{name: "likeEscapeChar", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		if len(conf.LikeEscapeChar) == 0 {
+			return nil
+		}
+
+		if len(conf.LikeEscapeChar) != 1 || strings.ContainsAny(conf.LikeEscapeChar, "%_'\"") {
+			return fmt.Errorf("must be a single character other than a wildcard or a quote, not %q", conf.LikeEscapeChar)
+		}
+
+		return nil
+	}},
+	{name: "localeCollations", severity: SEVERITY_ERROR, check: func(conf *AppConfigType) error {
+		for locale, collation := range conf.LocaleCollations {
+			if !validCollation.MatchString(collation) {
+				return fmt.Errorf("the collation %q of the locale %s is not a valid collation name", collation, locale)
+			}
+		}
+
+		return nil
+	}},
+	{name: "MYSQL_ADDR", severity: SEVERITY_WARN, check: func(conf *AppConfigType) error {
+		if len(conf.MysqlAddr) != 0 && len(conf.MysqlHosts) != 0 {
+			return errors.New("is deprecated in favor of the MYSQL_HOSTS and is ignored")
+		}
+
+		return nil
+	}},
+	{name: "softDeleteRetentionDays", severity: SEVERITY_WARN, check: func(conf *AppConfigType) error {
+		if _, exists := conf.Schedules["purge_soft_deleted"]; conf.SoftDeleteRetentionDays != 0 && !exists {
+			return errors.New("is set but the purge_soft_deleted task is not scheduled")
+		}
+
+		return nil
+	}},
+}
+
+// Validate checks the config against all of the rules at once, the failing SEVERITY_WARN rules are
+// returned as warnings while the failing SEVERITY_ERROR rules are joined to the returned error.
+func (c *AppConfigType) Validate() (warnings []string, err error) {
+	problems := []string{}
+
+	for _, rule := range configRules {
+		ruleErr := rule.check(c)
+		if ruleErr == nil {
+			continue
+		}
+
+		problem := fmt.Sprintf("%s %s", rule.name, ruleErr.Error())
+
+		if rule.severity == SEVERITY_WARN {
+			warnings = append(warnings, problem)
+			continue
+		}
+
+		problems = append(problems, problem)
+	}
+
+	if len(problems) != 0 {
+		err = fmt.Errorf("error: invalid config (%s)", strings.Join(problems, "; "))
+	}
+
+	return
+}
+
+// ErrConfigPermission is returned by the ReadConfigFile when the process is not allowed to read the config
+// file, it is told apart from a missing config file since the fix is different.
+var ErrConfigPermission = errors.New("error: permission denied reading the config file")
+
+// SECRET_MASK replaces the values of the secrets in the Dump of the config.
+const SECRET_MASK = "********"
+
+// secretField matches the names of the fields holding a secret (e.g. the FbClientSecret).
+var secretField = regexp.MustCompile(`(?i)(secret|password|passphrase|token|encryptionkey)$`)
+
+// Dump returns the effective config as a JSON-friendly map keyed by the names of the fields, the values
+// of the secrets are always masked with the SECRET_MASK (even the empty ones, so the dump does not tell
+// which secrets are set).
+func (conf *AppConfigType) Dump() (map[string]any, error) {
+	dumped := *conf
+
+	// The gorm.Config holds funcs and connections, neither can be encoded.
+	dumped.GormConfig = nil
+
+	b, err := json.Marshal(&dumped)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]any)
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	redact(m)
+	return m, nil
+}
+
+// Secrets returns the values of the secrets set in the config (e.g. the MysqlPassword), they are used
+// to scrub the secrets that leak into the logs.
+func (conf *AppConfigType) Secrets() []string {
+	secrets := make([]string, 0)
+	collectSecrets(reflect.ValueOf(conf).Elem(), &secrets)
+	return secrets
+}
+
+func collectSecrets(val reflect.Value, secrets *[]string) {
+	switch val.Kind() {
+	case reflect.Pointer:
+		if !val.IsNil() {
+			collectSecrets(val.Elem(), secrets)
+		}
+	case reflect.Struct:
+		// The structs of the other packages (e.g. the gorm.Config) do not hold the secrets of the config.
+		if val.Type().PkgPath() != reflect.TypeOf(AppConfigType{}).PkgPath() {
+			return
+		}
+
+		for i := 0; i < val.NumField(); i++ {
+			field := val.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			if field.Type.Kind() == reflect.String && secretField.MatchString(field.Name) {
+				if secret := val.Field(i).String(); len(secret) != 0 {
+					*secrets = append(*secrets, secret)
+				}
+
+				continue
+			}
+
+			collectSecrets(val.Field(i), secrets)
+		}
+	}
+}
+
+// redact masks the secrets of the val, the secrets nested in the sections (or in a list) are masked as well.
+func redact(val any) {
+	switch val := val.(type) {
+	case map[string]any:
+		for key, nested := range val {
+			if secretField.MatchString(key) {
+				val[key] = SECRET_MASK
+				continue
+			}
+
+			redact(nested)
+		}
+	case []any:
+		for _, nested := range val {
+			redact(nested)
+		}
+	}
+}
+
+// ConfigDiff is a field whose value differs between two configs, the Field is the dotted name of the
+// field (e.g. `SMTP.Retry.MaxAttempts`).
+type ConfigDiff struct {
+	Field    string `json:"field"`
+	Baseline any    `json:"baseline"`
+	Running  any    `json:"running"`
+}
+
+// Diff returns the fields whose values differ between the a and the b, sorted by their names. The
+// configs are compared by their Dump so the secrets are never reported, the lists are compared as a
+// whole.
+func Diff(a, b *AppConfigType) []ConfigDiff {
+	// The Dump only fails to encode the funcs of the gorm.Config, which it leaves out.
+	dumpA, _ := a.Dump()
+	dumpB, _ := b.Dump()
+
+	diffs := []ConfigDiff{}
+	diffValues("", dumpA, dumpB, &diffs)
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs
+}
+
+func diffValues(field string, a, b any, diffs *[]ConfigDiff) {
+	mapA, okA := a.(map[string]any)
+	mapB, okB := b.(map[string]any)
+
+	if !okA || !okB {
+		if !reflect.DeepEqual(a, b) {
+			*diffs = append(*diffs, ConfigDiff{Field: field, Baseline: a, Running: b})
+		}
+
+		return
+	}
+
+	keys := make(map[string]bool)
+	for key := range mapA {
+		keys[key] = true
+	}
+
+	for key := range mapB {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		nested := key
+		if len(field) != 0 {
+			nested = field + "." + key
+		}
+
+		diffValues(nested, mapA[key], mapB[key], diffs)
+	}
+}
+
+// CheckDrift returns the fields of the config that drifted from the baseline, the `configDrift.ignoredFields`
+// (and the fields nested in them) are left out.
+func (conf *AppConfigType) CheckDrift(baseline *AppConfigType) []ConfigDiff {
+	drift := []ConfigDiff{}
+
+	for _, diff := range Diff(baseline, conf) {
+		ignored := false
+		for _, field := range conf.ConfigDrift.IgnoredFields {
+			if diff.Field == field || strings.HasPrefix(diff.Field, field+".") {
+				ignored = true
+				break
+			}
+		}
+
+		if !ignored {
+			drift = append(drift, diff)
+		}
+	}
+
+	return drift
+}
+
+// LoadBaseline reads the baseline config file on top of a copy of the conf, so the fields missing from
+// the file (e.g. the ones read from the env) are not reported as drifting.
+func (conf *AppConfigType) LoadBaseline(path string) (*AppConfigType, error) {
+	copied := *conf
+	copied.GormConfig = nil
+
+	b, err := json.Marshal(&copied)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := &AppConfigType{}
+	if err := json.Unmarshal(b, baseline); err != nil {
+		return nil, err
+	}
+
+	if b, err = ReadConfigFile(path); err != nil {
+		return nil, err
+	}
+
+	if err := UnmarshalConfig(b, baseline); err != nil {
+		return nil, err
+	}
+
+	baseline.VersionInfo = parseVersion(baseline.Version)
+	return baseline, nil
+}
+
+// checkBaselineDrift reports the drift of the conf from the `configDrift.baseline`, an error is only
+// returned when the severity of the drift is `error`.
+func checkBaselineDrift(conf *AppConfigType) error {
+	if len(conf.ConfigDrift.Baseline) == 0 {
+		return nil
+	}
+
+	baseline, err := conf.LoadBaseline(conf.ConfigDrift.Baseline)
+	if err != nil {
+		return fmt.Errorf("error: loading the baseline config: %w", err)
+	}
+
+	drift := conf.CheckDrift(baseline)
+	if len(drift) == 0 {
+		return nil
+	}
+
+	problems := make([]string, 0, len(drift))
+	for _, diff := range drift {
+		problems = append(problems, fmt.Sprintf("%s is %v instead of %v", diff.Field, diff.Running, diff.Baseline))
+	}
+
+	if conf.ConfigDrift.Severity == "error" {
+		return fmt.Errorf("error: the config drifted from the baseline (%s)", strings.Join(problems, "; "))
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "warning: the config drifted from the baseline, %s\n", problem)
+	}
+
+	return nil
+}
+
+// ErrDuplicateKey is returned by the CheckDuplicateKeys when a key is repeated within an object.
+var ErrDuplicateKey = errors.New("error: duplicate config key")
+
+// FindDuplicateKeys scans the tokens of the JSON b for the keys that are repeated within the same object,
+// the json.Unmarshal silently keeps the last of them. The keys are compared case-insensitively since the
+// json.Unmarshal matches the fields that way (e.g. `dbPool` and `DbPool` set the same field). The keys are
+// returned as their dotted path (e.g. `dbPool.connMaxLifetimeSeconds`) in the order they were found.
+func FindDuplicateKeys(b []byte) ([]string, error) {
+	type frame struct {
+		object  bool
+		path    string
+		keys    map[string]bool
+		key     string
+		wantKey bool
+		index   int
+	}
+
+	stack := []*frame{}
+	duplicates := []string{}
+
+	// childPath is the path of the value that is about to be read within the top frame.
+	childPath := func() string {
+		if len(stack) == 0 {
+			return ""
+		}
+
+		top := stack[len(stack)-1]
+		if !top.object {
+			return fmt.Sprintf("%s[%d]", top.path, top.index)
+		}
+
+		if len(top.path) == 0 {
+			return top.key
+		}
+
+		return top.path + "." + top.key
+	}
+
+	// consumed moves the top frame past the value that was just read.
+	consumed := func() {
+		if len(stack) == 0 {
+			return
+		}
+
+		if top := stack[len(stack)-1]; top.object {
+			top.wantKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &frame{object: true, path: childPath(), keys: map[string]bool{}, wantKey: true})
+			continue
+		case json.Delim('['):
+			stack = append(stack, &frame{path: childPath()})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			consumed()
+			continue
+		}
+
+		if top := len(stack) - 1; top >= 0 && stack[top].object && stack[top].wantKey {
+			key := tok.(string)
+			stack[top].key, stack[top].wantKey = key, false
+
+			if stack[top].keys[strings.ToLower(key)] {
+				duplicates = append(duplicates, childPath())
+			}
+
+			stack[top].keys[strings.ToLower(key)] = true
+			continue
+		}
+
+		consumed()
+	}
+
+	return duplicates, nil
+}
+
+// CheckDuplicateKeys reports the keys repeated in the JSON b per the mode (see the `duplicateKeyMode`),
+// they are returned as warnings unless the mode is `error`.
+func CheckDuplicateKeys(b []byte, mode string) (warnings []string, err error) {
+	duplicates, err := FindDuplicateKeys(b)
+	if err != nil || len(duplicates) == 0 {
+		return nil, err
+	}
+
+	if mode == "error" {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateKey, strings.Join(duplicates, ", "))
+	}
+
+	for _, key := range duplicates {
+		warnings = append(warnings, fmt.Sprintf("the config key %s is duplicated, its last value is used", key))
+	}
+
+	return warnings, nil
+}
+
+// configField returns the field of the struct typ the JSON key is decoded into, the key is matched
+// case-insensitively just like the json.Unmarshal does.
+func configField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && len(name) == 0 {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				if found, ok := configField(embedded, key); ok {
+					return found, true
+				}
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// collectUnusedKeys walks the raw JSON val along the typ it is decoded into.
+func collectUnusedKeys(path string, val any, typ reflect.Type, unused *[]string) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	join := func(key string) string {
+		if len(path) == 0 {
+			return key
+		}
+
+		return path + "." + key
+	}
+
+	switch val := val.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			switch typ.Kind() {
+			case reflect.Struct:
+				field, ok := configField(typ, key)
+				if !ok {
+					*unused = append(*unused, join(key))
+					continue
+				}
+
+				collectUnusedKeys(join(key), val[key], field.Type, unused)
+			case reflect.Map:
+				collectUnusedKeys(join(key), val[key], typ.Elem(), unused)
+			}
+		}
+	case []any:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return
+		}
+
+		for i, elem := range val {
+			collectUnusedKeys(fmt.Sprintf("%s[%d]", path, i), elem, typ.Elem(), unused)
+		}
+	}
+}
+
+// FindUnusedKeys returns the dotted paths of the keys of the JSON b that do not map to any field of the
+// AppConfigType, the json.Unmarshal silently ignores them.
+func FindUnusedKeys(b []byte) ([]string, error) {
+	var raw any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	unused := []string{}
+	collectUnusedKeys("", raw, reflect.TypeOf(AppConfigType{}), &unused)
+
+	return unused, nil
+}
+
+// DeprecatedKey describes a key of the config that is on its way out, the Replacement (if any) tells the
+// operator where its value goes now. The key is rejected from the RemovedIn version of the app (e.g.
+// `1.0.0-build`) on.
+type DeprecatedKey struct {
+	Replacement string
+	RemovedIn   string
+}
+
+// ErrRemovedKey is returned by the CheckDeprecatedKeys when the config still has a removed key.
+var ErrRemovedKey = errors.New("error: removed config key")
+
+// DeprecatedKeys maps the dotted path of the deprecated keys of the app_config.json (e.g. `dbPool.prePing`)
+// to their deprecation, a key is added here whenever it is renamed or dropped.
+var DeprecatedKeys = map[string]DeprecatedKey{}
+
+// hasKey reports whether the dotted path is set in the raw config, the keys are matched
+// case-insensitively like the json.Unmarshal does.
+func hasKey(raw map[string]any, path string) bool {
+	key, rest, nested := strings.Cut(path, ".")
+
+	for name, val := range raw {
+		if !strings.EqualFold(name, key) {
+			continue
+		}
+
+		if !nested {
+			return true
+		}
+
+		if raw, isObject := val.(map[string]any); isObject && hasKey(raw, rest) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckDeprecatedKeys reports the DeprecatedKeys that are set in the JSON b, they are returned as warnings
+// with their replacement until the version of the app reaches their RemovedIn, they are an error from then on.
+func CheckDeprecatedKeys(b []byte, version *AppVersion) (warnings []string, err error) {
+	raw := map[string]any{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(DeprecatedKeys))
+	for key := range DeprecatedKeys {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	removed := []string{}
+
+	for _, key := range keys {
+		if !hasKey(raw, key) {
+			continue
+		}
+
+		deprecated := DeprecatedKeys[key]
+
+		guidance := "it is no longer used and must be removed"
+		if len(deprecated.Replacement) != 0 {
+			guidance = fmt.Sprintf("move its value to the %s", deprecated.Replacement)
+		}
+
+		if len(deprecated.RemovedIn) != 0 && version != nil && version.Compare(parseVersion(deprecated.RemovedIn)) >= 0 {
+			removed = append(removed, fmt.Sprintf("%s was removed in %s, %s", key, deprecated.RemovedIn, guidance))
+			continue
+		}
+
+		warning := fmt.Sprintf("the config key %s is deprecated, %s", key, guidance)
+		if len(deprecated.RemovedIn) != 0 {
+			warning += fmt.Sprintf(" before the %s", deprecated.RemovedIn)
+		}
+
+		warnings = append(warnings, warning)
+	}
+
+	if len(removed) != 0 {
+		return warnings, fmt.Errorf("%w: %s", ErrRemovedKey, strings.Join(removed, "; "))
+	}
+
+	return warnings, nil
+}
+
+// ErrConfigType is returned by the UnmarshalConfig when a value of the config is not of the type of its field.
+var ErrConfigType = errors.New("error: invalid config value")
+
+// UnmarshalConfig unmarshals the config b into v, a value that is not of the type of its field is reported
+// with the ErrConfigType naming the field, the expected type and the offending value instead of the cryptic
+// json.UnmarshalTypeError.
+func UnmarshalConfig(b []byte, v any) error {
+	err := json.Unmarshal(b, v)
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %s must be %s (%s) but got the %s %s", ErrConfigType,
+		typeErr.Field, describeKind(typeErr.Type), typeErr.Type, typeErr.Value, offendingValue(b, typeErr.Offset))
+}
+
+// describeKind describes the JSON value expected for the type t.
+func describeKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	}
+
+	return "an object"
+}
+
+// offendingValue returns the value of the config b that ends at the offset, the offset of a
+// json.UnmarshalTypeError is right past the value it failed to unmarshal.
+func offendingValue(b []byte, offset int64) string {
+	if offset <= 0 || offset > int64(len(b)) {
+		return ""
+	}
+
+	raw := strings.TrimSpace(string(b[:offset]))
+
+	// A string may contain any of the delimiters, so its opening quote is looked up instead.
+	if strings.HasSuffix(raw, `"`) {
+		for i := len(raw) - 2; i >= 0; i-- {
+			if raw[i] == '"' && (i == 0 || raw[i-1] != '\\') {
+				return raw[i:]
+			}
+		}
+
+		return raw
+	}
+
+	start := strings.LastIndexAny(raw, ":,[") + 1
+	return strings.TrimSpace(raw[start:])
+}
+
+// ReadConfigFile reads the config file at the path, a permission error is wrapped in the ErrConfigPermission
+// with the permissions of the file and the effective uid of the process so that the operator knows what to fix.
+func ReadConfigFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrPermission) {
+		mode := "unknown"
+		if info, statErr := os.Stat(path); statErr == nil {
+			mode = info.Mode().Perm().String()
+		}
+
+		return nil, fmt.Errorf("%w (%s has the mode %s, it must be readable by the uid %d, e.g. chmod 0644 or chown it to the uid)",
+			ErrConfigPermission, path, mode, os.Geteuid())
+	}
+
+	return b, err
+}
+
+// defaultConfig returns the config the app_config.json is read into, its sections are never nil.
+func defaultConfig() *AppConfigType {
+	return &AppConfigType{
+		Settings:        &settingsConfig{},
+		DynamicFlags:    &dynamicFlagsConfig{},
+		Logging:         &loggingConfig{},
+		SecurityHeaders: &securityHeadersConfig{},
+		DegradedMode:    &degradedModeConfig{},
+		PoolSaturation:  &poolSaturationConfig{},
+		WebhookDedup:    &webhookDedupConfig{},
+		WebhookBatching: &webhookBatchingConfig{},
+		DbPool:          &dbPoolConfig{},
+		HttpClient:      &httpClientConfig{},
+		SMTP:            &smtpConfig{Retry: &RetryPolicy{}},
+		MigrationLock:   &migrationLockConfig{},
+		IndexAdvisor:    &indexAdvisorConfig{},
+		AuditCompaction: &auditCompactionConfig{},
+		OrphanCleanup:   &orphanCleanupConfig{},
+		ConfigDrift:     &configDriftConfig{},
+		GormConfig:      &gorm.Config{},
+	}
+}
+
+// fillDefaults sets the sections of the conf that were set to null (e.g. `"smtp": null`) back to the
+// ones of the defaultConfig, the rules and the subsystems expect them to be set.
+func fillDefaults(conf *AppConfigType) {
+	fillStruct(reflect.ValueOf(conf).Elem(), reflect.ValueOf(defaultConfig()).Elem())
+}
+
+func fillStruct(val, defaults reflect.Value) {
+	for i := 0; i < val.NumField(); i++ {
+		field, def := val.Field(i), defaults.Field(i)
+		if !field.CanSet() || field.Kind() != reflect.Pointer || def.IsNil() {
+			continue
+		}
+
+		if field.IsNil() {
+			field.Set(def)
+			continue
+		}
+
+		if field.Elem().Kind() == reflect.Struct {
+			fillStruct(field.Elem(), def.Elem())
+		}
+	}
+}
+
+// loadConfig is the function that will be called by `AppConfig` to load the app_config.json file and parse its
+// content to fit into the appConfigType struct. This can be called by any batch codes that modifies the
+// app_config.json at runtime to rehydrate the loaded config.
+func loadConfig() {
+	conf := defaultConfig()
+
+	b, err := ReadConfigFile(config.DEFAULT)
+	if errors.Is(err, ErrConfigPermission) {
+		fmt.Fprintf(os.Stderr, "%s", err.Error())
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading app_config.json: %s", err.Error())
+		os.Exit(1)
+	}
+
+	err = UnmarshalConfig(b, &conf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error unmarshaling app_config.json: %s", err.Error())
+		os.Exit(1)
+	}
+
+	fillDefaults(conf)
+	conf.recordSources(b, SOURCE_FILE)
+	base = conf
+
+	// The keys are only checked once the config is loaded since the mode is part of it.
+	duplicateWarnings, err := CheckDuplicateKeys(b, conf.DuplicateKeyMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s", err.Error())
+		os.Exit(1)
+	}
+
+	for _, warning := range duplicateWarnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	if conf.WarnUnusedKeys {
+		unused, err := FindUnusedKeys(b)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s", err.Error())
+			os.Exit(1)
+		}
+
+		for _, key := range unused {
+			fmt.Fprintf(os.Stderr, "warning: the config key %s does not map to any field of the config and is ignored\n", key)
+		}
+	}
+
+	conf.VersionInfo = parseVersion(conf.Version)
+
+	deprecationWarnings, err := CheckDeprecatedKeys(b, conf.VersionInfo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s", err.Error())
+		os.Exit(1)
+	}
+
+	for _, warning := range deprecationWarnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	loc, err := time.LoadLocation(conf.Timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading the timezone: %s", err.Error())
+		os.Exit(1)
+	}
+
+	// gorm stamps the CreatedAt/UpdatedAt with the NowFunc, it defaults to the local time of
+	// the server which is not necessarily the configured timezone. The timezone can be overridden
+	// later on, so the location is looked up on every call.
+	conf.location = loc
+	conf.GormConfig.NowFunc = func() time.Time {
+		return time.Now().In(AppConfig().Location())
+	}
+
+	applyEnv(conf)
+
+	if err := CheckRequiredEnv(conf); err != nil {
+		fmt.Fprintf(os.Stderr, "%s", err.Error())
+		os.Exit(1)
+	}
+
+	for _, admin := range conf.AdminUsers {
+		admin.Password = os.ExpandEnv(admin.Password)
+	}
+
+	conf, err = withOverrides(conf, overrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error applying the config overrides: %s", err.Error())
+		os.Exit(1)
+	}
+
+	warnings, err := conf.Validate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s", err.Error())
+		os.Exit(1)
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	if err := checkBaselineDrift(conf); err != nil {
+		fmt.Fprintf(os.Stderr, "%s", err.Error())
+		os.Exit(1)
+	}
+
+	loaded.Store(conf)
+}
+
+// envOr returns the value of the environment variable, or the val (from the file) when it is not set.
+// Some orchestrators set the missing variables to an empty string (e.g. `MYSQL_USER=`), an empty value
+// is then treated as unset unless the TreatEmptyEnvAsValue of the conf is set. The field is the name of
+// the field the value is set to, it is recorded as coming from the env.
+func envOr(conf *AppConfigType, field, name, val string) string {
+	if env, ok := os.LookupEnv(name); ok && (len(env) != 0 || conf.TreatEmptyEnvAsValue) {
+		conf.setSource(field, SOURCE_ENV)
+		return env
+	}
+
+	return val
+}
+
+// applyEnv sets the fields of the conf that are taken from the environment variables.
+func applyEnv(conf *AppConfigType) {
+	conf.FbClientId = envOr(conf, "FbClientId", "FB_CLIENT_ID", conf.FbClientId)
+	conf.FbClientSecret = envOr(conf, "FbClientSecret", "FB_CLIENT_SECRET", conf.FbClientSecret)
+	conf.FbSdkVersion = envOr(conf, "FbSdkVersion", "FB_SDK_VERSION", conf.FbSdkVersion)
+	conf.FbRedirectUri = envOr(conf, "FbRedirectUri", "FB_REDIRECT_URI", conf.FbRedirectUri)
+
+	conf.FbBusinessClientId = envOr(conf, "FbBusinessClientId", "FB_BUSINESS_CLIENT_ID", conf.FbBusinessClientId)
+	conf.FbBusinessClientSecret = envOr(conf, "FbBusinessClientSecret", "FB_BUSINESS_CLIENT_SECRET", conf.FbBusinessClientSecret)
+	conf.FbBusinessClientScope = envOr(conf, "FbBusinessClientScope", "FB_BUSINESS_CLIENT_SCOPE", conf.FbBusinessClientScope)
+
+	conf.ServerAddr = envOr(conf, "ServerAddr", "SERVER_ADDR", conf.ServerAddr)
+	conf.ServerProto = envOr(conf, "ServerProto", "SERVER_PROTO", conf.ServerProto)
+	conf.ServerCertFile = envOr(conf, "ServerCertFile", "SERVER_CERT_FILE", conf.ServerCertFile)
+	conf.ServerKeyFile = envOr(conf, "ServerKeyFile", "SERVER_KEY_FILE", conf.ServerKeyFile)
+	conf.ServerPassphrase = envOr(conf, "ServerPassphrase", "SERVER_PASSPHRASE", conf.ServerPassphrase)
+	conf.MetricsAddr = envOr(conf, "MetricsAddr", "METRICS_ADDR", conf.MetricsAddr)
+	conf.BuildCommit = envOr(conf, "BuildCommit", "BUILD_COMMIT", conf.BuildCommit)
+	conf.Environment = envOr(conf, "Environment", "ENV", conf.Environment)
+
+	conf.MysqlUser = envOr(conf, "MysqlUser", "MYSQL_USER", conf.MysqlUser)
+	conf.MysqlPassword = envOr(conf, "MysqlPassword", "MYSQL_PASSWORD", conf.MysqlPassword)
+	conf.MysqlType = envOr(conf, "MysqlType", "MYSQL_TYPE", conf.MysqlType)
+	conf.MysqlSock = envOr(conf, "MysqlSock", "MYSQL_SOCK", conf.MysqlSock)
+	conf.MysqlAddr = envOr(conf, "MysqlAddr", "MYSQL_ADDR", conf.MysqlAddr)
+	conf.MysqlDbName = envOr(conf, "MysqlDbName", "MYSQL_DB_NAME", conf.MysqlDbName)
+	conf.MysqlFlags = envOr(conf, "MysqlFlags", "MYSQL_FLAGS", conf.MysqlFlags)
+
+	if hosts := os.Getenv("MYSQL_HOSTS"); len(hosts) != 0 {
+		conf.MysqlHosts = strings.Split(hosts, ",")
+		conf.setSource("MysqlHosts", SOURCE_ENV)
+	}
+
+	if replicas := os.Getenv("MYSQL_REPLICAS"); len(replicas) != 0 {
+		conf.MysqlReplicas = strings.Split(replicas, ",")
+		conf.setSource("MysqlReplicas", SOURCE_ENV)
+	}
+
+	conf.InuseDataSource = envOr(conf, "InuseDataSource", "INUSE_DATA_SOURCE", conf.InuseDataSource)
+	conf.DataEncryptionKey = envOr(conf, "DataEncryptionKey", "DATA_ENCRYPTION_KEY", conf.DataEncryptionKey)
+
+	if conf.SMTP != nil {
+		conf.SMTP.Password = envOr(conf, "SMTP.Password", "SMTP_PASSWORD", conf.SMTP.Password)
+	}
+}
+
+// ApplyOverrides replaces the overrides of the loaded config with the provided key-value pairs, a key must
+// be the name of a field in the AppConfigType (e.g. `Message`) and its value must be the JSON encoding of
+// the field. The overrides take precedence over both the app_config.json and the environment variables,
+// a field that is no longer overridden goes back to its value from them. The overrides are rejected when
+// the config is no longer valid with them, a section set to null goes back to its defaults.
+func ApplyOverrides(kv map[string]json.RawMessage) error {
+	AppConfig()
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	fresh := make(map[string]json.RawMessage, len(kv))
+	for key, val := range kv {
+		fresh[key] = val
+	}
+
+	// Make sure that the overrides fits the AppConfigType before keeping them, otherwise a single bad
+	// value would prevent the config from being reloaded.
+	next, err := withOverrides(base, fresh)
+	if err != nil {
+		return fmt.Errorf("error: invalid config override (%s)", err.Error())
+	}
+
+	if _, err := next.Validate(); err != nil {
+		return err
+	}
+
+	overrides = fresh
+	loaded.Store(next)
+	return nil
+}
+
+// OnSectionReload registers the apply func of the named section (e.g. `Logging`), it is called with the
+// config once the section was reloaded by ReloadSection.
+func OnSectionReload(name string, apply func(conf *AppConfigType)) {
+	sectionAppliers[strings.ToLower(name)] = append(sectionAppliers[strings.ToLower(name)], apply)
+}
+
+// ReloadSection re-reads the named top-level section (e.g. `Features`) of the app_config.json and applies
+// it, the rest of the config is left untouched so that the subsystems configured by the other sections
+// (e.g. the pool of the database) keep their state. The overrides of the section still take precedence
+// and the config must still be valid with the reloaded section, otherwise it is not applied.
+func ReloadSection(name string) error {
+	conf := AppConfig()
+
+	field, ok := reflect.TypeOf(*conf).FieldByNameFunc(func(field string) bool {
+		return strings.EqualFold(field, name)
+	})
+
+	if !ok || !field.IsExported() {
+		return fmt.Errorf("%w: %s", ErrUnknownSection, name)
+	}
+
+	b, err := ReadConfigFile(config.DEFAULT)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: keeping the current %s config, the config file could not be read (%s)\n", field.Name, err.Error())
+		scheduleReloadRetry(field.Name, time.Duration(conf.ReloadRetrySeconds)*time.Second)
+		return fmt.Errorf("%w: %w", ErrConfigUnavailable, err)
+	}
+
+	sections := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(b, &sections); err != nil {
+		return err
+	}
+
+	// The env-only fields (e.g. the MysqlUser) are never in the file, reloading them would wipe them.
+	raw, found := lookupSection(sections, field.Name)
+	if !found {
+		return fmt.Errorf("%w: %s is not in the config file", ErrUnknownSection, name)
+	}
+
+	val := reflect.New(field.Type)
+	if field.Type.Kind() == reflect.Pointer {
+		val.Elem().Set(reflect.New(field.Type.Elem()))
+	}
+
+	if err := UnmarshalConfig(raw, val.Interface()); err != nil {
+		return err
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	nextBase := base.clone()
+	reflect.ValueOf(nextBase).Elem().FieldByIndex(field.Index).Set(val.Elem())
+	fillDefaults(nextBase)
+
+	next, err := withOverrides(nextBase, overrides)
+	if err != nil {
+		return err
+	}
+
+	if _, err := next.Validate(); err != nil {
+		return err
+	}
+
+	base = nextBase
+	loaded.Store(next)
+
+	for _, apply := range sectionAppliers[strings.ToLower(field.Name)] {
+		apply(next)
+	}
+
+	return nil
+}
+
+// scheduleReloadRetry retries the reload of the section after the delay, unless a retry of it is already
+// pending. A retry that fails to read the config file again schedules the next one.
+func scheduleReloadRetry(name string, delay time.Duration) {
+	if delay == 0 {
+		return
+	}
+
+	reloadRetriesMu.Lock()
+	defer reloadRetriesMu.Unlock()
+
+	if _, pending := reloadRetries[name]; pending {
+		return
+	}
+
+	reloadRetries[name] = afterFunc(delay, func() {
+		reloadRetriesMu.Lock()
+		delete(reloadRetries, name)
+		reloadRetriesMu.Unlock()
+
+		if err := ReloadSection(name); err != nil && !errors.Is(err, ErrConfigUnavailable) {
+			fmt.Fprintf(os.Stderr, "warning: the retried reload of the %s config failed (%s)\n", name, err.Error())
+		}
+	})
+}
+
+// lookupSection returns the raw value of the section, its key is matched case-insensitively just like
+// the json.Unmarshal does.
+func lookupSection(sections map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	for key, raw := range sections {
+		if strings.EqualFold(key, name) {
+			return raw, true
+		}
+	}
+
+	return nil, false
+}
+
+// withOverrides returns a copy of the conf with the overrides applied on top, the overridden fields are
+// decoded into new values so that the conf itself is left untouched.
+func withOverrides(conf *AppConfigType, overrides map[string]json.RawMessage) (*AppConfigType, error) {
+	next := conf.clone()
+	if len(overrides) == 0 {
+		return next, nil
+	}
+
+	val := reflect.ValueOf(next).Elem()
+
+	for key, raw := range overrides {
+		field, ok := configField(val.Type(), key)
+		if !ok {
+			continue
+		}
+
+		// The current value is decoded first, an override of a section only replaces the keys it sets.
+		fresh := reflect.New(field.Type)
+		if current, err := json.Marshal(val.FieldByIndex(field.Index).Interface()); err == nil {
+			json.Unmarshal(current, fresh.Interface())
+		}
+
+		if err := json.Unmarshal(raw, fresh.Interface()); err != nil {
+			return nil, err
+		}
+
+		val.FieldByIndex(field.Index).Set(fresh.Elem())
+	}
+
+	fillDefaults(next)
+
+	if next.Timezone != conf.Timezone {
+		loc, err := time.LoadLocation(next.Timezone)
+		if err != nil {
+			return nil, err
+		}
+
+		next.location = loc
+	}
+
+	b, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	next.recordSources(b, SOURCE_OVERRIDE)
+	return next, nil
+}
+
+const (
+	// The sources of the values of the config, see the Provenance.
+	SOURCE_DEFAULT  = "default"
+	SOURCE_FILE     = "file"
+	SOURCE_ENV      = "env"
+	SOURCE_OVERRIDE = "override"
+)
+
+// provenanceMu guards the provenance of the loaded config, the AppConfigType is copied around by value
+// so it cannot hold the mutex itself.
+var provenanceMu sync.Mutex
+
+// clone returns a shallow copy of the conf with its own provenance.
+func (conf *AppConfigType) clone() *AppConfigType {
+	provenanceMu.Lock()
+	defer provenanceMu.Unlock()
+
+	next := *conf
+	next.provenance = maps.Clone(conf.provenance)
+	return &next
+}
+
+// Clone returns a copy of the conf whose sections and maps are copied as well, so that the copy can be
+// changed without changing the conf (e.g. by the tests publishing a config of their own).
+func (conf *AppConfigType) Clone() *AppConfigType {
+	next := conf.clone()
+	cloneStruct(reflect.ValueOf(next).Elem())
+	return next
+}
+
+func cloneStruct(val reflect.Value) {
+	pkgPath := reflect.TypeOf(AppConfigType{}).PkgPath()
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		if !field.CanSet() || (field.Kind() == reflect.Pointer || field.Kind() == reflect.Map) && field.IsNil() {
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.Map:
+			m := reflect.MakeMapWithSize(field.Type(), field.Len())
+			for iter := field.MapRange(); iter.Next(); {
+				m.SetMapIndex(iter.Key(), iter.Value())
+			}
+			field.Set(m)
+
+		case field.Kind() == reflect.Pointer && field.Elem().Kind() == reflect.Struct && field.Type().Elem().PkgPath() == pkgPath:
+			section := reflect.New(field.Type().Elem())
+			section.Elem().Set(field.Elem())
+			cloneStruct(section.Elem())
+			field.Set(section)
+		}
+	}
+}
+
+func (conf *AppConfigType) setSource(field, source string) {
+	provenanceMu.Lock()
+	defer provenanceMu.Unlock()
+
+	if conf.provenance == nil {
+		conf.provenance = make(map[string]string)
+	}
+
+	conf.provenance[field] = source
+}
+
+// recordSources records the source of the fields of the top-level keys of the JSON b.
+func (conf *AppConfigType) recordSources(b []byte, source string) {
+	keys := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return
+	}
+
+	for key := range keys {
+		if field, ok := configField(reflect.TypeOf(*conf), key); ok {
+			conf.setSource(field.Name, source)
+		}
+	}
+}
+
+// Provenance returns where the value of every field of the config came from, the `file`, the `env`, the
+// `override` (see the ApplyOverrides) or the `default` of the fields left unset. A field set by a single
+// environment variable within a section is keyed by its dotted name (e.g. `SMTP.Password`).
+func (conf *AppConfigType) Provenance() map[string]string {
+	provenanceMu.Lock()
+	defer provenanceMu.Unlock()
+
+	typ := reflect.TypeOf(*conf)
+	provenance := make(map[string]string, typ.NumField())
+
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.IsExported() {
+			provenance[field.Name] = SOURCE_DEFAULT
+		}
+	}
+
+	for field, source := range conf.provenance {
+		provenance[field] = source
+	}
+
+	return provenance
+}
+
+func Dsn() string {
+	return AppConfig().mysqlDsn()
+}
+
+// mysqlDsn assembles the DSN of the primary from the MYSQL_* environment variables.
+func (conf *AppConfigType) mysqlDsn() string {
+	connAddr := ""
+
+	connType := conf.MysqlType
+
+	if conf.MysqlType == "tcp" && len(conf.MysqlHosts) != 0 {
+		connType = MYSQL_FAILOVER_NET
+		connAddr = strings.Join(conf.MysqlHosts, ",")
+	} else if conf.MysqlType == "tcp" {
+		connAddr = conf.MysqlAddr
+	} else if conf.MysqlType == "unix" {
+		connAddr = conf.MysqlSock
+	}
+
+	return fmt.Sprintf(
+		`%s%s@%s(%s)/%s?%s`,
+		conf.MysqlUser,
+		":"+conf.MysqlPassword,
+		connType,
+		connAddr,
+		conf.MysqlDbName,
+		conf.MysqlFlags,
+	)
+}
+
+// ReplicaDsn returns the DSN of the replica at the (tcp) addr, the replica shares the credentials, the
+// database and the flags of the primary.
+func ReplicaDsn(addr string) string {
+	conf := AppConfig()
+
+	return fmt.Sprintf(
+		`%s:%s@tcp(%s)/%s?%s`,
+		conf.MysqlUser,
+		conf.MysqlPassword,
+		addr,
+		conf.MysqlDbName,
+		conf.MysqlFlags,
+	)
+}
+
+// SwapConfig publishes the conf as the loaded config and returns the one it replaced, the tests use it to
+// run with a config of their own. The config is otherwise only changed by the overrides and the reloads.
+func SwapConfig(conf *AppConfigType) *AppConfigType {
+	AppConfig()
+	return loaded.Swap(conf)
+}
+
+// AppConfig returns the loaded config, the config is loaded on the first call.
+func AppConfig() *AppConfigType {
+	if conf := loaded.Load(); conf != nil {
+		return conf
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	if loaded.Load() == nil {
 		loadConfig()
 	}
 
-	return loadedConfig
+	return loaded.Load()
 }
@@ -0,0 +1,38 @@
+package loader
+
+import (
+	"testing"
+	"time"
+)
+
+// SetAfterFunc overrides the scheduling of the reload retries, the previous func is returned so that the
+// tests can restore it.
+func SetAfterFunc(fn func(d time.Duration, f func()) *time.Timer) func(d time.Duration, f func()) *time.Timer {
+	bak := afterFunc
+	afterFunc = fn
+	return bak
+}
+
+// ApplyEnv sets the fields of the conf that are taken from the environment variables.
+func ApplyEnv(conf *AppConfigType) {
+	applyEnv(conf)
+}
+
+// RestoreConfig puts the loaded config back once the test is done, for the tests swapping it with the
+// ApplyOverrides or the ReloadSection.
+func RestoreConfig(t testing.TB) {
+	AppConfig()
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	conf, bakBase, bakOverrides := loaded.Load(), base, overrides
+
+	t.Cleanup(func() {
+		configMu.Lock()
+		defer configMu.Unlock()
+
+		loaded.Store(conf)
+		base, overrides = bakBase, bakOverrides
+	})
+}
@@ -0,0 +1,71 @@
+// This package creates the structured logger of the app from the `logging` section of the
+// app config, every handler defined here wraps another slog.Handler.
+
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+var (
+	logger atomic.Pointer[slog.Logger]
+	once   sync.Once
+
+	// output is where the records of the app logger are written.
+	output io.Writer = os.Stderr
+)
+
+func init() {
+	// The reloaded logger is swapped in, the records being logged with the previous one are not affected.
+	loader.OnSectionReload("Logging", func(conf *loader.AppConfigType) {
+		if logger.Load() != nil {
+			reloaded := slog.New(NewHandler(output))
+			logger.Store(reloaded)
+			slog.SetDefault(reloaded)
+		}
+	})
+}
+
+// ParseLevel converts the name of a level into a slog.Level, an unknown name falls back to def.
+func ParseLevel(name string, def slog.Level) slog.Level {
+	var level slog.Level
+
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return def
+	}
+
+	return level
+}
+
+// NewHandler creates the handler of the app logger writing JSON records to w.
+func NewHandler(w io.Writer) slog.Handler {
+	conf := loader.AppConfig().Logging
+
+	var handler slog.Handler = slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: ParseLevel(conf.Level, slog.LevelInfo),
+	})
+
+	handler = NewPIIHandler(handler, conf.PIIKeys)
+	handler = NewSamplingHandler(handler, conf.SampleRate, ParseLevel(conf.SampleLevel, slog.LevelWarn))
+	return handler
+}
+
+// Logger returns the app logger, it is created on the first call and is also set as the default
+// logger of the slog package. The logger is replaced when the `logging` section is reloaded, so it
+// must not be kept around.
+func Logger() *slog.Logger {
+	once.Do(func() {
+		created := slog.New(NewHandler(output))
+		logger.Store(created)
+		slog.SetDefault(created)
+	})
+
+	return logger.Load()
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// piiHandler masks the values of the attributes whose keys are listed in the `piiKeys` of the logging
+// config, the attributes nested in groups are masked as well.
+type piiHandler struct {
+	next slog.Handler
+	keys map[string]bool
+}
+
+func NewPIIHandler(next slog.Handler, keys []string) slog.Handler {
+	if len(keys) == 0 {
+		return next
+	}
+
+	h := &piiHandler{next: next, keys: make(map[string]bool)}
+	for _, key := range keys {
+		h.keys[strings.ToLower(key)] = true
+	}
+
+	return h
+}
+
+// MaskPII masks a sensitive value while keeping a hint of it, `alice@example.com` becomes
+// `a***@example.com` and `09171231234` becomes `***1234`.
+func MaskPII(val string) string {
+	if at := strings.LastIndex(val, "@"); at > 0 {
+		return val[:1] + "***" + val[at:]
+	}
+
+	if len(val) > 4 {
+		return "***" + val[len(val)-4:]
+	}
+
+	return "***"
+}
+
+func (h *piiHandler) mask(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+
+	if attr.Value.Kind() == slog.KindGroup {
+		attrs := attr.Value.Group()
+		masked := make([]slog.Attr, len(attrs))
+
+		for i, a := range attrs {
+			masked[i] = h.mask(a)
+		}
+
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(masked...)}
+	}
+
+	if h.keys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, MaskPII(attr.Value.String()))
+	}
+
+	return attr
+}
+
+func (h *piiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *piiHandler) Handle(ctx context.Context, r slog.Record) error {
+	masked := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+
+	r.Attrs(func(attr slog.Attr) bool {
+		masked.AddAttrs(h.mask(attr))
+		return true
+	})
+
+	return h.next.Handle(ctx, masked)
+}
+
+func (h *piiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		masked[i] = h.mask(attr)
+	}
+
+	return &piiHandler{next: h.next.WithAttrs(masked), keys: h.keys}
+}
+
+func (h *piiHandler) WithGroup(name string) slog.Handler {
+	return &piiHandler{next: h.next.WithGroup(name), keys: h.keys}
+}
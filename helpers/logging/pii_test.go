@@ -0,0 +1,43 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_piiAttributesShouldBeMasked(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	logger := slog.New(logging.NewPIIHandler(slog.NewJSONHandler(buf, nil), []string{"email", "Mobile"}))
+
+	logger.Info("signed up",
+		"email", "alice@example.com",
+		"user_id", 42,
+		slog.Group("profile", "mobile", "09171231234", "city", "Manila"),
+	)
+
+	record := map[string]any{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.Equal(t, "a***@example.com", record["email"])
+	assert.Equal(t, float64(42), record["user_id"], "A non-PII attribute must be untouched.")
+
+	profile := record["profile"].(map[string]any)
+	assert.Equal(t, "***1234", profile["mobile"], "The attributes nested in a group should be masked.")
+	assert.Equal(t, "Manila", profile["city"])
+}
+
+func Test_piiAttributesAddedWithWithShouldBeMasked(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	logger := slog.New(logging.NewPIIHandler(slog.NewJSONHandler(buf, nil), []string{"email"})).
+		With("email", "bob@example.com")
+
+	logger.Info("logged in")
+
+	assert.Contains(t, buf.String(), `"email":"b***@example.com"`)
+	assert.NotContains(t, buf.String(), "bob@example.com")
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// samplingHandler only passes 1 in `rate` records below the `below` level to the next handler, it is
+// used to keep the info level logs from overwhelming the production logs. The counter is shared by
+// the handlers derived with WithAttrs/WithGroup.
+type samplingHandler struct {
+	next    slog.Handler
+	rate    uint64
+	below   slog.Level
+	counter *atomic.Uint64
+}
+
+func NewSamplingHandler(next slog.Handler, rate uint64, below slog.Level) slog.Handler {
+	if rate <= 1 {
+		return next
+	}
+
+	return &samplingHandler{next, rate, below, &atomic.Uint64{}}
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.below && (h.counter.Add(1)-1)%h.rate != 0 {
+		return nil
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{h.next.WithAttrs(attrs), h.rate, h.below, h.counter}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{h.next.WithGroup(name), h.rate, h.below, h.counter}
+}
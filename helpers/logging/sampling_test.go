@@ -0,0 +1,40 @@
+package logging_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_infoMessagesShouldBeSampledAtTheConfiguredRate(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	logger := slog.New(logging.NewSamplingHandler(slog.NewJSONHandler(buf, nil), 5, slog.LevelWarn))
+
+	for i := 0; i < 100; i++ {
+		logger.Info("sampled")
+	}
+
+	assert.Equal(t, 20, strings.Count(buf.String(), `"msg":"sampled"`), "Only 1 in 5 info messages should be logged.")
+}
+
+func Test_warnAndErrorMessagesShouldAlwaysBeLogged(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	logger := slog.New(logging.NewSamplingHandler(slog.NewJSONHandler(buf, nil), 5, slog.LevelWarn)).With("component", "test")
+
+	for i := 0; i < 10; i++ {
+		logger.Warn("warned")
+		logger.Error("failed")
+	}
+
+	assert.Equal(t, 10, strings.Count(buf.String(), `"msg":"warned"`))
+	assert.Equal(t, 10, strings.Count(buf.String(), `"msg":"failed"`))
+}
+
+func Test_shouldParseTheConfiguredLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelWarn, logging.ParseLevel("warn", slog.LevelInfo))
+	assert.Equal(t, slog.LevelInfo, logging.ParseLevel("bogus", slog.LevelInfo), "An unknown level should fallback to the default.")
+}
@@ -0,0 +1,22 @@
+package logging_test
+
+import (
+	"testing"
+
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldScrubTheSecretsOfTheDSNs(t *testing.T) {
+	cases := map[string]string{
+		"dial erp:p@ss:w0rd@tcp(db:3306)/idream failed": "dial erp:********@tcp(db:3306)/idream failed",
+		"open mysql://erp:hunter2@db/idream":            "open mysql://erp:********@db/idream",
+		"sqlserver://db?user=erp&password=hunter2&x=1":  "sqlserver://db?user=erp&password=********&x=1",
+		"main.go:42 +0x1f":                              "main.go:42 +0x1f",
+		"the api key is k-123":                          "the api key is ********",
+	}
+
+	for s, expected := range cases {
+		assert.Equal(t, expected, logging.ScrubSecrets(s, []string{"k-123"}))
+	}
+}
@@ -0,0 +1,63 @@
+// This package holds the prometheus registry of the app, the metrics registered here are served by
+// the metrics server of the api (see `MetricsAddr`).
+
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	// RequestBodyBytes and ResponseBodyBytes are the sizes of the bodies read and written by every
+	// request, labeled by the route that handled it.
+	RequestBodyBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_body_bytes",
+		Help:    "The size of the request bodies read by the handlers.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	}, []string{"route"})
+
+	ResponseBodyBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_body_bytes",
+		Help:    "The size of the response bodies written by the handlers.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	}, []string{"route"})
+
+	// BuildInfo is always 1, its labels tell the version of the app that is exposing the metrics.
+	BuildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "erp_build_info",
+		Help: "The version, commit and environment of the running app.",
+	}, []string{"version", "commit", "environment"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestBodyBytes,
+		ResponseBodyBytes,
+		BuildInfo,
+	)
+}
+
+// ObservePayloadSizes records the body sizes of a request handled by the route.
+func ObservePayloadSizes(route string, requestBytes, responseBytes int64) {
+	RequestBodyBytes.WithLabelValues(route).Observe(float64(requestBytes))
+	ResponseBodyBytes.WithLabelValues(route).Observe(float64(responseBytes))
+}
+
+// SetBuildInfo replaces the labels of the BuildInfo with the given ones.
+func SetBuildInfo(version, commit, environment string) {
+	BuildInfo.Reset()
+	BuildInfo.WithLabelValues(version, commit, environment).Set(1)
+}
+
+// Handler returns the http.Handler exposing the metrics of the Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
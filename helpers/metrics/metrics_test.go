@@ -0,0 +1,29 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rommms07/idream-erp/helpers/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_handlerShouldExposeTheRegisteredMetrics(t *testing.T) {
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "go_goroutines", "The go collector should be registered.")
+}
+
+func Test_buildInfoShouldOnlyKeepTheLatestLabels(t *testing.T) {
+	metrics.SetBuildInfo("1.0.0", "aaaaaaa", "devel")
+	metrics.SetBuildInfo("1.0.1", "bbbbbbb", "production")
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, w.Body.String(), `erp_build_info{commit="bbbbbbb",environment="production",version="1.0.1"} 1`)
+	assert.NotContains(t, w.Body.String(), `commit="aaaaaaa"`)
+}
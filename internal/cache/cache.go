@@ -0,0 +1,107 @@
+// This package is the in-memory read-through cache of the expensive reads (e.g. the reports), the
+// concurrent misses of a same key share a single load when the `singleflight` of the app config is set
+// so that an expired entry does not stampede the database.
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// now is used to expire the entries, the tests override it.
+	now = time.Now
+)
+
+type entry struct {
+	val       any
+	expiresAt time.Time
+}
+
+// Cache keeps the loaded values for the ttl, a zero ttl keeps them until they are deleted.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	ttl     time.Duration
+	group   singleflight.Group
+}
+
+func New(ttl time.Duration) *Cache {
+	return &Cache{entries: make(map[string]*entry), ttl: ttl}
+}
+
+// Get returns the value of the key, the bool is false when the key is missing or has expired.
+func (c *Cache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+
+	if c.ttl != 0 && !now().Before(e.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return e.val, true
+}
+
+func (c *Cache) Set(key string, val any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &entry{val: val, expiresAt: now().Add(c.ttl)}
+}
+
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Load returns the cached value of the key, a miss loads the value and caches it (the errors are not
+// cached). The concurrent misses of the key share the load of the first one when the `singleflight` of
+// the app config is set.
+func Load[T any](c *Cache, key string, load func() (T, error)) (T, error) {
+	if val, ok := c.Get(key); ok {
+		return val.(T), nil
+	}
+
+	fill := func() (any, error) {
+		// Another caller may have cached the value since the Get above.
+		if val, ok := c.Get(key); ok {
+			return val, nil
+		}
+
+		val, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		c.Set(key, val)
+		return val, nil
+	}
+
+	var val any
+	var err error
+
+	if loader.AppConfig().Singleflight {
+		val, err, _ = c.group.Do(key, fill)
+	} else {
+		val, err = fill()
+	}
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return val.(T), nil
+}
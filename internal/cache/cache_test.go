@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/cache"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_concurrentMissesShouldShareASingleLoad(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Singleflight = true
+	})
+
+	c := cache.New(time.Minute)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+
+	load := func() (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	const callers = 50
+
+	var started, done sync.WaitGroup
+	started.Add(callers)
+	done.Add(callers)
+
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer done.Done()
+			started.Done()
+
+			val, err := cache.Load(c, "report:2024", load)
+			assert.Nil(t, err)
+			assert.Equal(t, 42, val)
+		}()
+	}
+
+	// The load is held until every caller missed the cache.
+	started.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	assert.Equal(t, int32(1), loads.Load(), "The loader should have run exactly once.")
+}
+
+func Test_shouldExpireTheEntriesAndNotCacheTheErrors(t *testing.T) {
+	t0 := time.Now()
+	defer cache.SetNow(func() time.Time { return t0 })()
+
+	c := cache.New(time.Minute)
+
+	_, err := cache.Load(c, "key", func() (string, error) { return "", errors.New("error: unavailable") })
+	assert.NotNil(t, err)
+
+	val, err := cache.Load(c, "key", func() (string, error) { return "first", nil })
+	assert.Nil(t, err)
+	assert.Equal(t, "first", val, "The error should not have been cached.")
+
+	val, _ = cache.Load(c, "key", func() (string, error) { return "second", nil })
+	assert.Equal(t, "first", val)
+
+	cache.SetNow(func() time.Time { return t0.Add(time.Minute) })
+
+	val, _ = cache.Load(c, "key", func() (string, error) { return "second", nil })
+	assert.Equal(t, "second", val, "The expired entry should have been loaded again.")
+}
@@ -0,0 +1,9 @@
+package cache
+
+import "time"
+
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
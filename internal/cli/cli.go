@@ -1,7 +1,31 @@
 package cli
 
+import (
+	"context"
+	"os"
+
+	"github.com/rommms07/idream-erp/internal/selftest"
+)
+
+const (
+	// SELFTEST_FLAG runs the self-test of the app and exits with its result.
+	SELFTEST_FLAG = "--selftest"
+)
+
 func Start(args []string) {
 	for _, val := range args[1:] {
+		if val == SELFTEST_FLAG {
+			os.Exit(selfTest())
+		}
+
 		println(val)
 	}
 }
+
+func selfTest() int {
+	if err := selftest.SelfTest(context.Background()); err != nil {
+		return 1
+	}
+
+	return 0
+}
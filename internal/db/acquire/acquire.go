@@ -0,0 +1,113 @@
+// This package bounds how long a statement waits for a connection of the pool, a statement made while
+// every connection is in use fails with the ErrPoolExhausted once the timeout passed instead of hanging
+// until a connection is released.
+
+package acquire
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// connKey and poolKey are the instance settings holding the acquired connection and the pool it was
+	// acquired from.
+	connKey = "acquire:conn"
+	poolKey = "acquire:pool"
+)
+
+var ErrPoolExhausted = errors.New("error: no connection of the pool was available in time")
+
+// Plugin is a gorm plugin acquiring the connection of every statement with a timeout.
+type Plugin struct {
+	timeout time.Duration
+}
+
+func New(timeout time.Duration) *Plugin {
+	return &Plugin{timeout: timeout}
+}
+
+func (p *Plugin) Name() string {
+	return "acquire"
+}
+
+// Initialize acquires the connection before the transaction of a write begins (it is begun on the
+// acquired connection) and releases it once the transaction ended. The `gorm:row` callbacks are left
+// alone since the rows are still read from the connection after the callbacks returned.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	return errors.Join(
+		callbacks.Create().Before("gorm:begin_transaction").Register("acquire:before_create", p.acquire),
+		callbacks.Create().After("gorm:commit_or_rollback_transaction").Register("acquire:after_create", release),
+		callbacks.Update().Before("gorm:begin_transaction").Register("acquire:before_update", p.acquire),
+		callbacks.Update().After("gorm:commit_or_rollback_transaction").Register("acquire:after_update", release),
+		callbacks.Delete().Before("gorm:begin_transaction").Register("acquire:before_delete", p.acquire),
+		callbacks.Delete().After("gorm:commit_or_rollback_transaction").Register("acquire:after_delete", release),
+		callbacks.Query().Before("gorm:query").Register("acquire:before_query", p.acquire),
+		callbacks.Query().After("gorm:query").Register("acquire:after_query", release),
+		callbacks.Raw().Before("gorm:raw").Register("acquire:before_raw", p.acquire),
+		callbacks.Raw().After("gorm:raw").Register("acquire:after_raw", release),
+	)
+}
+
+// swappablePool is a pool whose *sql.DB may be replaced, e.g. the Pool of the internal/db/reconnect package.
+type swappablePool interface {
+	DB() *sql.DB
+}
+
+func (p *Plugin) acquire(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+
+	// A transaction (or a prepared statement) already holds its connection.
+	pool, ok := db.Statement.ConnPool.(*sql.DB)
+	if swappable, isSwappable := db.Statement.ConnPool.(swappablePool); isSwappable {
+		pool, ok = swappable.DB(), true
+	}
+
+	if !ok {
+		return
+	}
+
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(parent, p.timeout)
+	defer cancel()
+
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+			err = fmt.Errorf("%w (waited %s)", ErrPoolExhausted, p.timeout)
+		}
+
+		db.AddError(err)
+		return
+	}
+
+	db.InstanceSet(poolKey, pool)
+	db.InstanceSet(connKey, conn)
+	db.Statement.ConnPool = conn
+}
+
+func release(db *gorm.DB) {
+	conn, ok := db.InstanceGet(connKey)
+	if !ok {
+		return
+	}
+
+	conn.(*sql.Conn).Close()
+
+	if pool, ok := db.InstanceGet(poolKey); ok {
+		db.Statement.ConnPool = pool.(gorm.ConnPool)
+	}
+}
@@ -0,0 +1,88 @@
+package acquire_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/acquire"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Order struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Status string
+}
+
+func newPoolOfOne(t *testing.T, conf *gorm.Config) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := mocks.NewGormMockWithConfig(conf)
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(acquire.New(50*time.Millisecond)))
+
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	return db, mock
+}
+
+func Test_shouldFailFastWhileThePoolIsExhausted(t *testing.T) {
+	db, mock := newPoolOfOne(t, &gorm.Config{SkipDefaultTransaction: true})
+
+	sqlDB, _ := db.DB()
+	held, err := sqlDB.Conn(context.Background())
+	assert.Nil(t, err)
+
+	done := make(chan error)
+	go func() {
+		orders := []*Order{}
+		done <- db.Find(&orders).Error
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, acquire.ErrPoolExhausted)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query should have timed out waiting for a connection")
+	}
+
+	// The query goes through once the connection is released.
+	held.Close()
+	mock.ExpectQuery("SELECT \\* FROM `orders`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	orders := []*Order{}
+	assert.Nil(t, db.Find(&orders).Error)
+	assert.Len(t, orders, 1)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldBeginTheTransactionOfAWriteOnTheAcquiredConnection(t *testing.T) {
+	db, mock := newPoolOfOne(t, &gorm.Config{})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `orders`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT \\* FROM `orders`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	assert.Nil(t, db.Create(&Order{Status: "pending"}).Error)
+
+	// The connection must have been released back to the pool of one.
+	orders := []*Order{}
+	assert.Nil(t, db.Find(&orders).Error)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_theCancellationOfTheCallerShouldNotBeReportedAsAnExhaustedPool(t *testing.T) {
+	db, _ := newPoolOfOne(t, &gorm.Config{SkipDefaultTransaction: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	orders := []*Order{}
+	err := db.WithContext(ctx).Find(&orders).Error
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, acquire.ErrPoolExhausted)
+}
@@ -0,0 +1,291 @@
+// This package suggests the indexes that are missing from the database, it watches the slow queries
+// made through gorm and collects the columns of the ones that scan an entire table. The suggestions
+// are only logged, no index is ever created by the advisor.
+
+package advisor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/rommms07/idream-erp/internal/scheduler"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	TASK_NAME = "advise_indexes"
+
+	startKey = "index_advisor:start"
+)
+
+var (
+	// exprColumn matches the column compared in the raw SQL of a clause.Expr (e.g. `name = ?`).
+	exprColumn = regexp.MustCompile("(?i)([`\\w.]+)\\s*(?:=|<>|!=|<=|>=|<|>|\\bin\\b|\\blike\\b|\\bis\\b|\\bbetween\\b)")
+
+	_default *Advisor
+	once     sync.Once
+)
+
+func init() {
+	scheduler.Default().Register(TASK_NAME, func(ctx context.Context) error {
+		Default().Report()
+		return nil
+	})
+}
+
+// Suggestion is an index that would have avoided the full table scans of the Occurrences queries.
+type Suggestion struct {
+	Table       string
+	Columns     []string
+	Occurrences int
+}
+
+// DDL returns the statement that creates the suggested index.
+func (s *Suggestion) DDL() string {
+	quoted := make([]string, len(s.Columns))
+	for i, column := range s.Columns {
+		quoted[i] = "`" + column + "`"
+	}
+
+	return fmt.Sprintf("CREATE INDEX `idx_%s_%s` ON `%s` (%s)",
+		s.Table, strings.Join(s.Columns, "_"), s.Table, strings.Join(quoted, ", "))
+}
+
+// Advisor is a gorm plugin collecting the full table scans of the slow queries.
+type Advisor struct {
+	mu    sync.Mutex
+	scans map[string]*Suggestion
+
+	SlowQuery      time.Duration
+	Explain        bool
+	MinOccurrences int
+}
+
+func New(slowQuery time.Duration, explain bool, minOccurrences int) *Advisor {
+	return &Advisor{
+		scans:          make(map[string]*Suggestion),
+		SlowQuery:      slowQuery,
+		Explain:        explain,
+		MinOccurrences: minOccurrences,
+	}
+}
+
+// Default returns the advisor configured by the `indexAdvisor` of the app config.
+func Default() *Advisor {
+	once.Do(func() {
+		conf := loader.AppConfig().IndexAdvisor
+		_default = New(time.Duration(conf.SlowQueryMs)*time.Millisecond, conf.Explain, conf.MinOccurrences)
+	})
+
+	return _default
+}
+
+func (a *Advisor) Name() string {
+	return "index_advisor"
+}
+
+// Initialize registers the callbacks timing the queries of the db.
+func (a *Advisor) Initialize(db *gorm.DB) error {
+	err := db.Callback().Query().Before("gorm:query").Register("index_advisor:before", func(db *gorm.DB) {
+		db.InstanceSet(startKey, time.Now())
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return db.Callback().Query().After("gorm:query").Register("index_advisor:after", a.afterQuery)
+}
+
+func (a *Advisor) afterQuery(db *gorm.DB) {
+	start, ok := db.InstanceGet(startKey)
+	if !ok || db.Error != nil || len(db.Statement.Table) == 0 || time.Since(start.(time.Time)) < a.SlowQuery {
+		return
+	}
+
+	columns := whereColumns(db.Statement)
+	if len(columns) == 0 {
+		return
+	}
+
+	table := db.Statement.Table
+
+	if a.Explain {
+		scanned, err := fullScan(db, table)
+		if err != nil {
+			logging.Logger().Warn("error explaining a slow query", "table", table, "error", err)
+			return
+		}
+
+		if !scanned {
+			return
+		}
+	}
+
+	a.Observe(table, columns)
+}
+
+// explainRow holds the columns of the EXPLAIN that are needed to tell a full table scan.
+type explainRow struct {
+	Table string
+	Type  string
+}
+
+// fullScan reports whether the EXPLAIN of the statement scans the entire table.
+func fullScan(db *gorm.DB, table string) (bool, error) {
+	rows := []*explainRow{}
+
+	err := db.Session(&gorm.Session{NewDB: true}).
+		Raw("EXPLAIN "+db.Statement.SQL.String(), db.Statement.Vars...).
+		Scan(&rows).Error
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, row := range rows {
+		if row.Table == table && strings.EqualFold(row.Type, "ALL") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// whereColumns returns the columns filtered by the WHERE of the statement, in the order they appear.
+func whereColumns(stmt *gorm.Statement) []string {
+	c, ok := stmt.Clauses["WHERE"]
+	if !ok {
+		return nil
+	}
+
+	where, ok := c.Expression.(clause.Where)
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	columns := []string{}
+
+	var walk func(exprs []clause.Expression)
+
+	add := func(column any) {
+		var name string
+
+		switch column := column.(type) {
+		case clause.Column:
+			name = column.Name
+		case string:
+			name = column
+		}
+
+		name = strings.Trim(name[strings.LastIndex(name, ".")+1:], "`")
+		if len(name) == 0 || name == clause.PrimaryKey || seen[name] {
+			return
+		}
+
+		seen[name] = true
+		columns = append(columns, name)
+	}
+
+	walk = func(exprs []clause.Expression) {
+		for _, expr := range exprs {
+			switch expr := expr.(type) {
+			case clause.Eq:
+				add(expr.Column)
+			case clause.Neq:
+				add(expr.Column)
+			case clause.Gt:
+				add(expr.Column)
+			case clause.Gte:
+				add(expr.Column)
+			case clause.Lt:
+				add(expr.Column)
+			case clause.Lte:
+				add(expr.Column)
+			case clause.Like:
+				add(expr.Column)
+			case clause.IN:
+				add(expr.Column)
+			case clause.AndConditions:
+				walk(expr.Exprs)
+			case clause.OrConditions:
+				walk(expr.Exprs)
+			case clause.Expr:
+				for _, match := range exprColumn.FindAllStringSubmatch(expr.SQL, -1) {
+					add(match[1])
+				}
+			}
+		}
+	}
+
+	walk(where.Exprs)
+	return columns
+}
+
+// Observe records a full table scan of the table filtered by the columns.
+func (a *Advisor) Observe(table string, columns []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := table + ":" + strings.Join(columns, ",")
+
+	s, exists := a.scans[key]
+	if !exists {
+		s = &Suggestion{Table: table, Columns: append([]string{}, columns...)}
+		a.scans[key] = s
+	}
+
+	s.Occurrences++
+}
+
+// Suggestions returns the scans that were seen at least MinOccurrences times, the most frequent first.
+func (a *Advisor) Suggestions() []*Suggestion {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	suggestions := []*Suggestion{}
+
+	for _, s := range a.scans {
+		if s.Occurrences >= a.MinOccurrences {
+			suggestions = append(suggestions, &Suggestion{s.Table, s.Columns, s.Occurrences})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Occurrences != suggestions[j].Occurrences {
+			return suggestions[i].Occurrences > suggestions[j].Occurrences
+		}
+
+		return suggestions[i].DDL() < suggestions[j].DDL()
+	})
+
+	return suggestions
+}
+
+// Report logs the suggestions and starts collecting the scans from scratch.
+func (a *Advisor) Report() []*Suggestion {
+	suggestions := a.Suggestions()
+
+	for _, s := range suggestions {
+		logging.Logger().Info("index suggestion",
+			"table", s.Table,
+			"columns", s.Columns,
+			"occurrences", s.Occurrences,
+			"ddl", s.DDL())
+	}
+
+	a.mu.Lock()
+	a.scans = make(map[string]*Suggestion)
+	a.mu.Unlock()
+
+	return suggestions
+}
@@ -0,0 +1,70 @@
+package advisor_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/advisor"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	Id         uint64 `gorm:"primaryKey"`
+	CustomerId uint64
+	Status     string
+}
+
+func Test_shouldSuggestTheColumnsOfTheRepeatedScans(t *testing.T) {
+	a := advisor.New(0, true, 3)
+
+	for i := 0; i < 4; i++ {
+		a.Observe("orders", []string{"customer_id", "status"})
+	}
+
+	a.Observe("invoices", []string{"due_at"})
+
+	suggestions := a.Report()
+
+	if assert.Len(t, suggestions, 1, "The scans seen less than MinOccurrences times must not be suggested.") {
+		assert.Equal(t, "orders", suggestions[0].Table)
+		assert.Equal(t, []string{"customer_id", "status"}, suggestions[0].Columns)
+		assert.Equal(t, 4, suggestions[0].Occurrences)
+		assert.Equal(t, "CREATE INDEX `idx_orders_customer_id_status` ON `orders` (`customer_id`, `status`)", suggestions[0].DDL())
+	}
+
+	assert.Empty(t, a.Suggestions(), "The report should start collecting the scans from scratch.")
+}
+
+func Test_shouldObserveTheSlowQueriesThatScanTheTable(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	a := advisor.New(0, true, 1)
+	assert.Nil(t, db.Use(a))
+
+	mock.ExpectQuery("SELECT \\* FROM `orders` WHERE customer_id = \\? AND `orders`.`status` = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "status"}))
+	mock.ExpectQuery("EXPLAIN SELECT \\* FROM `orders`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "table", "type", "possible_keys"}).
+			AddRow(1, "orders", "ALL", nil))
+
+	// A lookup by the primary key does not scan the table, it must not be suggested.
+	mock.ExpectQuery("SELECT \\* FROM `orders` WHERE status = \\? AND `orders`.`id` = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "status"}))
+	mock.ExpectQuery("EXPLAIN SELECT \\* FROM `orders`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "table", "type", "possible_keys"}).
+			AddRow(1, "orders", "const", "PRIMARY"))
+
+	rows := []*order{}
+	assert.Nil(t, db.Where("customer_id = ?", 1).Where(&order{Status: "pending"}).Find(&rows).Error)
+	assert.Nil(t, db.Where("status = ?", "paid").Find(&rows, 10).Error)
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	suggestions := a.Suggestions()
+
+	if assert.Len(t, suggestions, 1) {
+		assert.Equal(t, "orders", suggestions[0].Table)
+		assert.Equal(t, []string{"customer_id", "status"}, suggestions[0].Columns)
+	}
+}
@@ -0,0 +1,117 @@
+// This package stamps the records with the actor who created and last updated them, the actor is set on
+// the session of the write (e.g. `audit.WithActor(db, user.Id).Create(&order)`) or carried by its ctx
+// (see ContextWithActor).
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+const (
+	// ACTOR_KEY is the setting of the session holding the id of the actor.
+	ACTOR_KEY = "actor_id"
+
+	CREATED_BY_FIELD = "CreatedBy"
+	UPDATED_BY_FIELD = "UpdatedBy"
+)
+
+var ErrMissingActor = errors.New("error: the write must be made by an actor")
+
+type actorKey struct{}
+
+// Stamps is embedded by the models that track who created and last updated them.
+type Stamps struct {
+	CreatedBy uint64 `gorm:"index"`
+	UpdatedBy uint64 `gorm:"index"`
+}
+
+// WithActor sets the actor of the writes made with the returned session.
+func WithActor(db *gorm.DB, id uint64) *gorm.DB {
+	return db.Set(ACTOR_KEY, id)
+}
+
+// ContextWithActor returns a copy of the ctx carrying the actor, it is the actor of the writes made with
+// the ctx unless another one is set on their session.
+func ContextWithActor(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, actorKey{}, id)
+}
+
+// ActorFromContext returns the actor carried by the ctx, the second return value is false when there is none.
+func ActorFromContext(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(actorKey{}).(uint64)
+	return id, ok
+}
+
+// Actor returns the actor set on the session (or carried by its ctx), the second return value is false
+// when none was set.
+func Actor(db *gorm.DB) (uint64, bool) {
+	v, exists := db.Get(ACTOR_KEY)
+	if !exists {
+		if ctx := db.Statement.Context; ctx != nil {
+			return ActorFromContext(ctx)
+		}
+
+		return 0, false
+	}
+
+	switch id := v.(type) {
+	case uint64:
+		return id, true
+	case uint:
+		return uint64(id), true
+	case int:
+		return uint64(id), id >= 0
+	case int64:
+		return uint64(id), id >= 0
+	}
+
+	return 0, false
+}
+
+// Plugin is a gorm plugin stamping the CreatedBy and UpdatedBy of the models before they are written.
+type Plugin struct{}
+
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "audit"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("audit:before_create", stamp(CREATED_BY_FIELD, UPDATED_BY_FIELD)),
+		callbacks.Update().Before("gorm:update").Register("audit:before_update", stamp(UPDATED_BY_FIELD)),
+	)
+}
+
+func stamp(fields ...string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		if db.Error != nil || stmt.Schema == nil || stmt.Schema.LookUpField(UPDATED_BY_FIELD) == nil {
+			return
+		}
+
+		id, exists := Actor(db)
+		if !exists {
+			if loader.AppConfig().RequireActor {
+				db.AddError(fmt.Errorf("%w (%s)", ErrMissingActor, stmt.Schema.Name))
+			}
+
+			return
+		}
+
+		for _, field := range fields {
+			stmt.SetColumn(field, id, true)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package audit_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/audit"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Order struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Status string
+	audit.Stamps
+}
+
+func newAuditDb(t *testing.T, requireActor bool) (*gorm.DB, sqlmock.Sqlmock) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.RequireActor = requireActor
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(audit.New()))
+
+	t.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+	return db, mock
+}
+
+func Test_shouldStampTheActorOnCreate(t *testing.T) {
+	db, mock := newAuditDb(t, true)
+
+	mock.ExpectExec("INSERT INTO `orders` \\(`status`,`created_by`,`updated_by`\\)").
+		WithArgs("pending", 42, 42).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	order := &Order{Status: "pending"}
+	assert.Nil(t, audit.WithActor(db, 42).Create(order).Error)
+	assert.Equal(t, uint64(42), order.CreatedBy)
+	assert.Equal(t, uint64(42), order.UpdatedBy)
+}
+
+func Test_shouldStampTheActorOnUpdate(t *testing.T) {
+	db, mock := newAuditDb(t, true)
+
+	mock.ExpectExec("UPDATE `orders` SET `updated_by`=\\?,`status`=\\? WHERE `id` = \\?").
+		WithArgs(7, "shipped", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	order := &Order{Id: 1, Status: "pending", Stamps: audit.Stamps{CreatedBy: 42, UpdatedBy: 42}}
+	assert.Nil(t, audit.WithActor(db, 7).Model(order).Update("status", "shipped").Error)
+	assert.Equal(t, uint64(42), order.CreatedBy, "The creator must not change on an update.")
+}
+
+func Test_shouldRequireAnActorWhenEnforced(t *testing.T) {
+	db, _ := newAuditDb(t, true)
+
+	err := db.Create(&Order{Status: "pending"}).Error
+	assert.ErrorIs(t, err, audit.ErrMissingActor)
+
+	err = db.Model(&Order{Id: 1}).Update("status", "shipped").Error
+	assert.ErrorIs(t, err, audit.ErrMissingActor)
+}
+
+func Test_shouldAllowAMissingActorWhenNotEnforced(t *testing.T) {
+	db, mock := newAuditDb(t, false)
+
+	mock.ExpectExec("INSERT INTO `orders` \\(`status`,`created_by`,`updated_by`\\)").
+		WithArgs("pending", 0, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.Nil(t, db.Create(&Order{Status: "pending"}).Error)
+}
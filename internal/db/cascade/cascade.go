@@ -0,0 +1,251 @@
+// This package cascades the soft delete of a model to its children listed in the `softDeleteCascade`
+// of the app config (e.g. the orders of a deleted customer), the children are deleted along with their
+// parent within the same transaction and are restored along with it by the Restore.
+
+package cascade
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	idsKey     = "cascade:ids"
+	startedKey = "cascade:started_transaction"
+)
+
+// ErrNotSoftDeleted is returned by the Restore when the row is not soft-deleted.
+var ErrNotSoftDeleted = errors.New("error: the row is not soft-deleted")
+
+// Plugin is a gorm plugin cascading the soft deletes to the configured children.
+type Plugin struct{}
+
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "cascade"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	return errors.Join(
+		db.Callback().Delete().Before("gorm:delete").Register("cascade:before_delete", before),
+		db.Callback().Delete().After("gorm:after_delete").Register("cascade:after_delete", after),
+	)
+}
+
+// relations returns the configured children of the schema.
+func relations(s *schema.Schema) []string {
+	return loader.AppConfig().SoftDeleteCascade[s.Name]
+}
+
+// softDeleteField returns the `DeletedAt` of the schema, it is nil when the schema cannot be soft-deleted.
+func softDeleteField(s *schema.Schema) *schema.Field {
+	field := s.LookUpField("DeletedAt")
+	if field == nil || field.FieldType != reflect.TypeOf(gorm.DeletedAt{}) || s.PrioritizedPrimaryField == nil {
+		return nil
+	}
+
+	return field
+}
+
+// before collects the primary keys of the rows about to be soft-deleted, the rows are then deleted in a
+// transaction unless the delete already runs in one.
+func before(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.Unscoped || len(relations(stmt.Schema)) == 0 ||
+		softDeleteField(stmt.Schema) == nil {
+		return
+	}
+
+	ids, err := primaryKeys(db)
+	if err != nil || len(ids) == 0 {
+		db.AddError(err)
+		return
+	}
+
+	if _, inTx := stmt.ConnPool.(gorm.TxCommitter); !inTx {
+		tx := db.Session(&gorm.Session{NewDB: true}).Begin()
+		if tx.Error != nil {
+			db.AddError(tx.Error)
+			return
+		}
+
+		stmt.ConnPool = tx.Statement.ConnPool
+		db.InstanceSet(startedKey, tx)
+	}
+
+	db.InstanceSet(idsKey, ids)
+}
+
+// primaryKeys returns the primary keys of the model of the statement, they are queried with the
+// conditions of the statement when the model does not hold them.
+func primaryKeys(db *gorm.DB) ([]any, error) {
+	stmt := db.Statement
+	pk := stmt.Schema.PrioritizedPrimaryField
+
+	_, values := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, []*schema.Field{pk})
+	if len(values) != 0 {
+		ids := make([]any, 0, len(values))
+		for _, value := range values {
+			ids = append(ids, value[0])
+		}
+
+		return ids, nil
+	}
+
+	where, ok := stmt.Clauses["WHERE"]
+	if !ok {
+		return nil, nil
+	}
+
+	model := reflect.New(stmt.Schema.ModelType).Interface()
+	return pluck(db.Session(&gorm.Session{NewDB: true}).Model(model).Clauses(where.Expression), pk)
+}
+
+func pluck(tx *gorm.DB, pk *schema.Field) ([]any, error) {
+	dest := reflect.New(reflect.SliceOf(pk.FieldType))
+	if err := tx.Pluck(pk.DBName, dest.Interface()).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]any, dest.Elem().Len())
+	for i := range ids {
+		ids[i] = dest.Elem().Index(i).Interface()
+	}
+
+	return ids, nil
+}
+
+// after soft-deletes the children of the deleted rows with the same `deleted_at` as their parent,
+// then commits (or rolls back) the transaction started by the before.
+func after(db *gorm.DB) {
+	val, cascading := db.InstanceGet(idsKey)
+	if !cascading {
+		return
+	}
+
+	if db.Error == nil {
+		if deletedAt, ok := deletedAtOf(db.Statement); ok {
+			tx := db.Session(&gorm.Session{NewDB: true})
+			db.AddError(propagate(tx, db.Statement.Schema, val.([]any), nil, deletedAt))
+		}
+	}
+
+	started, ok := db.InstanceGet(startedKey)
+	if !ok {
+		return
+	}
+
+	if db.Error != nil {
+		started.(*gorm.DB).Rollback()
+	} else {
+		db.AddError(started.(*gorm.DB).Commit().Error)
+	}
+}
+
+// deletedAtOf returns the `deleted_at` set by the soft delete of the statement.
+func deletedAtOf(stmt *gorm.Statement) (any, bool) {
+	field := softDeleteField(stmt.Schema)
+
+	if set, ok := stmt.Clauses["SET"].Expression.(clause.Set); ok {
+		for _, assignment := range set {
+			if assignment.Column.Name == field.DBName {
+				return assignment.Value, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// propagate moves the `deleted_at` of the configured children of the rows (and of their own children)
+// from the from to the to, the children whose `deleted_at` is not the from are left as is.
+func propagate(tx *gorm.DB, s *schema.Schema, ids []any, from, to any) error {
+	for _, name := range relations(s) {
+		rel, ok := s.Relationships.Relations[name]
+		if !ok || (rel.Type != schema.HasOne && rel.Type != schema.HasMany) || len(rel.References) != 1 {
+			return fmt.Errorf("error: %s has no has-one or has-many relation %s to cascade to", s.Name, name)
+		}
+
+		child := rel.FieldSchema
+		field := softDeleteField(child)
+		if field == nil {
+			return fmt.Errorf("error: cannot cascade the soft delete of %s to %s, it cannot be soft-deleted", s.Name, child.Name)
+		}
+
+		pk := child.PrioritizedPrimaryField
+
+		childIds, err := pluck(tx.Session(&gorm.Session{NewDB: true}).Table(child.Table).
+			Where(map[string]any{rel.References[0].ForeignKey.DBName: ids, field.DBName: from}), pk)
+		if err != nil {
+			return err
+		}
+
+		if len(childIds) == 0 {
+			continue
+		}
+
+		err = tx.Session(&gorm.Session{NewDB: true}).Table(child.Table).
+			Where(map[string]any{pk.DBName: childIds}).
+			UpdateColumn(field.DBName, to).Error
+		if err != nil {
+			return err
+		}
+
+		if err := propagate(tx, child, childIds, from, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Restore restores the soft-deleted row of the model (which must hold its primary key) along with the
+// children that were soft-deleted with it.
+func Restore(db *gorm.DB, model any) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	field := softDeleteField(stmt.Schema)
+	if field == nil {
+		return fmt.Errorf("error: %s cannot be soft-deleted", stmt.Schema.Name)
+	}
+
+	pk := stmt.Schema.PrioritizedPrimaryField
+	id, zero := pk.ValueOf(db.Statement.Context, reflect.ValueOf(model))
+	if zero {
+		return fmt.Errorf("error: cannot restore a %s without its primary key", stmt.Schema.Name)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var deletedAt gorm.DeletedAt
+
+		err := tx.Table(stmt.Schema.Table).Where(map[string]any{pk.DBName: id}).
+			Select(field.DBName).Row().Scan(&deletedAt)
+		if err != nil {
+			return err
+		}
+
+		if !deletedAt.Valid {
+			return ErrNotSoftDeleted
+		}
+
+		err = tx.Table(stmt.Schema.Table).Where(map[string]any{pk.DBName: id}).
+			UpdateColumn(field.DBName, nil).Error
+		if err != nil {
+			return err
+		}
+
+		return propagate(tx, stmt.Schema, []any{id}, deletedAt.Time, nil)
+	})
+}
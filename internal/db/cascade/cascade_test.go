@@ -0,0 +1,113 @@
+package cascade_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/cascade"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Customer struct {
+	Id        uint64 `gorm:"primaryKey"`
+	Orders    []Order
+	DeletedAt gorm.DeletedAt
+}
+
+type Order struct {
+	Id         uint64 `gorm:"primaryKey"`
+	CustomerId uint64
+	Lines      []OrderLine
+	DeletedAt  gorm.DeletedAt
+}
+
+type OrderLine struct {
+	Id        uint64 `gorm:"primaryKey"`
+	OrderId   uint64
+	DeletedAt gorm.DeletedAt
+}
+
+var deletedAt = time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+func newCascadeDb(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SoftDeleteCascade = map[string][]string{"Customer": {"Orders"}, "Order": {"Lines"}}
+	})
+
+	db, mock, err := mocks.NewGormMockWithConfig(&gorm.Config{
+		SkipDefaultTransaction: true,
+		NowFunc:                func() time.Time { return deletedAt },
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(cascade.New()))
+
+	t.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+
+	return db, mock
+}
+
+func Test_shouldSoftDeleteTheChildrenWithTheirParent(t *testing.T) {
+	db, mock := newCascadeDb(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `customers` SET `deleted_at`=\\? WHERE `customers`.`id` = \\? AND `customers`.`deleted_at` IS NULL").
+		WithArgs(deletedAt, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT `id` FROM `orders` WHERE `customer_id` = \\? AND `deleted_at` IS NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10).AddRow(11))
+	mock.ExpectExec("UPDATE `orders` SET `deleted_at`=\\? WHERE `id` IN \\(\\?,\\?\\)").
+		WithArgs(deletedAt, 10, 11).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT `id` FROM `order_lines` WHERE `deleted_at` IS NULL AND `order_id` IN \\(\\?,\\?\\)").
+		WithArgs(10, 11).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(100))
+	mock.ExpectExec("UPDATE `order_lines` SET `deleted_at`=\\? WHERE `id` = \\?").
+		WithArgs(deletedAt, 100).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, db.Delete(&Customer{Id: 1}).Error)
+}
+
+func Test_shouldRollbackTheParentWhenTheCascadeFails(t *testing.T) {
+	db, mock := newCascadeDb(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `customers` SET `deleted_at`=\\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT `id` FROM `orders`").
+		WillReturnError(gorm.ErrInvalidDB)
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, db.Delete(&Customer{Id: 1}).Error, gorm.ErrInvalidDB)
+}
+
+func Test_shouldRestoreTheChildrenDeletedWithTheirParent(t *testing.T) {
+	db, mock := newCascadeDb(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT deleted_at FROM `customers` WHERE `id` = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"deleted_at"}).AddRow(deletedAt))
+	mock.ExpectExec("UPDATE `customers` SET `deleted_at`=\\? WHERE `id` = \\?").
+		WithArgs(nil, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT `id` FROM `orders` WHERE `customer_id` = \\? AND `deleted_at` = \\?").
+		WithArgs(1, deletedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectExec("UPDATE `orders` SET `deleted_at`=\\? WHERE `id` = \\?").
+		WithArgs(nil, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT `id` FROM `order_lines` WHERE `deleted_at` = \\? AND `order_id` = \\?").
+		WithArgs(deletedAt, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	assert.Nil(t, cascade.Restore(db, &Customer{Id: 1}))
+}
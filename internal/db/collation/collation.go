@@ -0,0 +1,62 @@
+// This package sorts the rows in the collation of the locale of the request (see the LocaleMiddleware of
+// the api/middleware package), the collation of a locale is taken from the `localeCollations` of the app
+// config. The locale is read from the context of the gorm session, so the queries must be made with the
+// context of the request (e.g. db.WithContext(c.Request.Context())).
+
+package collation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rommms07/idream-erp/core/validation"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Collation returns the collation of the locale carried by the ctx, a regional locale (e.g. `es-MX`)
+// falls back to its language. The bool is false when no collation is configured for it.
+func Collation(ctx context.Context) (string, bool) {
+	collations := loader.AppConfig().LocaleCollations
+
+	locale := strings.ReplaceAll(validation.Locale(ctx), "_", "-")
+	lang, _, _ := strings.Cut(locale, "-")
+
+	for _, key := range []string{locale, lang} {
+		if collation, exists := collations[key]; exists && len(collation) != 0 {
+			return collation, true
+		}
+	}
+
+	return "", false
+}
+
+// OrderBy is a scope sorting the rows by the column in the collation of the locale of the request, e.g.
+//
+//	db.WithContext(c.Request.Context()).Scopes(collation.OrderBy("name", false)).Find(&products)
+//
+// The rows are sorted in the collation of the column when the locale has no collation.
+func OrderBy(column string, desc bool) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		col := clause.Column{Name: column}
+
+		collation, ok := Collation(ctx)
+		if !ok {
+			return db.Order(clause.OrderByColumn{Column: col, Desc: desc})
+		}
+
+		sql := fmt.Sprintf("? COLLATE %s", collation)
+		if desc {
+			sql += " DESC"
+		}
+
+		return db.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: []any{col}}})
+	}
+}
@@ -0,0 +1,52 @@
+package collation_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/rommms07/idream-erp/api/middleware"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/collation"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Product struct {
+	Id   uint64 `gorm:"primaryKey"`
+	Name string
+}
+
+func Test_shouldSortInTheCollationOfTheLocaleOfTheRequest(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.LocaleCollations = map[string]string{"es": "utf8mb4_es_0900_ai_ci"}
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `products` ORDER BY `name` COLLATE utf8mb4_es_0900_ai_ci DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "ñandú"))
+	mock.ExpectQuery("SELECT \\* FROM `products` ORDER BY `name`$").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "apple"))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.LocaleMiddleware())
+	router.GET("/products", func(c *gin.Context) {
+		products := []*Product{}
+		assert.Nil(t, db.WithContext(c.Request.Context()).Scopes(collation.OrderBy("name", c.Query("desc") == "1")).Find(&products).Error)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/products?desc=1", nil)
+	req.Header.Set("Accept-Language", "es-MX,es;q=0.9")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/products", nil)
+	req.Header.Set("Accept-Language", "fr")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
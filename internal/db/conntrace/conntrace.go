@@ -0,0 +1,125 @@
+// This package logs the lifecycle of the physical connections to the database, every connection that
+// is established (or fails to be) and every connection that is closed. It is meant for debugging a flaky
+// connectivity, e.g. a proxy dropping the idle connections.
+
+package conntrace
+
+import (
+	"context"
+	"database/sql/driver"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// now is used to tell the age of a connection, the tests override it.
+	now = time.Now
+)
+
+// Connector wraps the connections of a driver.Connector so that their connect and close are logged.
+type Connector struct {
+	driver.Connector
+
+	logger *slog.Logger
+	nextId atomic.Uint64
+}
+
+func NewConnector(connector driver.Connector, logger *slog.Logger) *Connector {
+	return &Connector{Connector: connector, logger: logger}
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	start := now()
+
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		c.logger.Warn("db: failed to connect", "error", err, "elapsed", now().Sub(start))
+		return nil, err
+	}
+
+	tc := &tracedConn{Conn: conn, id: c.nextId.Add(1), connectedAt: now(), logger: c.logger}
+	c.logger.Info("db: connected", "conn", tc.id, "elapsed", tc.connectedAt.Sub(start))
+	return tc, nil
+}
+
+// tracedConn forwards the optional interfaces of the database/sql/driver to the wrapped connection, the
+// database/sql falls back to the mandatory ones when a forwarded interface returns a driver.ErrSkip.
+type tracedConn struct {
+	driver.Conn
+
+	id          uint64
+	connectedAt time.Time
+	logger      *slog.Logger
+}
+
+func (c *tracedConn) Close() error {
+	err := c.Conn.Close()
+
+	c.logger.Info("db: connection closed", "conn", c.id, "age", now().Sub(c.connectedAt), "error", err)
+	return err
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
@@ -0,0 +1,79 @@
+package conntrace_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/rommms07/idream-erp/internal/db/conntrace"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConn struct {
+	closed bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error              { c.closed = true; return nil }
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+type fakeConnector struct {
+	conns []*fakeConn
+	down  bool
+}
+
+func (fc *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if fc.down {
+		return nil, errors.New("connection refused")
+	}
+
+	conn := &fakeConn{}
+	fc.conns = append(fc.conns, conn)
+	return conn, nil
+}
+
+func (fc *fakeConnector) Driver() driver.Driver { return nil }
+
+func records(buf *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func Test_shouldLogTheConnectAndCloseOfAConnection(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fc := &fakeConnector{}
+
+	db := sql.OpenDB(conntrace.NewConnector(fc, slog.New(slog.NewJSONHandler(buf, nil))))
+
+	_, err := db.Exec("UPDATE orders SET status = 'shipped'")
+	assert.Nil(t, err)
+	assert.Len(t, fc.conns, 1)
+
+	assert.Nil(t, db.Close())
+	assert.True(t, fc.conns[0].closed, "The close must reach the wrapped connection.")
+
+	logged := records(buf)
+	if assert.Len(t, logged, 2) {
+		assert.Contains(t, logged[0], `"msg":"db: connected","conn":1`)
+		assert.Contains(t, logged[1], `"msg":"db: connection closed","conn":1`)
+	}
+}
+
+func Test_shouldLogAFailedConnect(t *testing.T) {
+	buf := &bytes.Buffer{}
+	db := sql.OpenDB(conntrace.NewConnector(&fakeConnector{down: true}, slog.New(slog.NewJSONHandler(buf, nil))))
+	defer db.Close()
+
+	assert.NotNil(t, db.Ping())
+	assert.Contains(t, buf.String(), `"msg":"db: failed to connect","error":"connection refused"`)
+}
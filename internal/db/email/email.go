@@ -0,0 +1,112 @@
+// This package normalizes the emails of the models implementing the EmailField before they are saved,
+// the email is trimmed and lowercased so that a login with a differently cased email still matches.
+
+package email
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// EmailField is implemented by the models whose email is normalized on save.
+type EmailField interface {
+	// EmailField returns the name of the field holding the email, the `emailFields` of the app config
+	// take precedence over it.
+	EmailField() string
+}
+
+// Normalize trims and lowercases the email, the lookups by email must normalize it the same way.
+func Normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Plugin is a gorm plugin normalizing the emails of the EmailField models before they are created or
+// updated, it does nothing unless the `normalizeEmails` of the app config is set.
+type Plugin struct{}
+
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "email"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	return errors.Join(
+		db.Callback().Create().Before("gorm:create").Register("email:before_create", normalize),
+		db.Callback().Update().Before("gorm:update").Register("email:before_update", normalize),
+	)
+}
+
+func field(s *schema.Schema, model EmailField) *schema.Field {
+	name := model.EmailField()
+	if configured, exists := loader.AppConfig().EmailFields[s.Name]; exists {
+		name = configured
+	}
+
+	return s.LookUpField(name)
+}
+
+func normalize(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || !loader.AppConfig().NormalizeEmails {
+		return
+	}
+
+	model, ok := reflect.New(stmt.Schema.ModelType).Interface().(EmailField)
+	if !ok {
+		return
+	}
+
+	field := field(stmt.Schema, model)
+	if field == nil || field.FieldType.Kind() != reflect.String {
+		db.AddError(fmt.Errorf("error: %s has no string email field to normalize", stmt.Schema.Name))
+		return
+	}
+
+	// The updates with a map (e.g. Update("email", ...)) hold the email in the map instead of the model.
+	if dest, ok := stmt.Dest.(map[string]any); ok {
+		for _, key := range []string{field.Name, field.DBName} {
+			if email, ok := dest[key].(string); ok {
+				dest[key] = Normalize(email)
+			}
+		}
+
+		return
+	}
+
+	values := []reflect.Value{stmt.ReflectValue}
+
+	// The updates with a struct (e.g. Updates(&User{...})) hold the email in the Dest.
+	if stmt.Dest != stmt.Model {
+		if dest := reflect.Indirect(reflect.ValueOf(stmt.Dest)); dest.Type() == stmt.Schema.ModelType {
+			values = append(values, dest)
+		}
+	}
+
+	for _, value := range values {
+		rows := []reflect.Value{}
+
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				rows = append(rows, reflect.Indirect(value.Index(i)))
+			}
+		case reflect.Struct:
+			rows = append(rows, value)
+		}
+
+		for _, row := range rows {
+			if email, zero := field.ValueOf(stmt.Context, row); !zero {
+				db.AddError(field.Set(stmt.Context, row, Normalize(reflect.ValueOf(email).String())))
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package email_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/email"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Account struct {
+	Id    uint64 `gorm:"primaryKey"`
+	Login string
+}
+
+func (a *Account) EmailField() string {
+	return "Login"
+}
+
+func newEmailDb(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.NormalizeEmails = true
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(email.New()))
+
+	t.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+	return db, mock
+}
+
+func Test_shouldStoreTheEmailLowercased(t *testing.T) {
+	db, mock := newEmailDb(t)
+
+	mock.ExpectExec("INSERT INTO `accounts` \\(`login`\\)").
+		WithArgs("jane.doe@idream.local").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	account := &Account{Login: "  Jane.Doe@iDream.local "}
+	assert.Nil(t, db.Create(account).Error)
+	assert.Equal(t, "jane.doe@idream.local", account.Login)
+
+	mock.ExpectQuery("SELECT \\* FROM `accounts` WHERE login = \\?").
+		WithArgs("jane.doe@idream.local").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "login"}).AddRow(1, "jane.doe@idream.local"))
+
+	found := &Account{}
+	assert.Nil(t, db.Where("login = ?", email.Normalize("JANE.DOE@idream.local")).First(found).Error)
+	assert.Equal(t, uint64(1), found.Id, "The lookup by the lowercased email should match.")
+}
+
+func Test_shouldNormalizeTheEmailOfTheUpdates(t *testing.T) {
+	db, mock := newEmailDb(t)
+
+	mock.ExpectExec("UPDATE `accounts` SET `login`=\\? WHERE `id` = \\?").
+		WithArgs("jane@idream.local", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, db.Model(&Account{Id: 1}).Update("login", "Jane@iDream.local").Error)
+
+	mock.ExpectExec("UPDATE `accounts` SET `login`=\\? WHERE `id` = \\?").
+		WithArgs("john@idream.local", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, db.Model(&Account{Id: 1}).Updates(&Account{Login: " John@iDream.local"}).Error)
+}
+
+func Test_shouldKeepTheEmailWhenTheNormalizationIsDisabled(t *testing.T) {
+	db, mock := newEmailDb(t)
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.NormalizeEmails = false
+	})
+
+	mock.ExpectExec("INSERT INTO `accounts` \\(`login`\\)").
+		WithArgs("Jane@iDream.local").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.Nil(t, db.Create(&Account{Login: "Jane@iDream.local"}).Error)
+}
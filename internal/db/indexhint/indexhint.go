@@ -0,0 +1,78 @@
+// This package injects a MySQL index hint (e.g. `USE INDEX (idx_orders_status)`) after the table of the
+// queries that are known to pick the wrong index. The hint is either set on the query itself or looked
+// up by the tag of the query in the `indexHints` of the app config:
+//
+//	db.Set(indexhint.HINT_KEY, "USE INDEX (idx_orders_status)").Find(&orders)
+//	db.Set(indexhint.TAG_KEY, "orders_by_status").Find(&orders)
+//
+// The other databases have no index hints, the queries are left untouched on them.
+
+package indexhint
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	HINT_KEY = "index_hint"
+	TAG_KEY  = "query_tag"
+)
+
+// validHint matches the index hints of MySQL, anything else is rejected so that a hint can never smuggle
+// some SQL into the query.
+var validHint = regexp.MustCompile(`(?i)^(use|force|ignore)\s+(index|key)(\s+for\s+(join|order\s+by|group\s+by))?\s*\(\s*\w*(\s*,\s*\w+)*\s*\)$`)
+
+// Plugin is a gorm plugin injecting the index hints into the queries.
+type Plugin struct{}
+
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "index_hint"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	return db.Callback().Query().Before("gorm:query").Register("index_hint:before_query", inject)
+}
+
+// hint returns the index hint of the query, the hint set on it takes precedence over the one of its tag.
+func hint(db *gorm.DB) string {
+	if val, ok := db.Get(HINT_KEY); ok {
+		hint, _ := val.(string)
+		return hint
+	}
+
+	if val, ok := db.Get(TAG_KEY); ok {
+		if tag, ok := val.(string); ok {
+			return loader.AppConfig().IndexHints[tag]
+		}
+	}
+
+	return ""
+}
+
+func inject(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || db.Dialector.Name() != "mysql" || stmt.TableExpr != nil || len(stmt.Table) == 0 {
+		return
+	}
+
+	hint := hint(db)
+	if len(hint) == 0 {
+		return
+	}
+
+	if !validHint.MatchString(hint) {
+		db.AddError(fmt.Errorf("error: invalid index hint %q", hint))
+		return
+	}
+
+	stmt.TableExpr = &clause.Expr{SQL: fmt.Sprintf("%s %s", stmt.Quote(stmt.Table), hint)}
+}
@@ -0,0 +1,66 @@
+package indexhint_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/indexhint"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Order struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Status string
+}
+
+func newHintDb(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(indexhint.New()))
+
+	t.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+	return db, mock
+}
+
+func Test_shouldInjectTheIndexHintOfTheQuery(t *testing.T) {
+	db, mock := newHintDb(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `orders` USE INDEX \\(idx_orders_status\\) WHERE status = \\?").
+		WithArgs("paid").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `orders` WHERE status = \\?").
+		WithArgs("paid").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	orders := []*Order{}
+	assert.Nil(t, db.Set(indexhint.HINT_KEY, "USE INDEX (idx_orders_status)").Where("status = ?", "paid").Find(&orders).Error)
+	assert.Nil(t, db.Where("status = ?", "paid").Find(&orders).Error, "A query without a hint should be left untouched.")
+}
+
+func Test_shouldInjectTheConfiguredHintOfTheTag(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.IndexHints = map[string]string{"orders_by_status": "FORCE INDEX FOR ORDER BY (idx_orders_status)"}
+	})
+
+	db, mock := newHintDb(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `orders` FORCE INDEX FOR ORDER BY \\(idx_orders_status\\) ORDER BY status").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `orders` ORDER BY status").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	orders := []*Order{}
+	assert.Nil(t, db.Set(indexhint.TAG_KEY, "orders_by_status").Order("status").Find(&orders).Error)
+	assert.Nil(t, db.Set(indexhint.TAG_KEY, "unknown").Order("status").Find(&orders).Error)
+}
+
+func Test_shouldRejectAnInvalidHint(t *testing.T) {
+	db, _ := newHintDb(t)
+
+	orders := []*Order{}
+	err := db.Set(indexhint.HINT_KEY, "USE INDEX (idx); DROP TABLE orders").Find(&orders).Error
+	assert.ErrorContains(t, err, "invalid index hint")
+}
@@ -19,3 +19,18 @@ func init() {
 		test_totalMigratedModels++
 	}
 }
+
+// SetTransactionalDDL sets whether the DDL of the dialect can be rolled back, the returned func
+// restores it.
+func SetTransactionalDDL(dialect string, transactional bool) func() {
+	bak, ok := transactionalDDL[dialect]
+	transactionalDDL[dialect] = transactional
+
+	return func() {
+		if ok {
+			transactionalDDL[dialect] = bak
+		} else {
+			delete(transactionalDDL, dialect)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package gorm
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ForeignKey is a foreign key of a table, Columns are in the order of the referenced key.
+type ForeignKey struct {
+	Table   string
+	Columns []string
+}
+
+// ForeignKeys returns the foreign keys of the relationships declared by the models, a foreign key
+// declared on both sides of a relationship (e.g. `HasMany` and `BelongsTo`) is only returned once.
+// The join tables of the many2many relationships are skipped since their primary key covers them.
+func ForeignKeys(db *gorm.DB, models ...any) ([]ForeignKey, error) {
+	seen := make(map[string]bool)
+	keys := make([]ForeignKey, 0)
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+
+		for _, rel := range stmt.Schema.Relationships.Relations {
+			if rel.Type == schema.Many2Many {
+				continue
+			}
+
+			key := ForeignKey{}
+			for _, ref := range rel.References {
+				// The references without a primary key are the polymorphic type values.
+				if ref.PrimaryKey == nil || ref.ForeignKey == nil {
+					continue
+				}
+
+				key.Table = ref.ForeignKey.Schema.Table
+				key.Columns = append(key.Columns, ref.ForeignKey.DBName)
+			}
+
+			id := key.Table + "(" + strings.Join(key.Columns, ",") + ")"
+			if len(key.Columns) == 0 || seen[id] {
+				continue
+			}
+
+			seen[id] = true
+			keys = append(keys, key)
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Table < keys[j].Table
+	})
+
+	return keys, nil
+}
+
+// isIndexedBy reports whether the columns of the foreign key are the leftmost columns of the index,
+// which is what the database needs to use the index when joining on the foreign key.
+func isIndexedBy(key ForeignKey, index gorm.Index) bool {
+	columns := index.Columns()
+	if len(columns) < len(key.Columns) {
+		return false
+	}
+
+	for i, column := range key.Columns {
+		if !strings.EqualFold(columns[i], column) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MissingForeignKeyIndexes returns the foreign keys of the models without a supporting index in
+// the database.
+func MissingForeignKeyIndexes(db *gorm.DB, models ...any) ([]ForeignKey, error) {
+	keys, err := ForeignKeys(db, models...)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string][]gorm.Index)
+	missing := make([]ForeignKey, 0)
+
+	for _, key := range keys {
+		if _, ok := indexes[key.Table]; !ok {
+			if indexes[key.Table], err = db.Migrator().GetIndexes(key.Table); err != nil {
+				return nil, err
+			}
+		}
+
+		indexed := false
+		for _, index := range indexes[key.Table] {
+			if indexed = isIndexedBy(key, index); indexed {
+				break
+			}
+		}
+
+		if !indexed {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing, nil
+}
+
+// IndexForeignKeys warns about the foreign keys of the models without a supporting index, joining
+// on them scans the whole table. When create is set the missing indexes are created instead.
+func IndexForeignKeys(db *gorm.DB, logger *slog.Logger, create bool, models ...any) error {
+	missing, err := MissingForeignKeyIndexes(db, models...)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range missing {
+		columns := strings.Join(key.Columns, ",")
+
+		if !create {
+			logger.Warn("foreign key without an index", "table", key.Table, "columns", columns)
+			continue
+		}
+
+		name := db.NamingStrategy.IndexName(key.Table, strings.Join(key.Columns, "_"))
+		ddl := fmt.Sprintf("CREATE INDEX `%s` ON `%s` (`%s`)", name, key.Table, strings.Join(key.Columns, "`, `"))
+
+		if err := db.Exec(ddl).Error; err != nil {
+			return err
+		}
+
+		logger.Info("created the index of a foreign key", "table", key.Table, "columns", columns, "index", name)
+	}
+
+	return nil
+}
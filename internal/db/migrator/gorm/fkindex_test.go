@@ -0,0 +1,94 @@
+package gorm_test
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/migrator/gorm"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Customer struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Orders []Order
+}
+
+type Order struct {
+	Id         uint64 `gorm:"primaryKey"`
+	CustomerId uint64
+	Lines      []OrderLine
+}
+
+type OrderLine struct {
+	Id      uint64 `gorm:"primaryKey"`
+	OrderId uint64 `gorm:"index"`
+}
+
+var statisticsColumns = []string{"TABLE_NAME", "COLUMN_NAME", "INDEX_NAME", "NON_UNIQUE"}
+
+func expectCurrentDatabase(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT DATABASE()").
+		WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("erp"))
+	mock.ExpectQuery("SELECT SCHEMA_NAME from Information_schema.SCHEMATA").
+		WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME"}).AddRow("erp"))
+}
+
+func expectIndexes(mock sqlmock.Sqlmock) {
+	expectCurrentDatabase(mock)
+	mock.ExpectQuery("FROM\\s+information_schema.STATISTICS").
+		WithArgs("erp", "order_lines").
+		WillReturnRows(sqlmock.NewRows(statisticsColumns).
+			AddRow("order_lines", "id", "PRIMARY", 0).
+			AddRow("order_lines", "order_id", "idx_order_lines_order_id", 1))
+	expectCurrentDatabase(mock)
+	mock.ExpectQuery("FROM\\s+information_schema.STATISTICS").
+		WithArgs("erp", "orders").
+		WillReturnRows(sqlmock.NewRows(statisticsColumns).
+			AddRow("orders", "id", "PRIMARY", 0))
+}
+
+func Test_shouldListTheForeignKeysOfTheModelsOnce(t *testing.T) {
+	db, _, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	keys, err := gorm.ForeignKeys(db, &Customer{}, &Order{}, &OrderLine{})
+	assert.Nil(t, err)
+	assert.Equal(t, []gorm.ForeignKey{
+		{Table: "order_lines", Columns: []string{"order_id"}},
+		{Table: "orders", Columns: []string{"customer_id"}},
+	}, keys)
+}
+
+func Test_shouldWarnAboutTheForeignKeysWithoutAnIndex(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	buf := &bytes.Buffer{}
+	expectIndexes(mock)
+
+	assert.Nil(t, gorm.IndexForeignKeys(db, slog.New(slog.NewJSONHandler(buf, nil)), false,
+		&Customer{}, &Order{}, &OrderLine{}))
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	assert.Contains(t, buf.String(), `"msg":"foreign key without an index","table":"orders","columns":"customer_id"`)
+	assert.NotContains(t, buf.String(), "order_lines")
+}
+
+func Test_shouldCreateTheMissingIndexesOfTheForeignKeys(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	buf := &bytes.Buffer{}
+	expectIndexes(mock)
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX `idx_orders_customer_id` ON `orders` (`customer_id`)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Nil(t, gorm.IndexForeignKeys(db, slog.New(slog.NewJSONHandler(buf, nil)), true,
+		&Customer{}, &Order{}, &OrderLine{}))
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.NotContains(t, buf.String(), "foreign key without an index")
+}
@@ -0,0 +1,46 @@
+package gorm
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMigrationLocked = errors.New("error: another instance is migrating the models")
+)
+
+// Locker guards the migration so that only a single instance migrates the models at a time, the
+// db given to the locker is pinned to a single connection for the whole migration.
+type Locker interface {
+	// Lock waits up to the timeout for the lock, it returns false when the lock was not acquired.
+	Lock(db *gorm.DB, name string, timeout time.Duration) (bool, error)
+	Unlock(db *gorm.DB, name string) error
+}
+
+// MysqlLocker uses the advisory lock of MySQL (GET_LOCK/RELEASE_LOCK), the lock is bound to the
+// connection that acquired it, it is released when the connection is closed too.
+type MysqlLocker struct{}
+
+func (MysqlLocker) Lock(db *gorm.DB, name string, timeout time.Duration) (bool, error) {
+	var acquired sql.NullInt64
+
+	err := db.Raw("SELECT GET_LOCK(?, ?)", name, int64(timeout.Seconds())).Scan(&acquired).Error
+	if err != nil {
+		return false, err
+	}
+
+	// GET_LOCK returns NULL when an error occured (e.g. the thread was killed).
+	if !acquired.Valid {
+		return false, errors.New("error: GET_LOCK returned NULL")
+	}
+
+	return acquired.Int64 == 1, nil
+}
+
+func (MysqlLocker) Unlock(db *gorm.DB, name string) error {
+	var released sql.NullInt64
+	return db.Raw("SELECT RELEASE_LOCK(?)", name).Scan(&released).Error
+}
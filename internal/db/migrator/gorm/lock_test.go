@@ -0,0 +1,95 @@
+package gorm_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/migrator/gorm"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	_gorm "gorm.io/gorm"
+)
+
+// memLocker stands in for the advisory lock of the database, it is shared by the migrators of
+// the test to simulate several instances connected to the same database.
+type memLocker struct {
+	sem chan struct{}
+}
+
+func (l *memLocker) Lock(db *_gorm.DB, name string, timeout time.Duration) (bool, error) {
+	select {
+	case l.sem <- struct{}{}:
+		return true, nil
+	case <-time.After(timeout):
+		return false, nil
+	}
+}
+
+func (l *memLocker) Unlock(db *_gorm.DB, name string) error {
+	<-l.sem
+	return nil
+}
+
+func newLockedMigrator(t *testing.T, locker gorm.Locker, migrate func()) *gorm.GormMigrator {
+	db, _, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	inst := gorm.NewGormMigrator().Add(&ExampleModel{}).SetDB(db)
+	inst.Locker = locker
+	inst.LockTimeout = 50 * time.Millisecond
+	inst.CustomAutoMigrateFunc = func(db *_gorm.DB, model any) { migrate() }
+
+	return inst
+}
+
+func Test_onlyOneOfTheConcurrentMigratorsShouldRun(t *testing.T) {
+	locker := &memLocker{sem: make(chan struct{}, 1)}
+	started, release := make(chan struct{}), make(chan struct{})
+
+	var mu sync.Mutex
+	runs := 0
+
+	first := newLockedMigrator(t, locker, func() {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+
+		close(started)
+		<-release
+	})
+
+	second := newLockedMigrator(t, locker, func() {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+	})
+
+	done := make(chan error)
+	go func() { done <- first.Migrate() }()
+
+	<-started
+	assert.ErrorIs(t, second.Migrate(), gorm.ErrMigrationLocked, "The second migrator must not run while the first holds the lock.")
+
+	close(release)
+	assert.Nil(t, <-done)
+	assert.Equal(t, 1, runs, "Only one migrator should have run the migration.")
+}
+
+func Test_mysqlLockerShouldUseTheAdvisoryLock(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT GET_LOCK\\(\\?, \\?\\)").WithArgs("erp_migration", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(1))
+	mock.ExpectQuery("SELECT RELEASE_LOCK\\(\\?\\)").WithArgs("erp_migration").
+		WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+
+	acquired, err := gorm.MysqlLocker{}.Lock(db, "erp_migration", 10*time.Second)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+
+	assert.Nil(t, gorm.MysqlLocker{}.Unlock(db, "erp_migration"))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
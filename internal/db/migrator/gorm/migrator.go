@@ -3,7 +3,9 @@ package gorm
 import (
 	"errors"
 	"reflect"
+	"time"
 
+	"github.com/rommms07/idream-erp/helpers/loader"
 	"gorm.io/gorm"
 )
 
@@ -14,12 +16,46 @@ var (
 )
 
 type GormMigrator struct {
-	models map[string]any
-	db     *gorm.DB
+	models     map[string]any
+	migrations []*Migration
+	db         *gorm.DB
 
 	// CustomAutoMigrateFunc can be used by the developer to create a custom way of
 	// migrating the models stored in the m.models field.
 	CustomAutoMigrateFunc func(db *gorm.DB, model any)
+
+	// Locker is acquired before migrating the models, when it is nil the models are migrated
+	// without locking. LockTimeout is how long an instance waits for the lock before giving up
+	// with an ErrMigrationLocked.
+	Locker      Locker
+	LockName    string
+	LockTimeout time.Duration
+
+	// ValidateTags makes the `Migrate` check the tags of the models with ValidateModelTags, the
+	// migration is aborted when any of the models is invalid.
+	ValidateTags bool
+
+	// StrictJSONTags makes the `Migrate` check that every exported field of the models has a json tag
+	// with ValidateJSONTags, the migration is aborted when any of them is missing.
+	StrictJSONTags bool
+
+	// Partitioning makes the `Migrate` partition the tables of the models that implement Partitioned,
+	// see ApplyPartitioning.
+	Partitioning bool
+
+	// SnapshotSchema makes the `Migrate` record the DDL of the schema tagged with the version of the app
+	// once the models are migrated, see RecordSchemaSnapshot.
+	SnapshotSchema bool
+
+	// Mode is either MIGRATION_MODE_AUTO (the default) which AutoMigrates the models or
+	// MIGRATION_MODE_VERSIONED which runs the pending migrations added with AddMigration. The
+	// destructive migrations are only run when AllowDestructive is set.
+	Mode             string
+	AllowDestructive bool
+
+	// Retry is how the versioned migrations failing with a transient error (see IsTransientError) are
+	// retried, a nil Retry runs them once.
+	Retry *loader.RetryPolicy
 }
 
 func NewGormMigrator() *GormMigrator {
@@ -34,6 +70,16 @@ func (m *GormMigrator) Add(model any) *GormMigrator {
 	return m
 }
 
+// Models returns the models added to the migrator.
+func (m *GormMigrator) Models() []any {
+	models := make([]any, 0, len(m.models))
+	for _, model := range m.models {
+		models = append(models, model)
+	}
+
+	return models
+}
+
 func (m *GormMigrator) SetDB(db *gorm.DB) *GormMigrator {
 	m.db = db
 	return m
@@ -44,6 +90,46 @@ func (m *GormMigrator) Migrate() error {
 		return errors.New("error: cannot migrate models without setting a database first")
 	}
 
+	if m.ValidateTags {
+		if err := ValidateModelTags(m.Models()...); err != nil {
+			return err
+		}
+	}
+
+	if m.StrictJSONTags {
+		if err := ValidateJSONTags(m.Models()...); err != nil {
+			return err
+		}
+	}
+
+	if m.Locker == nil {
+		return m.migrate()
+	}
+
+	return m.db.Connection(func(conn *gorm.DB) error {
+		acquired, err := m.Locker.Lock(conn, m.LockName, m.LockTimeout)
+		if err != nil {
+			return err
+		}
+
+		if !acquired {
+			return ErrMigrationLocked
+		}
+
+		defer m.Locker.Unlock(conn, m.LockName)
+		return m.migrate()
+	})
+}
+
+func (m *GormMigrator) migrate() error {
+	if m.Mode == MIGRATION_MODE_VERSIONED {
+		if err := m.migrateVersioned(); err != nil {
+			return err
+		}
+
+		return m.snapshot()
+	}
+
 	for name, model := range m.models {
 		if len(name) == 0 {
 			continue
@@ -55,7 +141,20 @@ func (m *GormMigrator) Migrate() error {
 			m.CustomAutoMigrateFunc(m.db, model)
 		}
 
+		if m.Partitioning {
+			if err := ApplyPartitioning(m.db, model); err != nil {
+				return err
+			}
+		}
+	}
+
+	return m.snapshot()
+}
+
+func (m *GormMigrator) snapshot() error {
+	if !m.SnapshotSchema {
+		return nil
 	}
 
-	return nil
+	return RecordSchemaSnapshot(m.db, loader.AppConfig().VersionInfo.String())
 }
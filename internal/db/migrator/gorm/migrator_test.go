@@ -32,3 +32,85 @@ func Test_migratorAddMustBeAbleToAddNewModel(t *testing.T) {
 
 	assert.Equal(t, 1, gorm.GetTestCounter(), "Did not properly migrate ExampleModel to the database.")
 }
+
+type TypoModel struct {
+	Id    uint64 `gorm:"primaryKey"`
+	Email string `gofm:"unique"`
+}
+
+type DuplicateColumnModel struct {
+	Id    uint64 `gorm:"primaryKey"`
+	Name  string
+	Alias string `gorm:"column:name"`
+}
+
+type NoPrimaryKeyModel struct {
+	Name string `gorm:"unique" json:"name"`
+}
+
+func Test_shouldReportTheTypoInTheTagOfAModel(t *testing.T) {
+	err := gorm.ValidateModelTags(&ExampleModel{}, &TypoModel{})
+
+	if assert.NotNil(t, err, "The typo in the tag was not reported.") {
+		assert.Contains(t, err.Error(), `unknown tag key "gofm" on field Email`)
+		assert.NotContains(t, err.Error(), "ExampleModel", "A valid model must not be reported.")
+	}
+}
+
+func Test_shouldReportDuplicatedColumnsAndMissingPrimaryKeys(t *testing.T) {
+	err := gorm.ValidateModelTags(&DuplicateColumnModel{}, NoPrimaryKeyModel{})
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `column "name" is used by the fields Name, Alias`)
+		assert.Contains(t, err.Error(), "model NoPrimaryKeyModel: missing a primary key")
+	}
+}
+
+type Timestamps struct {
+	CreatedAt int64 `json:"created_at"`
+}
+
+type JSONTaggedModel struct {
+	Timestamps
+	Id           uint64 `gorm:"primaryKey" json:"id"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"-"`
+	secret       string
+}
+
+type UntaggedModel struct {
+	Id       uint64 `gorm:"primaryKey" json:"id"`
+	Nickname string
+}
+
+func Test_shouldReportTheFieldsWithoutAJSONTag(t *testing.T) {
+	assert.Nil(t, gorm.ValidateJSONTags(&JSONTaggedModel{}), "The unexported and the embedded fields need no tag.")
+
+	err := gorm.ValidateJSONTags(&JSONTaggedModel{}, &UntaggedModel{})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "error: model UntaggedModel: field Nickname has no json tag", err.Error())
+	}
+}
+
+func Test_migrateShouldAbortOnAMissingJSONTagInStrictMode(t *testing.T) {
+	gorm.ResetMigratedCounter()
+
+	inst := gorm.NewGormMigrator().Add(&UntaggedModel{}).SetDB(&_gorm.DB{})
+	assert.Nil(t, inst.Migrate(), "The json tags should only be checked in the strict mode.")
+
+	gorm.ResetMigratedCounter()
+	inst.StrictJSONTags = true
+
+	assert.NotNil(t, inst.Migrate())
+	assert.Equal(t, 0, gorm.GetTestCounter(), "No model should be migrated when a json tag is missing.")
+}
+
+func Test_migrateShouldAbortOnInvalidModelTags(t *testing.T) {
+	gorm.ResetMigratedCounter()
+
+	inst := gorm.NewGormMigrator().Add(&TypoModel{}).SetDB(&_gorm.DB{})
+	inst.ValidateTags = true
+
+	assert.NotNil(t, inst.Migrate())
+	assert.Equal(t, 0, gorm.GetTestCounter(), "No model should be migrated when the tags are invalid.")
+}
@@ -0,0 +1,94 @@
+package gorm
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrNullableColumn is returned when a critical column is nullable in the database.
+var ErrNullableColumn = errors.New("error: a critical column is nullable")
+
+// NullableColumn is a critical column that is nullable (or missing) in the database.
+type NullableColumn struct {
+	Table   string
+	Column  string
+	Missing bool
+}
+
+func (nc NullableColumn) String() string {
+	if nc.Missing {
+		return fmt.Sprintf("%s.%s (missing)", nc.Table, nc.Column)
+	}
+
+	return nc.Table + "." + nc.Column
+}
+
+// NullableCriticalColumns returns the columns (listed by their table) that are nullable or missing in
+// the database, the columns are looked up in the information_schema of MySQL.
+func NullableCriticalColumns(db *gorm.DB, columns map[string][]string) ([]NullableColumn, error) {
+	tables := make([]string, 0, len(columns))
+	for table := range columns {
+		tables = append(tables, table)
+	}
+
+	sort.Strings(tables)
+
+	nullable := []NullableColumn{}
+
+	for _, table := range tables {
+		rows := []struct {
+			ColumnName string
+			IsNullable string
+		}{}
+
+		err := db.Raw("SELECT column_name, is_nullable FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ?", table).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		isNullable := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			isNullable[strings.ToLower(row.ColumnName)] = strings.EqualFold(row.IsNullable, "YES")
+		}
+
+		for _, column := range columns[table] {
+			null, exists := isNullable[strings.ToLower(column)]
+			if !exists || null {
+				nullable = append(nullable, NullableColumn{Table: table, Column: column, Missing: !exists})
+			}
+		}
+	}
+
+	return nullable, nil
+}
+
+// CheckNotNullColumns warns about the critical columns that are nullable in the database, a model with a
+// mistaken tag (e.g. a pointer field) is migrated with a nullable column the code assumes is never NULL.
+// When strict is set the ErrNullableColumn is returned instead.
+func CheckNotNullColumns(db *gorm.DB, logger *slog.Logger, strict bool, columns map[string][]string) error {
+	nullable, err := NullableCriticalColumns(db, columns)
+	if err != nil || len(nullable) == 0 {
+		return err
+	}
+
+	if strict {
+		names := make([]string, len(nullable))
+		for i, column := range nullable {
+			names[i] = column.String()
+		}
+
+		return fmt.Errorf("%w: %s", ErrNullableColumn, strings.Join(names, ", "))
+	}
+
+	for _, column := range nullable {
+		logger.Warn("critical column is nullable", "table", column.Table, "column", column.Column, "missing", column.Missing)
+	}
+
+	return nil
+}
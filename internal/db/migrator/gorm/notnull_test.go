@@ -0,0 +1,55 @@
+package gorm_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/migrator/gorm"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	_gorm "gorm.io/gorm"
+)
+
+// Receipt mistakenly declares its Number as a pointer, gorm migrates it as a nullable column.
+type Receipt struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Number *string
+	Total  int64
+}
+
+func expectReceiptColumns(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT column_name, is_nullable FROM information_schema.columns").
+		WithArgs("receipts").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "is_nullable"}).
+			AddRow("id", "NO").
+			AddRow("number", "YES").
+			AddRow("total", "NO"))
+}
+
+func Test_shouldReportTheNullableCriticalColumns(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	stmt := &_gorm.Statement{DB: db}
+	assert.Nil(t, stmt.Parse(&Receipt{}))
+	assert.False(t, stmt.Schema.LookUpField("Number").NotNull)
+
+	critical := map[string][]string{stmt.Schema.Table: {"number", "total", "issued_at"}}
+
+	expectReceiptColumns(mock)
+	err = gorm.CheckNotNullColumns(db, slog.Default(), true, critical)
+	assert.ErrorIs(t, err, gorm.ErrNullableColumn)
+	assert.Contains(t, err.Error(), "receipts.number, receipts.issued_at (missing)")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	expectReceiptColumns(mock)
+	assert.Nil(t, gorm.CheckNotNullColumns(db, logger, false, critical), "The nullable columns should only be warned about.")
+	assert.Contains(t, buf.String(), "column=number")
+	assert.NotContains(t, buf.String(), "column=total")
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,102 @@
+package gorm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PARTITION_BY_MONTH = "month"
+)
+
+// Partitioning is the partitioning strategy declared by a model, the table is partitioned by range
+// on the date Column with a partition for each of the Count months starting from the month of From.
+// The rows after the last month go to the catch-all `pmax` partition.
+//
+// Note that MySQL requires the Column to be a part of every unique key of the table (including the
+// primary key).
+type Partitioning struct {
+	Column string
+	By     string
+	From   time.Time
+	Count  int
+}
+
+// Partitioned is implemented by the models that must be partitioned once their table is migrated.
+type Partitioned interface {
+	Partitioning() *Partitioning
+}
+
+// PartitionDDL returns the `ALTER TABLE ... PARTITION BY RANGE` statement of the table.
+func PartitionDDL(table, column string, p *Partitioning) (string, error) {
+	if p.By != PARTITION_BY_MONTH {
+		return "", fmt.Errorf("error: unsupported partitioning %q of table %s", p.By, table)
+	}
+
+	if p.Count <= 0 {
+		return "", fmt.Errorf("error: table %s must have at least one partition", table)
+	}
+
+	month := time.Date(p.From.Year(), p.From.Month(), 1, 0, 0, 0, 0, time.UTC)
+	partitions := make([]string, 0, p.Count+1)
+
+	for i := 0; i < p.Count; i++ {
+		next := month.AddDate(0, 1, 0)
+		partitions = append(partitions, fmt.Sprintf("PARTITION p%s VALUES LESS THAN (TO_DAYS('%s'))",
+			month.Format("200601"), next.Format("2006-01-02")))
+
+		month = next
+	}
+
+	partitions = append(partitions, "PARTITION pmax VALUES LESS THAN MAXVALUE")
+
+	return fmt.Sprintf("ALTER TABLE `%s` PARTITION BY RANGE (TO_DAYS(`%s`)) (%s)",
+		table, column, strings.Join(partitions, ", ")), nil
+}
+
+// ApplyPartitioning partitions the table of the model when it implements Partitioned, the table is
+// left as is when it is already partitioned. Partitioning is only supported on MySQL, the other
+// databases are skipped.
+func ApplyPartitioning(db *gorm.DB, model any) error {
+	partitioned, ok := model.(Partitioned)
+	if !ok || db.Dialector == nil || db.Dialector.Name() != "mysql" {
+		return nil
+	}
+
+	p := partitioned.Partitioning()
+	if p == nil {
+		return nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	field := stmt.Schema.LookUpField(p.Column)
+	if field == nil || len(field.DBName) == 0 {
+		return fmt.Errorf("error: partition column %s does not exist in %s", p.Column, stmt.Schema.Name)
+	}
+
+	ddl, err := PartitionDDL(stmt.Schema.Table, field.DBName, p)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+
+	err = db.Raw("SELECT COUNT(*) FROM information_schema.PARTITIONS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL",
+		stmt.Schema.Table).Scan(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count != 0 {
+		return nil
+	}
+
+	return db.Exec(ddl).Error
+}
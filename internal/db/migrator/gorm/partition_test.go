@@ -0,0 +1,67 @@
+package gorm_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/migrator/gorm"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type LedgerEntry struct {
+	Id       uint64    `gorm:"primaryKey"`
+	PostedAt time.Time `gorm:"primaryKey"`
+	Amount   int64
+}
+
+func (*LedgerEntry) Partitioning() *gorm.Partitioning {
+	return &gorm.Partitioning{
+		Column: "PostedAt",
+		By:     gorm.PARTITION_BY_MONTH,
+		From:   time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC),
+		Count:  3,
+	}
+}
+
+const LEDGER_PARTITION_DDL = "ALTER TABLE `ledger_entries` PARTITION BY RANGE (TO_DAYS(`posted_at`)) (" +
+	"PARTITION p202311 VALUES LESS THAN (TO_DAYS('2023-12-01')), " +
+	"PARTITION p202312 VALUES LESS THAN (TO_DAYS('2024-01-01')), " +
+	"PARTITION p202401 VALUES LESS THAN (TO_DAYS('2024-02-01')), " +
+	"PARTITION pmax VALUES LESS THAN MAXVALUE)"
+
+func Test_migrationShouldPartitionTheDeclaringModels(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	inst := gorm.NewGormMigrator().Add(&LedgerEntry{}).Add(&ExampleModel{}).SetDB(db)
+	inst.Partitioning = true
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.PARTITIONS").
+		WithArgs("ledger_entries").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(regexp.QuoteMeta(LEDGER_PARTITION_DDL)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Nil(t, inst.Migrate())
+	assert.Nil(t, mock.ExpectationsWereMet(), "Only the table of LedgerEntry should have been partitioned.")
+}
+
+func Test_shouldNotRepartitionAPartitionedTable(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.PARTITIONS").
+		WithArgs("ledger_entries").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	assert.Nil(t, gorm.ApplyPartitioning(db, &LedgerEntry{}))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldRejectAnUnsupportedPartitioning(t *testing.T) {
+	_, err := gorm.PartitionDDL("ledger_entries", "posted_at", &gorm.Partitioning{By: "week", Count: 1})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,79 @@
+package gorm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaSnapshot is the row recording the DDL of the schema after a successful migration, the snapshots
+// of the previous versions tell what a rollback of the app has to restore.
+type SchemaSnapshot struct {
+	Id      uint64 `gorm:"primaryKey"`
+	Version string `gorm:"size:64;index"`
+	DDL     string `gorm:"type:longtext"`
+	TakenAt time.Time
+}
+
+// DumpSchema returns the DDL of the tables of the database (except the `schema_snapshots`), the tables
+// are listed from the information_schema of MySQL or from the sqlite_master of SQLite.
+func DumpSchema(db *gorm.DB) (string, error) {
+	snapshots := &gorm.Statement{DB: db}
+	if err := snapshots.Parse(&SchemaSnapshot{}); err != nil {
+		return "", err
+	}
+
+	statements := []string{}
+
+	switch name := db.Dialector.Name(); name {
+	case "mysql":
+		tables := []string{}
+		err := db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name").
+			Scan(&tables).Error
+		if err != nil {
+			return "", err
+		}
+
+		for _, table := range tables {
+			if table == snapshots.Table {
+				continue
+			}
+
+			var created, ddl string
+			if err := db.Raw(fmt.Sprintf("SHOW CREATE TABLE %s", db.Statement.Quote(table))).Row().Scan(&created, &ddl); err != nil {
+				return "", err
+			}
+
+			statements = append(statements, ddl)
+		}
+	case "sqlite":
+		err := db.Raw("SELECT sql FROM sqlite_master WHERE sql IS NOT NULL AND tbl_name <> ? ORDER BY type DESC, name", snapshots.Table).
+			Scan(&statements).Error
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("error: cannot dump the schema of a %s database", name)
+	}
+
+	return strings.Join(statements, ";\n\n") + ";\n", nil
+}
+
+// RecordSchemaSnapshot records the DDL of the schema tagged with the version of the app, the
+// `schema_snapshots` table is created on the first snapshot.
+func RecordSchemaSnapshot(db *gorm.DB, version string) error {
+	if !db.Migrator().HasTable(&SchemaSnapshot{}) {
+		if err := db.Migrator().CreateTable(&SchemaSnapshot{}); err != nil {
+			return err
+		}
+	}
+
+	ddl, err := DumpSchema(db)
+	if err != nil {
+		return err
+	}
+
+	return db.Create(&SchemaSnapshot{Version: version, DDL: ddl, TakenAt: time.Now()}).Error
+}
@@ -0,0 +1,43 @@
+package gorm_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/migrator/gorm"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldSnapshotTheSchemaWithTheVersionAfterTheMigration(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.VersionInfo = &loader.AppVersion{Major: 1, Minor: 4, Build: 2}
+	})
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	inst := gorm.NewGormMigrator().SetDB(db)
+	inst.SnapshotSchema = true
+
+	usersDDL := "CREATE TABLE `users` (\n  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n)"
+	ordersDDL := "CREATE TABLE `orders` (\n  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n)"
+
+	mock.ExpectQuery("SELECT DATABASE()").
+		WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("idream"))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("orders").AddRow("schema_snapshots").AddRow("users"))
+	mock.ExpectQuery("SHOW CREATE TABLE `orders`").
+		WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("orders", ordersDDL))
+	mock.ExpectQuery("SHOW CREATE TABLE `users`").
+		WillReturnRows(sqlmock.NewRows([]string{"Table", "Create Table"}).AddRow("users", usersDDL))
+	mock.ExpectExec("INSERT INTO `schema_snapshots` \\(`version`,`ddl`,`taken_at`\\)").
+		WithArgs("1.4.2", ordersDDL+";\n\n"+usersDDL+";\n", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.Nil(t, inst.Migrate())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
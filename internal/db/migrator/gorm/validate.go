@@ -0,0 +1,182 @@
+package gorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// KnownTagKeys are the struct tag keys accepted on the fields of a model, any other key is most
+	// likely a typo of `gorm` (e.g. `gofm:"unique"`) which gorm silently ignores.
+	KnownTagKeys = []string{"gorm", "json", "xml", "yaml", "form", "binding", "validate", "protobuf", "protobuf_oneof"}
+)
+
+// tagKeys returns the keys of a struct tag formatted as `key:"value" key2:"value"`.
+func tagKeys(tag reflect.StructTag) (keys []string) {
+	s := string(tag)
+
+	for {
+		s = strings.TrimLeft(s, " ")
+
+		i := strings.Index(s, `:"`)
+		if i <= 0 {
+			return
+		}
+
+		keys = append(keys, s[:i])
+		s = s[i+2:]
+
+		// Skip the quoted value while honoring the escaped quotes.
+		for j := 0; j < len(s); j++ {
+			if s[j] == '\\' {
+				j++
+				continue
+			}
+
+			if s[j] == '"' {
+				s = s[j+1:]
+				break
+			}
+		}
+	}
+}
+
+func checkTagKeys(model string, typ reflect.Type, known map[string]bool) (errs []error) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		for _, key := range tagKeys(field.Tag) {
+			if !known[key] {
+				errs = append(errs, fmt.Errorf("error: model %s: unknown tag key %q on field %s", model, key, field.Name))
+			}
+		}
+
+		ftyp := field.Type
+		if ftyp.Kind() == reflect.Pointer {
+			ftyp = ftyp.Elem()
+		}
+
+		if ftyp.Kind() == reflect.Struct && (field.Anonymous || strings.Contains(field.Tag.Get("gorm"), "embedded")) {
+			errs = append(errs, checkTagKeys(model, ftyp, known)...)
+		}
+	}
+
+	return
+}
+
+// ValidateModelTags reflects over the models and reports the unknown tag keys, the duplicated column
+// names and the models that are missing a primary key. All of the problems are aggregated into the
+// returned error.
+func ValidateModelTags(models ...any) error {
+	known := make(map[string]bool)
+	for _, key := range KnownTagKeys {
+		known[key] = true
+	}
+
+	errs := []error{}
+	cache := &sync.Map{}
+
+	for _, model := range models {
+		typ := reflect.TypeOf(model)
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		if typ.Kind() != reflect.Struct {
+			errs = append(errs, fmt.Errorf("error: %s is not a struct", typ.String()))
+			continue
+		}
+
+		errs = append(errs, checkTagKeys(typ.Name(), typ, known)...)
+
+		s, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error: model %s: %s", typ.Name(), err.Error()))
+			continue
+		}
+
+		columns := make(map[string][]string)
+		for _, field := range s.Fields {
+			if len(field.DBName) != 0 {
+				columns[field.DBName] = append(columns[field.DBName], field.Name)
+			}
+		}
+
+		names := make([]string, 0, len(columns))
+		for name := range columns {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			if len(columns[name]) > 1 {
+				errs = append(errs, fmt.Errorf("error: model %s: column %q is used by the fields %s", typ.Name(), name, strings.Join(columns[name], ", ")))
+			}
+		}
+
+		if len(s.PrimaryFields) == 0 {
+			errs = append(errs, fmt.Errorf("error: model %s: missing a primary key", typ.Name()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkJSONTags(model string, typ reflect.Type) (errs []error) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		ftyp := field.Type
+		if ftyp.Kind() == reflect.Pointer {
+			ftyp = ftyp.Elem()
+		}
+
+		// The fields of an untagged embedded struct are encoded as the fields of the model.
+		if field.Anonymous && ftyp.Kind() == reflect.Struct {
+			if _, tagged := field.Tag.Lookup("json"); !tagged {
+				errs = append(errs, checkJSONTags(model, ftyp)...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if _, tagged := field.Tag.Lookup("json"); !tagged {
+			errs = append(errs, fmt.Errorf("error: model %s: field %s has no json tag", model, field.Name))
+		}
+	}
+
+	return
+}
+
+// ValidateJSONTags reports the exported fields of the models without a `json` tag, their Go names
+// would otherwise leak into the responses of the api. A field that must not be encoded is tagged with
+// `json:"-"`.
+func ValidateJSONTags(models ...any) error {
+	errs := []error{}
+
+	for _, model := range models {
+		typ := reflect.TypeOf(model)
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		if typ.Kind() != reflect.Struct {
+			errs = append(errs, fmt.Errorf("error: %s is not a struct", typ.String()))
+			continue
+		}
+
+		errs = append(errs, checkJSONTags(typ.Name(), typ)...)
+	}
+
+	return errors.Join(errs...)
+}
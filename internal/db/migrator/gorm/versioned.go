@@ -0,0 +1,183 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/rommms07/idream-erp/internal/db/reconnect"
+	"github.com/rommms07/idream-erp/internal/retry"
+	"gorm.io/gorm"
+)
+
+const (
+	MIGRATION_MODE_AUTO      = "auto"
+	MIGRATION_MODE_VERSIONED = "versioned"
+)
+
+var (
+	ErrDestructiveMigration = errors.New("error: refused to run a destructive migration")
+
+	// ErrPartialMigration is returned when a migration failed after some of its statements were applied
+	// and the database cannot roll them back (e.g. the DDL of MySQL commits implicitly). The schema must
+	// be fixed by hand before the migration is run again.
+	ErrPartialMigration = errors.New("error: a migration was partially applied and needs a manual intervention")
+)
+
+// transactionalDDL are the dialects whose DDL can be rolled back within a transaction.
+var transactionalDDL = map[string]bool{
+	"postgres":  true,
+	"sqlite":    true,
+	"sqlserver": true,
+}
+
+// IsTransientError reports whether a failed migration can be retried as is, that is when the database
+// was unreachable or the migration lost a deadlock (1213) or a lock wait (1205).
+func IsTransientError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1205 || mysqlErr.Number == 1213
+	}
+
+	return reconnect.IsConnError(err)
+}
+
+// countingPool counts the statements of a migration that were applied.
+type countingPool struct {
+	gorm.ConnPool
+	applied int
+}
+
+func (p *countingPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	res, err := p.ConnPool.ExecContext(ctx, query, args...)
+	if err == nil {
+		p.applied++
+	}
+
+	return res, err
+}
+
+// Commit and Rollback keep the pool a gorm.TxCommitter, the transaction (and the nested ones of the
+// migration) are committed through it.
+func (p *countingPool) Commit() error {
+	return p.ConnPool.(gorm.TxCommitter).Commit()
+}
+
+func (p *countingPool) Rollback() error {
+	return p.ConnPool.(gorm.TxCommitter).Rollback()
+}
+
+// Migration is a single step of the versioned mode, the migrations are run in the order of their
+// Version. A migration that drops or rewrites what the running version of the app still uses (e.g.
+// dropping a column) must be marked as Destructive, otherwise the old instances of a rolling deploy
+// break as soon as it is applied.
+type Migration struct {
+	Version     string
+	Name        string
+	Destructive bool
+	Up          func(tx *gorm.DB) error
+}
+
+// SchemaMigration is the row recording an applied migration.
+type SchemaMigration struct {
+	Version   string `gorm:"primaryKey;size:64"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// AddMigration adds the migrations run by the versioned mode.
+func (m *GormMigrator) AddMigration(migrations ...*Migration) *GormMigrator {
+	m.migrations = append(m.migrations, migrations...)
+	return m
+}
+
+// Migrations returns the added migrations sorted by their version.
+func (m *GormMigrator) Migrations() []*Migration {
+	migrations := append([]*Migration{}, m.migrations...)
+
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations
+}
+
+// migrateVersioned runs the migrations that were not applied yet, each in its own transaction. It
+// stops at the first destructive migration unless AllowDestructive is set, the migrations before it
+// are still applied. A migration failing with a transient error is retried with the Retry policy unless
+// it was partially applied.
+func (m *GormMigrator) migrateVersioned() error {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		if err := m.db.Migrator().CreateTable(&SchemaMigration{}); err != nil {
+			return err
+		}
+	}
+
+	applied := []string{}
+	if err := m.db.Model(&SchemaMigration{}).Pluck("version", &applied).Error; err != nil {
+		return err
+	}
+
+	done := make(map[string]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	for _, migration := range m.Migrations() {
+		if done[migration.Version] {
+			continue
+		}
+
+		if migration.Destructive && !m.AllowDestructive {
+			return fmt.Errorf("%w: %s (%s), set the allowDestructive of the config once no instance depends on the old schema",
+				ErrDestructiveMigration, migration.Version, migration.Name)
+		}
+
+		partial := false
+		err := retry.Do(context.Background(), m.Retry, func(err error) bool {
+			return !partial && IsTransientError(err)
+		}, func(ctx context.Context) (err error) {
+			partial, err = m.apply(migration)
+			return err
+		})
+
+		if partial {
+			return fmt.Errorf("%w: %s (%s) failed after some of its statements were applied, revert them (or complete the migration and record it in the schema_migrations) before migrating again: %v",
+				ErrPartialMigration, migration.Version, migration.Name, err)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error: migration %s (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// apply runs the migration in its own transaction, the returned bool is true when the migration failed
+// but some of its statements could not be rolled back.
+func (m *GormMigrator) apply(migration *Migration) (bool, error) {
+	var pool *countingPool
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		pool = &countingPool{ConnPool: tx.Statement.ConnPool}
+		tx.Statement.ConnPool = pool
+
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+
+	partial := err != nil && pool != nil && pool.applied != 0 && !transactionalDDL[m.db.Dialector.Name()]
+	return partial, err
+}
@@ -0,0 +1,154 @@
+package gorm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/migrator/gorm"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	_gorm "gorm.io/gorm"
+)
+
+// newVersionedMigrator creates a versioned migrator whose database already applied the 0001
+// migration, the 0002 migration drops a column used by the running version of the app.
+func newVersionedMigrator(t *testing.T, ran *[]string) (*gorm.GormMigrator, sqlmock.Sqlmock) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	step := func(version string) func(tx *_gorm.DB) error {
+		return func(tx *_gorm.DB) error {
+			*ran = append(*ran, version)
+			return nil
+		}
+	}
+
+	inst := gorm.NewGormMigrator().SetDB(db).AddMigration(
+		&gorm.Migration{Version: "0002", Name: "drop_users_fbid", Destructive: true, Up: step("0002")},
+		&gorm.Migration{Version: "0001", Name: "create_users", Up: step("0001")},
+	)
+
+	inst.Mode = gorm.MIGRATION_MODE_VERSIONED
+
+	mock.ExpectQuery("SELECT DATABASE()").
+		WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("idream"))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT `version` FROM `schema_migrations`").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("0001"))
+
+	return inst, mock
+}
+
+func Test_destructiveMigrationShouldBeBlockedWithoutTheFlag(t *testing.T) {
+	ran := []string{}
+	inst, mock := newVersionedMigrator(t, &ran)
+
+	err := inst.Migrate()
+
+	assert.ErrorIs(t, err, gorm.ErrDestructiveMigration)
+	assert.Contains(t, err.Error(), "0002 (drop_users_fbid)", "The error should name the blocked migration.")
+	assert.Empty(t, ran)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_destructiveMigrationShouldRunWithTheFlag(t *testing.T) {
+	ran := []string{}
+	inst, mock := newVersionedMigrator(t, &ran)
+	inst.AllowDestructive = true
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `schema_migrations`").
+		WithArgs("0002", "drop_users_fbid", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, inst.Migrate())
+	assert.Equal(t, []string{"0002"}, ran, "Only the pending migration should have run.")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+// newFailingMigrator creates a versioned migrator with a pending 0003 migration adding two columns,
+// the second ALTER fails with the errs (one per attempt).
+func newFailingMigrator(t *testing.T, errs ...error) (*gorm.GormMigrator, sqlmock.Sqlmock) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	inst := gorm.NewGormMigrator().SetDB(db).AddMigration(&gorm.Migration{
+		Version: "0003",
+		Name:    "add_users_phone",
+		Up: func(tx *_gorm.DB) error {
+			if err := tx.Exec("ALTER TABLE users ADD phone varchar(32)").Error; err != nil {
+				return err
+			}
+
+			return tx.Exec("ALTER TABLE users ADD phone_verified bool").Error
+		},
+	})
+
+	inst.Mode = gorm.MIGRATION_MODE_VERSIONED
+
+	mock.ExpectQuery("SELECT DATABASE()").
+		WillReturnRows(sqlmock.NewRows([]string{"DATABASE()"}).AddRow("idream"))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT `version` FROM `schema_migrations`").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+	for _, err := range errs {
+		mock.ExpectBegin()
+		mock.ExpectExec("ALTER TABLE users ADD phone ").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE users ADD phone_verified").WillReturnError(err)
+		mock.ExpectRollback()
+	}
+
+	return inst, mock
+}
+
+func Test_failingTransactionalMigrationShouldLeaveNoPartialChanges(t *testing.T) {
+	t.Cleanup(gorm.SetTransactionalDDL("mysql", true))
+
+	inst, mock := newFailingMigrator(t, errors.New("error: duplicate column"))
+
+	err := inst.Migrate()
+
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, gorm.ErrPartialMigration, "The rolled back migration should not need a manual intervention.")
+	assert.Contains(t, err.Error(), "0003 (add_users_phone)")
+	assert.Nil(t, mock.ExpectationsWereMet(), "The migration should be rolled back without being recorded.")
+}
+
+func Test_partiallyAppliedMysqlMigrationShouldNeedAManualIntervention(t *testing.T) {
+	inst, mock := newFailingMigrator(t,
+		&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+	inst.Retry = &loader.RetryPolicy{MaxAttempts: 3}
+
+	err := inst.Migrate()
+
+	assert.ErrorIs(t, err, gorm.ErrPartialMigration)
+	assert.Contains(t, err.Error(), "schema_migrations", "The error should tell how to recover.")
+	assert.Nil(t, mock.ExpectationsWereMet(), "A partially applied migration should not be retried.")
+}
+
+func Test_transientMigrationFailureShouldBeRetried(t *testing.T) {
+	t.Cleanup(gorm.SetTransactionalDDL("mysql", true))
+
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	inst, mock := newFailingMigrator(t, deadlock)
+	inst.Retry = &loader.RetryPolicy{MaxAttempts: 3}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE users ADD phone ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE users ADD phone_verified").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `schema_migrations`").
+		WithArgs("0003", "add_users_phone", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, inst.Migrate())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
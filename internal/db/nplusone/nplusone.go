@@ -0,0 +1,96 @@
+// This package warns about the likely N+1 queries during the development, it counts the queries of the
+// same shape (i.e. the same SQL with its placeholders) made within a request and logs a warning once a
+// shape was made more times than the threshold, e.g. when the rows of a list are loaded one at a time.
+
+package nplusone
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+const (
+	DEFAULT_THRESHOLD = 5
+)
+
+type trackerKey struct{}
+
+// tracker counts the queries of every shape made within a request.
+type tracker struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// WithTracking returns a copy of the ctx in which the queries are counted, it must be the ctx of a
+// single request.
+func WithTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trackerKey{}, &tracker{counts: make(map[string]int)})
+}
+
+// Enabled reports whether the detection is enabled, it only runs in the development environment.
+func Enabled() bool {
+	conf := loader.AppConfig()
+	return conf.DetectNPlusOne && conf.IsDevelopment()
+}
+
+// Threshold returns the configured `nPlusOneThreshold`.
+func Threshold() int {
+	if threshold := loader.AppConfig().NPlusOneThreshold; threshold > 0 {
+		return threshold
+	}
+
+	return DEFAULT_THRESHOLD
+}
+
+// Plugin is a gorm plugin counting the queries made within the ctx returned by WithTracking.
+type Plugin struct {
+	threshold int
+	logger    *slog.Logger
+}
+
+func New(threshold int, logger *slog.Logger) *Plugin {
+	return &Plugin{threshold: threshold, logger: logger}
+}
+
+func (p *Plugin) Name() string {
+	return "nplusone"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	return errors.Join(
+		callbacks.Query().After("gorm:query").Register("nplusone:after_query", p.count),
+		callbacks.Row().After("gorm:row").Register("nplusone:after_row", p.count),
+	)
+}
+
+func (p *Plugin) count(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil || db.DryRun {
+		return
+	}
+
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return
+	}
+
+	shape := db.Statement.SQL.String()
+
+	t.mu.Lock()
+	t.counts[shape]++
+	n := t.counts[shape]
+	t.mu.Unlock()
+
+	// Warn once per shape and request, right when it crosses the threshold.
+	if n == p.threshold+1 {
+		p.logger.WarnContext(ctx, "db: likely N+1 query, the same query was made repeatedly within a request",
+			"sql", shape, "table", db.Statement.Table, "count", n)
+	}
+}
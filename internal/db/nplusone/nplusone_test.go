@@ -0,0 +1,69 @@
+package nplusone_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/nplusone"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	Id         uint64
+	CustomerId uint64
+}
+
+func Test_shouldWarnAboutTheRepeatedQueriesOfARequest(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, db.Use(nplusone.New(3, slog.New(slog.NewTextHandler(buf, nil)))))
+
+	load := func(ctx context.Context, times int) {
+		for i := 0; i < times; i++ {
+			mock.ExpectQuery("SELECT \\* FROM `orders` WHERE customer_id = \\?").
+				WithArgs(i).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id"}).AddRow(1, i))
+
+			orders := []order{}
+			assert.Nil(t, db.WithContext(ctx).Where("customer_id = ?", i).Find(&orders).Error)
+		}
+	}
+
+	ctx := nplusone.WithTracking(context.Background())
+
+	load(ctx, 3)
+	assert.Empty(t, buf.String(), "The threshold must not warn.")
+
+	load(ctx, 3)
+	assert.Equal(t, 1, strings.Count(buf.String(), "likely N+1 query"), "The shape must only be warned about once.")
+	assert.Contains(t, buf.String(), "count=4")
+
+	// The queries of another request (or outside of any) are counted apart.
+	buf.Reset()
+	load(nplusone.WithTracking(context.Background()), 3)
+	load(context.Background(), 5)
+	assert.Empty(t, buf.String())
+
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldOnlyBeEnabledInTheDevelopment(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DetectNPlusOne = true
+		conf.Environment = "devel"
+	})
+	assert.True(t, nplusone.Enabled())
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.Environment = "production"
+	})
+	assert.False(t, nplusone.Enabled())
+}
@@ -0,0 +1,89 @@
+// This package implements the optimistic locking of the models having a `Version` field, an update only
+// applies when the version of the row is still the one that was read and bumps it. A conflicting update
+// fails with the ErrStaleWrite, the operations that can simply re-read the row are re-run by the
+// RetryOptimistic.
+
+package optimistic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/retry"
+	"gorm.io/gorm"
+)
+
+const (
+	// VERSION_FIELD is the field of the models holding their version.
+	VERSION_FIELD = "Version"
+
+	DEFAULT_RETRY_ATTEMPTS = 3
+)
+
+// ErrStaleWrite is returned by the Update when the row was modified since the model was read.
+var ErrStaleWrite = errors.New("error: the row was modified by another write")
+
+// Update applies the updates to the row of the model as long as its version is still the one of the
+// model, the version of the model is bumped along with the row.
+func Update(tx *gorm.DB, model any, updates map[string]any) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	field := stmt.Schema.LookUpField(VERSION_FIELD)
+	if field == nil {
+		return fmt.Errorf("error: %s has no %s field", stmt.Schema.Name, VERSION_FIELD)
+	}
+
+	row := reflect.Indirect(reflect.ValueOf(model))
+	val, _ := field.ValueOf(tx.Statement.Context, row)
+
+	version, ok := val.(uint64)
+	if !ok {
+		return fmt.Errorf("error: the %s of %s must be an uint64", VERSION_FIELD, stmt.Schema.Name)
+	}
+
+	values := make(map[string]any, len(updates)+1)
+	for key, val := range updates {
+		values[key] = val
+	}
+
+	values[field.DBName] = version + 1
+
+	res := tx.Model(model).Where(fmt.Sprintf("%s = ?", tx.Statement.Quote(field.DBName)), version).Updates(values)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrStaleWrite
+	}
+
+	return field.Set(tx.Statement.Context, row, version+1)
+}
+
+// RetryOptimistic runs the fn within a transaction and re-runs it whenever it fails with the
+// ErrStaleWrite, up to the maxAttempts (the `optimisticRetryAttempts` when it is zero). The fn must
+// (re-)read the rows it updates with the tx so that a retry sees the conflicting write. The error of
+// the last attempt is returned once they are exhausted.
+func RetryOptimistic(ctx context.Context, db *gorm.DB, maxAttempts int, fn func(tx *gorm.DB) error) error {
+	if maxAttempts == 0 {
+		maxAttempts = loader.AppConfig().OptimisticRetryAttempts
+	}
+
+	if maxAttempts == 0 {
+		maxAttempts = DEFAULT_RETRY_ATTEMPTS
+	}
+
+	policy := &loader.RetryPolicy{MaxAttempts: maxAttempts}
+	retryable := func(err error) bool { return errors.Is(err, ErrStaleWrite) }
+
+	// The conflicts are resolved by the database itself, they must not spend the shared retry budget.
+	return retry.DoWithBudget(ctx, nil, policy, retryable, func(ctx context.Context) error {
+		return db.WithContext(ctx).Transaction(fn)
+	})
+}
@@ -0,0 +1,70 @@
+package optimistic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/optimistic"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Stock struct {
+	Id       uint64 `gorm:"primaryKey"`
+	Quantity int64
+	Version  uint64
+}
+
+// expectAttempt expects a single attempt of the restock, the update is stale unless applied.
+func expectAttempt(mock sqlmock.Sqlmock, version int, applied bool) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `stocks` WHERE `stocks`.`id` = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity", "version"}).AddRow(1, 10, version))
+
+	exec := mock.ExpectExec("UPDATE `stocks` SET `quantity`=\\?,`version`=\\? WHERE `version` = \\? AND `id` = \\?").
+		WithArgs(15, version+1, version, 1)
+
+	if applied {
+		exec.WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		return
+	}
+
+	exec.WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+}
+
+func restock(tx *gorm.DB) error {
+	stock := &Stock{}
+	if err := tx.Take(stock, 1).Error; err != nil {
+		return err
+	}
+
+	return optimistic.Update(tx, stock, map[string]any{"quantity": stock.Quantity + 5})
+}
+
+func Test_shouldRetryAConflictUntilItResolves(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectAttempt(mock, 3, false)
+	expectAttempt(mock, 4, true)
+
+	assert.Nil(t, optimistic.RetryOptimistic(context.Background(), db, 3, restock))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldGiveUpOnAConflictThatNeverResolves(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	expectAttempt(mock, 3, false)
+	expectAttempt(mock, 4, false)
+
+	err = optimistic.RetryOptimistic(context.Background(), db, 2, restock)
+	assert.ErrorIs(t, err, optimistic.ErrStaleWrite)
+	assert.Nil(t, mock.ExpectationsWereMet(), "No attempt past the max must be made.")
+}
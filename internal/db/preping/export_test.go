@@ -0,0 +1,10 @@
+package preping
+
+import "time"
+
+// SetNow overrides the clock of the connections, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
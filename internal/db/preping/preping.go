@@ -0,0 +1,124 @@
+// This package pings the connections that were idle for too long before they are handed to a query,
+// a connection that fails the ping is discarded by the database/sql and replaced by a fresh one. This
+// catches the connections that were silently dropped by the server (or a proxy) while idle.
+
+package preping
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// now is used to tell how long a connection was idle, the tests override it.
+	now = time.Now
+)
+
+// Connector wraps the connections of a driver.Connector so that they are pinged once they were idle
+// for longer than the IdleThreshold.
+type Connector struct {
+	driver.Connector
+
+	IdleThreshold time.Duration
+}
+
+func NewConnector(connector driver.Connector, idleThreshold time.Duration) *Connector {
+	return &Connector{Connector: connector, IdleThreshold: idleThreshold}
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &pingedConn{Conn: conn, threshold: c.IdleThreshold}
+	pc.idleSince.Store(now().UnixNano())
+	return pc, nil
+}
+
+// pingedConn forwards the optional interfaces of the database/sql/driver to the wrapped connection, the
+// database/sql falls back to the mandatory ones when a forwarded interface returns a driver.ErrSkip.
+type pingedConn struct {
+	driver.Conn
+
+	threshold time.Duration
+	idleSince atomic.Int64
+}
+
+// IsValid is called when the connection is put back into the pool, which is when it starts idling.
+func (c *pingedConn) IsValid() bool {
+	c.idleSince.Store(now().UnixNano())
+
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+// ResetSession is called before a pooled connection is reused, the connection is discarded when it
+// fails the ping.
+func (c *pingedConn) ResetSession(ctx context.Context) error {
+	if idle := now().Sub(time.Unix(0, c.idleSince.Load())); idle >= c.threshold {
+		if err := c.Ping(ctx); err != nil {
+			return driver.ErrBadConn
+		}
+	}
+
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *pingedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *pingedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c *pingedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+func (c *pingedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+func (c *pingedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+func (c *pingedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
@@ -0,0 +1,98 @@
+package preping_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/internal/db/preping"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn is a connection of the fakeConnector, a dead connection fails its ping and its queries.
+type fakeConn struct {
+	id     int
+	dead   bool
+	closed bool
+	pings  int
+	execs  *[]int
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error              { c.closed = true; return nil }
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	c.pings++
+
+	if c.dead {
+		return errors.New("broken pipe")
+	}
+
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.dead {
+		return nil, errors.New("broken pipe")
+	}
+
+	*c.execs = append(*c.execs, c.id)
+	return driver.RowsAffected(0), nil
+}
+
+type fakeConnector struct {
+	mu    sync.Mutex
+	conns []*fakeConn
+	execs []int
+}
+
+func (fc *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	conn := &fakeConn{id: len(fc.conns) + 1, execs: &fc.execs}
+	fc.conns = append(fc.conns, conn)
+	return conn, nil
+}
+
+func (fc *fakeConnector) Driver() driver.Driver { return nil }
+
+func Test_shouldDiscardAStaleConnection(t *testing.T) {
+	clock := time.Now()
+	defer preping.SetNow(func() time.Time { return clock })()
+
+	fake := &fakeConnector{}
+	db := sql.OpenDB(preping.NewConnector(fake, 10*time.Second))
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err := db.Exec("UPDATE orders SET status = 'paid'")
+	assert.Nil(t, err)
+
+	// The connection is reused without a ping while it was not idle for long.
+	clock = clock.Add(5 * time.Second)
+	_, err = db.Exec("UPDATE orders SET status = 'paid'")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, fake.conns[0].pings)
+
+	// The server dropped the connection while it was idle.
+	fake.conns[0].dead = true
+	clock = clock.Add(time.Minute)
+
+	_, err = db.Exec("UPDATE orders SET status = 'paid'")
+	assert.Nil(t, err, "The query must transparently run on a fresh connection.")
+
+	if assert.Len(t, fake.conns, 2) {
+		assert.Equal(t, 1, fake.conns[0].pings)
+		assert.True(t, fake.conns[0].closed, "The stale connection must be discarded.")
+		assert.Equal(t, []int{1, 1, 2}, fake.execs)
+	}
+}
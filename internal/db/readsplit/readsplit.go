@@ -0,0 +1,113 @@
+// This package routes the plain reads of gorm to the replicas of the database, the locking reads
+// (`SELECT ... FOR UPDATE`), the writes and everything that runs within a transaction stay on the
+// primary. The routing is enabled by the `autoReadSplit` of the app config.
+
+package readsplit
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+const (
+	primaryKey = "read_split:primary"
+	poolKey    = "read_split:pool"
+)
+
+var (
+	// plainSelect matches a raw SQL that reads and lockingRead matches the ones locking the rows.
+	plainSelect = regexp.MustCompile(`(?is)^\s*(\(\s*)?(select|with)\b`)
+	lockingRead = regexp.MustCompile(`(?i)\bfor\s+(update|share)\b|\block\s+in\s+share\s+mode\b`)
+)
+
+// Primary returns a session whose reads stay on the primary, e.g. to read a row that was just written
+// before it reached the replicas.
+func Primary(db *gorm.DB) *gorm.DB {
+	return db.Set(primaryKey, true)
+}
+
+type pinnedKey struct{}
+
+// WithPrimary returns a copy of the ctx whose statements all stay on the primary, it pins the
+// statements of a request that must read its own writes.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pinnedKey{}, true)
+}
+
+// IsPinned reports whether the statements of the ctx stay on the primary, see the WithPrimary.
+func IsPinned(ctx context.Context) bool {
+	pinned, _ := ctx.Value(pinnedKey{}).(bool)
+	return pinned
+}
+
+// Plugin is a gorm plugin sending the plain reads to the replicas in turn.
+type Plugin struct {
+	replicas []gorm.ConnPool
+	next     atomic.Uint64
+}
+
+func New(replicas ...gorm.ConnPool) *Plugin {
+	return &Plugin{replicas: replicas}
+}
+
+func (p *Plugin) Name() string {
+	return "read_split"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	return errors.Join(
+		callbacks.Query().Before("gorm:query").Register("read_split:before_query", p.route),
+		callbacks.Query().After("gorm:query").Register("read_split:after_query", restore),
+		callbacks.Row().Before("gorm:row").Register("read_split:before_row", p.route),
+		callbacks.Row().After("gorm:row").Register("read_split:after_row", restore),
+	)
+}
+
+// IsPlainRead reports whether the statement only reads without locking the rows, a raw SQL is told
+// by its text and a built one by its clauses.
+func IsPlainRead(stmt *gorm.Statement) bool {
+	if sql := stmt.SQL.String(); len(sql) != 0 {
+		return plainSelect.MatchString(sql) && !lockingRead.MatchString(sql)
+	}
+
+	_, locking := stmt.Clauses["FOR"]
+	return !locking
+}
+
+func (p *Plugin) route(db *gorm.DB) {
+	if len(p.replicas) == 0 || db.Error != nil {
+		return
+	}
+
+	if primary, ok := db.Get(primaryKey); ok && primary.(bool) {
+		return
+	}
+
+	if ctx := db.Statement.Context; ctx != nil && IsPinned(ctx) {
+		return
+	}
+
+	// The reads of a transaction must see its writes.
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return
+	}
+
+	if !IsPlainRead(db.Statement) {
+		return
+	}
+
+	db.InstanceSet(poolKey, db.Statement.ConnPool)
+	db.Statement.ConnPool = p.replicas[(p.next.Add(1)-1)%uint64(len(p.replicas))]
+}
+
+func restore(db *gorm.DB) {
+	if pool, ok := db.InstanceGet(poolKey); ok {
+		db.Statement.ConnPool = pool.(gorm.ConnPool)
+	}
+}
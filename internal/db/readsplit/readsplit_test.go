@@ -0,0 +1,102 @@
+package readsplit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/readsplit"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Order struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Status string
+}
+
+func newSplitDb(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+	db, writer, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	conn, replica, err := sqlmock.New()
+	assert.Nil(t, err)
+
+	assert.Nil(t, db.Use(readsplit.New(conn)))
+	return db, writer, replica
+}
+
+func Test_aPlainSelectShouldRouteToAReplica(t *testing.T) {
+	db, writer, replica := newSplitDb(t)
+
+	replica.ExpectQuery("SELECT \\* FROM `orders`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	replica.ExpectQuery("SELECT count\\(\\*\\) FROM orders").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	orders := []*Order{}
+	assert.Nil(t, db.Find(&orders).Error)
+
+	var n int64
+	assert.Nil(t, db.Raw("SELECT count(*) FROM orders").Scan(&n).Error)
+
+	assert.Nil(t, replica.ExpectationsWereMet())
+	assert.Nil(t, writer.ExpectationsWereMet())
+}
+
+func Test_aLockingSelectShouldRouteToTheWriter(t *testing.T) {
+	db, writer, replica := newSplitDb(t)
+
+	writer.ExpectQuery("SELECT \\* FROM `orders` WHERE `orders`.`id` = \\? LIMIT 1 FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	writer.ExpectQuery("SELECT status FROM orders WHERE id = \\? LOCK IN SHARE MODE").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("paid"))
+	writer.ExpectExec("UPDATE `orders` SET `status`=\\?").WillReturnResult(sqlmock.NewResult(0, 1))
+	writer.ExpectQuery("SELECT \\* FROM `orders`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	order := &Order{}
+	assert.Nil(t, db.Clauses(clause.Locking{Strength: "UPDATE"}).Take(order, 1).Error)
+
+	var status string
+	assert.Nil(t, db.Raw("SELECT status FROM orders WHERE id = ? LOCK IN SHARE MODE", 1).Scan(&status).Error)
+
+	assert.Nil(t, db.Model(order).Update("status", "shipped").Error)
+
+	// Reading a row that was just written must not hit a replica that is behind.
+	orders := []*Order{}
+	assert.Nil(t, readsplit.Primary(db).Find(&orders).Error)
+
+	assert.Nil(t, writer.ExpectationsWereMet())
+	assert.Nil(t, replica.ExpectationsWereMet())
+}
+
+func Test_theReadsOfATransactionShouldStayOnTheWriter(t *testing.T) {
+	db, writer, replica := newSplitDb(t)
+
+	writer.ExpectBegin()
+	writer.ExpectQuery("SELECT \\* FROM `orders`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	writer.ExpectCommit()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		orders := []*Order{}
+		return tx.Find(&orders).Error
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, writer.ExpectationsWereMet())
+	assert.Nil(t, replica.ExpectationsWereMet())
+}
+
+func Test_thePinnedReadsShouldRouteToTheWriter(t *testing.T) {
+	db, writer, replica := newSplitDb(t)
+
+	writer.ExpectQuery("SELECT \\* FROM `orders`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	replica.ExpectQuery("SELECT \\* FROM `orders`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	orders := []*Order{}
+	assert.Nil(t, db.WithContext(readsplit.WithPrimary(context.Background())).Find(&orders).Error)
+	assert.Nil(t, db.WithContext(context.Background()).Find(&orders).Error, "An unpinned read should still route to a replica.")
+
+	assert.Nil(t, replica.ExpectationsWereMet())
+	assert.Nil(t, writer.ExpectationsWereMet())
+}
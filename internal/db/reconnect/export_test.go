@@ -0,0 +1,10 @@
+package reconnect
+
+import "time"
+
+// SetAfter overrides the wait of the backoff, the returned func restores it.
+func SetAfter(fn func(d time.Duration) <-chan time.Time) func() {
+	bak := after
+	after = fn
+	return func() { after = bak }
+}
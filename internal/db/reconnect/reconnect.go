@@ -0,0 +1,212 @@
+// This package recovers the connection pool of the database after a connectivity loss, the pool is
+// replaced by a freshly opened one once the database is reachable again so that a brief network blip
+// does not leave the app failing until it is restarted.
+
+package reconnect
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_mysql "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+const (
+	DEFAULT_ERROR_THRESHOLD = 3
+	DEFAULT_MIN_BACKOFF     = 500 * time.Millisecond
+	DEFAULT_MAX_BACKOFF     = 30 * time.Second
+)
+
+var (
+	// after is used to wait for the backoff, the tests override it.
+	after = time.After
+)
+
+// IsConnError reports whether the err is caused by the connection to the database rather than by the
+// statement (e.g. a syntax error or a constraint violation).
+func IsConnError(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, _mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
+
+// Pool is a gorm.ConnPool forwarding the statements to the current *sql.DB, the *sql.DB is replaced
+// by a new one opened with the open func once ErrorThreshold statements in a row failed with a
+// connection error and the new one answers a ping. The reopen is retried with an exponential backoff
+// between the MinBackoff and the MaxBackoff until it succeeds.
+type Pool struct {
+	ErrorThreshold int
+	MinBackoff     time.Duration
+	MaxBackoff     time.Duration
+
+	logger     *slog.Logger
+	open       func() (*sql.DB, error)
+	current    atomic.Pointer[sql.DB]
+	failures   atomic.Int64
+	recovering atomic.Bool
+
+	// wg tracks the running recovery, see Wait.
+	wg sync.WaitGroup
+}
+
+// NewPool opens the first *sql.DB of the pool with the open func.
+func NewPool(open func() (*sql.DB, error), logger *slog.Logger) (*Pool, error) {
+	db, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pool{
+		ErrorThreshold: DEFAULT_ERROR_THRESHOLD,
+		MinBackoff:     DEFAULT_MIN_BACKOFF,
+		MaxBackoff:     DEFAULT_MAX_BACKOFF,
+		logger:         logger,
+		open:           open,
+	}
+
+	p.current.Store(db)
+	return p, nil
+}
+
+// DB returns the current *sql.DB of the pool.
+func (p *Pool) DB() *sql.DB {
+	return p.current.Load()
+}
+
+// GetDBConn lets the gorm.DB.DB return the current *sql.DB (e.g. to read the stats of the pool).
+func (p *Pool) GetDBConn() (*sql.DB, error) {
+	return p.DB(), nil
+}
+
+func (p *Pool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.DB().PrepareContext(ctx, query)
+}
+
+func (p *Pool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.DB().ExecContext(ctx, query, args...)
+}
+
+func (p *Pool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.DB().QueryContext(ctx, query, args...)
+}
+
+func (p *Pool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.DB().QueryRowContext(ctx, query, args...)
+}
+
+func (p *Pool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return p.DB().BeginTx(ctx, opts)
+}
+
+func (p *Pool) Ping() error {
+	return p.DB().Ping()
+}
+
+func (p *Pool) Close() error {
+	return p.DB().Close()
+}
+
+// Observe records the outcome of a statement, the recovery is started once the ErrorThreshold
+// connection errors in a row were observed.
+func (p *Pool) Observe(err error) {
+	if !IsConnError(err) {
+		if err == nil {
+			p.failures.Store(0)
+		}
+
+		return
+	}
+
+	if p.failures.Add(1) < int64(p.ErrorThreshold) || !p.recovering.CompareAndSwap(false, true) {
+		return
+	}
+
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+		defer p.recovering.Store(false)
+
+		p.recover()
+	}()
+}
+
+// Wait waits for the running recovery (if any) to finish.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Pool) recover() {
+	backoff := p.MinBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := p.reset()
+		if err == nil {
+			p.logger.Info("db: the connection pool was reopened", "attempts", attempt)
+			return
+		}
+
+		p.logger.Warn("db: failed to reopen the connection pool", "attempt", attempt, "backoff", backoff, "error", err)
+
+		<-after(backoff)
+		backoff = min(backoff*2, p.MaxBackoff)
+	}
+}
+
+// reset opens a new *sql.DB and swaps it with the current one once it answers a ping, the old one is
+// closed (its connections in use are closed once released).
+func (p *Pool) reset() error {
+	db, err := p.open()
+	if err != nil {
+		return err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return err
+	}
+
+	old := p.current.Swap(db)
+	p.failures.Store(0)
+
+	return old.Close()
+}
+
+// Plugin is a gorm plugin feeding the outcome of every statement to the Pool.
+type Plugin struct {
+	pool *Pool
+}
+
+func New(pool *Pool) *Plugin {
+	return &Plugin{pool: pool}
+}
+
+func (p *Plugin) Name() string {
+	return "reconnect"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	observe := func(db *gorm.DB) { p.pool.Observe(db.Error) }
+
+	return errors.Join(
+		callbacks.Create().After("gorm:after_create").Register("reconnect:after_create", observe),
+		callbacks.Query().After("gorm:after_query").Register("reconnect:after_query", observe),
+		callbacks.Update().After("gorm:after_update").Register("reconnect:after_update", observe),
+		callbacks.Delete().After("gorm:after_delete").Register("reconnect:after_delete", observe),
+		callbacks.Row().After("gorm:row").Register("reconnect:after_row", observe),
+		callbacks.Raw().After("gorm:raw").Register("reconnect:after_raw", observe),
+	)
+}
@@ -0,0 +1,145 @@
+package reconnect_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/internal/db/reconnect"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type fakeConn struct {
+	connector *fakeConnector
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error              { return nil }
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.connector.isDown() {
+		return nil, driver.ErrBadConn
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+// fakeConnector refuses the connections while the database is down.
+type fakeConnector struct {
+	mu   sync.Mutex
+	down bool
+}
+
+func (fc *fakeConnector) setDown(down bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.down = down
+}
+
+func (fc *fakeConnector) isDown() bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.down
+}
+
+func (fc *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if fc.isDown() {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	}
+
+	return &fakeConn{connector: fc}, nil
+}
+
+func (fc *fakeConnector) Driver() driver.Driver { return nil }
+
+func newPool(t *testing.T, fc *fakeConnector) (*reconnect.Pool, *[]*sql.DB) {
+	opened := []*sql.DB{}
+
+	pool, err := reconnect.NewPool(func() (*sql.DB, error) {
+		db := sql.OpenDB(fc)
+		opened = append(opened, db)
+		return db, nil
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assert.Nil(t, err)
+	pool.ErrorThreshold = 2
+	return pool, &opened
+}
+
+func Test_shouldTellTheConnectionErrors(t *testing.T) {
+	assert.True(t, reconnect.IsConnError(driver.ErrBadConn))
+	assert.True(t, reconnect.IsConnError(io.ErrUnexpectedEOF))
+	assert.True(t, reconnect.IsConnError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	assert.False(t, reconnect.IsConnError(nil))
+	assert.False(t, reconnect.IsConnError(errors.New("error: duplicate entry")))
+}
+
+func Test_shouldReopenThePoolOnceTheDatabaseIsBack(t *testing.T) {
+	fc := &fakeConnector{}
+	pool, opened := newPool(t, fc)
+
+	backoffs := make(chan time.Duration)
+	waits := make(chan time.Time)
+	defer reconnect.SetAfter(func(d time.Duration) <-chan time.Time {
+		backoffs <- d
+		return waits
+	})()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: pool, SkipInitializeWithVersion: true}), &gorm.Config{SkipDefaultTransaction: true})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(reconnect.New(pool)))
+
+	first := pool.DB()
+	assert.Nil(t, db.Exec("DO 1").Error)
+
+	fc.setDown(true)
+	assert.True(t, reconnect.IsConnError(db.Exec("DO 1").Error))
+	assert.True(t, reconnect.IsConnError(db.Exec("DO 1").Error))
+
+	// The database is still down, so the first reopen fails and the recovery backs off.
+	assert.Equal(t, reconnect.DEFAULT_MIN_BACKOFF, <-backoffs)
+	assert.Same(t, first, pool.DB())
+
+	fc.setDown(false)
+	waits <- time.Now()
+	pool.Wait()
+
+	assert.Len(t, *opened, 3)
+	assert.Same(t, (*opened)[2], pool.DB())
+	assert.NotSame(t, first, pool.DB())
+	assert.ErrorContains(t, first.Ping(), "database is closed")
+
+	assert.Nil(t, db.Exec("DO 1").Error)
+
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	assert.Same(t, pool.DB(), sqlDB)
+}
+
+func Test_shouldNotReopenThePoolOnTheStatementErrors(t *testing.T) {
+	pool, opened := newPool(t, &fakeConnector{})
+
+	for i := 0; i < 5; i++ {
+		pool.Observe(errors.New("error: duplicate entry"))
+	}
+
+	pool.Observe(driver.ErrBadConn)
+	pool.Observe(nil)
+	pool.Observe(driver.ErrBadConn)
+	pool.Wait()
+
+	assert.Len(t, *opened, 1)
+}
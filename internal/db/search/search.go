@@ -0,0 +1,44 @@
+// This package builds the LIKE clauses of the searches, the `%` and `_` of the search terms are escaped
+// so that a user cannot run a wildcard scan of the table with them. The escape character is the
+// `likeEscapeChar` of the app config.
+
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+// DEFAULT_ESCAPE_CHAR is the escape character used when the `likeEscapeChar` is not set.
+const DEFAULT_ESCAPE_CHAR = "!"
+
+func escapeChar() string {
+	if c := loader.AppConfig().LikeEscapeChar; len(c) != 0 {
+		return c
+	}
+
+	return DEFAULT_ESCAPE_CHAR
+}
+
+// SanitizeLike escapes the wildcards (and the escape character itself) of the term, the term then only
+// matches itself in a LIKE clause with the `ESCAPE` of the escape character.
+func SanitizeLike(term string) string {
+	esc := escapeChar()
+
+	return strings.NewReplacer(esc, esc+esc, "%", esc+"%", "_", esc+"_").Replace(term)
+}
+
+// Search is a scope matching the rows whose column contains the term, e.g.
+//
+//	db.Scopes(search.Search("name", c.Query("q"))).Find(&products)
+func Search(column, term string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			fmt.Sprintf("%s LIKE ? ESCAPE '%s'", db.Statement.Quote(column), escapeChar()),
+			"%"+SanitizeLike(term)+"%",
+		)
+	}
+}
@@ -0,0 +1,42 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/search"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type Product struct {
+	Id   uint64 `gorm:"primaryKey"`
+	Name string
+}
+
+func Test_shouldEscapeTheWildcardsOfTheTerm(t *testing.T) {
+	assert.Equal(t, "50!% off", search.SanitizeLike("50% off"))
+	assert.Equal(t, "snake!_case", search.SanitizeLike("snake_case"))
+	assert.Equal(t, "wow!!", search.SanitizeLike("wow!"), "The escape character must be escaped as well.")
+	assert.Equal(t, "chair", search.SanitizeLike("chair"))
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.LikeEscapeChar = "#"
+	})
+	assert.Equal(t, "50#% off ##1 !", search.SanitizeLike("50% off #1 !"))
+}
+
+func Test_shouldMatchAPercentOfTheTermLiterally(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `products` WHERE `name` LIKE \\? ESCAPE '!'").
+		WithArgs("%100!%%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "100% cotton shirt"))
+
+	products := []*Product{}
+	assert.Nil(t, db.Scopes(search.Search("name", "100%")).Find(&products).Error)
+	assert.Len(t, products, 1)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,181 @@
+// This package generates the URL-safe slugs of the named models (e.g. the products and the categories)
+// on create, a slug that is already taken is suffixed with a number (e.g. `office-chair-2`).
+
+package slug
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// SLUG_FIELD is the field of the model the slug is written to.
+	SLUG_FIELD = "Slug"
+
+	// DEFAULT_SLUG is used when nothing of the source is left in the slug (e.g. an empty name).
+	DEFAULT_SLUG = "untitled"
+)
+
+// Sluggable is implemented by the models that have their `Slug` generated on create, a slug that was
+// already set is kept as is.
+type Sluggable interface {
+	// SlugSource returns the name of the field the slug is derived from, the `slugSources` of the app
+	// config take precedence over it.
+	SlugSource() string
+}
+
+// transliterations are the built-in ASCII spellings of the accented latin characters.
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ð': "d", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y",
+	'ß': "ss", 'þ': "th", 'ł': "l", 'ş': "s", 'ğ': "g", 'ı': "i",
+}
+
+// Slugify converts the s into a slug of lowercase ASCII letters and digits separated by dashes, the
+// characters without a transliteration are treated as separators.
+func Slugify(s string) string {
+	configured := loader.AppConfig().SlugTransliterations
+
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(s) {
+		spelling, exists := configured[string(r)]
+		if !exists {
+			spelling, exists = transliterations[r]
+		}
+
+		if !exists {
+			spelling = string(r)
+		}
+
+		for _, c := range spelling {
+			if ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+				if dash && b.Len() != 0 {
+					b.WriteByte('-')
+				}
+
+				b.WriteRune(c)
+				dash = false
+				continue
+			}
+
+			dash = true
+		}
+	}
+
+	if b.Len() == 0 {
+		return DEFAULT_SLUG
+	}
+
+	return b.String()
+}
+
+// Plugin is a gorm plugin generating the slugs of the Sluggable models before they are created.
+type Plugin struct{}
+
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "slug"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register("slug:before_create", generate)
+}
+
+func source(s *schema.Schema, model Sluggable) *schema.Field {
+	name := model.SlugSource()
+	if configured, exists := loader.AppConfig().SlugSources[s.Name]; exists {
+		name = configured
+	}
+
+	return s.LookUpField(name)
+}
+
+func generate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+
+	model, ok := reflect.New(stmt.Schema.ModelType).Interface().(Sluggable)
+	if !ok {
+		return
+	}
+
+	field, from := stmt.Schema.LookUpField(SLUG_FIELD), source(stmt.Schema, model)
+	if field == nil || from == nil {
+		db.AddError(fmt.Errorf("error: %s must have both the %s and the slug source fields", stmt.Schema.Name, SLUG_FIELD))
+		return
+	}
+
+	rows := []reflect.Value{}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			rows = append(rows, reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		rows = append(rows, stmt.ReflectValue)
+	}
+
+	// The slugs handed out in the same batch are taken as well.
+	taken := make(map[string]bool)
+
+	for _, row := range rows {
+		if current, zero := field.ValueOf(stmt.Context, row); !zero {
+			taken[fmt.Sprint(current)] = true
+			continue
+		}
+
+		name, _ := from.ValueOf(stmt.Context, row)
+
+		slug, err := unique(stmt.Context, db, field.DBName, Slugify(fmt.Sprint(name)), taken)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+
+		taken[slug] = true
+		db.AddError(field.Set(stmt.Context, row, slug))
+	}
+}
+
+// unique returns the base when it is not taken yet, otherwise the base with the lowest free suffix
+// starting from 2.
+func unique(ctx context.Context, db *gorm.DB, column, base string, taken map[string]bool) (string, error) {
+	existing := []string{}
+
+	err := db.Session(&gorm.Session{NewDB: true, Context: ctx}).
+		Table(db.Statement.Table).
+		Where(fmt.Sprintf("%s = ? OR %s LIKE ?", db.Statement.Quote(column), db.Statement.Quote(column)), base, base+"-%").
+		Pluck(column, &existing).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, slug := range existing {
+		taken[slug] = true
+	}
+
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	return slug, nil
+}
@@ -0,0 +1,90 @@
+package slug_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/slug"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Product struct {
+	Id   uint64 `gorm:"primaryKey"`
+	Name string
+	Slug string
+}
+
+func (p *Product) SlugSource() string {
+	return "Name"
+}
+
+func newSlugDb(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(slug.New()))
+
+	t.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+	return db, mock
+}
+
+func Test_shouldSlugifyTheName(t *testing.T) {
+	assert.Equal(t, "ergonomic-office-chair-v2", slug.Slugify("  Ergonomic Office Chair (v2)! "))
+	assert.Equal(t, "untitled", slug.Slugify("???"))
+}
+
+func Test_shouldTransliterateTheAccentedCharacters(t *testing.T) {
+	assert.Equal(t, "creme-brulee-a-la-francaise", slug.Slugify("Crème Brûlée à la Française"))
+	assert.Equal(t, "grosse-strasse", slug.Slugify("Größe Straße"))
+
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.SlugTransliterations = map[string]string{"ö": "oe", "&": "and"}
+	})
+	assert.Equal(t, "groesse-and-co", slug.Slugify("Größe & Co"))
+}
+
+func Test_shouldGenerateTheSlugOnCreate(t *testing.T) {
+	db, mock := newSlugDb(t)
+
+	mock.ExpectQuery("SELECT `slug` FROM `products` WHERE `slug` = \\? OR `slug` LIKE \\?").
+		WithArgs("office-chair", "office-chair-%").
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+	mock.ExpectExec("INSERT INTO `products` \\(`name`,`slug`\\)").
+		WithArgs("Office Chair", "office-chair").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	product := &Product{Name: "Office Chair"}
+	assert.Nil(t, db.Create(product).Error)
+	assert.Equal(t, "office-chair", product.Slug)
+}
+
+func Test_shouldSuffixATakenSlug(t *testing.T) {
+	db, mock := newSlugDb(t)
+
+	mock.ExpectQuery("SELECT `slug` FROM `products` WHERE `slug` = \\? OR `slug` LIKE \\?").
+		WithArgs("office-chair", "office-chair-%").
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("office-chair").AddRow("office-chair-2"))
+	mock.ExpectQuery("SELECT `slug` FROM `products` WHERE `slug` = \\? OR `slug` LIKE \\?").
+		WithArgs("office-chair", "office-chair-%").
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("office-chair").AddRow("office-chair-2"))
+	mock.ExpectExec("INSERT INTO `products` \\(`name`,`slug`\\)").
+		WithArgs("Office Chair", "office-chair-3", "Office Chair", "office-chair-4").
+		WillReturnResult(sqlmock.NewResult(1, 2))
+
+	products := []*Product{{Name: "Office Chair"}, {Name: "Office Chair"}}
+	assert.Nil(t, db.Create(&products).Error)
+	assert.Equal(t, "office-chair-3", products[0].Slug)
+	assert.Equal(t, "office-chair-4", products[1].Slug, "The slugs of the same batch must not collide.")
+}
+
+func Test_shouldKeepASlugThatWasSet(t *testing.T) {
+	db, mock := newSlugDb(t)
+
+	mock.ExpectExec("INSERT INTO `products` \\(`name`,`slug`\\)").
+		WithArgs("Office Chair", "the-chair").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.Nil(t, db.Create(&Product{Name: "Office Chair", Slug: "the-chair"}).Error)
+}
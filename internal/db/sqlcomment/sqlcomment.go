@@ -0,0 +1,95 @@
+// This package prepends the id of the request to the SQL sent by gorm (e.g. `/* req=<id> */ SELECT ...`)
+// so that the queries of the slow log can be correlated back to the request that made them.
+
+package sqlcomment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/rommms07/idream-erp/internal/requestid"
+	"gorm.io/gorm"
+)
+
+// commentedPool prepends the comment to every statement sent through the wrapped ConnPool.
+type commentedPool struct {
+	gorm.ConnPool
+	comment string
+}
+
+func (p *commentedPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.ConnPool.ExecContext(ctx, p.comment+query, args...)
+}
+
+func (p *commentedPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.ConnPool.QueryContext(ctx, p.comment+query, args...)
+}
+
+func (p *commentedPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.ConnPool.QueryRowContext(ctx, p.comment+query, args...)
+}
+
+// Comment returns the SQL comment of the request id.
+func Comment(id string) string {
+	return fmt.Sprintf("/* req=%s */ ", id)
+}
+
+// Plugin is a gorm plugin commenting the statements made with a context carrying a request id.
+type Plugin struct{}
+
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "sql_comment"
+}
+
+// Initialize wraps the ConnPool of the statements around the callbacks sending them, the ConnPool is
+// restored right after so that the transaction callbacks still see the original one.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("sql_comment:before_create", wrap),
+		callbacks.Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").Register("sql_comment:after_create", unwrap),
+		callbacks.Query().Before("gorm:query").Register("sql_comment:before_query", wrap),
+		callbacks.Query().After("gorm:query").Register("sql_comment:after_query", unwrap),
+		callbacks.Update().Before("gorm:update").Register("sql_comment:before_update", wrap),
+		callbacks.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").Register("sql_comment:after_update", unwrap),
+		callbacks.Delete().Before("gorm:delete").Register("sql_comment:before_delete", wrap),
+		callbacks.Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").Register("sql_comment:after_delete", unwrap),
+		callbacks.Row().Before("gorm:row").Register("sql_comment:before_row", wrap),
+		callbacks.Row().After("gorm:row").Register("sql_comment:after_row", unwrap),
+		callbacks.Raw().Before("gorm:raw").Register("sql_comment:before_raw", wrap),
+		callbacks.Raw().After("gorm:raw").Register("sql_comment:after_raw", unwrap),
+	)
+}
+
+func wrap(db *gorm.DB) {
+	if db.Statement.Context == nil {
+		return
+	}
+
+	id, ok := requestid.From(db.Statement.Context)
+	if !ok || !requestid.Valid(id) {
+		return
+	}
+
+	switch db.Statement.ConnPool.(type) {
+	// A prepared statement is cached by its SQL, commenting it would prepare a new statement for
+	// every request.
+	case *gorm.PreparedStmtDB, *gorm.PreparedStmtTX, *commentedPool:
+		return
+	}
+
+	db.Statement.ConnPool = &commentedPool{ConnPool: db.Statement.ConnPool, comment: Comment(id)}
+}
+
+func unwrap(db *gorm.DB) {
+	if pool, ok := db.Statement.ConnPool.(*commentedPool); ok {
+		db.Statement.ConnPool = pool.ConnPool
+	}
+}
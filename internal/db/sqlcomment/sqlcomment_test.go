@@ -0,0 +1,68 @@
+package sqlcomment_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/internal/db/sqlcomment"
+	"github.com/rommms07/idream-erp/internal/requestid"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Invoice struct {
+	Id     uint64 `gorm:"primaryKey"`
+	Status string
+}
+
+func Test_shouldCommentTheSqlWithTheRequestId(t *testing.T) {
+	db, mock, err := mocks.NewGormMockWithConfig(&gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(sqlcomment.New()))
+
+	ctx := requestid.With(context.Background(), "4f2a9c")
+
+	mock.ExpectQuery("^/\\* req=4f2a9c \\*/ SELECT \\* FROM `invoices`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "draft"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^/\\* req=4f2a9c \\*/ INSERT INTO `invoices`").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	invoices := []*Invoice{}
+	assert.Nil(t, db.WithContext(ctx).Find(&invoices).Error)
+	assert.Nil(t, db.WithContext(ctx).Create(&Invoice{Status: "sent"}).Error)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldLeaveTheSqlWithoutARequestIdAlone(t *testing.T) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(sqlcomment.New()))
+
+	mock.ExpectQuery("^SELECT \\* FROM `invoices`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}))
+
+	invoices := []*Invoice{}
+	assert.Nil(t, db.WithContext(context.Background()).Find(&invoices).Error)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_shouldNotCommentThePreparedStatements(t *testing.T) {
+	db, mock, err := mocks.NewGormMockWithConfig(&gorm.Config{SkipDefaultTransaction: true, PrepareStmt: true})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(sqlcomment.New()))
+
+	mock.ExpectPrepare("^SELECT \\* FROM `invoices`").
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}))
+
+	invoices := []*Invoice{}
+	ctx := requestid.With(context.Background(), "4f2a9c")
+
+	assert.Nil(t, db.WithContext(ctx).Find(&invoices).Error)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,88 @@
+// This package stamps the UpdatedAt (and any other autoUpdateTime field) of the models on the updates
+// that skip the hooks of gorm, e.g. the UpdateColumns of a bulk update, which gorm otherwise leaves
+// untouched. A field that is omitted from the update (e.g. `Omit("updated_at")`) is not stamped. The
+// raw statements are never stamped since their columns are unknown to gorm.
+
+package timestamps
+
+import (
+	"reflect"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Plugin is a gorm plugin stamping the autoUpdateTime fields on the updates skipping the hooks, it does
+// nothing unless the `stampBulkUpdates` of the app config is set.
+type Plugin struct{}
+
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "timestamps"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	return db.Callback().Update().Before("gorm:update").Register("timestamps:before_update", stamp)
+}
+
+// value returns the time of the field per its autoUpdateTime, the NowFunc is in the configured timezone.
+func value(field *schema.Field, db *gorm.DB) any {
+	now := db.NowFunc()
+
+	switch field.AutoUpdateTime {
+	case schema.UnixNanosecond:
+		return now.UnixNano()
+	case schema.UnixMillisecond:
+		return now.UnixMilli()
+	case schema.UnixSecond:
+		return now.Unix()
+	}
+
+	return now
+}
+
+func stamp(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || !stmt.SkipHooks || !loader.AppConfig().StampBulkUpdates {
+		return
+	}
+
+	columns, _ := stmt.SelectAndOmitColumns(false, true)
+
+	for _, field := range stmt.Schema.Fields {
+		if field.AutoUpdateTime == 0 || !field.Updatable {
+			continue
+		}
+
+		if selected, exists := columns[field.DBName]; exists && !selected {
+			continue
+		}
+
+		switch dest := stmt.Dest.(type) {
+		case map[string]any:
+			if _, exists := dest[field.Name]; exists {
+				continue
+			}
+
+			if _, exists := dest[field.DBName]; exists {
+				continue
+			}
+
+			dest[field.DBName] = value(field, db)
+		default:
+			// The UpdateColumns with a struct only update its non-zero fields, the stamp makes it one.
+			row := reflect.Indirect(reflect.ValueOf(stmt.Dest))
+			if row.Kind() != reflect.Struct || row.Type() != stmt.Schema.ModelType || !row.CanAddr() {
+				continue
+			}
+
+			if _, zero := field.ValueOf(stmt.Context, row); zero {
+				db.AddError(field.Set(stmt.Context, row, value(field, db)))
+			}
+		}
+	}
+}
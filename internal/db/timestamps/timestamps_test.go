@@ -0,0 +1,70 @@
+package timestamps_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/timestamps"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Product struct {
+	Id        uint64 `gorm:"primaryKey"`
+	Status    string
+	UpdatedAt time.Time
+}
+
+var stampedAt = time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+
+func newTimestampsDb(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.StampBulkUpdates = true
+	})
+
+	db, mock, err := mocks.NewGormMockWithConfig(&gorm.Config{
+		SkipDefaultTransaction: true,
+		NowFunc:                func() time.Time { return stampedAt },
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(timestamps.New()))
+
+	t.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+	return db, mock
+}
+
+func Test_shouldStampTheUpdatedAtOnABulkUpdate(t *testing.T) {
+	db, mock := newTimestampsDb(t)
+
+	mock.ExpectExec("UPDATE `products` SET `status`=\\?,`updated_at`=\\? WHERE id IN \\(\\?,\\?\\)").
+		WithArgs("archived", stampedAt, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	res := db.Model(&Product{}).Where("id IN ?", []uint{1, 2}).UpdateColumns(map[string]any{"status": "archived"})
+	assert.Nil(t, res.Error)
+	assert.Equal(t, int64(2), res.RowsAffected)
+}
+
+func Test_shouldStampTheUpdatedAtOfAStructUpdate(t *testing.T) {
+	db, mock := newTimestampsDb(t)
+
+	mock.ExpectExec("UPDATE `products` SET `status`=\\?,`updated_at`=\\? WHERE `id` = \\?").
+		WithArgs("archived", stampedAt, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	product := &Product{Id: 1}
+	assert.Nil(t, db.Model(product).UpdateColumns(&Product{Status: "archived"}).Error)
+}
+
+func Test_shouldNotStampAnOmittedUpdatedAt(t *testing.T) {
+	db, mock := newTimestampsDb(t)
+
+	mock.ExpectExec("UPDATE `products` SET `status`=\\? WHERE id = \\?").
+		WithArgs("archived", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, db.Model(&Product{}).Omit("updated_at").Where("id = ?", 1).UpdateColumns(map[string]any{"status": "archived"}).Error)
+}
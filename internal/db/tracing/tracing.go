@@ -0,0 +1,157 @@
+// This package records a span of every statement sent by gorm, the attributes of the spans follow the
+// database conventions of OpenTelemetry (`db.system`, `db.statement`, ...) so that an exporter can hand
+// them over as is. The password of the DSN is always masked and the bind parameters are only recorded
+// when the `dbTraceBindParameters` of the app config is set.
+
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"gorm.io/gorm"
+)
+
+const (
+	ATTR_DB_SYSTEM            = "db.system"
+	ATTR_DB_CONNECTION_STRING = "db.connection_string"
+	ATTR_DB_STATEMENT         = "db.statement"
+	ATTR_DB_STATEMENT_ARGS    = "db.statement.args"
+	ATTR_DB_SQL_TABLE         = "db.sql.table"
+	ATTR_DB_ROWS_AFFECTED     = "db.rows_affected"
+
+	startKey = "tracing:start"
+)
+
+var (
+	// now is used to time the spans, the tests override it.
+	now = time.Now
+)
+
+// Span is the trace of a statement.
+type Span struct {
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]any
+	Err        error
+}
+
+// Exporter receives the ended spans.
+type Exporter interface {
+	Export(span *Span)
+}
+
+// InMemoryExporter keeps the spans in memory, it is meant for the tests.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *InMemoryExporter) Export(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]*Span{}, e.spans...)
+}
+
+// LogExporter logs the spans at the debug level.
+type LogExporter struct {
+	Logger *slog.Logger
+}
+
+func (e *LogExporter) Export(span *Span) {
+	args := []any{"duration", span.End.Sub(span.Start), "error", span.Err}
+	for key, val := range span.Attributes {
+		args = append(args, key, val)
+	}
+
+	e.Logger.Debug(span.Name, args...)
+}
+
+// Plugin is a gorm plugin exporting a span of every statement.
+type Plugin struct {
+	exporter Exporter
+
+	// dsn is the redacted DSN of the database.
+	dsn string
+}
+
+// New creates the plugin, the dsn is redacted right away so that its password is never held by it.
+func New(exporter Exporter, dsn string) *Plugin {
+	return &Plugin{exporter: exporter, dsn: logging.RedactDSN(dsn)}
+}
+
+func (p *Plugin) Name() string {
+	return "tracing"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", start),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", p.end("create")),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", start),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", p.end("query")),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", start),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", p.end("update")),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", start),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", p.end("delete")),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", start),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", p.end("row")),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", start),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", p.end("raw")),
+	)
+}
+
+func start(db *gorm.DB) {
+	db.InstanceSet(startKey, now())
+}
+
+func (p *Plugin) end(op string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		val, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+
+		stmt := db.Statement
+		span := &Span{
+			Name:  "gorm." + op,
+			Start: val.(time.Time),
+			End:   now(),
+			Err:   db.Error,
+			Attributes: map[string]any{
+				ATTR_DB_SYSTEM:            db.Dialector.Name(),
+				ATTR_DB_CONNECTION_STRING: p.dsn,
+				ATTR_DB_STATEMENT:         stmt.SQL.String(),
+				ATTR_DB_SQL_TABLE:         stmt.Table,
+				ATTR_DB_ROWS_AFFECTED:     db.RowsAffected,
+			},
+		}
+
+		if loader.AppConfig().DbTraceBindParameters {
+			args := make([]string, len(stmt.Vars))
+			for i, v := range stmt.Vars {
+				args[i] = fmt.Sprint(v)
+			}
+
+			span.Attributes[ATTR_DB_STATEMENT_ARGS] = args
+		}
+
+		p.exporter.Export(span)
+	}
+}
@@ -0,0 +1,76 @@
+package tracing_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/db/tracing"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const dsn = "erp:hunter2@tcp(db.internal:3306)/erp?parseTime=true"
+
+type Customer struct {
+	Id    uint64 `gorm:"primaryKey"`
+	Email string
+}
+
+func newTracedDb(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, *tracing.InMemoryExporter) {
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	exporter := &tracing.InMemoryExporter{}
+	assert.Nil(t, db.Use(tracing.New(exporter, dsn)))
+
+	t.Cleanup(func() { assert.Nil(t, mock.ExpectationsWereMet()) })
+	return db, mock, exporter
+}
+
+func expectCustomer(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT \\* FROM `customers` WHERE email = \\?").
+		WithArgs("jane@idream.local").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "jane@idream.local"))
+}
+
+func Test_spansShouldMaskTheDSNAndLeaveOutTheBindParameters(t *testing.T) {
+	db, mock, exporter := newTracedDb(t)
+	expectCustomer(mock)
+
+	assert.Nil(t, db.Where("email = ?", "jane@idream.local").Find(&[]*Customer{}).Error)
+
+	spans := exporter.Spans()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+
+	attrs := spans[0].Attributes
+	assert.Equal(t, "gorm.query", spans[0].Name)
+	assert.Equal(t, "erp:"+loader.SECRET_MASK+"@tcp(db.internal:3306)/erp?parseTime=true", attrs[tracing.ATTR_DB_CONNECTION_STRING])
+	assert.Equal(t, "SELECT * FROM `customers` WHERE email = ?", attrs[tracing.ATTR_DB_STATEMENT])
+	assert.Equal(t, "customers", attrs[tracing.ATTR_DB_SQL_TABLE])
+	assert.NotContains(t, attrs, tracing.ATTR_DB_STATEMENT_ARGS)
+
+	for key, val := range attrs {
+		assert.NotContains(t, fmt.Sprint(val), "hunter2", "The %s should not hold the password.", key)
+		assert.NotContains(t, fmt.Sprint(val), "jane@idream.local", "The %s should not hold a bind parameter.", key)
+	}
+}
+
+func Test_spansShouldHoldTheBindParametersWhenAllowed(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.DbTraceBindParameters = true
+	})
+
+	db, mock, exporter := newTracedDb(t)
+	expectCustomer(mock)
+
+	assert.Nil(t, db.Where("email = ?", "jane@idream.local").Find(&[]*Customer{}).Error)
+
+	if spans := exporter.Spans(); assert.Len(t, spans, 1) {
+		assert.Equal(t, []string{"jane@idream.local"}, spans[0].Attributes[tracing.ATTR_DB_STATEMENT_ARGS])
+	}
+}
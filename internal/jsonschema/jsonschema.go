@@ -0,0 +1,233 @@
+// This package validates the JSON documents against a JSON Schema, only the subset of the keywords
+// used by the request schemas of the api is supported: type, properties, required,
+// additionalProperties, items, enum, minLength, maxLength, pattern, minimum and maximum.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// Schema is a parsed JSON Schema, the unsupported keywords are ignored.
+type Schema struct {
+	Type                 string             `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+
+	pattern *regexp.Regexp
+}
+
+// FieldError is a violation of the schema, the Field is the path of the offending value (e.g.
+// `items[0].sku`) and it is empty when the violation is about the whole document.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Parse parses the schema and compiles its patterns.
+func Parse(b []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("error: invalid schema: %w", err)
+	}
+
+	return s, s.compile()
+}
+
+// Load parses the schema from the file.
+func Load(path string) (*Schema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, path)
+	}
+
+	return s, nil
+}
+
+func (s *Schema) compile() (err error) {
+	if len(s.Pattern) != 0 {
+		if s.pattern, err = regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("error: invalid pattern %q: %w", s.Pattern, err)
+		}
+	}
+
+	for _, prop := range s.Properties {
+		if err := prop.compile(); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+
+	return nil
+}
+
+// ValidateJSON decodes the document and validates it against the schema.
+func (s *Schema) ValidateJSON(b []byte) ([]FieldError, error) {
+	var v any
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return s.Validate(v), nil
+}
+
+// Validate validates the decoded document against the schema, the numbers of the document are
+// either json.Number or float64.
+func (s *Schema) Validate(v any) []FieldError {
+	errs := make([]FieldError, 0)
+	s.validate("", v, &errs)
+	return errs
+}
+
+func (s *Schema) validate(field string, v any, errs *[]FieldError) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) != 0 && !isType(s.Type, v) {
+		fail("must be of type %s", s.Type)
+		return
+	}
+
+	if len(s.Enum) != 0 && !inEnum(s.Enum, v) {
+		fail("must be one of %v", s.Enum)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, FieldError{Field: join(field, name), Message: "is required"})
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(join(field, name), v[name], errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, FieldError{Field: join(field, name), Message: "is not allowed"})
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", field, i), item, errs)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %d characters long", *s.MinLength)
+		}
+
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters long", *s.MaxLength)
+		}
+
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.Pattern)
+		}
+	case json.Number, float64:
+		n, _ := toFloat(v)
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+func join(parent, name string) string {
+	if len(parent) == 0 {
+		return name
+	}
+
+	return parent + "." + name
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
+
+func isType(typ string, v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case string:
+		return typ == "string"
+	case map[string]any:
+		return typ == "object"
+	case []any:
+		return typ == "array"
+	case json.Number, float64:
+		if typ == "number" {
+			return true
+		}
+
+		f, _ := toFloat(v)
+		return typ == "integer" && f == float64(int64(f))
+	}
+
+	return false
+}
+
+func inEnum(enum []any, v any) bool {
+	f, isNumber := toFloat(v)
+
+	for _, e := range enum {
+		if n, ok := toFloat(e); ok && isNumber && n == f {
+			return true
+		}
+
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package jsonschema_test
+
+import (
+	"testing"
+
+	"github.com/rommms07/idream-erp/internal/jsonschema"
+	"github.com/stretchr/testify/assert"
+)
+
+const ORDER_SCHEMA = `{
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"status": {"enum": ["draft", "posted"]},
+		"lines": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["sku"],
+				"properties": {
+					"sku": {"type": "string", "maxLength": 8},
+					"quantity": {"type": "integer", "minimum": 1}
+				}
+			}
+		}
+	}
+}`
+
+func Test_shouldReportThePathsOfTheNestedViolations(t *testing.T) {
+	schema, err := jsonschema.Parse([]byte(ORDER_SCHEMA))
+	assert.Nil(t, err)
+
+	errs, err := schema.ValidateJSON([]byte(`{
+		"status": "void",
+		"note": "",
+		"lines": [{"sku": "A-1", "quantity": 2}, {"sku": "TOO-LONG-SKU", "quantity": 0.5}, {}]
+	}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []jsonschema.FieldError{
+		{Field: "lines[1].quantity", Message: "must be of type integer"},
+		{Field: "lines[1].sku", Message: "must be at most 8 characters long"},
+		{Field: "lines[2].sku", Message: "is required"},
+		{Field: "note", Message: "is not allowed"},
+		{Field: "status", Message: "must be one of [draft posted]"},
+	}, errs)
+}
+
+func Test_shouldRejectAnInvalidPattern(t *testing.T) {
+	_, err := jsonschema.Parse([]byte(`{"properties": {"code": {"pattern": "("}}}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,143 @@
+// This package starts the modules of the app (e.g. the invoicing) in the order of their dependencies, a
+// module is started only after every module it depends on was started.
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+var (
+	_default *Registry
+	once     sync.Once
+)
+
+// Init initializes a module, the ctx is the one given to the Start.
+type Init func(ctx context.Context) error
+
+type module struct {
+	deps []string
+	init Init
+}
+
+type Registry struct {
+	mu      sync.Mutex
+	modules map[string]*module
+	toggles map[string]bool
+}
+
+func New(toggles map[string]bool) *Registry {
+	return &Registry{
+		modules: make(map[string]*module),
+		toggles: toggles,
+	}
+}
+
+// Default returns the registry of the app, its toggles are taken from the `modules` of the app config.
+func Default() *Registry {
+	once.Do(func() {
+		_default = New(loader.AppConfig().Modules)
+	})
+
+	return _default
+}
+
+// Register adds the module under the given name along with the names of the modules it depends on.
+func (r *Registry) Register(name string, deps []string, init Init) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.modules[name] = &module{deps: deps, init: init}
+	return r
+}
+
+func (r *Registry) enabled(name string) bool {
+	enabled, exists := r.toggles[name]
+	return !exists || enabled
+}
+
+// Order returns the names of the enabled modules in the order they must be started, the modules that
+// do not depend on each other are ordered by their name. An error is returned when a module depends on
+// a module that is not registered (or is disabled) or when the dependencies form a cycle.
+func (r *Registry) Order() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := []string{}
+	for name := range r.modules {
+		if r.enabled(name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int)
+	order := []string{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("error: the modules have a dependency cycle (%s -> %s)", strings.Join(path, " -> "), name)
+		}
+
+		state[name] = visiting
+
+		for _, dep := range r.modules[name].deps {
+			if _, exists := r.modules[dep]; !exists || !r.enabled(dep) {
+				return fmt.Errorf("error: the module %s depends on the missing module %s", name, dep)
+			}
+
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// Start initializes the enabled modules in the order of their dependencies, it stops at the first
+// module that fails to initialize.
+func (r *Registry) Start(ctx context.Context) error {
+	order, err := r.Order()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range order {
+		r.mu.Lock()
+		init := r.modules[name].init
+		r.mu.Unlock()
+
+		if err := init(ctx); err != nil {
+			return fmt.Errorf("error: failed to initialize the module %s (%s)", name, err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package modules_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rommms07/idream-erp/internal/modules"
+	"github.com/stretchr/testify/assert"
+)
+
+func recorder(started *[]string, name string) modules.Init {
+	return func(ctx context.Context) error {
+		*started = append(*started, name)
+		return nil
+	}
+}
+
+func Test_shouldStartTheModulesInTheOrderOfTheirDependencies(t *testing.T) {
+	started := []string{}
+
+	err := modules.New(nil).
+		Register("invoicing", []string{"sequences", "customers"}, recorder(&started, "invoicing")).
+		Register("customers", []string{"sequences"}, recorder(&started, "customers")).
+		Register("sequences", nil, recorder(&started, "sequences")).
+		Register("audit", nil, recorder(&started, "audit")).
+		Start(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"audit", "sequences", "customers", "invoicing"}, started)
+}
+
+func Test_shouldRejectADependencyCycle(t *testing.T) {
+	started := []string{}
+
+	err := modules.New(nil).
+		Register("invoicing", []string{"sequences"}, recorder(&started, "invoicing")).
+		Register("sequences", []string{"ledger"}, recorder(&started, "sequences")).
+		Register("ledger", []string{"invoicing"}, recorder(&started, "ledger")).
+		Start(context.Background())
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invoicing -> sequences -> ledger -> invoicing")
+	}
+
+	assert.Empty(t, started, "No module must be started when the order cannot be resolved.")
+}
+
+func Test_shouldRejectAMissingOrDisabledDependency(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	_, err := modules.New(nil).Register("invoicing", []string{"sequences"}, noop).Order()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "the module invoicing depends on the missing module sequences")
+	}
+
+	registry := modules.New(map[string]bool{"sequences": false}).
+		Register("invoicing", []string{"sequences"}, noop).
+		Register("sequences", nil, noop)
+
+	_, err = registry.Order()
+	assert.NotNil(t, err)
+
+	order, err := modules.New(map[string]bool{"invoicing": false, "sequences": false}).
+		Register("invoicing", []string{"sequences"}, noop).
+		Register("sequences", nil, noop).
+		Register("audit", nil, noop).
+		Order()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"audit"}, order)
+}
+
+func Test_shouldStopAtTheFirstModuleFailingToInitialize(t *testing.T) {
+	started := []string{}
+
+	err := modules.New(nil).
+		Register("sequences", nil, func(ctx context.Context) error { return errors.New("the table is missing") }).
+		Register("invoicing", []string{"sequences"}, recorder(&started, "invoicing")).
+		Start(context.Background())
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "failed to initialize the module sequences (the table is missing)")
+	}
+
+	assert.Empty(t, started)
+}
@@ -0,0 +1,63 @@
+// This package holds the http client shared by the outbound calls of the app (e.g. the Graph API), its
+// transport keeps the idle connections around so that the successive calls to the same host do not
+// open a new socket every time.
+
+package outbound
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+var (
+	transport *http.Transport
+	client    *http.Client
+	once      sync.Once
+)
+
+// NewTransport creates a pooled transport tuned with the `httpClient` of the app config.
+func NewTransport() *http.Transport {
+	conf := loader.AppConfig().HttpClient
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if conf.MaxIdleConns != 0 {
+		t.MaxIdleConns = conf.MaxIdleConns
+	}
+
+	if conf.MaxIdleConnsPerHost != 0 {
+		t.MaxIdleConnsPerHost = conf.MaxIdleConnsPerHost
+	}
+
+	if conf.IdleTimeoutMs != 0 {
+		t.IdleConnTimeout = time.Duration(conf.IdleTimeoutMs) * time.Millisecond
+	}
+
+	return t
+}
+
+func initialize() {
+	once.Do(func() {
+		transport = NewTransport()
+		client = &http.Client{
+			Transport: transport,
+			Timeout:   time.Duration(loader.AppConfig().HttpClient.TimeoutMs) * time.Millisecond,
+		}
+	})
+}
+
+// Transport returns the transport shared by the outbound calls, it is created on the first call.
+func Transport() *http.Transport {
+	initialize()
+	return transport
+}
+
+// HttpClient returns the client shared by the outbound calls, its timeout is the `timeoutMs` of the
+// `httpClient` config. A caller needing a different timeout must create its own client around the
+// Transport instead of creating a new transport.
+func HttpClient() *http.Client {
+	initialize()
+	return client
+}
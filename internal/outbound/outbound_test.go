@@ -0,0 +1,43 @@
+package outbound_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rommms07/idream-erp/internal/outbound"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldReuseTheConnectionsToTheSameHost(t *testing.T) {
+	var opened atomic.Int64
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+
+	server.Start()
+	defer server.Close()
+
+	for i := 0; i < 5; i++ {
+		res, err := outbound.HttpClient().Get(server.URL)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	assert.Equal(t, int64(1), opened.Load(), "The successive calls should have reused the first connection.")
+	assert.Same(t, outbound.HttpClient(), outbound.HttpClient())
+}
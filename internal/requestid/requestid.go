@@ -0,0 +1,41 @@
+// This package carries the id of the request in its context, the id ties the logs and the queries
+// made while serving a request back to it.
+
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+type idKey struct{}
+
+// validId restricts the ids received from the clients, the id ends up in the logs and in the SQL
+// comments so it must never be able to escape them.
+var validId = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+
+// New returns a random id.
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether the id can be used as the id of a request.
+func Valid(id string) bool {
+	return validId.MatchString(id)
+}
+
+// With returns a copy of the ctx carrying the id.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// From returns the id carried by the ctx, the second return value is false when there is none.
+func From(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(idKey{}).(string)
+	return id, ok && len(id) != 0
+}
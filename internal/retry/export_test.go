@@ -0,0 +1,26 @@
+package retry
+
+import "time"
+
+// SetAfter overrides the wait of the backoff, the returned func restores it.
+func SetAfter(fn func(d time.Duration) <-chan time.Time) func() {
+	bak := after
+	after = fn
+	return func() { after = bak }
+}
+
+// SetNow overrides the time the budgets are refilled by, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
+
+// SetDefaultBudget overrides the budget shared by the retries, the returned func restores it.
+func SetDefaultBudget(budget *Budget) func() {
+	DefaultBudget()
+
+	bak := defaultBudget
+	defaultBudget = budget
+	return func() { defaultBudget = bak }
+}
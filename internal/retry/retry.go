@@ -0,0 +1,138 @@
+// This package retries the operations failing with a transient error (e.g. a greylisted email) with
+// an exponential backoff, the policies are the RetryPolicy sections of the app config. The retries of
+// all of the policies share the `retryBudget` of the app config.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+const (
+	DEFAULT_MULTIPLIER = 2
+)
+
+// ErrBudgetExhausted is joined to the error of the last attempt when it was not retried because the
+// retry budget is empty.
+var ErrBudgetExhausted = errors.New("error: the retry budget is exhausted")
+
+var (
+	// after is used to wait for the backoff, the tests override it.
+	after = time.After
+
+	// now is used to refill the budget, the tests override it.
+	now = time.Now
+
+	defaultBudget     *Budget
+	defaultBudgetOnce sync.Once
+)
+
+// Budget is a token bucket every retry takes a token from, the first attempts never take any so that the
+// operations still run once when it is empty.
+type Budget struct {
+	mu     sync.Mutex
+	limit  *loader.RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// NewBudget creates a full budget, a nil limit creates a budget that is never exhausted.
+func NewBudget(limit *loader.RateLimit) *Budget {
+	b := &Budget{limit: limit, last: now()}
+	if limit != nil {
+		b.tokens = float64(limit.Burst)
+	}
+
+	return b
+}
+
+// Take takes a token from the budget, the tokens are lazily refilled by the Rate of the limit.
+func (b *Budget) Take() bool {
+	if b == nil || b.limit == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := now()
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+t.Sub(b.last).Seconds()*b.limit.Rate)
+	b.last = t
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// DefaultBudget returns the budget of the `retryBudget` of the app config.
+func DefaultBudget() *Budget {
+	defaultBudgetOnce.Do(func() {
+		defaultBudget = NewBudget(loader.AppConfig().RetryBudget)
+	})
+
+	return defaultBudget
+}
+
+// Backoff returns the wait before the attempt following the nth failed attempt (starting from 1).
+func Backoff(policy *loader.RetryPolicy, n int) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier == 0 {
+		multiplier = DEFAULT_MULTIPLIER
+	}
+
+	wait := float64(policy.InitialBackoffMs)
+	for i := 1; i < n; i++ {
+		wait *= multiplier
+	}
+
+	if policy.MaxBackoffMs != 0 && wait > float64(policy.MaxBackoffMs) {
+		wait = float64(policy.MaxBackoffMs)
+	}
+
+	return time.Duration(wait) * time.Millisecond
+}
+
+// Do runs the fn until it succeeds, fails with an error that is not retryable or runs out of the
+// MaxAttempts of the policy (a nil policy or a zero MaxAttempts runs it once). The error of the last
+// attempt is returned, the ctx.Err() is returned instead when the ctx is done while waiting. No retry is
+// made once the DefaultBudget is exhausted, the error is then joined with the ErrBudgetExhausted.
+func Do(ctx context.Context, policy *loader.RetryPolicy, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	return DoWithBudget(ctx, DefaultBudget(), policy, retryable, fn)
+}
+
+// DoWithBudget is the same as Do but the retries take their tokens from the budget, a nil budget opts
+// out of it (e.g. for the retries that never reach another service).
+func DoWithBudget(ctx context.Context, budget *Budget, policy *loader.RetryPolicy, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	attempts := 1
+	if policy != nil && policy.MaxAttempts > 1 {
+		attempts = policy.MaxAttempts
+	}
+
+	var err error
+
+	for n := 1; ; n++ {
+		if err = fn(ctx); err == nil || n == attempts || !retryable(err) {
+			return err
+		}
+
+		if !budget.Take() {
+			return fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-after(Backoff(policy, n)):
+		}
+	}
+}
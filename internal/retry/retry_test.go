@@ -0,0 +1,119 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("error: transient")
+
+func Test_shouldDoubleTheBackoffUpToTheMax(t *testing.T) {
+	policy := &loader.RetryPolicy{InitialBackoffMs: 100, MaxBackoffMs: 350}
+
+	assert.Equal(t, 100*time.Millisecond, retry.Backoff(policy, 1))
+	assert.Equal(t, 200*time.Millisecond, retry.Backoff(policy, 2))
+	assert.Equal(t, 350*time.Millisecond, retry.Backoff(policy, 3))
+}
+
+func Test_shouldRetryTheTransientErrorsUpToTheMaxAttempts(t *testing.T) {
+	waits := []time.Duration{}
+	defer retry.SetAfter(func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	})()
+
+	policy := &loader.RetryPolicy{MaxAttempts: 3, InitialBackoffMs: 10}
+	transient := func(err error) bool { return errors.Is(err, errTransient) }
+
+	calls := 0
+	err := retry.Do(context.Background(), policy, transient, func(ctx context.Context) error {
+		calls++
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, waits)
+
+	calls = 0
+	err = retry.Do(context.Background(), policy, transient, func(ctx context.Context) error {
+		calls++
+		return errors.New("error: permanent")
+	})
+
+	assert.EqualError(t, err, "error: permanent")
+	assert.Equal(t, 1, calls, "A permanent error must not be retried.")
+}
+
+func Test_shouldStopRetryingOnceTheBudgetIsExhausted(t *testing.T) {
+	defer retry.SetAfter(func(d time.Duration) <-chan time.Time {
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	})()
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer retry.SetNow(func() time.Time { return clock })()
+	defer retry.SetDefaultBudget(retry.NewBudget(&loader.RateLimit{Rate: 1, Burst: 2}))()
+
+	policy := &loader.RetryPolicy{MaxAttempts: 5}
+	transient := func(err error) bool { return errors.Is(err, errTransient) }
+
+	calls := 0
+	fail := func(ctx context.Context) error {
+		calls++
+		return errTransient
+	}
+
+	err := retry.Do(context.Background(), policy, transient, fail)
+	assert.ErrorIs(t, err, retry.ErrBudgetExhausted)
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 3, calls, "Only the 2 retries of the budget must be made.")
+
+	calls = 0
+	err = retry.Do(context.Background(), policy, transient, fail)
+	assert.ErrorIs(t, err, retry.ErrBudgetExhausted)
+	assert.Equal(t, 1, calls, "No retry must be made while the budget is exhausted.")
+
+	clock = clock.Add(time.Second)
+
+	calls = 0
+	retry.Do(context.Background(), policy, transient, fail)
+	assert.Equal(t, 2, calls, "The retries must resume once the budget is refilled.")
+}
+
+func Test_theCallersShouldPassTheirOwnBudgetOrOptOut(t *testing.T) {
+	defer retry.SetAfter(func(d time.Duration) <-chan time.Time {
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	})()
+
+	defer retry.SetDefaultBudget(retry.NewBudget(&loader.RateLimit{Rate: 0, Burst: 0}))()
+
+	policy := &loader.RetryPolicy{MaxAttempts: 3}
+	transient := func(err error) bool { return errors.Is(err, errTransient) }
+
+	calls := 0
+	fail := func(ctx context.Context) error {
+		calls++
+		return errTransient
+	}
+
+	err := retry.DoWithBudget(context.Background(), nil, policy, transient, fail)
+	assert.NotErrorIs(t, err, retry.ErrBudgetExhausted)
+	assert.Equal(t, 3, calls, "A nil budget must never be exhausted.")
+
+	calls = 0
+	err = retry.DoWithBudget(context.Background(), retry.NewBudget(&loader.RateLimit{Rate: 0, Burst: 1}), policy, transient, fail)
+	assert.ErrorIs(t, err, retry.ErrBudgetExhausted)
+	assert.Equal(t, 2, calls, "Only the retry of the own budget must be made.")
+}
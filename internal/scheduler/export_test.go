@@ -0,0 +1,11 @@
+package scheduler
+
+import "time"
+
+// SetAfter overrides the func used by the scheduler to wait for the next firing of a task, the
+// returned func restores it.
+func SetAfter(fn func(d time.Duration) <-chan time.Time) func() {
+	bak := after
+	after = fn
+	return func() { after = bak }
+}
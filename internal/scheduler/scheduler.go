@@ -0,0 +1,203 @@
+// This package runs the recurring tasks of the app (e.g. the nightly Facebook profile sync), the
+// schedule of every task is taken from the `schedules` of the app config.
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+)
+
+var (
+	// now and after are used to tell and wait for the time, the tests override them to control
+	// when the tasks are fired.
+	now   = time.Now
+	after = time.After
+
+	_default *Scheduler
+	once     sync.Once
+)
+
+// Task is the function run by the scheduler, the ctx is cancelled when the scheduler is stopped.
+type Task func(ctx context.Context) error
+
+// Schedule returns the next time a task must be fired after the given time.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+type everySchedule time.Duration
+
+func (d everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// ParseSchedule parses a standard 5-field cron spec or an `@every <duration>` descriptor, unlike the
+// cron package the duration of the `@every` is not rounded to the second.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, err
+		}
+
+		if d <= 0 {
+			return nil, fmt.Errorf("error: invalid interval %q", spec)
+		}
+
+		return everySchedule(d), nil
+	}
+
+	return cron.ParseStandard(spec)
+}
+
+type Scheduler struct {
+	mu    sync.Mutex
+	tasks map[string]Task
+	specs map[string]string
+
+	// WaitForReady makes the Run wait for the SetReady before firing any task, so the tasks do not
+	// compete with the warmup of the instance.
+	WaitForReady bool
+
+	ready       chan struct{}
+	readyOnce   sync.Once
+	maintenance atomic.Bool
+}
+
+func New(specs map[string]string) *Scheduler {
+	return &Scheduler{
+		tasks: make(map[string]Task),
+		specs: specs,
+		ready: make(chan struct{}),
+	}
+}
+
+// Default returns the scheduler of the app, its specs and the `schedulerWaitForReady` are taken from
+// the app config.
+func Default() *Scheduler {
+	once.Do(func() {
+		_default = New(loader.AppConfig().Schedules)
+		_default.WaitForReady = loader.AppConfig().SchedulerWaitForReady
+	})
+
+	return _default
+}
+
+// SetReady signals that the instance is ready (the database is connected and migrated), the tasks
+// start firing from then on. It is safe to call it more than once.
+func (s *Scheduler) SetReady() {
+	s.readyOnce.Do(func() { close(s.ready) })
+}
+
+// SetMaintenance pauses (or resumes) the firing of the tasks, the runs that already started are left
+// to finish.
+func (s *Scheduler) SetMaintenance(on bool) {
+	s.maintenance.Store(on)
+}
+
+// Register adds the task under the given name, a task is only fired when it has a spec.
+func (s *Scheduler) Register(name string, task Task) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks[name] = task
+	return s
+}
+
+// Run fires the registered tasks according to their specs until the ctx is cancelled, it waits for
+// the running tasks before returning. A task is never run concurrently with itself, a firing is
+// skipped while the previous run of the task is still going (or while in the maintenance mode). With
+// the WaitForReady, nothing is fired until the SetReady is called.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	schedules := make(map[string]Schedule)
+
+	for name, spec := range s.specs {
+		if _, exists := s.tasks[name]; !exists {
+			logging.Logger().Warn("no task is registered for the schedule", "task", name)
+			continue
+		}
+
+		schedule, err := ParseSchedule(spec)
+		if err != nil {
+			s.mu.Unlock()
+			return fmt.Errorf("error: invalid schedule of the task %s (%s)", name, err.Error())
+		}
+
+		schedules[name] = schedule
+	}
+
+	tasks := s.tasks
+	s.mu.Unlock()
+
+	if s.WaitForReady {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.ready:
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	for name, schedule := range schedules {
+		wg.Add(1)
+
+		go func(name string, task Task, schedule Schedule) {
+			defer wg.Done()
+			s.loop(ctx, name, task, schedule)
+		}(name, tasks[name], schedule)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, name string, task Task, schedule Schedule) {
+	var running atomic.Bool
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+
+	for {
+		t := now()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-after(schedule.Next(t).Sub(t)):
+		}
+
+		if s.maintenance.Load() {
+			logging.Logger().Debug("skipped the task, the app is in maintenance mode", "task", name)
+			continue
+		}
+
+		if !running.CompareAndSwap(false, true) {
+			logging.Logger().Warn("skipped the task, its previous run is still going", "task", name)
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer running.Store(false)
+
+			if err := task(ctx); err != nil {
+				logging.Logger().Error("the scheduled task failed", "task", name, "error", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,191 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shouldParseTheSupportedSpecs(t *testing.T) {
+	t0 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	every, err := scheduler.ParseSchedule("@every 20ms")
+	assert.Nil(t, err)
+	assert.Equal(t, t0.Add(20*time.Millisecond), every.Next(t0))
+
+	nightly, err := scheduler.ParseSchedule("30 2 * * *")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 1, 1, 2, 30, 0, 0, time.UTC), nightly.Next(t0))
+
+	_, err = scheduler.ParseSchedule("every day")
+	assert.NotNil(t, err)
+}
+
+func Test_taskShouldFireOnEveryTick(t *testing.T) {
+	ticks := make(chan time.Time)
+	defer scheduler.SetAfter(func(time.Duration) <-chan time.Time { return ticks })()
+
+	fired := make(chan struct{})
+	s := scheduler.New(map[string]string{"sync": "@every 20ms"}).
+		Register("sync", func(ctx context.Context) error {
+			select {
+			case fired <- struct{}{}:
+			case <-ctx.Done():
+			}
+
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	// A tick is skipped while the previous run has not yet returned, so keep ticking until the
+	// task is fired.
+	for i := 0; i < 3; i++ {
+	tick:
+		for {
+			select {
+			case ticks <- time.Now():
+			case <-fired:
+				break tick
+			}
+		}
+	}
+
+	cancel()
+	assert.Nil(t, <-done)
+}
+
+func Test_taskShouldNotOverlapWithItself(t *testing.T) {
+	ticks := make(chan time.Time)
+	defer scheduler.SetAfter(func(time.Duration) <-chan time.Time { return ticks })()
+
+	var runs, concurrent, maxConcurrent atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+
+	s := scheduler.New(map[string]string{"report": "@every 20ms"}).
+		Register("report", func(ctx context.Context) error {
+			if n := concurrent.Add(1); n > maxConcurrent.Load() {
+				maxConcurrent.Store(n)
+			}
+
+			runs.Add(1)
+			started <- struct{}{}
+			<-release
+			concurrent.Add(-1)
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	ticks <- time.Now()
+	<-started
+
+	// The first run is still going, these firings must be skipped.
+	ticks <- time.Now()
+	ticks <- time.Now()
+
+	close(release)
+	cancel()
+	assert.Nil(t, <-done)
+
+	assert.Equal(t, int32(1), runs.Load(), "The overlapping firings should have been skipped.")
+	assert.Equal(t, int32(1), maxConcurrent.Load())
+}
+
+func Test_runShouldFailOnAnInvalidSpec(t *testing.T) {
+	s := scheduler.New(map[string]string{"sync": "every day"}).
+		Register("sync", func(ctx context.Context) error { return nil })
+
+	assert.NotNil(t, s.Run(context.Background()))
+}
+
+// tryTick sends a tick to the scheduler, it reports false when no task was waiting for it.
+func tryTick(ticks chan time.Time) bool {
+	select {
+	case ticks <- time.Now():
+		return true
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+func Test_tasksShouldNotFireUntilTheSchedulerIsReady(t *testing.T) {
+	ticks := make(chan time.Time)
+	defer scheduler.SetAfter(func(time.Duration) <-chan time.Time { return ticks })()
+
+	var runs atomic.Int32
+	fired := make(chan struct{}, 1)
+
+	s := scheduler.New(map[string]string{"sync": "@every 20ms"}).
+		Register("sync", func(ctx context.Context) error {
+			runs.Add(1)
+			fired <- struct{}{}
+			return nil
+		})
+	s.WaitForReady = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	assert.False(t, tryTick(ticks), "The task should not wait for a tick before the scheduler is ready.")
+	assert.Equal(t, int32(0), runs.Load())
+
+	s.SetReady()
+
+	assert.True(t, tryTick(ticks))
+	<-fired
+
+	cancel()
+	assert.Nil(t, <-done)
+	assert.Equal(t, int32(1), runs.Load())
+}
+
+func Test_tasksShouldPauseInTheMaintenanceMode(t *testing.T) {
+	ticks := make(chan time.Time)
+	defer scheduler.SetAfter(func(time.Duration) <-chan time.Time { return ticks })()
+
+	var runs atomic.Int32
+	fired := make(chan struct{}, 1)
+
+	s := scheduler.New(map[string]string{"sync": "@every 20ms"}).
+		Register("sync", func(ctx context.Context) error {
+			runs.Add(1)
+			fired <- struct{}{}
+			return nil
+		})
+	s.SetMaintenance(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, tryTick(ticks))
+	}
+
+	assert.Equal(t, int32(0), runs.Load(), "The ticks of the maintenance mode should have been skipped.")
+
+	s.SetMaintenance(false)
+
+	// The tick sent before the resume may have been skipped yet, so keep ticking until the task fires.
+	for resumed := false; !resumed; {
+		select {
+		case <-fired:
+			resumed = true
+		default:
+			tryTick(ticks)
+		}
+	}
+
+	cancel()
+	assert.Nil(t, <-done)
+}
@@ -0,0 +1,91 @@
+// This package implements the `--selftest` mode of the app, it checks that the instance is able to
+// run (the config, the database and the Facebook credentials) and reports the result of every check.
+// It is meant to be used as a gate of the deployments.
+
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rommms07/idream-erp/core/auth/facebook"
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"gorm.io/gorm"
+)
+
+// Check is a single check of the self-test, it is toggled by its Name in the `selfTestChecks`.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// ConfigCheck checks that the required env of the app config is set and that the config is valid.
+func ConfigCheck() Check {
+	return Check{"config", func(ctx context.Context) error {
+		conf := loader.AppConfig()
+		_, err := conf.Validate()
+		return errors.Join(loader.CheckRequiredEnv(conf), err)
+	}}
+}
+
+// DatabaseCheck connects to the database with the open and runs a trivial query.
+func DatabaseCheck(open func() (*gorm.DB, error)) Check {
+	return Check{"database", func(ctx context.Context) error {
+		db, err := open()
+		if err != nil {
+			return err
+		}
+
+		return db.WithContext(ctx).Exec("SELECT 1").Error
+	}}
+}
+
+// FacebookCheck checks the consumer credentials of the app with the Graph API.
+func FacebookCheck() Check {
+	return Check{"facebook", func(ctx context.Context) error {
+		return facebook.CheckCredentials(ctx, facebook.LoginType_CONSUMER)
+	}}
+}
+
+// DefaultChecks returns the checks of the app that are enabled in the config.
+func DefaultChecks() []Check {
+	toggles := loader.AppConfig().SelfTestChecks
+	checks := []Check{}
+
+	for _, check := range []Check{ConfigCheck(), DatabaseCheck(source.Open[gorm.DB]), FacebookCheck()} {
+		if enabled, exists := toggles[check.Name]; exists && !enabled {
+			continue
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// Run runs all of the checks and writes the result of each to w, a failing check does not stop the
+// others. The returned error joins the errors of the failed checks.
+func Run(ctx context.Context, w io.Writer, checks ...Check) error {
+	errs := []error{}
+
+	for _, check := range checks {
+		if err := check.Run(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", check.Name, err))
+			fmt.Fprintf(w, "FAIL\t%s\t%s\n", check.Name, err.Error())
+			continue
+		}
+
+		fmt.Fprintf(w, "ok\t%s\n", check.Name)
+	}
+
+	return errors.Join(errs...)
+}
+
+// SelfTest runs the enabled checks of the app and reports them to the stdout.
+func SelfTest(ctx context.Context) error {
+	return Run(ctx, os.Stdout, DefaultChecks()...)
+}
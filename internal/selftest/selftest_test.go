@@ -0,0 +1,76 @@
+package selftest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/core/auth/facebook"
+	"github.com/rommms07/idream-erp/internal/selftest"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// useGraph points the Graph API to a stand-in answering with the status.
+func useGraph(t *testing.T, status int) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"access_token":"app|token"}`))
+	}))
+
+	bak := facebook.FACEBOOK_GRAPH
+	facebook.FACEBOOK_GRAPH = srv.URL
+
+	t.Cleanup(func() {
+		facebook.FACEBOOK_GRAPH = bak
+		srv.Close()
+	})
+}
+
+func Test_selfTestShouldPassWhenAllOfTheChecksPass(t *testing.T) {
+	useGraph(t, http.StatusOK)
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectExec("SELECT 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	report := &bytes.Buffer{}
+	err = selftest.Run(context.Background(), report,
+		selftest.ConfigCheck(),
+		selftest.DatabaseCheck(func() (*gorm.DB, error) { return db, nil }),
+		selftest.FacebookCheck())
+
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "ok\tconfig\nok\tdatabase\nok\tfacebook\n", report.String())
+}
+
+func Test_selfTestShouldReportTheFailedDatabase(t *testing.T) {
+	useGraph(t, http.StatusOK)
+
+	dbErr := errors.New("dial tcp 127.0.0.1:3306: connect: connection refused")
+
+	report := &bytes.Buffer{}
+	err := selftest.Run(context.Background(), report,
+		selftest.ConfigCheck(),
+		selftest.DatabaseCheck(func() (*gorm.DB, error) { return nil, dbErr }),
+		selftest.FacebookCheck())
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "database: ")
+	assert.Equal(t, "ok\tconfig\nFAIL\tdatabase\t"+dbErr.Error()+"\nok\tfacebook\n", report.String(),
+		"The other checks should still run after the failed check.")
+}
+
+func Test_facebookCheckShouldFailOnRejectedCredentials(t *testing.T) {
+	useGraph(t, http.StatusBadRequest)
+
+	err := selftest.Run(context.Background(), &bytes.Buffer{}, selftest.FacebookCheck())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,10 @@
+package storage
+
+import "time"
+
+// SetNow overrides the clock used to tell the age of the files, the returned func restores it.
+func SetNow(fn func() time.Time) func() {
+	bak := now
+	now = fn
+	return func() { now = bak }
+}
@@ -0,0 +1,187 @@
+// This package keeps the files stored on the disk (e.g. the attachments) and cleans up the orphaned ones,
+// a file is orphaned once no row of the `references` of the `orphanCleanup` of the app config holds its
+// key anymore (e.g. its row was deleted).
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rommms07/idream-erp/core/source"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/rommms07/idream-erp/internal/scheduler"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	TASK_NAME = "cleanup_orphaned_files"
+
+	// LOOKUP_BATCH_SIZE is how many keys are looked up in the referencing tables at a time.
+	LOOKUP_BATCH_SIZE = 500
+)
+
+// ErrInvalidKey is returned for a key that would escape the directory of the store.
+var ErrInvalidKey = errors.New("error: the key of the file is not local to the store")
+
+// ErrNoReferences is returned by the CleanupOrphans when no referencing table is configured, every
+// file would be an orphan otherwise.
+var ErrNoReferences = errors.New("error: the references of the orphan cleanup are empty")
+
+var (
+	// now is used to tell the age of the files, the tests override it.
+	now = time.Now
+)
+
+func init() {
+	scheduler.Default().Register(TASK_NAME, func(ctx context.Context) error {
+		dir := loader.AppConfig().OrphanCleanup.Dir
+		if len(dir) == 0 {
+			return nil
+		}
+
+		_, err := CleanupOrphans(ctx, source.Source[gorm.DB](), &DiskStore{Dir: dir})
+		return err
+	})
+}
+
+// Object is a stored file, the Key is its path relative to the store with forward slashes.
+type Object struct {
+	Key     string
+	ModTime time.Time
+}
+
+// Store lists and deletes the stored files.
+type Store interface {
+	List(ctx context.Context) ([]*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DiskStore is a Store of the files of the Dir (and of its subdirectories).
+type DiskStore struct {
+	Dir string
+}
+
+func (s *DiskStore) List(ctx context.Context) ([]*Object, error) {
+	objects := []*Object{}
+
+	err := filepath.WalkDir(s.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.Dir, path)
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, &Object{Key: filepath.ToSlash(rel), ModTime: info.ModTime()})
+		return nil
+	})
+
+	return objects, err
+}
+
+func (s *DiskStore) Delete(ctx context.Context, key string) error {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) {
+		return ErrInvalidKey
+	}
+
+	return os.Remove(filepath.Join(s.Dir, name))
+}
+
+// referenced returns the keys held by the columns of the referencing tables.
+func referenced(ctx context.Context, db *gorm.DB, keys []string) (map[string]bool, error) {
+	found := make(map[string]bool, len(keys))
+
+	for table, column := range loader.AppConfig().OrphanCleanup.References {
+		for start := 0; start < len(keys); start += LOOKUP_BATCH_SIZE {
+			batch := keys[start:min(start+LOOKUP_BATCH_SIZE, len(keys))]
+
+			values := make([]any, len(batch))
+			for i, key := range batch {
+				values[i] = key
+			}
+
+			held := []string{}
+			err := db.WithContext(ctx).Table(table).
+				Where(clause.IN{Column: clause.Column{Name: column}, Values: values}).
+				Pluck(column, &held).Error
+			if err != nil {
+				return nil, fmt.Errorf("error: unable to look up the files referenced by %s.%s: %w", table, column, err)
+			}
+
+			for _, key := range held {
+				found[key] = true
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// CleanupOrphans deletes the files of the store that are older than the `graceMinutes` and that no row
+// references, in the `dryRun` they are only reported. It returns the keys of the orphans.
+func CleanupOrphans(ctx context.Context, db *gorm.DB, store Store) ([]string, error) {
+	conf := loader.AppConfig().OrphanCleanup
+
+	if len(conf.References) == 0 {
+		return nil, ErrNoReferences
+	}
+
+	objects, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := now().Add(-time.Duration(conf.GraceMinutes) * time.Minute)
+
+	candidates := []string{}
+	for _, object := range objects {
+		if object.ModTime.Before(cutoff) {
+			candidates = append(candidates, object.Key)
+		}
+	}
+
+	found, err := referenced(ctx, db, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []string{}
+	for _, key := range candidates {
+		if found[key] {
+			continue
+		}
+
+		orphans = append(orphans, key)
+
+		if conf.DryRun {
+			logging.Logger().Info("the cleanup would delete the orphaned file", "key", key)
+			continue
+		}
+
+		if err := store.Delete(ctx, key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return orphans, err
+		}
+	}
+
+	return orphans, nil
+}
@@ -0,0 +1,96 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/storage"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func storeFile(t *testing.T, dir, key string, modTime time.Time) {
+	name := filepath.Join(dir, filepath.FromSlash(key))
+	assert.Nil(t, os.MkdirAll(filepath.Dir(name), 0o750))
+	assert.Nil(t, os.WriteFile(name, []byte(key), 0o640))
+	assert.Nil(t, os.Chtimes(name, modTime, modTime))
+}
+
+func setOrphanCleanup(t *testing.T, dryRun bool) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.OrphanCleanup.References = map[string]string{"attachments": "file_key"}
+		conf.OrphanCleanup.GraceMinutes, conf.OrphanCleanup.DryRun = 60, dryRun
+	})
+}
+
+func Test_shouldDeleteTheOrphansPastTheGracePeriod(t *testing.T) {
+	setOrphanCleanup(t, false)
+
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	defer storage.SetNow(func() time.Time { return t0 })()
+
+	dir := t.TempDir()
+	storeFile(t, dir, "invoices/old-orphan.pdf", t0.Add(-2*time.Hour))
+	storeFile(t, dir, "invoices/old-referenced.pdf", t0.Add(-2*time.Hour))
+	storeFile(t, dir, "invoices/uploading.pdf", t0.Add(-10*time.Minute))
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT `file_key` FROM `attachments` WHERE `file_key` IN \\(\\?,\\?\\)").
+		WithArgs("invoices/old-orphan.pdf", "invoices/old-referenced.pdf").
+		WillReturnRows(sqlmock.NewRows([]string{"file_key"}).AddRow("invoices/old-referenced.pdf"))
+
+	orphans, err := storage.CleanupOrphans(context.Background(), db, &storage.DiskStore{Dir: dir})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"invoices/old-orphan.pdf"}, orphans)
+
+	assert.NoFileExists(t, filepath.Join(dir, "invoices", "old-orphan.pdf"))
+	assert.FileExists(t, filepath.Join(dir, "invoices", "old-referenced.pdf"))
+	assert.FileExists(t, filepath.Join(dir, "invoices", "uploading.pdf"), "An orphan within the grace period must be kept.")
+}
+
+func Test_shouldOnlyReportTheOrphansInTheDryRun(t *testing.T) {
+	setOrphanCleanup(t, true)
+
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	defer storage.SetNow(func() time.Time { return t0 })()
+
+	dir := t.TempDir()
+	storeFile(t, dir, "orphan.png", t0.Add(-2*time.Hour))
+
+	db, mock, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	mock.ExpectQuery("SELECT `file_key` FROM `attachments` WHERE `file_key` = \\?").
+		WithArgs("orphan.png").
+		WillReturnRows(sqlmock.NewRows([]string{"file_key"}))
+
+	orphans, err := storage.CleanupOrphans(context.Background(), db, &storage.DiskStore{Dir: dir})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"orphan.png"}, orphans)
+	assert.FileExists(t, filepath.Join(dir, "orphan.png"))
+}
+
+func Test_shouldRefuseToCleanupWithoutReferences(t *testing.T) {
+	setOrphanCleanup(t, false)
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.OrphanCleanup.References = nil
+	})
+
+	dir := t.TempDir()
+	storeFile(t, dir, "invoices/old.pdf", time.Now().Add(-48*time.Hour))
+
+	db, _, err := mocks.NewGormMock()
+	assert.Nil(t, err)
+
+	_, err = storage.CleanupOrphans(context.Background(), db, &storage.DiskStore{Dir: dir})
+	assert.ErrorIs(t, err, storage.ErrNoReferences)
+	assert.FileExists(t, filepath.Join(dir, "invoices", "old.pdf"))
+}
@@ -0,0 +1,10 @@
+package webhook
+
+import "time"
+
+// SetAfterFunc overrides the scheduling of the batches, the returned func restores it.
+func SetAfterFunc(fn func(d time.Duration, f func()) *time.Timer) func() {
+	bak := afterFunc
+	afterFunc = fn
+	return func() { afterFunc = bak }
+}
@@ -0,0 +1,194 @@
+// This package delivers the events of the app to the webhook subscriptions, every payload is signed with
+// the HMAC-SHA256 of the secret of the subscription so that the receiver can verify it came from us. The
+// events of a batching subscription are delivered together as a single JSON array per the
+// `webhookBatching` of the app config, the signature then covers the whole batch.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/helpers/logging"
+	"github.com/rommms07/idream-erp/internal/outbound"
+)
+
+const (
+	// SIGNATURE_HEADER holds the `sha256=<hex>` signature of the payload.
+	SIGNATURE_HEADER = "X-Webhook-Signature"
+
+	// BATCH_HEADER holds the number of the events of a batched payload.
+	BATCH_HEADER = "X-Webhook-Batch-Size"
+
+	// DEFAULT_DELIVERY_TIMEOUT bounds a delivery when the `httpClient` config has no timeout.
+	DEFAULT_DELIVERY_TIMEOUT = 10 * time.Second
+)
+
+var (
+	// afterFunc schedules the delivery of the batches, the tests override it.
+	afterFunc = time.AfterFunc
+)
+
+type Subscription struct {
+	Id     string
+	URL    string
+	Secret string
+
+	// Batch opts the subscription into the batching, see the `webhookBatching` of the app config.
+	Batch bool
+}
+
+type Event struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// Sign returns the signature of the payload as it is sent in the SIGNATURE_HEADER.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type batch struct {
+	sub    *Subscription
+	events []*Event
+}
+
+// Dispatcher delivers the events with the client, the pending batches are kept per subscription.
+type Dispatcher struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	pending map[string]*batch
+}
+
+// NewDispatcher creates a dispatcher delivering with the client, a nil client delivers through the
+// pooled outbound.Transport with the deliveryTimeout.
+func NewDispatcher(client *http.Client) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Transport: outbound.Transport(), Timeout: deliveryTimeout()}
+	}
+
+	return &Dispatcher{client: client, pending: make(map[string]*batch)}
+}
+
+// deliveryTimeout returns the `timeoutMs` of the `httpClient` config, a subscription that never answers
+// must not hold a delivery forever so the DEFAULT_DELIVERY_TIMEOUT is used when it is unset.
+func deliveryTimeout() time.Duration {
+	if timeoutMs := loader.AppConfig().HttpClient.TimeoutMs; timeoutMs != 0 {
+		return time.Duration(timeoutMs) * time.Millisecond
+	}
+
+	return DEFAULT_DELIVERY_TIMEOUT
+}
+
+// batching reports whether the events of the subscription are batched and for how long.
+func batching(sub *Subscription) (time.Duration, bool) {
+	conf := loader.AppConfig().WebhookBatching
+	if conf == nil || conf.WindowMs == 0 {
+		return 0, false
+	}
+
+	return time.Duration(conf.WindowMs) * time.Millisecond, sub.Batch || slices.Contains(conf.Subscriptions, sub.Id)
+}
+
+// Send delivers the event to the subscription right away, unless the subscription is batching in which
+// case the event joins its pending batch and the error of the delivery is only logged.
+func (d *Dispatcher) Send(ctx context.Context, sub *Subscription, event *Event) error {
+	window, batched := batching(sub)
+	if !batched {
+		return d.deliver(ctx, sub, event)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if b, exists := d.pending[sub.Id]; exists {
+		b.events = append(b.events, event)
+		return nil
+	}
+
+	d.pending[sub.Id] = &batch{sub: sub, events: []*Event{event}}
+	afterFunc(window, func() { d.flush(sub.Id) })
+
+	return nil
+}
+
+// Flush delivers all of the pending batches at once, e.g. before shutting down.
+func (d *Dispatcher) Flush() {
+	d.mu.Lock()
+	ids := make([]string, 0, len(d.pending))
+	for id := range d.pending {
+		ids = append(ids, id)
+	}
+	d.mu.Unlock()
+
+	for _, id := range ids {
+		d.flush(id)
+	}
+}
+
+func (d *Dispatcher) flush(id string) {
+	d.mu.Lock()
+	b, exists := d.pending[id]
+	delete(d.pending, id)
+	d.mu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	// The batches are flushed in the background, nothing else would ever cancel their delivery.
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout())
+	defer cancel()
+
+	if err := d.deliver(ctx, b.sub, b.events); err != nil {
+		logging.Logger().Error("the webhook batch could not be delivered", "subscription", id, "events", len(b.events), "error", err)
+	}
+}
+
+// deliver posts the payload (either an event or a batch of them) to the subscription.
+func (d *Dispatcher) deliver(ctx context.Context, sub *Subscription, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SIGNATURE_HEADER, Sign(sub.Secret, body))
+
+	if events, isBatch := payload.([]*Event); isBatch {
+		req.Header.Set(BATCH_HEADER, fmt.Sprint(len(events)))
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("error: the webhook of the subscription %s answered with a %d", sub.Id, res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+	"github.com/rommms07/idream-erp/internal/webhook"
+	"github.com/rommms07/idream-erp/tests/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type delivery struct {
+	body      []byte
+	signature string
+	size      string
+}
+
+func receiver(t *testing.T) (*httptest.Server, func() []delivery) {
+	var mu sync.Mutex
+	deliveries := []delivery{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		deliveries = append(deliveries, delivery{body, r.Header.Get(webhook.SIGNATURE_HEADER), r.Header.Get(webhook.BATCH_HEADER)})
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, func() []delivery {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]delivery{}, deliveries...)
+	}
+}
+
+func Test_shouldDeliverTheEventsWithinTheWindowAsOneSignedBatch(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.WebhookBatching.WindowMs = 500
+		conf.WebhookBatching.Subscriptions = []string{"erp-sync"}
+	})
+
+	flushes := []func(){}
+	defer webhook.SetAfterFunc(func(d time.Duration, f func()) *time.Timer {
+		assert.Equal(t, 500*time.Millisecond, d)
+		flushes = append(flushes, f)
+		return nil
+	})()
+
+	srv, deliveries := receiver(t)
+	sub := &webhook.Subscription{Id: "erp-sync", URL: srv.URL, Secret: "s3cret"}
+	dispatcher := webhook.NewDispatcher(srv.Client())
+
+	for _, id := range []string{"evt_1", "evt_2", "evt_3"} {
+		assert.Nil(t, dispatcher.Send(context.Background(), sub, &webhook.Event{Id: id, Type: "order.paid"}))
+	}
+
+	assert.Empty(t, deliveries(), "Nothing should be delivered before the window elapses.")
+
+	if assert.Len(t, flushes, 1, "A single delivery should be scheduled for the batch.") {
+		flushes[0]()
+	}
+
+	if got := deliveries(); assert.Len(t, got, 1) {
+		events := []*webhook.Event{}
+		assert.Nil(t, json.Unmarshal(got[0].body, &events))
+		assert.Len(t, events, 3)
+		assert.Equal(t, "3", got[0].size)
+		assert.Equal(t, webhook.Sign("s3cret", got[0].body), got[0].signature, "The signature should cover the whole batch.")
+	}
+}
+
+func Test_shouldDeliverTheEventsOfANonBatchingSubscriptionRightAway(t *testing.T) {
+	srv, deliveries := receiver(t)
+	sub := &webhook.Subscription{Id: "crm", URL: srv.URL, Secret: "s3cret"}
+
+	assert.Nil(t, webhook.NewDispatcher(srv.Client()).Send(context.Background(), sub, &webhook.Event{Id: "evt_1", Type: "order.paid"}))
+
+	if got := deliveries(); assert.Len(t, got, 1) {
+		assert.JSONEq(t, `{"id": "evt_1", "type": "order.paid", "data": null}`, string(got[0].body))
+		assert.Equal(t, webhook.Sign("s3cret", got[0].body), got[0].signature)
+		assert.Empty(t, got[0].size)
+	}
+}
+
+func Test_theFlushShouldGiveUpOnASubscriptionThatNeverAnswers(t *testing.T) {
+	mocks.SetConfig(t, func(conf *loader.AppConfigType) {
+		conf.WebhookBatching.WindowMs = 500
+		conf.HttpClient.TimeoutMs = 50
+	})
+
+	defer webhook.SetAfterFunc(func(d time.Duration, f func()) *time.Timer { return nil })()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	sub := &webhook.Subscription{Id: "erp-sync", URL: srv.URL, Secret: "s3cret", Batch: true}
+	dispatcher := webhook.NewDispatcher(srv.Client())
+	assert.Nil(t, dispatcher.Send(context.Background(), sub, &webhook.Event{Id: "evt_1", Type: "order.paid"}))
+
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Flush()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The flush should have given up on the delivery.")
+	}
+}
@@ -0,0 +1,19 @@
+package mocks
+
+import (
+	"testing"
+
+	"github.com/rommms07/idream-erp/helpers/loader"
+)
+
+// SetConfig publishes a clone of the loaded config changed by the set for the duration of the test, the
+// original config is put back once the test is done. The clone is returned for the tests reading it.
+func SetConfig(t testing.TB, set func(conf *loader.AppConfigType)) *loader.AppConfigType {
+	conf := loader.AppConfig().Clone()
+	set(conf)
+
+	bak := loader.SwapConfig(conf)
+	t.Cleanup(func() { loader.SwapConfig(bak) })
+
+	return conf
+}
@@ -0,0 +1,31 @@
+// This package contains the fixtures shared by the test suites of the other packages,
+// it must never be imported by non-test code.
+
+package mocks
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// NewGormMock opens a gorm.DB on top of a sqlmock connection using the mysql dialector,
+// this lets us assert the queries produced by gorm without having a live database.
+func NewGormMock() (*gorm.DB, sqlmock.Sqlmock, error) {
+	return NewGormMockWithConfig(&gorm.Config{SkipDefaultTransaction: true})
+}
+
+// NewGormMockWithConfig is the same as NewGormMock but the gorm.DB is opened with the given config.
+func NewGormMockWithConfig(conf *gorm.Config) (*gorm.DB, sqlmock.Sqlmock, error) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      conn,
+		SkipInitializeWithVersion: true,
+	}), conf)
+
+	return db, mock, err
+}